@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -31,12 +32,17 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/workloadmeta/defaults"
 	workloadmetafx "github.com/DataDog/datadog-agent/comp/core/workloadmeta/fx"
 	"github.com/DataDog/datadog-agent/comp/logs/agent/agentimpl"
+	logsconfig "github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/dryrun"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/schedulers/ad"
+	"github.com/DataDog/datadog-agent/pkg/logs/sds"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -55,6 +61,17 @@ type CliParams struct {
 
 	// inactivityTimeout represents the time in seconds that the program will wait for new logs before exiting
 	inactivityTimeout time.Duration
+
+	// SamplePath represents the path to a sample file run through the whole
+	// pipeline of each source, the result being printed as a diff.
+	SamplePath string
+
+	// SDSRulesFile represents the path to the SDS rules file applied to the
+	// sample, logs_config.sds.rules_file by default.
+	SDSRulesFile string
+
+	// ShowEncoded prints the logs of the sample as encoded for the intake.
+	ShowEncoded bool
 }
 
 // Commands returns a slice of subcommands for the 'agent' command.
@@ -67,13 +84,23 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "analyze-logs",
 		Short: "Analyze logs configuration in isolation",
-		Long:  `Run a Datadog agent logs configuration and print the results to stdout`,
+		Long: `Run a Datadog agent logs configuration and print the results to stdout.
+
+With --sample, the logs of the sample file are run through the decoder, the processing
+rules, the Sensitive Data Scanner and the encoder of each source, and the result is
+printed as a diff between the sample and the logs as sent, annotated with the rules
+which dropped, masked or merged each log. With --encoded, each log sent is followed by
+the log as encoded for the intake.`,
 		RunE: func(_ *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return fmt.Errorf("log config file path is required")
 			}
 			cliParams.LogConfigPath = args[0]
-			return fxutil.OneShot(runAnalyzeLogs,
+			run := runAnalyzeLogs
+			if cliParams.SamplePath != "" {
+				run = runDryRun
+			}
+			return fxutil.OneShot(run,
 				core.Bundle(),
 				fx.Supply(cliParams),
 				fx.Supply(core.BundleParams{
@@ -91,6 +118,10 @@ func Commands(globalParams *command.GlobalParams) []*cobra.Command {
 	cmd.Flags().StringVarP(&cliParams.CoreConfigPath, "core-config", "C", defaultCoreConfigPath, "Path to the core configuration file (optional)")
 	// Add flag for inactivity timeout (optional)
 	cmd.Flags().DurationVarP(&cliParams.inactivityTimeout, "inactivity-timeout", "t", defaultInactivityTimeout, "Time that the program will wait for new logs before exiting (optional)")
+	// Add flags for the dry-run (optional)
+	cmd.Flags().StringVarP(&cliParams.SamplePath, "sample", "s", "", "Path to a sample file to run through the whole pipeline, printing a diff of the results (optional)")
+	cmd.Flags().StringVar(&cliParams.SDSRulesFile, "sds-rules-file", "", "Path to the SDS rules file applied with --sample (default: logs_config.sds.rules_file)")
+	cmd.Flags().BoolVar(&cliParams.ShowEncoded, "encoded", false, "Print the logs as encoded for the intake with --sample (optional)")
 
 	return []*cobra.Command{cmd}
 }
@@ -154,6 +185,75 @@ func runAnalyzeLogsHelper(cliParams *CliParams, config config.Component, ac auto
 	return agentimpl.SetUpLaunchers(config, configSource)
 }
 
+// runDryRun runs the sample file through the pipeline of each source and
+// prints the results as a diff.
+func runDryRun(cliParams *CliParams, config config.Component, ac autodiscovery.Component) error {
+	sample, err := os.ReadFile(cliParams.SamplePath)
+	if err != nil {
+		return err
+	}
+	sources, err := getSources(ac, cliParams)
+	if err != nil {
+		return err
+	}
+	for i, source := range sources {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := dryRunSource(cliParams, config, source, sample, os.Stdout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dryRunSource runs the sample through the pipeline of a source and writes
+// the results as a diff.
+func dryRunSource(cliParams *CliParams, config config.Component, source *sources.LogSource, sample []byte, w io.Writer) error {
+	if err := source.Config.Validate(); err != nil {
+		return fmt.Errorf("error with config: %v", err)
+	}
+	processingRules, err := logsconfig.GlobalProcessingRules(config)
+	if err != nil {
+		return err
+	}
+
+	p := processor.New(config, make(chan *message.Message), make(chan *message.Message), processingRules, processor.JSONEncoder,
+		diagnostic.NewBufferedMessageReceiver(nil, nil), nil, metrics.NewNoopPipelineMonitor("analyze-logs"), nil)
+	if err := configureSDS(cliParams, config, p); err != nil {
+		return err
+	}
+
+	results, err := dryrun.Run(source, p, sample)
+	if err != nil {
+		return err
+	}
+	return dryrun.WriteDiff(w, cliParams.SamplePath, results, cliParams.ShowEncoded)
+}
+
+// configureSDS loads the local SDS rules in the processor, if any.
+func configureSDS(cliParams *CliParams, config config.Component, p *processor.Processor) error {
+	rulesFile := cliParams.SDSRulesFile
+	if rulesFile == "" {
+		rulesFile = sds.LocalRulesFile(config)
+	} else if !sds.SDSEnabled {
+		return fmt.Errorf("this agent was built without the Sensitive Data Scanner")
+	}
+	if rulesFile == "" || !sds.SDSEnabled {
+		return nil
+	}
+
+	standardRules, agentConfig, err := sds.LoadLocalRules(rulesFile)
+	if err != nil {
+		return err
+	}
+	if _, err := p.ReconfigureSDS(sds.ReconfigureOrder{Type: sds.StandardRules, Config: standardRules}); err != nil {
+		return err
+	}
+	_, err = p.ReconfigureSDS(sds.ReconfigureOrder{Type: sds.AgentConfig, Config: agentConfig})
+	return err
+}
+
 func getSources(ac autodiscovery.Component, cliParams *CliParams) ([]*sources.LogSource, error) {
 	sources, err := resolveFileConfig(cliParams)
 	if err == nil {
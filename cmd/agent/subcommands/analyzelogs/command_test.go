@@ -6,6 +6,7 @@
 package analyzelogs
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -42,6 +43,19 @@ func TestCommand(t *testing.T) {
 		})
 }
 
+func TestCommandSample(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"analyze-logs", "-s", "sample.log", "--sds-rules-file", "sds.yaml", "--encoded", "path/to/log/config.yaml"},
+		runDryRun,
+		func(_ core.BundleParams, cliParams *CliParams) {
+			require.Equal(t, "path/to/log/config.yaml", cliParams.LogConfigPath)
+			require.Equal(t, "sample.log", cliParams.SamplePath)
+			require.Equal(t, "sds.yaml", cliParams.SDSRulesFile)
+			require.True(t, cliParams.ShowEncoded)
+		})
+}
+
 func CreateTestFile(tempDir string, fileName string, fileContent string) *os.File {
 	if _, err := os.Stat(tempDir); os.IsNotExist(err) {
 		err = os.MkdirAll(tempDir, 0755)
@@ -202,3 +216,64 @@ func TestRunAnalyzeLogsInvalidConfig(t *testing.T) {
 	_, _, _, err := runAnalyzeLogsHelper(cliParams, config, ac)
 	assert.Error(t, err)
 }
+
+func TestDryRunSource(t *testing.T) {
+	tempDir := "tmp"
+	defer os.RemoveAll(tempDir)
+	sample := CreateTestFile(tempDir, "sample.log", `2024-05-15 12:00:00 starting
+2024-05-15 12:00:01 GET /healthcheck
+2024-05-15 12:00:02 login token=abcdef
+2024-05-15 12:00:03 panic
+  at main.go:12
+`)
+	defer os.Remove(sample.Name())
+
+	configFile := CreateTestFile(tempDir, "config.yaml", fmt.Sprintf(`logs:
+  - type: file
+    path: %s
+    log_processing_rules:
+      - type: multi_line
+        name: new_log_start_with_date
+        pattern: \d{4}-\d{2}-\d{2}
+      - type: exclude_at_match
+        name: exclude_healthchecks
+        pattern: healthcheck
+      - type: mask_sequences
+        name: mask_tokens
+        pattern: token=\S+
+        replace_placeholder: token=[masked]
+`, sample.Name()))
+	defer os.Remove(configFile.Name())
+
+	config := config.NewMock(t)
+	cliParams := &CliParams{
+		LogConfigPath: configFile.Name(),
+		SamplePath:    sample.Name(),
+	}
+	sources, err := resolveFileConfig(cliParams)
+	require.NoError(t, err)
+	require.Len(t, sources, 1)
+
+	content, err := os.ReadFile(sample.Name())
+	require.NoError(t, err)
+	var out bytes.Buffer
+	require.NoError(t, dryRunSource(cliParams, config, sources[0], content, &out))
+
+	expected := fmt.Sprintf(`--- %[1]s
++++ %[1]s (processed)
+@@ line 1 @@
+  2024-05-15 12:00:00 starting
+@@ line 2 @@ dropped by exclude_at_match rule "exclude_healthchecks"
+- 2024-05-15 12:00:01 GET /healthcheck
+@@ line 3 @@ masked by mask_sequences rule "mask_tokens"
+- 2024-05-15 12:00:02 login token=abcdef
++ 2024-05-15 12:00:02 login token=[masked]
+@@ lines 4-5 @@ merged 2 lines (multi_line rule "new_log_start_with_date")
+- 2024-05-15 12:00:03 panic
+-   at main.go:12
++ 2024-05-15 12:00:03 panic\n  at main.go:12
+
+4 logs: 3 sent, 1 dropped, 1 modified, 1 merged
+`, sample.Name())
+	assert.Equal(t, expected, out.String())
+}
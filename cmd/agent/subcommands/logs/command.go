@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package logs implements 'agent logs'.
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sds"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// maxLineSize is the size of the longest line of a sample file.
+const maxLineSize = 1024 * 1024
+
+// CliParams are the command-line arguments for the 'logs' subcommands
+type CliParams struct {
+	*command.GlobalParams
+
+	// RulesFile is the path of the local SDS rules file to test, defaulting to
+	// logs_config.sds.rules_file.
+	RulesFile string
+
+	// SampleFile is the path of the file holding the logs to scan, one log per
+	// line, or "-" to read them from the standard input.
+	SampleFile string
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &CliParams{
+		GlobalParams: globalParams,
+	}
+
+	logsCmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Logs agent tools",
+		Long:  ``,
+	}
+
+	sdsCmd := &cobra.Command{
+		Use:   "sds",
+		Short: "Sensitive Data Scanner tools",
+		Long:  ``,
+	}
+
+	sdsTestCmd := &cobra.Command{
+		Use:   "test <sample file>",
+		Short: "Run the logs of a sample file through the local Sensitive Data Scanner rules",
+		Long: `Scan every line of the sample file (or of the standard input when "-") with the rules
+of the local Sensitive Data Scanner rules file, and print the lines as they would be sent.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			cliParams.SampleFile = args[0]
+			return fxutil.OneShot(testSDSRules,
+				fx.Supply(cliParams),
+				fx.Supply(command.GetDefaultCoreBundleParams(cliParams.GlobalParams)),
+				core.Bundle(),
+			)
+		},
+	}
+	sdsTestCmd.Flags().StringVarP(&cliParams.RulesFile, "rules-file", "r", "", "Path of the SDS rules file (default: logs_config.sds.rules_file)")
+
+	sdsCmd.AddCommand(sdsTestCmd)
+	logsCmd.AddCommand(sdsCmd)
+
+	return []*cobra.Command{logsCmd}
+}
+
+func testSDSRules(config config.Component, cliParams *CliParams) error {
+	if !sds.SDSEnabled {
+		return fmt.Errorf("this agent was built without the Sensitive Data Scanner")
+	}
+
+	rulesFile := cliParams.RulesFile
+	if rulesFile == "" {
+		rulesFile = sds.LocalRulesFile(config)
+	}
+	if rulesFile == "" {
+		return fmt.Errorf("no SDS rules file: use --rules-file or set logs_config.sds.rules_file")
+	}
+	standardRules, agentConfig, err := sds.LoadLocalRules(rulesFile)
+	if err != nil {
+		return err
+	}
+
+	scanner := sds.CreateScanner("sds-test")
+	defer scanner.Delete()
+	if _, err := scanner.Reconfigure(sds.ReconfigureOrder{Type: sds.StandardRules, Config: standardRules}); err != nil {
+		return err
+	}
+	if _, err := scanner.Reconfigure(sds.ReconfigureOrder{Type: sds.AgentConfig, Config: agentConfig}); err != nil {
+		return err
+	}
+	if !scanner.IsReady() {
+		return fmt.Errorf("no enabled rule in %s", rulesFile)
+	}
+
+	var input io.Reader = os.Stdin
+	if cliParams.SampleFile != "-" {
+		f, err := os.Open(cliParams.SampleFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	return scanLines(scanner, input, os.Stdout)
+}
+
+// scanLines scans each line of the input and writes it to the output as
+// processed by the scanner, followed by the tags of the matching rules.
+func scanLines(scanner *sds.Scanner, input io.Reader, output io.Writer) error {
+	var lines, mutatedLines int
+	lineScanner := bufio.NewScanner(input)
+	lineScanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for lineScanner.Scan() {
+		lines++
+		content := lineScanner.Bytes()
+		msg := message.NewMessage(content, nil, "", 0)
+		mutated, processed, err := scanner.Scan(content, msg)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", lines, err)
+		}
+		if mutated {
+			mutatedLines++
+			content = processed
+		}
+
+		var tags []string
+		for _, tag := range msg.ProcessingTags {
+			if tag != sds.ScannedTag {
+				tags = append(tags, tag)
+			}
+		}
+		if len(tags) > 0 {
+			fmt.Fprintf(output, "%s\t[%s]\n", content, strings.Join(tags, ", "))
+		} else {
+			fmt.Fprintf(output, "%s\n", content)
+		}
+	}
+	if err := lineScanner.Err(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(output, "\n%d lines scanned, %d lines modified\n", lines, mutatedLines)
+	return nil
+}
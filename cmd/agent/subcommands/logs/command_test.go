@@ -0,0 +1,26 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package logs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestSDSTestCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"logs", "sds", "test", "--rules-file", "sds.yaml", "sample.log"},
+		testSDSRules,
+		func(cliParams *CliParams) {
+			require.Equal(t, "sds.yaml", cliParams.RulesFile)
+			require.Equal(t, "sample.log", cliParams.SampleFile)
+		})
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package capture implements 'trace-agent capture'.
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/trace-agent/subcommands"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const defaultCaptureDuration = time.Minute
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	duration time.Duration
+	path     string
+}
+
+// MakeCommand returns the capture subcommand for the 'trace-agent' command.
+func MakeCommand(globalParamsGetter func() *subcommands.GlobalParams) *cobra.Command {
+	cliParams := &cliParams{}
+	captureCmd := &cobra.Command{
+		Use:   "capture",
+		Short: "Capture the payloads received by a running trace-agent",
+		Long: `Records the payloads received by the running trace-agent, along with their headers, to a capture
+file which can be replayed with 'trace-agent replay'. Only the traces and stats payloads are recorded,
+and the capture stops once it reaches 256MB. The payloads may contain sensitive data.`,
+		RunE: func(*cobra.Command, []string) error {
+			return fxutil.OneShot(startCapture,
+				fx.Supply(cliParams),
+				fx.Supply(config.NewAgentParams(globalParamsGetter().ConfPath, config.WithFleetPoliciesDirPath(globalParamsGetter().FleetPoliciesDirPath))),
+				fx.Supply(option.None[secrets.Component]()),
+				config.Module(),
+			)
+		},
+		SilenceUsage: true,
+	}
+	captureCmd.Flags().DurationVarP(&cliParams.duration, "duration", "d", defaultCaptureDuration, "Duration traffic capture should span, 10m at most.")
+	captureCmd.Flags().StringVarP(&cliParams.path, "path", "p", "", "Directory path to write the capture to, the temporary directory of the trace-agent by default.")
+
+	return captureCmd
+}
+
+func startCapture(config config.Component, cliParams *cliParams) error {
+	if err := util.SetAuthToken(config); err != nil {
+		return err
+	}
+	port := config.GetInt("apm_config.debug.port")
+	if port <= 0 {
+		return fmt.Errorf("invalid apm_config.debug.port -- %d", port)
+	}
+	form := url.Values{
+		"duration": {cliParams.duration.String()},
+		"path":     {cliParams.path},
+	}
+	c := util.GetClient()
+	c.Timeout = config.GetDuration("server_timeout") * time.Second
+	resp, err := util.DoPost(c, fmt.Sprintf("https://127.0.0.1:%d/capture", port), "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("could not start the capture: %s", strings.TrimSpace(err.Error()))
+	}
+	var res struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(resp, &res); err != nil {
+		return err
+	}
+	fmt.Printf("Capture started, capture file being written to: %s\n", res.Path)
+	return nil
+}
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package replay implements 'trace-agent replay'.
+package replay
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/trace-agent/subcommands"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/trace/capture"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+// cliParams are the command-line arguments for this subcommand
+type cliParams struct {
+	file     string
+	target   string
+	realtime bool
+}
+
+// MakeCommand returns the replay subcommand for the 'trace-agent' command.
+func MakeCommand(globalParamsGetter func() *subcommands.GlobalParams) *cobra.Command {
+	cliParams := &cliParams{}
+	replayCmd := &cobra.Command{
+		Use:   "replay <capture file>",
+		Short: "Replay a capture of payloads against a running trace-agent",
+		Long:  `Sends the payloads of a capture file made with 'trace-agent capture' to a running trace-agent.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			cliParams.file = args[0]
+			return fxutil.OneShot(replay,
+				fx.Supply(cliParams),
+				fx.Supply(config.NewAgentParams(globalParamsGetter().ConfPath, config.WithFleetPoliciesDirPath(globalParamsGetter().FleetPoliciesDirPath))),
+				fx.Supply(option.None[secrets.Component]()),
+				config.Module(),
+			)
+		},
+		SilenceUsage: true,
+	}
+	replayCmd.Flags().StringVarP(&cliParams.target, "target", "t", "", "URL of the trace-agent to replay the payloads to, http://localhost:<apm_config.receiver_port> by default.")
+	replayCmd.Flags().BoolVarP(&cliParams.realtime, "realtime", "r", false, "Pace the payloads like they were received, instead of sending them as fast as possible.")
+
+	return replayCmd
+}
+
+func replay(config config.Component, cliParams *cliParams) error {
+	f, err := os.Open(cliParams.file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := capture.NewReader(f)
+	if err != nil {
+		return err
+	}
+	target := cliParams.target
+	if target == "" {
+		target = fmt.Sprintf("http://localhost:%d", config.GetInt("apm_config.receiver_port"))
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	fmt.Printf("Replaying %s to %s...\n", cliParams.file, target)
+	stats, err := capture.Replay(ctx, r, &http.Client{Timeout: 10 * time.Second}, target, cliParams.realtime)
+	fmt.Printf("Replayed %d payloads, %d failed.\n", stats.Sent, stats.Failed)
+	return err
+}
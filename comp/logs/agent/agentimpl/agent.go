@@ -35,6 +35,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/metadata/inventoryagent"
 	rctypes "github.com/DataDog/datadog-agent/comp/remote-config/rcclient/types"
 	logscompression "github.com/DataDog/datadog-agent/comp/serializer/logscompression/def"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/config/model"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
@@ -42,6 +43,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/schedulers"
 	"github.com/DataDog/datadog-agent/pkg/logs/sds"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
@@ -87,6 +89,9 @@ type dependencies struct {
 	SchedulerProviders []schedulers.Scheduler `group:"log-agent-scheduler"`
 	Tagger             tagger.Component
 	Compression        logscompression.Component
+	// SenderManager provides the sender of the metrics generated from logs,
+	// it is not available in all the agents embedding the logs agent.
+	SenderManager sender.SenderManager `optional:"true"`
 }
 
 type provides struct {
@@ -126,6 +131,7 @@ type logAgent struct {
 	schedulerProviders        []schedulers.Scheduler
 	integrationsLogs          integrations.Component
 	compression               logscompression.Component
+	senderManager             sender.SenderManager
 
 	// make sure this is done only once, when we're ready
 	prepareSchedulers sync.Once
@@ -158,6 +164,7 @@ func newLogsAgent(deps dependencies) provides {
 			integrationsLogs:   integrationsLogs,
 			tagger:             deps.Tagger,
 			compression:        deps.Compression,
+			senderManager:      deps.SenderManager,
 		}
 		deps.Lc.Append(fx.Hook{
 			OnStart: logsAgent.start,
@@ -165,7 +172,8 @@ func newLogsAgent(deps dependencies) provides {
 		})
 
 		var rcListener rctypes.ListenerProvider
-		if sds.SDSEnabled {
+		// local SDS rules take precedence over the ones sent through RC
+		if sds.SDSEnabled && sds.LocalRulesFile(deps.Config) == "" {
 			rcListener.ListenerProvider = rctypes.RCListener{
 				state.ProductSDSAgentConfig: logsAgent.onUpdateSDSAgentConfig,
 				state.ProductSDSRules:       logsAgent.onUpdateSDSRules,
@@ -214,10 +222,28 @@ func (a *logAgent) start(context.Context) error {
 		return err
 	}
 
-	a.startPipeline()
+	if err := a.startPipeline(); err != nil {
+		a.log.Error("Could not start logs-agent: ", err)
+		_ = a.stop(context.Background())
+		return err
+	}
 	return nil
 }
 
+// metricSender returns the sender of the metrics generated from logs by the
+// generate_metric processing rules, nil if there is none.
+func (a *logAgent) metricSender() processor.MetricSender {
+	if a.senderManager == nil {
+		return nil
+	}
+	sender, err := a.senderManager.GetDefaultSender()
+	if err != nil {
+		a.log.Warnf("Metrics won't be generated from logs: %v", err)
+		return nil
+	}
+	return sender
+}
+
 func (a *logAgent) setupAgent() error {
 	if a.endpoints.UseHTTP {
 		status.SetCurrentTransport(status.TransportHTTP)
@@ -254,7 +280,7 @@ func (a *logAgent) setupAgent() error {
 
 // Start starts all the elements of the data pipeline
 // in the right order to prevent data loss
-func (a *logAgent) startPipeline() {
+func (a *logAgent) startPipeline() error {
 
 	// setup the status
 	status.Init(a.started, a.endpoints, a.sources, a.tracker, metrics.LogsExpvars)
@@ -268,12 +294,36 @@ func (a *logAgent) startPipeline() {
 	)
 	starter.Start()
 
-	if !sds.ShouldBlockCollectionUntilSDSConfiguration(a.config) {
+	// the SDS configuration is not received through RC when the rules are
+	// local, nothing could unblock the collection if they can't be loaded.
+	if path := sds.LocalRulesFile(a.config); sds.SDSEnabled && path != "" {
+		if err := a.loadLocalSDSRules(path); err != nil {
+			return err
+		}
 		a.startSchedulers()
-	} else {
+	} else if sds.ShouldBlockCollectionUntilSDSConfiguration(a.config) {
 		a.log.Info("logs-agent ready, schedulers not started: waiting for an SDS configuration to start the logs collection")
 		a.started.Store(status.StatusCollectionNotStarted)
+	} else {
+		a.startSchedulers()
+	}
+	return nil
+}
+
+// loadLocalSDSRules configures the SDS scanners with the local rules file.
+func (a *logAgent) loadLocalSDSRules(path string) error {
+	standardRules, agentConfig, err := sds.LoadLocalRules(path)
+	if err != nil {
+		return fmt.Errorf("can't load local SDS rules: %v", err)
+	}
+	if _, err := a.pipelineProvider.ReconfigureSDSStandardRules(standardRules); err != nil {
+		return fmt.Errorf("can't load local SDS standard rules: %v", err)
 	}
+	if _, err := a.pipelineProvider.ReconfigureSDSAgentConfig(agentConfig); err != nil {
+		return fmt.Errorf("can't load local SDS rules: %v", err)
+	}
+	a.log.Infof("Loaded local SDS rules from %s", path)
+	return nil
 }
 
 func (a *logAgent) startSchedulers() {
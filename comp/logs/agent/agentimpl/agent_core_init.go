@@ -57,6 +57,7 @@ func (a *logAgent) SetupPipeline(processingRules []*config.ProcessingRule, wmeta
 		a.hostname,
 		a.config,
 		a.compression,
+		a.metricSender(),
 		a.config.GetBool("logs_config.disable_distributed_senders"), // legacy
 		false, // serverless
 	)
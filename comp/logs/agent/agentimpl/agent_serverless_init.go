@@ -59,6 +59,7 @@ func (a *logAgent) SetupPipeline(
 		a.hostname,
 		a.config,
 		a.compression,
+		a.metricSender(),
 		true, // disable distributed sending for serverless
 		true, // serverless
 	)
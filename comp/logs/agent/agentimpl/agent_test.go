@@ -44,6 +44,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/client/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/sds"
 	"github.com/DataDog/datadog-agent/pkg/logs/service"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 	logsStatus "github.com/DataDog/datadog-agent/pkg/logs/status"
@@ -237,6 +238,26 @@ func (suite *AgentTestSuite) TestGetPipelineProvider() {
 	assert.NotNil(suite.T(), agent.GetPipelineProvider())
 }
 
+func (suite *AgentTestSuite) TestAgentFailsWithInvalidLocalSDSRules() {
+	if !sds.SDSEnabled {
+		suite.T().Skip("the agent is built without SDS")
+	}
+	l := mock.NewMockLogsIntake(suite.T())
+	defer l.Close()
+
+	endpoint := tcp.AddrToEndPoint(l.Addr())
+	endpoints := config.NewEndpoints(endpoint, nil, true, false)
+
+	// nothing but the local rules could unblock the collection
+	suite.configOverrides["logs_config.sds.rules_file"] = suite.testDir + "/missing.yaml"
+	suite.configOverrides["logs_config.sds.wait_for_configuration"] = "no_collection"
+	agent, _, _ := createAgent(suite, endpoints)
+
+	err := agent.startPipeline()
+	assert.ErrorContains(suite.T(), err, "can't load local SDS rules")
+	agent.stop(context.TODO())
+}
+
 func (suite *AgentTestSuite) TestStatusProvider() {
 	tests := []struct {
 		name     string
@@ -133,9 +133,16 @@ func (l *LogsConfigKeys) useCompression() bool {
 	return l.getConfig().GetBool(l.getConfigKey("use_compression"))
 }
 
+// hasAdditionalEndpoints returns true if logs are dual shipped to other Datadog intakes, endpoints with a
+// custom destination (file, webhook) are not taken into account.
 func (l *LogsConfigKeys) hasAdditionalEndpoints() bool {
 	endpoints, _ := l.getAdditionalEndpoints()
-	return len(endpoints) > 0
+	for _, e := range endpoints {
+		if e.Type == DatadogEndpointType {
+			return true
+		}
+	}
+	return false
 }
 
 // getMainAPIKey return the global API key for the current config with the path used to get it. Main api key means the
@@ -28,6 +28,19 @@ type IntakeProtocol string
 // IntakeOrigin indicates the log source to use for an endpoint intake.
 type IntakeOrigin string
 
+// EndpointType indicates where an endpoint ships logs to.
+type EndpointType string
+
+// Endpoint types
+const (
+	// DatadogEndpointType ships logs to a Datadog intake, it is the default.
+	DatadogEndpointType EndpointType = ""
+	// FileEndpointType writes logs to a rotating local file, one JSON message per line.
+	FileEndpointType EndpointType = "file"
+	// WebhookEndpointType posts logs to a generic HTTP endpoint, with a configurable body and headers.
+	WebhookEndpointType EndpointType = "webhook"
+)
+
 const (
 	_ EPIntakeVersion = iota
 	// EPIntakeVersion1 is version 1 of the envets platform intake API
@@ -71,6 +84,19 @@ type Endpoint struct {
 	TrackType IntakeTrackType
 	Protocol  IntakeProtocol
 	Origin    IntakeOrigin
+
+	// Type is where the endpoint ships logs to, additional endpoints only.
+	Type EndpointType `mapstructure:"type" json:"type"`
+
+	// File endpoints settings
+	Path        string `mapstructure:"path" json:"path"`
+	MaxFileSize int64  `mapstructure:"max_file_size" json:"max_file_size"`
+	MaxFiles    int    `mapstructure:"max_files" json:"max_files"`
+
+	// Webhook endpoints settings
+	URL          string            `mapstructure:"url" json:"url"`
+	BodyTemplate string            `mapstructure:"body_template" json:"body_template"`
+	Headers      map[string]string `mapstructure:"headers" json:"headers"`
 }
 
 // unmarshalEndpoint is used to load additional endpoints from the configuration which stored as JSON/mapstructure.
@@ -162,6 +188,7 @@ func loadTCPAdditionalEndpoints(main Endpoint, l *LogsConfigKeys) []Endpoint {
 		newE.TrackType = e.TrackType
 		newE.Protocol = e.Protocol
 		newE.Origin = e.Origin
+		newE.setCustomDestination(e.Endpoint)
 
 		if e.UseSSL != nil {
 			newE.useSSL = *e.UseSSL
@@ -198,6 +225,7 @@ func loadHTTPAdditionalEndpoints(main Endpoint, l *LogsConfigKeys, intakeTrackTy
 		newE.TrackType = e.TrackType
 		newE.Protocol = e.Protocol
 		newE.Origin = e.Origin
+		newE.setCustomDestination(e.Endpoint)
 
 		if e.UseSSL != nil {
 			newE.useSSL = *e.UseSSL
@@ -220,6 +248,18 @@ func loadHTTPAdditionalEndpoints(main Endpoint, l *LogsConfigKeys, intakeTrackTy
 	return newEndpoints
 }
 
+// setCustomDestination copies the settings of the endpoints not shipping logs
+// to a Datadog intake.
+func (e *Endpoint) setCustomDestination(from Endpoint) {
+	e.Type = from.Type
+	e.Path = from.Path
+	e.MaxFileSize = from.MaxFileSize
+	e.MaxFiles = from.MaxFiles
+	e.URL = from.URL
+	e.BodyTemplate = from.BodyTemplate
+	e.Headers = from.Headers
+}
+
 // GetAPIKey returns the latest API Key for the Endpoint, including when the configuration gets updated at runtime
 func (e *Endpoint) GetAPIKey() string {
 	return e.apiKey.Load()
@@ -232,6 +272,13 @@ func (e *Endpoint) UseSSL() bool {
 
 // GetStatus returns the endpoint status
 func (e *Endpoint) GetStatus(prefix string, useHTTP bool) string {
+	switch e.Type {
+	case FileEndpointType:
+		return fmt.Sprintf("%sWriting logs to file %s", prefix, e.Path)
+	case WebhookEndpointType:
+		return fmt.Sprintf("%sSending logs in HTTP to webhook %s", prefix, e.URL)
+	}
+
 	compression := "uncompressed"
 	if e.UseCompression {
 		compression = "compressed"
@@ -313,6 +360,11 @@ func (e *Endpoint) onConfigUpdate(l *LogsConfigKeys) {
 	})
 }
 
+// IsCustom returns true if the endpoint does not ship logs to a Datadog intake.
+func (e *Endpoint) IsCustom() bool {
+	return e.Type != DatadogEndpointType
+}
+
 // IsReliable returns true if the endpoint is reliable. Endpoints are reliable by default.
 func (e *Endpoint) IsReliable() bool {
 	return e.isReliable
@@ -63,6 +63,38 @@ func (suite *EndpointsTestSuite) TestLogsEndpointConfig() {
 	suite.Equal(1234, endpoints.Main.Port)
 }
 
+func (suite *EndpointsTestSuite) TestBuildEndpointsWithCustomEndpoints() {
+	suite.config.SetWithoutSource("api_key", "azerty")
+	suite.config.SetWithoutSource("logs_config.additional_endpoints", `[
+	{"type": "file", "path": "/var/log/datadog/security.log", "max_file_size": 1024, "max_files": 2},
+	{"type": "webhook", "url": "https://collector.example.com/logs", "headers": {"Authorization": "Bearer token"}, "body_template": "{{ join .Messages \"\\n\" }}", "is_reliable": false}
+]`)
+
+	// custom endpoints do not prevent the use of HTTP to ship logs to Datadog
+	endpoints, err := BuildEndpoints(suite.config, HTTPConnectivitySuccess, "test-track", "test-proto", "test-source")
+	suite.Nil(err)
+	suite.True(endpoints.UseHTTP)
+	suite.False(endpoints.Main.IsCustom())
+	suite.Equal(3, len(endpoints.Endpoints))
+
+	file := endpoints.Endpoints[1]
+	suite.True(file.IsCustom())
+	suite.True(file.IsReliable())
+	suite.Equal(FileEndpointType, file.Type)
+	suite.Equal("/var/log/datadog/security.log", file.Path)
+	suite.Equal(int64(1024), file.MaxFileSize)
+	suite.Equal(2, file.MaxFiles)
+	suite.Equal("Reliable: Writing logs to file /var/log/datadog/security.log", file.GetStatus("Reliable: ", true))
+
+	webhook := endpoints.Endpoints[2]
+	suite.True(webhook.IsCustom())
+	suite.False(webhook.IsReliable())
+	suite.Equal(WebhookEndpointType, webhook.Type)
+	suite.Equal("https://collector.example.com/logs", webhook.URL)
+	suite.Equal(map[string]string{"Authorization": "Bearer token"}, webhook.Headers)
+	suite.Equal(`{{ join .Messages "\n" }}`, webhook.BodyTemplate)
+}
+
 func (suite *EndpointsTestSuite) TestBuildEndpointsShouldSucceedWithDefaultAndValidOverride() {
 	var endpoints *Endpoints
 
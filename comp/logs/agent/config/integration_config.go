@@ -59,6 +59,13 @@ type LogsConfig struct {
 	IncludeMatches     StringSliceField `mapstructure:"include_matches" json:"include_matches" yaml:"include_matches"`          // Journald
 	ExcludeMatches     StringSliceField `mapstructure:"exclude_matches" json:"exclude_matches" yaml:"exclude_matches"`          // Journald
 	ContainerMode      bool             `mapstructure:"container_mode" json:"container_mode" yaml:"container_mode"`             // Journald
+	// TagFields maps journal fields to the tag names their value is reported as.
+	TagFields map[string]string `mapstructure:"tag_fields" json:"tag_fields" yaml:"tag_fields"` // Journald
+	// CursorPerUnit tails each included unit with its own cursor.
+	CursorPerUnit bool `mapstructure:"cursor_per_unit" json:"cursor_per_unit" yaml:"cursor_per_unit"` // Journald
+	// UseEntryHostname reports the logs with the hostname of their journal
+	// entry, e.g. for journals collected from remote hosts.
+	UseEntryHostname bool `mapstructure:"use_entry_hostname" json:"use_entry_hostname" yaml:"use_entry_hostname"` // Journald
 
 	Image string // Docker
 	Label string // Docker
@@ -93,6 +100,12 @@ type LogsConfig struct {
 	AutoMultiLine               *bool   `mapstructure:"auto_multi_line_detection" json:"auto_multi_line_detection" yaml:"auto_multi_line_detection"`
 	AutoMultiLineSampleSize     int     `mapstructure:"auto_multi_line_sample_size" json:"auto_multi_line_sample_size" yaml:"auto_multi_line_sample_size"`
 	AutoMultiLineMatchThreshold float64 `mapstructure:"auto_multi_line_match_threshold" json:"auto_multi_line_match_threshold" yaml:"auto_multi_line_match_threshold"`
+
+	// ThroughputLimit caps the rate at which the tailers of this source forward logs.
+	ThroughputLimit *ThroughputLimit `mapstructure:"throughput_limit" json:"throughput_limit" yaml:"throughput_limit"`
+
+	// Timestamp extracts the timestamp of the logs from their content.
+	Timestamp *TimestampExtraction `mapstructure:"timestamp" json:"timestamp" yaml:"timestamp"`
 }
 
 // StringSliceField is a custom type for unmarshalling comma-separated string values or typical yaml fields into a slice of strings.
@@ -164,6 +177,9 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprintf(&b, ws("IncludeUserUnits: %#v,"), c.IncludeUserUnits)
 		fmt.Fprintf(&b, ws("ExcludeUserUnits: %#v,"), c.ExcludeUserUnits)
 		fmt.Fprintf(&b, ws("ContainerMode: %t,"), c.ContainerMode)
+		fmt.Fprintf(&b, ws("TagFields: %#v,"), c.TagFields)
+		fmt.Fprintf(&b, ws("CursorPerUnit: %t,"), c.CursorPerUnit)
+		fmt.Fprintf(&b, ws("UseEntryHostname: %t,"), c.UseEntryHostname)
 	case WindowsEventType:
 		fmt.Fprintf(&b, ws("ChannelPath: %#v,"), c.ChannelPath)
 		fmt.Fprintf(&b, ws("Query: %#v,"), c.Query)
@@ -190,7 +206,9 @@ func (c *LogsConfig) Dump(multiline bool) string {
 		fmt.Fprint(&b, ws("AutoMultiLine: nil,"))
 	}
 	fmt.Fprintf(&b, ws("AutoMultiLineSampleSize: %d,"), c.AutoMultiLineSampleSize)
-	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f}"), c.AutoMultiLineMatchThreshold)
+	fmt.Fprintf(&b, ws("AutoMultiLineMatchThreshold: %f,"), c.AutoMultiLineMatchThreshold)
+	fmt.Fprintf(&b, ws("ThroughputLimit: %+v,"), c.ThroughputLimit)
+	fmt.Fprintf(&b, ws("Timestamp: %+v}"), c.Timestamp)
 	return b.String()
 }
 
@@ -289,6 +307,12 @@ func (c *LogsConfig) Validate() error {
 	case c.Type == UDPType && c.Port == 0:
 		return fmt.Errorf("udp source must have a port")
 	}
+	if err := c.ThroughputLimit.Validate(); err != nil {
+		return err
+	}
+	if err := c.Timestamp.Validate(); err != nil {
+		return err
+	}
 	err := ValidateProcessingRules(c.ProcessingRules)
 	if err != nil {
 		return err
@@ -23,6 +23,9 @@ func TestValidateShouldSucceedWithValidConfigs(t *testing.T) {
 		{Type: UDPType, Port: 5678},
 		{Type: DockerType},
 		{Type: JournaldType, ProcessingRules: []*ProcessingRule{{Name: "foo", Type: ExcludeAtMatch, Pattern: ".*"}}},
+		{Type: FileType, Path: "/var/log/foo.log", ThroughputLimit: &ThroughputLimit{BytesPerSecond: 1024}},
+		{Type: DockerType, ThroughputLimit: &ThroughputLimit{LinesPerSecond: 100, Policy: PausePolicy}},
+		{Type: FileType, Path: "/var/log/foo.log", Timestamp: &TimestampExtraction{AutoDetect: true, MaxPast: "24h"}},
 	}
 
 	for _, config := range validConfigs {
@@ -44,6 +47,9 @@ func TestValidateShouldFailWithInvalidConfigs(t *testing.T) {
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch, Pattern: ".*"}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Type: ExcludeAtMatch}}},
 		{Type: DockerType, ProcessingRules: []*ProcessingRule{{Pattern: ".*"}}},
+		{Type: DockerType, ThroughputLimit: &ThroughputLimit{BytesPerSecond: -1}},
+		{Type: DockerType, ThroughputLimit: &ThroughputLimit{LinesPerSecond: 100, Policy: "block"}},
+		{Type: DockerType, Timestamp: &TimestampExtraction{}},
 	}
 
 	for _, config := range invalidConfigs {
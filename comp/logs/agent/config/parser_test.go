@@ -39,6 +39,11 @@ func TestParseJSONWithValidFormatShouldSucceed(t *testing.T) {
 	rule := config.ProcessingRules[0]
 	assert.Equal(t, "multi_line", rule.Type)
 	assert.Equal(t, "numbers", rule.Name)
+
+	configs, err = ParseJSON([]byte(`[{"log_processing_rules":[{"type":"generate_metric","name":"latency","pattern":"took (?P<duration>\\d+)ms","metric":{"name":"app.duration","type":"distribution","value":"duration","tags":["team:logs"]}}]}]`))
+	assert.Nil(t, err)
+	rule = configs[0].ProcessingRules[0]
+	assert.Equal(t, &MetricRule{Name: "app.duration", Type: "distribution", Value: "duration", Tags: []string{"team:logs"}}, rule.Metric)
 }
 
 func TestParseJSONWithInvalidFormatShouldFail(t *testing.T) {
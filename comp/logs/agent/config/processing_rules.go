@@ -16,6 +16,14 @@ const (
 	IncludeAtMatch = "include_at_match"
 	MaskSequences  = "mask_sequences"
 	MultiLine      = "multi_line"
+	GenerateMetric = "generate_metric"
+)
+
+// Types of the metrics generated by generate_metric processing rules
+const (
+	CountMetric        = "count"
+	GaugeMetric        = "gauge"
+	DistributionMetric = "distribution"
 )
 
 // ProcessingRule defines an exclusion or a masking rule to
@@ -25,11 +33,25 @@ type ProcessingRule struct {
 	Name               string
 	ReplacePlaceholder string `mapstructure:"replace_placeholder" json:"replace_placeholder" yaml:"replace_placeholder"`
 	Pattern            string
+	Metric             *MetricRule `mapstructure:"metric" json:"metric,omitempty" yaml:"metric,omitempty"`
 	// TODO: should be moved out
 	Regex       *regexp.Regexp
 	Placeholder []byte
 }
 
+// MetricRule defines the metric submitted for each log line matching a
+// generate_metric processing rule.  The metric is tagged with the tags of the
+// log and the named capture groups of the pattern.
+type MetricRule struct {
+	Name string `mapstructure:"name" json:"name" yaml:"name"`
+	// Type is one of count (default), gauge or distribution.
+	Type string `mapstructure:"type" json:"type,omitempty" yaml:"type,omitempty"`
+	// Value is the name of the capture group holding the value of the metric,
+	// count metrics are incremented by one when it is not set.
+	Value string   `mapstructure:"value" json:"value,omitempty" yaml:"value,omitempty"`
+	Tags  []string `mapstructure:"tags" json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
 // ValidateProcessingRules validates the rules and raises an error if one is misconfigured.
 // Each processing rule must have:
 // - a valid name
@@ -44,6 +66,10 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		switch rule.Type {
 		case ExcludeAtMatch, IncludeAtMatch, MaskSequences, MultiLine:
 			break
+		case GenerateMetric:
+			if err := validateMetricRule(rule); err != nil {
+				return err
+			}
 		case "":
 			return fmt.Errorf("type must be set for processing rule `%s`", rule.Name)
 		default:
@@ -53,10 +79,31 @@ func ValidateProcessingRules(rules []*ProcessingRule) error {
 		if rule.Pattern == "" {
 			return fmt.Errorf("no pattern provided for processing rule: %s", rule.Name)
 		}
-		_, err := regexp.Compile(rule.Pattern)
+		re, err := regexp.Compile(rule.Pattern)
 		if err != nil {
 			return fmt.Errorf("invalid pattern %s for processing rule: %s", rule.Pattern, rule.Name)
 		}
+		if rule.Type == GenerateMetric && rule.Metric.Value != "" && re.SubexpIndex(rule.Metric.Value) < 0 {
+			return fmt.Errorf("no capture group %s in the pattern of processing rule: %s", rule.Metric.Value, rule.Name)
+		}
+	}
+	return nil
+}
+
+func validateMetricRule(rule *ProcessingRule) error {
+	if rule.Metric == nil || rule.Metric.Name == "" {
+		return fmt.Errorf("a metric name must be set for processing rule `%s`", rule.Name)
+	}
+	switch rule.Metric.Type {
+	case "":
+		rule.Metric.Type = CountMetric
+	case CountMetric:
+	case GaugeMetric, DistributionMetric:
+		if rule.Metric.Value == "" {
+			return fmt.Errorf("a value capture group must be set for the %s metric of processing rule `%s`", rule.Metric.Type, rule.Name)
+		}
+	default:
+		return fmt.Errorf("metric type %s is not supported for processing rule `%s`", rule.Metric.Type, rule.Name)
 	}
 	return nil
 }
@@ -69,7 +116,7 @@ func CompileProcessingRules(rules []*ProcessingRule) error {
 			return err
 		}
 		switch rule.Type {
-		case ExcludeAtMatch, IncludeAtMatch:
+		case ExcludeAtMatch, IncludeAtMatch, GenerateMetric:
 			rule.Regex = re
 		case MaskSequences:
 			rule.Regex = re
@@ -33,3 +33,26 @@ func TestCompileShouldFailWithInvalidRules(t *testing.T) {
 		assert.Nil(t, rule.Regex)
 	}
 }
+
+func TestValidateGenerateMetricRules(t *testing.T) {
+	valid := []*ProcessingRule{
+		{Name: "count", Type: GenerateMetric, Pattern: "ERROR", Metric: &MetricRule{Name: "app.errors"}},
+		{Name: "gauge", Type: GenerateMetric, Pattern: `queue=(?P<size>\d+)`, Metric: &MetricRule{Name: "app.queue", Type: GaugeMetric, Value: "size"}},
+		{Name: "distribution", Type: GenerateMetric, Pattern: `took (?P<duration>\d+)ms`, Metric: &MetricRule{Name: "app.duration", Type: DistributionMetric, Value: "duration"}},
+	}
+	assert.NoError(t, ValidateProcessingRules(valid))
+	assert.Equal(t, CountMetric, valid[0].Metric.Type)
+	assert.NoError(t, CompileProcessingRules(valid))
+	assert.NotNil(t, valid[0].Regex)
+
+	invalid := []*ProcessingRule{
+		{Name: "no metric", Type: GenerateMetric, Pattern: "ERROR"},
+		{Name: "no name", Type: GenerateMetric, Pattern: "ERROR", Metric: &MetricRule{}},
+		{Name: "unknown type", Type: GenerateMetric, Pattern: "ERROR", Metric: &MetricRule{Name: "app.errors", Type: "rate"}},
+		{Name: "no value", Type: GenerateMetric, Pattern: "ERROR", Metric: &MetricRule{Name: "app.errors", Type: GaugeMetric}},
+		{Name: "unknown group", Type: GenerateMetric, Pattern: `took (?P<duration>\d+)ms`, Metric: &MetricRule{Name: "app.duration", Type: DistributionMetric, Value: "latency"}},
+	}
+	for _, rule := range invalid {
+		assert.Error(t, ValidateProcessingRules([]*ProcessingRule{rule}), rule.Name)
+	}
+}
@@ -0,0 +1,57 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+)
+
+// Throughput limit policies
+const (
+	// DropPolicy drops the messages exceeding the throughput limit of a source.
+	DropPolicy = "drop"
+	// PausePolicy pauses the tailing of a source until its throughput is back
+	// under its limit.
+	PausePolicy = "pause"
+)
+
+// ThroughputLimit defines the maximum rate at which the tailers of a source
+// forward logs to the pipeline, and what happens to the logs exceeding it.
+type ThroughputLimit struct {
+	BytesPerSecond int    `mapstructure:"bytes_per_second" json:"bytes_per_second" yaml:"bytes_per_second"`
+	LinesPerSecond int    `mapstructure:"lines_per_second" json:"lines_per_second" yaml:"lines_per_second"`
+	Policy         string `mapstructure:"policy" json:"policy" yaml:"policy"`
+}
+
+// IsEnabled returns true if at least one limit is set.
+func (l *ThroughputLimit) IsEnabled() bool {
+	return l != nil && (l.BytesPerSecond > 0 || l.LinesPerSecond > 0)
+}
+
+// ShouldPause returns true if the tailing should be paused rather than logs
+// dropped when the limit is exceeded.
+func (l *ThroughputLimit) ShouldPause() bool {
+	return l != nil && l.Policy == PausePolicy
+}
+
+// Validate returns an error if the throughput limit is misconfigured.
+func (l *ThroughputLimit) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.BytesPerSecond < 0 {
+		return fmt.Errorf("throughput limit bytes_per_second must be positive, got %d", l.BytesPerSecond)
+	}
+	if l.LinesPerSecond < 0 {
+		return fmt.Errorf("throughput limit lines_per_second must be positive, got %d", l.LinesPerSecond)
+	}
+	switch l.Policy {
+	case "", DropPolicy, PausePolicy:
+		return nil
+	default:
+		return fmt.Errorf("throughput limit policy %s is not supported, must be %s or %s", l.Policy, DropPolicy, PausePolicy)
+	}
+}
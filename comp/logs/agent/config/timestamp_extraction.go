@@ -0,0 +1,214 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Out of range timestamp policies
+const (
+	// RejectTimestampPolicy discards the timestamps outside of the accepted
+	// range, the logs are timestamped at ingestion time.
+	RejectTimestampPolicy = "reject"
+	// ClampTimestampPolicy replaces the timestamps outside of the accepted
+	// range by the closest bound of the range.
+	ClampTimestampPolicy = "clamp"
+)
+
+// timestampGroup is the name of the capture group of the pattern holding the
+// timestamp, the whole match is used when the pattern has no such group.
+const timestampGroup = "timestamp"
+
+// TimestampExtraction defines how the timestamp of the logs of a source is
+// extracted from their content.  Logs without a timestamp, or with a timestamp
+// which can't be parsed, are timestamped at ingestion time.
+type TimestampExtraction struct {
+	// Pattern locates the timestamp in the log, the timestamp is the
+	// "timestamp" named capture group or the whole match.  The timestamp
+	// detector of the auto multi-line detection is used when it is not set.
+	Pattern string `mapstructure:"pattern" json:"pattern" yaml:"pattern"`
+	// Layouts are the Go layouts (e.g. 2006-01-02 15:04:05) or strftime
+	// formats (e.g. %Y-%m-%d %H:%M:%S) the timestamp is parsed with, in order.
+	Layouts []string `mapstructure:"layouts" json:"layouts" yaml:"layouts"`
+	// AutoDetect parses the timestamp with the common timestamp formats when
+	// none of the layouts matches.
+	AutoDetect bool `mapstructure:"auto_detect" json:"auto_detect" yaml:"auto_detect"`
+	// Timezone is the location of the timestamps without a time zone, UTC by
+	// default.
+	Timezone string `mapstructure:"timezone" json:"timezone" yaml:"timezone"`
+	// MaxPast and MaxFuture are durations (e.g. 24h) bounding the accepted
+	// timestamps around the ingestion time, the range is unbounded when unset.
+	MaxPast   string `mapstructure:"max_past" json:"max_past" yaml:"max_past"`
+	MaxFuture string `mapstructure:"max_future" json:"max_future" yaml:"max_future"`
+	// OutOfRangePolicy is one of reject (default) or clamp.
+	OutOfRangePolicy string `mapstructure:"out_of_range_policy" json:"out_of_range_policy" yaml:"out_of_range_policy"`
+}
+
+// IsEnabled returns true if timestamps are extracted.
+func (t *TimestampExtraction) IsEnabled() bool {
+	return t != nil && (len(t.Layouts) > 0 || t.AutoDetect)
+}
+
+// Validate returns an error if the timestamp extraction is misconfigured.
+func (t *TimestampExtraction) Validate() error {
+	if t == nil {
+		return nil
+	}
+	if !t.IsEnabled() {
+		return fmt.Errorf("timestamp extraction requires layouts or auto_detect")
+	}
+	if _, err := t.Regex(); err != nil {
+		return err
+	}
+	if _, err := t.GoLayouts(); err != nil {
+		return err
+	}
+	if _, err := t.Location(); err != nil {
+		return err
+	}
+	if _, _, err := t.Range(); err != nil {
+		return err
+	}
+	switch t.OutOfRangePolicy {
+	case "", RejectTimestampPolicy, ClampTimestampPolicy:
+		return nil
+	default:
+		return fmt.Errorf("invalid timestamp out_of_range_policy %q, must be one of %q or %q", t.OutOfRangePolicy, RejectTimestampPolicy, ClampTimestampPolicy)
+	}
+}
+
+// Regex returns the compiled pattern, or nil if the timestamp detector is used
+// to locate the timestamp.
+func (t *TimestampExtraction) Regex() (*regexp.Regexp, error) {
+	if t.Pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp pattern %q: %v", t.Pattern, err)
+	}
+	return re, nil
+}
+
+// TimestampSubmatch returns the timestamp located by a compiled pattern in the
+// content, or nil if the pattern doesn't match.
+func TimestampSubmatch(re *regexp.Regexp, content []byte) []byte {
+	match := re.FindSubmatch(content)
+	if match == nil {
+		return nil
+	}
+	if i := re.SubexpIndex(timestampGroup); i > 0 {
+		return match[i]
+	}
+	return match[0]
+}
+
+// GoLayouts returns the layouts as Go layouts, the strftime formats being
+// converted.
+func (t *TimestampExtraction) GoLayouts() ([]string, error) {
+	layouts := make([]string, 0, len(t.Layouts))
+	for _, layout := range t.Layouts {
+		if !strings.Contains(layout, "%") {
+			layouts = append(layouts, layout)
+			continue
+		}
+		goLayout, err := StrftimeToGoLayout(layout)
+		if err != nil {
+			return nil, err
+		}
+		layouts = append(layouts, goLayout)
+	}
+	return layouts, nil
+}
+
+// Location returns the location of the timestamps without a time zone.
+func (t *TimestampExtraction) Location() (*time.Location, error) {
+	if t.Timezone == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp timezone %q: %v", t.Timezone, err)
+	}
+	return loc, nil
+}
+
+// Range returns the maximum durations the timestamps can be in the past and in
+// the future of the ingestion time, 0 meaning unbounded.
+func (t *TimestampExtraction) Range() (maxPast time.Duration, maxFuture time.Duration, err error) {
+	parse := func(field, value string) (time.Duration, error) {
+		if value == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid timestamp %s %q, must be a positive duration", field, value)
+		}
+		return d, nil
+	}
+	if maxPast, err = parse("max_past", t.MaxPast); err != nil {
+		return 0, 0, err
+	}
+	if maxFuture, err = parse("max_future", t.MaxFuture); err != nil {
+		return 0, 0, err
+	}
+	return maxPast, maxFuture, nil
+}
+
+// strftimeDirectives maps the supported strftime directives to their Go layout
+// equivalent.
+var strftimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'e': "_2",
+	'b': "Jan",
+	'h': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "000000",
+	'L': "000",
+	'p': "PM",
+	'z': "-0700",
+	'Z': "MST",
+	'j': "002",
+	'T': "15:04:05",
+	'F': "2006-01-02",
+	'D': "01/02/06",
+	'R': "15:04",
+	'%': "%",
+}
+
+// StrftimeToGoLayout converts a strftime format to a Go layout.
+func StrftimeToGoLayout(format string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if i+1 == len(format) {
+			return "", fmt.Errorf("invalid timestamp layout %q: trailing %%", format)
+		}
+		i++
+		directive, ok := strftimeDirectives[format[i]]
+		if !ok {
+			return "", fmt.Errorf("invalid timestamp layout %q: unsupported directive %%%c", format, format[i])
+		}
+		b.WriteString(directive)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,65 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimestampExtractionValidate(t *testing.T) {
+	valid := []*TimestampExtraction{
+		nil,
+		{AutoDetect: true},
+		{Layouts: []string{"2006-01-02 15:04:05", "%Y-%m-%dT%H:%M:%S.%f%z"}},
+		{Pattern: `^\[(?P<timestamp>[^\]]+)\]`, AutoDetect: true, Timezone: "America/New_York"},
+		{AutoDetect: true, MaxPast: "720h", MaxFuture: "5m", OutOfRangePolicy: ClampTimestampPolicy},
+	}
+	for _, cfg := range valid {
+		assert.NoError(t, cfg.Validate(), "%+v", cfg)
+	}
+
+	invalid := []*TimestampExtraction{
+		{},
+		{Pattern: "(", AutoDetect: true},
+		{Layouts: []string{"%Y-%Q"}},
+		{Layouts: []string{"%Y-%"}},
+		{AutoDetect: true, Timezone: "Mars/Olympus_Mons"},
+		{AutoDetect: true, MaxPast: "yesterday"},
+		{AutoDetect: true, MaxFuture: "-1h"},
+		{AutoDetect: true, OutOfRangePolicy: "drop"},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, cfg.Validate(), "%+v", cfg)
+	}
+}
+
+func TestStrftimeToGoLayout(t *testing.T) {
+	testCases := map[string]string{
+		"%Y-%m-%d %H:%M:%S":    "2006-01-02 15:04:05",
+		"%d/%b/%Y:%H:%M:%S %z": "02/Jan/2006:15:04:05 -0700",
+		"%FT%T.%f":             "2006-01-02T15:04:05.000000",
+		"%a %b %e %I:%M:%S %p": "Mon Jan _2 03:04:05 PM",
+		"100%% at %H:%M":       "100% at 15:04",
+		"no directive":         "no directive",
+	}
+	for format, expected := range testCases {
+		layout, err := StrftimeToGoLayout(format)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, layout, format)
+	}
+}
+
+func TestTimestampSubmatch(t *testing.T) {
+	withGroup := regexp.MustCompile(`ts=(?P<timestamp>\S+)`)
+	assert.Equal(t, "2024-05-14T10:30:00Z", string(TimestampSubmatch(withGroup, []byte("ts=2024-05-14T10:30:00Z msg=hello"))))
+	assert.Nil(t, TimestampSubmatch(withGroup, []byte("msg=hello")))
+
+	withoutGroup := regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	assert.Equal(t, "2024-05-14", string(TimestampSubmatch(withoutGroup, []byte("on 2024-05-14"))))
+}
@@ -37,6 +37,11 @@ func (a *NullAuditor) GetTailingMode(_ string) string {
 	return ""
 }
 
+// GetFingerprint returns an empty string
+func (a *NullAuditor) GetFingerprint(_ string) string {
+	return ""
+}
+
 // Start starts the NullAuditor main loop
 func (a *NullAuditor) Start() {
 	go a.run()
@@ -31,6 +31,13 @@ const defaultCleanupPeriod = 300 * time.Second
 // latest version of the API used by the auditor to retrieve the registry from disk.
 const registryAPIVersion = 2
 
+// rotatedIdentifier returns the identifier under which the registry keeps the
+// entry of a file whose content had the given fingerprint, once the file has
+// been rotated. It matches auditor.RotatedIdentifier of pkg/logs/auditor.
+func rotatedIdentifier(fingerprint string) string {
+	return "rotated:" + fingerprint
+}
+
 // A RegistryEntry represents an entry in the registry where we keep track
 // of current offsets
 type RegistryEntry struct {
@@ -38,6 +45,7 @@ type RegistryEntry struct {
 	Offset             string
 	TailingMode        string
 	IngestionTimestamp int64
+	Fingerprint        string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -172,6 +180,17 @@ func (a *registryAuditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetFingerprint returns the fingerprint of the content the last committed
+// offset refers to for a given identifier, returns an empty string if it does
+// not exist.
+func (a *registryAuditor) GetFingerprint(identifier string) string {
+	entry, exists := a.readOnlyRegistryEntryCopy(identifier)
+	if !exists {
+		return ""
+	}
+	return entry.Fingerprint
+}
+
 // run keeps up to date the registry on different events
 func (a *registryAuditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -195,7 +214,7 @@ func (a *registryAuditor) run() {
 			}
 			// update the registry with the new entry
 			for _, msg := range payload.MessageMetas {
-				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.IngestionTimestamp)
+				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint, msg.IngestionTimestamp)
 			}
 		case <-cleanUpTicker.C:
 			// remove expired offsets from the registry
@@ -250,7 +269,7 @@ func (a *registryAuditor) cleanupRegistry() {
 }
 
 // updateRegistry updates the registry entry matching identifier with the new offset and timestamp
-func (a *registryAuditor) updateRegistry(identifier string, offset string, tailingMode string, ingestionTimestamp int64) {
+func (a *registryAuditor) updateRegistry(identifier string, offset string, tailingMode string, fingerprint string, ingestionTimestamp int64) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -266,6 +285,11 @@ func (a *registryAuditor) updateRegistry(identifier string, offset string, taili
 		if v.IngestionTimestamp > ingestionTimestamp {
 			return
 		}
+		// Keep the entry of the previous content of a rotated file, so that
+		// reading its compressed archive can resume from there
+		if v.Fingerprint != "" && fingerprint != "" && v.Fingerprint != fingerprint {
+			a.registry[rotatedIdentifier(v.Fingerprint)] = v
+		}
 	}
 
 	a.registry[identifier] = &RegistryEntry{
@@ -273,6 +297,7 @@ func (a *registryAuditor) updateRegistry(identifier string, offset string, taili
 		Offset:             offset,
 		TailingMode:        tailingMode,
 		IngestionTimestamp: ingestionTimestamp,
+		Fingerprint:        fingerprint,
 	}
 }
 
@@ -61,16 +61,25 @@ func (suite *AuditorTestSuite) TestAuditorStartStop() {
 func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.Equal(0, len(suite.a.registry))
-	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "", 0)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("end", suite.a.registry[suite.source.Config.Path].TailingMode)
-	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", 1)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", "", 1)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("43", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("beginning", suite.a.registry[suite.source.Config.Path].TailingMode)
 }
 
+func (suite *AuditorTestSuite) TestAuditorKeepsRotatedEntry() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "end", "2f0b9c1e4d3a5b68", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "7", "end", "8e1d6c0b2a4f3957", 1)
+	suite.Equal(2, len(suite.a.registry))
+	suite.Equal("7", suite.a.GetOffset(suite.source.Config.Path))
+	suite.Equal("43", suite.a.GetOffset(rotatedIdentifier("2f0b9c1e4d3a5b68")))
+}
+
 func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.a.registry[suite.source.Config.Path] = &RegistryEntry{
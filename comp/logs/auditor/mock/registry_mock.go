@@ -16,6 +16,7 @@ import (
 type RegistryMock struct {
 	offset      string
 	tailingMode string
+	fingerprint string
 }
 
 // GetOffset returns the offset.
@@ -38,6 +39,16 @@ func (r *RegistryMock) SetTailingMode(tailingMode string) {
 	r.tailingMode = tailingMode
 }
 
+// GetFingerprint returns the fingerprint.
+func (r *RegistryMock) GetFingerprint(_ string) string {
+	return r.fingerprint
+}
+
+// SetFingerprint sets the fingerprint.
+func (r *RegistryMock) SetFingerprint(fingerprint string) {
+	r.fingerprint = fingerprint
+}
+
 // Channel returns a channel
 func (r *RegistryMock) Channel() chan *message.Payload {
 	return nil
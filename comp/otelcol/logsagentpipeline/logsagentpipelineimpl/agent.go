@@ -225,6 +225,7 @@ func (a *Agent) SetupPipeline(
 		a.hostname,
 		a.config,
 		a.compression,
+		nil, // metric sender
 		a.config.GetBool("logs_config.disable_distributed_senders"),
 		false, // serverless
 	)
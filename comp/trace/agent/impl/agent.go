@@ -32,6 +32,7 @@ import (
 	compression "github.com/DataDog/datadog-agent/comp/trace/compression/def"
 	"github.com/DataDog/datadog-agent/comp/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/config/env"
+	"github.com/DataDog/datadog-agent/pkg/logs/sds"
 	"github.com/DataDog/datadog-agent/pkg/pidfile"
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	agentrt "github.com/DataDog/datadog-agent/pkg/runtime"
@@ -105,6 +106,7 @@ type component struct {
 	telemetryCollector telemetry.TelemetryCollector
 	at                 authtoken.Component
 	wg                 *sync.WaitGroup
+	spanScanner        *spanScanner
 }
 
 // NewAgent creates a new Agent component.
@@ -118,6 +120,17 @@ func NewAgent(deps dependencies) (traceagent.Component, error) {
 		_ = deps.Shutdowner.Shutdown()
 		return c, nil
 	}
+	var scanner *spanScanner
+	if tracecfg.SDS.Enabled {
+		if !sds.SDSEnabled {
+			log.Warn("apm_config.sds.enabled is set but this trace-agent was built without the Sensitive Data Scanner, the span tags won't be scanned")
+		} else {
+			var err error
+			if scanner, err = newSpanScanner(tracecfg.SDS.RulesFile); err != nil {
+				return nil, fmt.Errorf("can't load the SDS rules: %v", err)
+			}
+		}
+	}
 	ctx, cancel := context.WithCancel(deps.Context) // Several related non-components require a shared context to gracefully stop.
 	c = component{
 		cancel:             cancel,
@@ -128,6 +141,7 @@ func NewAgent(deps dependencies) (traceagent.Component, error) {
 		tagger:             deps.Tagger,
 		at:                 deps.At,
 		wg:                 &sync.WaitGroup{},
+		spanScanner:        scanner,
 	}
 	statsdCl, err := setupMetrics(deps.Statsd, c.config, c.telemetryCollector)
 	if err != nil {
@@ -144,6 +158,9 @@ func NewAgent(deps dependencies) (traceagent.Component, error) {
 		statsdCl,
 		deps.Compressor,
 	)
+	if scanner != nil {
+		c.Agent.SensitiveDataScanner = scanner
+	}
 
 	c.config.OnUpdateAPIKey(c.UpdateAPIKey)
 
@@ -254,6 +271,7 @@ func stop(ag component) error {
 		log.Error("Could not flush statsd: ", err)
 	}
 	stopAgentSidekicks(ag.config, ag.Statsd, ag.params.DisableInternalProfiling)
+	ag.spanScanner.stop()
 	if ag.params.CPUProfile != "" {
 		pprof.StopCPUProfile()
 	}
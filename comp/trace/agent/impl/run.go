@@ -80,6 +80,15 @@ func runAgentSidekicks(ag component) error {
 		},
 	})
 
+	// Adding a route to capture the received payloads from the CLI.
+	captureHandler := ag.Agent.Receiver.CaptureHandler()
+	ag.Agent.DebugServer.AddRoute("/capture", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if apiutil.Validate(w, req) != nil {
+			return
+		}
+		captureHandler.ServeHTTP(w, req)
+	}))
+
 	if secrets, ok := ag.secrets.Get(); ok {
 		// Adding a route to trigger a secrets refresh from the CLI.
 		// TODO - components: the secrets comp already export a route but it requires the API component which is not
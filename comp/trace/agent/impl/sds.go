@@ -0,0 +1,66 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agentimpl
+
+import (
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/sds"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// spanScanner scans the span tag values with the Sensitive Data Scanner of the logs.
+type spanScanner struct {
+	scanner *sds.Scanner
+}
+
+// newSpanScanner returns a spanScanner applying the rules of the local SDS
+// rules file at path.
+func newSpanScanner(path string) (*spanScanner, error) {
+	standardRules, agentConfig, err := sds.LoadLocalRules(path)
+	if err != nil {
+		return nil, err
+	}
+	scanner := sds.CreateScanner("trace-agent")
+	if _, err := scanner.Reconfigure(sds.ReconfigureOrder{Type: sds.StandardRules, Config: standardRules}); err != nil {
+		scanner.Delete()
+		return nil, err
+	}
+	if _, err := scanner.Reconfigure(sds.ReconfigureOrder{Type: sds.AgentConfig, Config: agentConfig}); err != nil {
+		scanner.Delete()
+		return nil, err
+	}
+	if !scanner.IsReady() {
+		scanner.Delete()
+		return nil, fmt.Errorf("no enabled rule in %s", path)
+	}
+	log.Infof("Scanning the span tags with the SDS rules from %s", path)
+	return &spanScanner{scanner: scanner}, nil
+}
+
+// Scan implements pkgagent.SensitiveDataScanner.
+func (s *spanScanner) Scan(value string) (string, []string) {
+	mutated, processed, rules, err := s.scanner.ScanRules([]byte(value))
+	if err != nil {
+		log.Debugf("Error scanning a span tag with SDS: %v", err)
+		return value, nil
+	}
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	if mutated {
+		value = string(processed)
+	}
+	return value, names
+}
+
+// stop deallocates the scanner.
+func (s *spanScanner) stop() {
+	if s != nil {
+		s.scanner.Delete()
+	}
+}
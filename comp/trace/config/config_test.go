@@ -633,6 +633,37 @@ func TestFullYamlConfig(t *testing.T) {
 		},
 	}, cfg.ReplaceTags)
 
+	assert.True(t, cfg.ZipkinReceiverEnabled)
+	assert.True(t, cfg.JaegerReceiverEnabled)
+
+	assert.True(t, cfg.TailSampling.Enabled)
+	assert.Equal(t, 30*time.Second, cfg.TailSampling.DecisionWait)
+	assert.EqualValues(t, 1048576, cfg.TailSampling.MaxMemory)
+	assert.Equal(t, []*traceconfig.TailSamplingPolicy{
+		{Name: "slow", Type: traceconfig.TailSamplingLatency, ThresholdMS: 500},
+		{Name: "resource_1", Type: traceconfig.TailSamplingResource, Service: "web", Pattern: "^POST /checkout", Re: regexp.MustCompile("^POST /checkout")},
+	}, cfg.TailSampling.Policies)
+	assert.Equal(t, traceconfig.ServiceMapConfig{Enabled: true, MaxEdges: 50}, cfg.ServiceMap)
+	assert.Equal(t, []*traceconfig.FilterRule{
+		{Name: "drop_health_checks", Action: traceconfig.FilterDrop, Service: "^web$", Resource: "^GET /health", ServiceRe: regexp.MustCompile("^web$"), ResourceRe: regexp.MustCompile("^GET /health")},
+		{Name: "replace_tag_1", Action: traceconfig.FilterReplaceTag, Tag: "customer.id", Pattern: "[0-9]+", Repl: "?", Re: regexp.MustCompile("[0-9]+")},
+	}, cfg.FilterRules)
+	assert.Equal(t, []string{"tenant", "region"}, cfg.ExtraAggregationTags)
+	assert.Equal(t, 20, cfg.ExtraAggregationTagsMaxValues)
+	assert.Equal(t, traceconfig.FileExportConfig{
+		Enabled:     true,
+		Dir:         "/var/lib/datadog-agent/apm-export",
+		Format:      traceconfig.FileExportJSON,
+		MaxFileSize: 1048576,
+		MaxFileAge:  time.Minute,
+		MaxFiles:    3,
+	}, cfg.FileExport)
+	assert.Equal(t, traceconfig.SDSConfig{Enabled: true, RulesFile: "/etc/datadog-agent/sds-rules.yaml"}, cfg.SDS)
+	assert.Equal(t, []*traceconfig.ServiceBudget{
+		{Service: "web-store", Env: "prod", MaxSpansPerSecond: 500},
+		{Env: "staging", MaxSpansPerSecond: 100},
+	}, cfg.ServiceBudgets)
+
 	assert.EqualValues(t, []string{"/health", "/500"}, cfg.Ignore["resource"])
 
 	o := cfg.Obfuscation
@@ -647,6 +678,9 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(t, o.Redis.Enabled)
 	assert.True(t, o.Memcached.Enabled)
 	assert.True(t, o.Memcached.KeepCommand)
+	assert.False(t, o.GraphQL.Enabled)
+	assert.True(t, o.DynamoDB.Enabled)
+	assert.EqualValues(t, []string{"tenant_id"}, o.DynamoDB.KeepValues)
 	assert.True(t, o.CreditCards.Enabled)
 	assert.True(t, o.CreditCards.Luhn)
 	assert.True(t, o.Cache.Enabled)
@@ -1289,6 +1323,88 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(t, 337.41, cfg.MaxRemoteTPS)
 	})
 
+	env = "DD_APM_FILE_EXPORT_FORMAT"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "msgpack")
+		t.Setenv("DD_APM_FILE_EXPORT_PATH", "/tmp/apm-export")
+		t.Setenv("DD_APM_FILE_EXPORT_MAX_FILE_AGE", "0.5")
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, traceconfig.FileExportMsgpack, cfg.FileExport.Format)
+		assert.Equal(t, "/tmp/apm-export", cfg.FileExport.Dir)
+		assert.Equal(t, 500*time.Millisecond, cfg.FileExport.MaxFileAge)
+	})
+
+	env = "DD_APM_SDS_RULES_FILE"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "/tmp/sds-rules.yaml")
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, "/tmp/sds-rules.yaml", cfg.SDS.RulesFile)
+	})
+
+	env = "DD_APM_SDS_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+		t.Setenv("DD_LOGS_CONFIG_SDS_RULES_FILE", "/tmp/logs-sds-rules.yaml")
+
+		c := buildConfigComponent(t, true)
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.True(t, cfg.SDS.Enabled)
+		// the rules of the logs are used by default
+		assert.Equal(t, "/tmp/logs-sds-rules.yaml", cfg.SDS.RulesFile)
+	})
+
+	env = "DD_APM_SERVICE_MAP_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+		t.Setenv("DD_APM_SERVICE_MAP_MAX_EDGES", "20")
+
+		c := buildConfigComponent(t, true)
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, traceconfig.ServiceMapConfig{Enabled: true, MaxEdges: 20}, cfg.ServiceMap)
+	})
+
+	env = "DD_APM_SERVICE_BUDGETS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `[{"service":"web","max_spans_per_second":10.5}]`)
+
+		c := buildConfigComponent(t, true)
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []*traceconfig.ServiceBudget{{Service: "web", MaxSpansPerSecond: 10.5}}, cfg.ServiceBudgets)
+	})
+
+	env = "DD_APM_EXTRA_AGGREGATION_TAGS"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `["tenant","team"]`)
+		t.Setenv("DD_APM_EXTRA_AGGREGATION_TAGS_MAX_VALUES", "0")
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []string{"tenant", "team"}, cfg.ExtraAggregationTags)
+		assert.Equal(t, 0, cfg.ExtraAggregationTagsMaxValues)
+	})
+
 	env = "DD_APM_ADDITIONAL_ENDPOINTS"
 	t.Run(env, func(t *testing.T) {
 		t.Setenv(env, `{"url1": ["key1", "key2"], "url2": ["key3"]}`)
@@ -1598,6 +1714,37 @@ func TestLoadEnv(t *testing.T) {
 		assert.True(t, cfg.Obfuscation.Memcached.KeepCommand)
 	})
 
+	env = "DD_APM_OBFUSCATION_GRAPHQL_ENABLED"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "true")
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		assert.True(t, pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.graphql.enabled"))
+		assert.True(t, cfg.Obfuscation.GraphQL.Enabled)
+	})
+
+	env = "DD_APM_OBFUSCATION_DYNAMODB_KEEP_VALUES"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, `["tenant_id", "region"]`)
+
+		c := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/full.yaml"},
+		}))
+		cfg := c.Object()
+
+		assert.NotNil(t, cfg)
+		expected := []string{"tenant_id", "region"}
+		actualConfig := pkgconfigsetup.Datadog().GetStringSlice("apm_config.obfuscation.dynamodb.keep_values")
+		actualParsed := cfg.Obfuscation.DynamoDB.KeepValues
+		assert.Equal(t, expected, actualConfig)
+		assert.Equal(t, expected, actualParsed)
+	})
+
 	env = "DD_APM_OBFUSCATION_MONGODB_ENABLED"
 	t.Run(env, func(t *testing.T) {
 		t.Setenv(env, "true")
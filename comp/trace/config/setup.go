@@ -273,6 +273,12 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.peer_tags") {
 		c.PeerTags = core.GetStringSlice("apm_config.peer_tags")
 	}
+	if core.IsSet("apm_config.extra_aggregation_tags") {
+		c.ExtraAggregationTags = core.GetStringSlice("apm_config.extra_aggregation_tags")
+	}
+	if core.IsSet("apm_config.extra_aggregation_tags_max_values") {
+		c.ExtraAggregationTagsMaxValues = core.GetInt("apm_config.extra_aggregation_tags_max_values")
+	}
 
 	if core.IsSet("apm_config.extra_sample_rate") {
 		c.ExtraSampleRate = core.GetFloat64("apm_config.extra_sample_rate")
@@ -317,6 +323,70 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		c.ErrorTrackingStandalone = core.GetBool("apm_config.error_tracking_standalone.enabled")
 	}
 
+	if core.IsSet("apm_config.tail_sampling.enabled") {
+		c.TailSampling.Enabled = core.GetBool("apm_config.tail_sampling.enabled")
+	}
+	if core.IsSet("apm_config.tail_sampling.decision_wait") {
+		c.TailSampling.DecisionWait = time.Duration(core.GetFloat64("apm_config.tail_sampling.decision_wait") * float64(time.Second))
+	}
+	if core.IsSet("apm_config.tail_sampling.max_memory") {
+		c.TailSampling.MaxMemory = core.GetInt64("apm_config.tail_sampling.max_memory")
+	}
+	if k := "apm_config.tail_sampling.policies"; core.IsSet(k) {
+		policies := make([]*config.TailSamplingPolicy, 0)
+		if err := structure.UnmarshalKey(core, k, &policies); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"policy_name\",\"type\":\"latency\",\"threshold_ms\":500}]', error: %v", k, err)
+		} else {
+			if err := compileTailSamplingPolicies(policies); err != nil {
+				return fmt.Errorf("tail_sampling: %s", err)
+			}
+			c.TailSampling.Policies = policies
+		}
+	}
+	if c.TailSampling.Enabled && len(c.TailSampling.Policies) == 0 {
+		log.Warn("Tail sampling is enabled without policies, only the traces with an error will be kept.")
+		c.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "errors", Type: config.TailSamplingError}}
+	}
+
+	if core.IsSet("apm_config.file_export.enabled") {
+		c.FileExport.Enabled = core.GetBool("apm_config.file_export.enabled")
+	}
+	if core.IsSet("apm_config.file_export.path") {
+		c.FileExport.Dir = core.GetString("apm_config.file_export.path")
+	}
+	if core.IsSet("apm_config.file_export.format") {
+		c.FileExport.Format = core.GetString("apm_config.file_export.format")
+	}
+	if core.IsSet("apm_config.file_export.max_file_size") {
+		c.FileExport.MaxFileSize = core.GetInt64("apm_config.file_export.max_file_size")
+	}
+	if core.IsSet("apm_config.file_export.max_file_age") {
+		c.FileExport.MaxFileAge = time.Duration(core.GetFloat64("apm_config.file_export.max_file_age") * float64(time.Second))
+	}
+	if core.IsSet("apm_config.file_export.max_files") {
+		c.FileExport.MaxFiles = core.GetInt("apm_config.file_export.max_files")
+	}
+	if c.FileExport.Enabled {
+		if c.FileExport.Dir == "" {
+			return errors.New("file_export: a path is required")
+		}
+		if f := c.FileExport.Format; f != config.FileExportMsgpack && f != config.FileExportJSON {
+			return fmt.Errorf("file_export: unknown format %q, must be msgpack or json", f)
+		}
+	}
+
+	if core.IsSet("apm_config.sds.enabled") {
+		c.SDS.Enabled = core.GetBool("apm_config.sds.enabled")
+	}
+	// the rules of the logs are used by default
+	c.SDS.RulesFile = core.GetString("logs_config.sds.rules_file")
+	if core.IsSet("apm_config.sds.rules_file") {
+		c.SDS.RulesFile = core.GetString("apm_config.sds.rules_file")
+	}
+	if c.SDS.Enabled && c.SDS.RulesFile == "" {
+		return errors.New("sds: a rules_file is required")
+	}
+
 	if core.IsSet("apm_config.max_remote_traces_per_second") {
 		c.MaxRemoteTPS = core.GetFloat64("apm_config.max_remote_traces_per_second")
 	}
@@ -367,6 +437,37 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 		}
 	}
 
+	if core.IsSet("apm_config.service_map.enabled") {
+		c.ServiceMap.Enabled = core.GetBool("apm_config.service_map.enabled")
+	}
+	if core.IsSet("apm_config.service_map.max_edges") {
+		c.ServiceMap.MaxEdges = core.GetInt("apm_config.service_map.max_edges")
+	}
+
+	if k := "apm_config.filter_rules"; core.IsSet(k) {
+		rules := make([]*config.FilterRule, 0)
+		if err := structure.UnmarshalKey(core, k, &rules); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"name\": \"rule_name\",\"action\":\"drop\",\"service\":\"^web$\",\"resource\":\"^GET /health\"}]', error: %v", k, err)
+		} else {
+			if err := compileFilterRules(rules); err != nil {
+				return fmt.Errorf("filter_rules: %s", err)
+			}
+			c.FilterRules = rules
+		}
+	}
+
+	if k := "apm_config.service_budgets"; core.IsSet(k) {
+		budgets := make([]*config.ServiceBudget, 0)
+		if err := structure.UnmarshalKey(core, k, &budgets); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"service\": \"web\",\"env\":\"prod\",\"max_spans_per_second\":1000}]', error: %v", k, err)
+		} else {
+			if err := validateServiceBudgets(budgets); err != nil {
+				return fmt.Errorf("service_budgets: %s", err)
+			}
+			c.ServiceBudgets = budgets
+		}
+	}
+
 	if core.IsSet("bind_host") || core.IsSet("apm_config.apm_non_local_traffic") {
 		if core.IsSet("bind_host") {
 			host := core.GetString("bind_host")
@@ -426,6 +527,9 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	}
 	applyOrCreateInstallSignature(c)
 
+	c.ZipkinReceiverEnabled = core.GetBool("apm_config.zipkin.enabled")
+	c.JaegerReceiverEnabled = core.GetBool("apm_config.jaeger.enabled")
+
 	if core.GetBool("apm_config.telemetry.enabled") {
 		c.TelemetryConfig.Enabled = true
 		c.TelemetryConfig.Endpoints = []*config.Endpoint{{
@@ -470,6 +574,9 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	c.Obfuscation.CreditCards.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.credit_cards.enabled")
 	c.Obfuscation.CreditCards.Luhn = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.credit_cards.luhn")
 	c.Obfuscation.CreditCards.KeepValues = pkgconfigsetup.Datadog().GetStringSlice("apm_config.obfuscation.credit_cards.keep_values")
+	c.Obfuscation.GraphQL.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.graphql.enabled")
+	c.Obfuscation.DynamoDB.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.dynamodb.enabled")
+	c.Obfuscation.DynamoDB.KeepValues = pkgconfigsetup.Datadog().GetStringSlice("apm_config.obfuscation.dynamodb.keep_values")
 	c.Obfuscation.Cache.Enabled = pkgconfigsetup.Datadog().GetBool("apm_config.obfuscation.cache.enabled")
 	c.Obfuscation.Cache.MaxSize = pkgconfigsetup.Datadog().GetInt64("apm_config.obfuscation.cache.max_size")
 
@@ -719,6 +826,110 @@ func compileReplaceRules(rules []*config.ReplaceRule) error {
 	return nil
 }
 
+// validateServiceBudgets validates the service budgets. If it fails it returns
+// the first error.
+func validateServiceBudgets(budgets []*config.ServiceBudget) error {
+	for i, b := range budgets {
+		if b.Service == "" && b.Env == "" {
+			return fmt.Errorf("budget #%d: one of service or env is required", i)
+		}
+		if b.MaxSpansPerSecond <= 0 {
+			return fmt.Errorf("budget #%d (service:%s,env:%s): max_spans_per_second must be positive", i, b.Service, b.Env)
+		}
+	}
+	return nil
+}
+
+// compileFilterRules validates the filter rules and compiles their regular
+// expressions. If it fails it returns the first error.
+func compileFilterRules(rules []*config.FilterRule) error {
+	compile := func(r *config.FilterRule, field, pattern string) (*regexp.Regexp, error) {
+		if pattern == "" {
+			return nil, nil
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %s: %s", r.Name, field, err)
+		}
+		return re, nil
+	}
+	for i, r := range rules {
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%s_%d", r.Action, i)
+		}
+		switch r.Action {
+		case config.FilterDrop, config.FilterKeep:
+		case config.FilterSetTag, config.FilterRemoveTag:
+			if r.Tag == "" {
+				return fmt.Errorf("rule %q: %s rules must have a \"tag\"", r.Name, r.Action)
+			}
+		case config.FilterReplaceTag:
+			if r.Tag == "" || r.Pattern == "" {
+				return fmt.Errorf("rule %q: replace_tag rules must have a \"tag\" and a \"pattern\"", r.Name)
+			}
+		default:
+			return fmt.Errorf("rule %q: unknown action %q, must be one of drop, keep, set_tag, remove_tag or replace_tag", r.Name, r.Action)
+		}
+		var err error
+		if r.ServiceRe, err = compile(r, "service", r.Service); err != nil {
+			return err
+		}
+		if r.OperationRe, err = compile(r, "operation", r.Operation); err != nil {
+			return err
+		}
+		if r.ResourceRe, err = compile(r, "resource", r.Resource); err != nil {
+			return err
+		}
+		if r.Re, err = compile(r, "pattern", r.Pattern); err != nil {
+			return err
+		}
+		if len(r.Tags) > 0 {
+			r.TagsRe = make(map[string]*regexp.Regexp, len(r.Tags))
+			for k, pattern := range r.Tags {
+				if r.TagsRe[k], err = compile(r, "tag "+k, pattern); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// compileTailSamplingPolicies validates the tail sampling policies and compiles
+// their regular expressions. If it fails it returns the first error.
+func compileTailSamplingPolicies(policies []*config.TailSamplingPolicy) error {
+	for i, p := range policies {
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("%s_%d", p.Type, i)
+		}
+		switch p.Type {
+		case config.TailSamplingLatency:
+			if p.ThresholdMS <= 0 {
+				return fmt.Errorf("policy %q: latency policies must have a positive \"threshold_ms\"", p.Name)
+			}
+		case config.TailSamplingError:
+		case config.TailSamplingResource:
+			if p.Pattern == "" {
+				return fmt.Errorf("policy %q: resource policies must have a \"pattern\"", p.Name)
+			}
+		case config.TailSamplingTag:
+			if p.Key == "" {
+				return fmt.Errorf("policy %q: tag policies must have a \"key\"", p.Name)
+			}
+		default:
+			return fmt.Errorf("policy %q: unknown type %q, must be one of latency, error, resource or tag", p.Name, p.Type)
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				return fmt.Errorf("policy %q: %s", p.Name, err)
+			}
+			p.Re = re
+		}
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
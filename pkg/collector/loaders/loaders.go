@@ -7,7 +7,11 @@
 package loaders
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 
 	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
@@ -23,6 +27,24 @@ import (
 // interpreter might not be initialized when `init`ing a package)
 type LoaderFactory func(sender.SenderManager, option.Option[integrations.Component], tagger.Component) (check.Loader, error)
 
+// ErrDisabled is returned, possibly wrapped, by the factories of the loaders
+// disabled in the configuration, which are skipped without being reported.
+var ErrDisabled = errors.New("loader disabled")
+
+// CheckFilePath returns the path of the file of the check name in dir, with the
+// given suffix. It returns an error if the name could point outside of dir.
+func CheckFilePath(dir, name, suffix string) (string, error) {
+	if name == "" || name == "." || strings.ContainsAny(name, `/\`) || strings.Contains(name, "..") {
+		return "", fmt.Errorf("invalid check name %q", name)
+	}
+	dir = filepath.Clean(dir)
+	path := filepath.Join(dir, name+suffix)
+	if filepath.Dir(path) != dir {
+		return "", fmt.Errorf("invalid check name %q", name)
+	}
+	return path, nil
+}
+
 var factoryCatalog = make(map[int][]LoaderFactory)
 var loaderCatalog = []check.Loader{}
 var once sync.Once
@@ -49,6 +71,10 @@ func LoaderCatalog(senderManager sender.SenderManager, logReceiver option.Option
 		for _, k := range keys {
 			for _, factory := range factoryCatalog[k] {
 				loader, err := factory(senderManager, logReceiver, tagger)
+				if errors.Is(err, ErrDisabled) {
+					log.Debugf("Skipping a check loader: %v", err)
+					continue
+				}
 				if err != nil {
 					log.Infof("Failed to instantiate %s: %v", loader, err)
 					continue
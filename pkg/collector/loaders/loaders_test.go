@@ -7,6 +7,7 @@ package loaders
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -72,9 +73,14 @@ func TestLoaderCatalog(t *testing.T) {
 		return l3, errors.New("error")
 	}
 
+	factory4 := func(sender.SenderManager, option.Option[integrations.Component], tagger.Component) (check.Loader, error) {
+		return nil, fmt.Errorf("loader four is disabled: %w", ErrDisabled)
+	}
+
 	RegisterLoader(20, factory1)
 	RegisterLoader(10, factory2)
 	RegisterLoader(30, factory3)
+	RegisterLoader(40, factory4)
 	senderManager := mocksender.CreateDefaultDemultiplexer()
 	logReceiver := option.None[integrations.Component]()
 	tagger := nooptagger.NewComponent()
@@ -0,0 +1,232 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/protocol"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// cancelGracePeriod is the time given to a plugin to exit once canceled,
+// after which it is killed.
+const cancelGracePeriod = 5 * time.Second
+
+// PluginCheck is a check run by a plugin process. The process is started when
+// the check is configured and kept running between the runs. When it crashes
+// or times out, it is restarted and configured again at the next run.
+type PluginCheck struct {
+	corechecks.CheckBase
+	path       string
+	limits     limits
+	runTimeout time.Duration
+
+	params  protocol.ConfigureParams
+	version string
+
+	mu       sync.Mutex // guards proc and canceled
+	proc     *process
+	canceled bool
+}
+
+// newPluginCheck returns a check run by the plugin at path.
+func newPluginCheck(name, path string, l limits, runTimeout time.Duration) *PluginCheck {
+	return &PluginCheck{
+		CheckBase:  corechecks.NewCheckBase(name),
+		path:       path,
+		limits:     l,
+		runTimeout: runTimeout,
+	}
+}
+
+// Loader returns the name of the check loader
+func (*PluginCheck) Loader() string {
+	return PluginCheckLoaderName
+}
+
+// Version returns the version reported by the plugin
+func (c *PluginCheck) Version() string {
+	return c.version
+}
+
+// Configure starts the plugin and configures it with the instance
+func (c *PluginCheck) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(integrationConfigDigest, data, initConfig)
+	if err := c.CommonConfigure(senderManager, initConfig, data, source); err != nil {
+		return err
+	}
+	c.params = protocol.ConfigureParams{
+		Version:    protocol.Version,
+		Name:       c.String(),
+		CheckID:    string(c.ID()),
+		InitConfig: string(initConfig),
+		Instance:   string(data),
+		Source:     source,
+	}
+	_, err := c.process()
+	return err
+}
+
+// Run runs the check once in the plugin
+func (c *PluginCheck) Run() error {
+	s, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	p, err := c.process()
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.call(protocol.Request{Method: protocol.MethodRun}, c.runTimeout, func(m protocol.Message) {
+		c.submit(s, m)
+	})
+	if errors.Is(err, errTimeout) {
+		p.kill()
+		return fmt.Errorf("plugin %s did not complete its run within %s and was killed", c.path, c.runTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	s.Commit()
+	if resp.Error != "" {
+		return errors.New(resp.Error)
+	}
+	return nil
+}
+
+// Cancel asks the plugin to exit, killing it if it doesn't
+func (c *PluginCheck) Cancel() {
+	c.mu.Lock()
+	p := c.proc
+	c.proc = nil
+	c.canceled = true
+	c.mu.Unlock()
+
+	if p != nil {
+		p.stop(cancelGracePeriod)
+	}
+}
+
+// process returns the running plugin process, starting and configuring it
+// if it isn't running.
+func (c *PluginCheck) process() (*process, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.canceled {
+		return nil, fmt.Errorf("check %s is canceled", c.ID())
+	}
+	if c.proc != nil && !c.proc.exited() {
+		return c.proc, nil
+	}
+	if c.proc != nil {
+		log.Warnf("Restarting plugin %s of check %s: %s", c.path, c.ID(), c.proc.exitError())
+	}
+	c.proc = nil
+
+	p, err := startProcess(string(c.ID()), c.path, c.limits)
+	if err != nil {
+		return nil, err
+	}
+	params := c.params
+	resp, err := p.call(protocol.Request{Method: protocol.MethodConfigure, Configure: &params}, c.runTimeout, func(m protocol.Message) {
+		log.Debugf("plugin %s: ignoring %s submitted while configuring", c.path, m.Type)
+	})
+	if err == nil && resp.Version != protocol.Version {
+		err = fmt.Errorf("plugin %s speaks version %d of the protocol, expected %d", c.path, resp.Version, protocol.Version)
+	} else if err == nil && resp.Error != "" {
+		err = fmt.Errorf("plugin %s could not be configured: %s", c.path, resp.Error)
+	}
+	if err != nil {
+		p.kill()
+		return nil, err
+	}
+	c.version = resp.CheckVersion
+	c.proc = p
+	return p, nil
+}
+
+// submit forwards the data submitted by the plugin to the sender.
+func (c *PluginCheck) submit(s sender.Sender, m protocol.Message) {
+	switch {
+	case m.Type == protocol.TypeMetric && m.Metric != nil:
+		submitMetric(s, m.Metric)
+	case m.Type == protocol.TypeServiceCheck && m.ServiceCheck != nil:
+		sc := m.ServiceCheck
+		s.ServiceCheck(sc.Name, servicecheck.ServiceCheckStatus(sc.Status), sc.Hostname, sc.Tags, sc.Message)
+	case m.Type == protocol.TypeEvent && m.Event != nil:
+		s.Event(toEvent(m.Event))
+	case m.Type == protocol.TypeWarning:
+		_ = c.Warn(m.Warning)
+	default:
+		log.Debugf("plugin %s: ignoring invalid message of type %q", c.path, m.Type)
+	}
+}
+
+// submitMetric forwards a metric sample to the sender.
+func submitMetric(s sender.Sender, m *protocol.Metric) {
+	switch m.Type {
+	case protocol.Gauge:
+		if m.Timestamp > 0 {
+			if err := s.GaugeWithTimestamp(m.Name, m.Value, m.Hostname, m.Tags, m.Timestamp); err != nil {
+				log.Debugf("could not submit gauge %s: %s", m.Name, err)
+			}
+			return
+		}
+		s.Gauge(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Count:
+		if m.Timestamp > 0 {
+			if err := s.CountWithTimestamp(m.Name, m.Value, m.Hostname, m.Tags, m.Timestamp); err != nil {
+				log.Debugf("could not submit count %s: %s", m.Name, err)
+			}
+			return
+		}
+		s.Count(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Rate:
+		s.Rate(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.MonotonicCount:
+		s.MonotonicCount(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Counter:
+		s.Counter(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Histogram:
+		s.Histogram(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Historate:
+		s.Historate(m.Name, m.Value, m.Hostname, m.Tags)
+	case protocol.Distribution:
+		s.Distribution(m.Name, m.Value, m.Hostname, m.Tags)
+	default:
+		log.Debugf("ignoring metric %s of unknown type %q", m.Name, m.Type)
+	}
+}
+
+// toEvent converts an event submitted by a plugin.
+func toEvent(e *protocol.Event) event.Event {
+	ev := event.Event{
+		Title:          e.Title,
+		Text:           e.Text,
+		Ts:             e.Timestamp,
+		Host:           e.Hostname,
+		Tags:           e.Tags,
+		AggregationKey: e.AggregationKey,
+		SourceTypeName: e.SourceTypeName,
+	}
+	if p, err := event.GetEventPriorityFromString(e.Priority); err == nil {
+		ev.Priority = p
+	}
+	if t, err := event.GetAlertTypeFromString(e.AlertType); err == nil {
+		ev.AlertType = t
+	}
+	return ev
+}
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/protocol"
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/sdk"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+// helperEnv makes the test binary serve helperCheck when set, so that the
+// tests can run it as a plugin.
+const helperEnv = "TEST_CHECK_PLUGIN_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) != "" {
+		if err := sdk.Serve(&helperCheck{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// helperCheck behaves as the mode of its instance says.
+type helperCheck struct {
+	mode string
+	runs int
+}
+
+func (c *helperCheck) Configure(_, instance []byte) error {
+	c.mode = strings.TrimSpace(strings.TrimPrefix(string(instance), "mode:"))
+	if c.mode == "invalid" {
+		return errors.New("invalid instance")
+	}
+	return nil
+}
+
+func (c *helperCheck) Run(s sdk.Sender) error {
+	c.runs++
+	switch c.mode {
+	case "crash":
+		os.Exit(3)
+	case "hang":
+		time.Sleep(time.Hour)
+	case "slow":
+		time.Sleep(200 * time.Millisecond)
+	case "error":
+		return errors.New("run error")
+	case "limits":
+		var rlim syscall.Rlimit
+		if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlim); err != nil {
+			return err
+		}
+		_ = s.Metric(protocol.Gauge, "plugin.max_open_files", float64(rlim.Cur), "", nil)
+		return nil
+	}
+	_ = s.Metric(protocol.Gauge, "plugin.runs", float64(c.runs), "", []string{"a:b"})
+	_ = s.Metric(protocol.MonotonicCount, "plugin.total", 10, "host", nil)
+	_ = s.ServiceCheck("plugin.can_connect", protocol.StatusCritical, "", nil, "down")
+	_ = s.Event(protocol.Event{Title: "title", Text: "text", AlertType: "error"})
+	_ = s.Warn("warning")
+	return nil
+}
+
+func (c *helperCheck) Version() string { return "1.0.0" }
+
+// newTestLoader returns a loader of the test binary, as the plugin of the
+// check "helper".
+func newTestLoader(t *testing.T) *PluginCheckLoader {
+	dir := t.TempDir()
+	exe, err := os.Executable()
+	require.NoError(t, err)
+	require.NoError(t, os.Symlink(exe, filepath.Join(dir, "helper")))
+	t.Setenv(helperEnv, "1")
+
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("check_plugins.enabled", true)
+	cfg.SetWithoutSource("check_plugins.path", dir)
+	cfg.SetWithoutSource("check_plugins.run_timeout", time.Second)
+	l, err := NewPluginCheckLoader(cfg)
+	require.NoError(t, err)
+	return l
+}
+
+// loadTestCheck loads the check "helper" with an instance of the given mode.
+func loadTestCheck(t *testing.T, l *PluginCheckLoader, mode string) (*PluginCheck, *mocksender.MockSender, error) {
+	conf := integration.Config{Name: "helper", Instances: []integration.Data{integration.Data("mode: " + mode)}}
+	c := newPluginCheck(conf.Name, "", l.limits, l.runTimeout)
+	c.BuildID(conf.FastDigest(), conf.Instances[0], conf.InitConfig)
+	s := mocksender.NewMockSender(c.ID())
+	s.SetupAcceptAll()
+
+	ch, err := l.Load(s.GetSenderManager(), conf, conf.Instances[0])
+	if ch == nil {
+		return nil, s, err
+	}
+	pc := ch.(*PluginCheck)
+	t.Cleanup(pc.Cancel)
+	return pc, s, err
+}
+
+func TestPluginCheckRun(t *testing.T) {
+	l := newTestLoader(t)
+	c, s, err := loadTestCheck(t, l, "ok")
+	require.NoError(t, err)
+	assert.Equal(t, PluginCheckLoaderName, c.Loader())
+	assert.Equal(t, "1.0.0", c.Version())
+	assert.Equal(t, "helper", c.String())
+
+	require.NoError(t, c.Run())
+	require.NoError(t, c.Run())
+	s.AssertMetric(t, "Gauge", "plugin.runs", 1, "", []string{"a:b"})
+	s.AssertMetric(t, "Gauge", "plugin.runs", 2, "", []string{"a:b"})
+	s.AssertMetric(t, "MonotonicCount", "plugin.total", 10, "host", nil)
+	s.AssertServiceCheck(t, "plugin.can_connect", servicecheck.ServiceCheckCritical, "", nil, "down")
+	s.AssertEvent(t, event.Event{Title: "title", Text: "text", AlertType: event.AlertTypeError}, 0)
+	s.AssertNumberOfCalls(t, "Commit", 2)
+	assert.Len(t, c.GetWarnings(), 2)
+
+	c.Cancel()
+	assert.Error(t, c.Run(), "canceled checks don't run")
+}
+
+func TestPluginCheckErrors(t *testing.T) {
+	l := newTestLoader(t)
+
+	_, _, err := loadTestCheck(t, l, "invalid")
+	assert.ErrorContains(t, err, "invalid instance")
+
+	c, s, err := loadTestCheck(t, l, "error")
+	require.NoError(t, err)
+	assert.EqualError(t, c.Run(), "run error")
+	s.AssertNumberOfCalls(t, "Commit", 1)
+
+	_, err = l.Load(nil, integration.Config{Name: "missing"}, nil)
+	assert.ErrorContains(t, err, "not found")
+
+	for _, name := range []string{"", ".", "..", "../helper", "sub/helper", `sub\helper`} {
+		_, err = l.Load(nil, integration.Config{Name: name}, nil)
+		assert.ErrorContains(t, err, "invalid check name", name)
+	}
+}
+
+func TestPluginCheckLimits(t *testing.T) {
+	l := newTestLoader(t)
+	l.limits.maxOpenFiles = 64
+
+	// the limits apply before the plugin is executed
+	c, s, err := loadTestCheck(t, l, "limits")
+	require.NoError(t, err)
+	require.NoError(t, c.Run())
+	s.AssertMetric(t, "Gauge", "plugin.max_open_files", 64, "", nil)
+}
+
+func TestPluginCheckRestart(t *testing.T) {
+	l := newTestLoader(t)
+
+	// the crashed plugin is restarted at the next run
+	c, _, err := loadTestCheck(t, l, "crash")
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.Run(), "exit status 3")
+	first := c.proc
+	assert.ErrorContains(t, c.Run(), "exit status 3")
+	assert.NotSame(t, first, c.proc)
+
+	// the plugin is killed when its run times out
+	c, s, err := loadTestCheck(t, l, "hang")
+	require.NoError(t, err)
+	start := time.Now()
+	assert.ErrorContains(t, c.Run(), "killed")
+	assert.Less(t, time.Since(start), 10*time.Second)
+	assert.True(t, c.proc.exited())
+	s.AssertNotCalled(t, "Commit", mock.Anything)
+}
+
+func TestPluginCheckCancelDuringRun(t *testing.T) {
+	l := newTestLoader(t)
+	c, s, err := loadTestCheck(t, l, "slow")
+	require.NoError(t, err)
+
+	// the cancel waits for the pending run, which gets its own response and
+	// submissions
+	ran := make(chan error)
+	go func() { ran <- c.Run() }()
+	time.Sleep(50 * time.Millisecond)
+	c.Cancel()
+	require.NoError(t, <-ran)
+	s.AssertMetric(t, "Gauge", "plugin.runs", 1, "", []string{"a:b"})
+	s.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestPluginCheckLoaderDisabled(t *testing.T) {
+	_, err := NewPluginCheckLoader(configmock.New(t))
+	assert.ErrorIs(t, err, loaders.ErrDisabled)
+}
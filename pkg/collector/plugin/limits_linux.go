@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// cpuPeriod is the period of the CPU limit of the plugins, in microseconds.
+const cpuPeriod = 100000
+
+// limitedCommand returns the command running the plugin at path within the
+// limits l, which apply before the plugin is executed, and the function
+// releasing the resources of the limits once the plugin has exited.
+//
+// The open files limit is set by a shell executing the plugin, and the memory
+// and CPU limits by the cgroup v2 the plugin is started in, created under the
+// cgroup of l. The plugin is killed when the agent dies.
+func limitedCommand(name, path string, l limits) (*exec.Cmd, func(), error) {
+	var cmd *exec.Cmd
+	if l.maxOpenFiles > 0 {
+		cmd = exec.Command("/bin/sh", "-c", `ulimit -n "$1" && exec "$0"`, path, strconv.FormatUint(l.maxOpenFiles, 10))
+	} else {
+		cmd = exec.Command(path)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	if l.maxMemory == 0 && l.maxCPUPercent == 0 {
+		return cmd, func() {}, nil
+	}
+
+	dir, err := createCgroup(l.cgroupPath, name, l)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		_ = os.Remove(dir)
+		return nil, nil, err
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(f.Fd())
+	release := func() {
+		_ = f.Close()
+		if err := os.Remove(dir); err != nil {
+			log.Debugf("plugin %s: could not remove cgroup %s: %s", name, dir, err)
+		}
+	}
+	return cmd, release, nil
+}
+
+// createCgroup creates the cgroup of a plugin under root, enabling the
+// controllers of the limits l in root, and returns its path.
+func createCgroup(root, name string, l limits) (string, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return "", fmt.Errorf("could not create cgroup %s: %w", root, err)
+	}
+	var controllers []string
+	if l.maxMemory > 0 {
+		controllers = append(controllers, "+memory")
+	}
+	if l.maxCPUPercent > 0 {
+		controllers = append(controllers, "+cpu")
+	}
+	if err := writeCgroupFile(root, "cgroup.subtree_control", strings.Join(controllers, " ")); err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp(root, name+"-")
+	if err != nil {
+		return "", fmt.Errorf("could not create the cgroup of plugin %s: %w", name, err)
+	}
+	if l.maxMemory > 0 {
+		err = writeCgroupFile(dir, "memory.max", strconv.FormatUint(l.maxMemory, 10))
+	}
+	if err == nil && l.maxCPUPercent > 0 {
+		err = writeCgroupFile(dir, "cpu.max", fmt.Sprintf("%d %d", l.maxCPUPercent*cpuPeriod/100, cpuPeriod))
+	}
+	if err != nil {
+		_ = os.Remove(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// writeCgroupFile writes value to the file of the cgroup dir.
+func writeCgroupFile(dir, file, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("could not write %s of cgroup %s: %w", file, dir, err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCgroup(t *testing.T) {
+	readFile := func(dir, file string) string {
+		b, err := os.ReadFile(filepath.Join(dir, file))
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	root := filepath.Join(t.TempDir(), "plugins")
+	dir, err := createCgroup(root, "check", limits{maxMemory: 64 << 20, maxCPUPercent: 50})
+	require.NoError(t, err)
+	assert.Equal(t, root, filepath.Dir(dir))
+	assert.True(t, strings.HasPrefix(filepath.Base(dir), "check-"))
+	assert.Equal(t, "+memory +cpu", readFile(root, "cgroup.subtree_control"))
+	assert.Equal(t, "67108864", readFile(dir, "memory.max"))
+	assert.Equal(t, "50000 100000", readFile(dir, "cpu.max"))
+
+	// only the controllers of the set limits are enabled
+	dir, err = createCgroup(root, "check", limits{maxCPUPercent: 200})
+	require.NoError(t, err)
+	assert.Equal(t, "+cpu", readFile(root, "cgroup.subtree_control"))
+	assert.Equal(t, "200000 100000", readFile(dir, "cpu.max"))
+	assert.NoFileExists(t, filepath.Join(dir, "memory.max"))
+}
@@ -0,0 +1,23 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux
+
+package plugin
+
+import (
+	"os/exec"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// limitedCommand returns the command running the plugin at path. The limits
+// are only supported on Linux, they are ignored elsewhere.
+func limitedCommand(_, path string, l limits) (*exec.Cmd, func(), error) {
+	if l.maxMemory > 0 || l.maxCPUPercent > 0 || l.maxOpenFiles > 0 {
+		log.Warnf("Resource limits of the check plugins are only supported on Linux, ignoring them")
+	}
+	return exec.Command(path), func() {}, nil
+}
@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package plugin implements the loader of the check plugins: checks written
+// in any language and shipped as executables, run by the agent as long-lived
+// subprocesses speaking the protocol of the protocol package.
+package plugin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
+	integrations "github.com/DataDog/datadog-agent/comp/logs/integrations/def"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+// PluginCheckLoaderName is the name of the plugin check loader
+const PluginCheckLoaderName string = "plugin"
+
+// PluginCheckLoader loads the checks from the executables of the plugins
+// directory, named after the checks.
+type PluginCheckLoader struct {
+	dir        string
+	limits     limits
+	runTimeout time.Duration
+}
+
+// NewPluginCheckLoader creates a loader for the check plugins, if enabled
+func NewPluginCheckLoader(cfg pkgconfigmodel.Reader) (*PluginCheckLoader, error) {
+	if !cfg.GetBool("check_plugins.enabled") {
+		return nil, fmt.Errorf("check plugins are disabled: %w", loaders.ErrDisabled)
+	}
+	dir := cfg.GetString("check_plugins.path")
+	if dir == "" {
+		dir = filepath.Join(cfg.GetString("additional_checksd"), "plugins")
+	}
+	return &PluginCheckLoader{
+		dir: dir,
+		limits: limits{
+			maxMemory:     uint64(max(cfg.GetInt64("check_plugins.max_memory"), 0)),
+			maxCPUPercent: uint64(max(cfg.GetInt64("check_plugins.max_cpu_percent"), 0)),
+			maxOpenFiles:  uint64(max(cfg.GetInt64("check_plugins.max_open_files"), 0)),
+			cgroupPath:    cfg.GetString("check_plugins.cgroup_path"),
+		},
+		runTimeout: cfg.GetDuration("check_plugins.run_timeout"),
+	}, nil
+}
+
+// Name returns the plugin loader name
+func (*PluginCheckLoader) Name() string {
+	return PluginCheckLoaderName
+}
+
+// Load returns a check run by the plugin named after the check
+func (pl *PluginCheckLoader) Load(senderManager sender.SenderManager, config integration.Config, instance integration.Data) (check.Check, error) {
+	suffix := ""
+	if runtime.GOOS == "windows" {
+		suffix = ".exe"
+	}
+	path, err := loaders.CheckFilePath(pl.dir, config.Name, suffix)
+	if err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err != nil || fi.IsDir() {
+		return nil, fmt.Errorf("Check plugin %s not found in %s", config.Name, pl.dir)
+	}
+
+	c := newPluginCheck(config.Name, path, pl.limits, pl.runTimeout)
+	if err := c.Configure(senderManager, config.FastDigest(), instance, config.InitConfig, config.Source); err != nil {
+		log.Errorf("plugin.loader: could not configure check %s: %s", c, err)
+		return c, fmt.Errorf("Could not configure check %s: %s", c, err)
+	}
+	return c, nil
+}
+
+func (pl *PluginCheckLoader) String() string {
+	return "Plugin Check Loader"
+}
+
+func init() {
+	factory := func(sender.SenderManager, option.Option[integrations.Component], tagger.Component) (check.Loader, error) {
+		return NewPluginCheckLoader(pkgconfigsetup.Datadog())
+	}
+
+	loaders.RegisterLoader(40, factory)
+}
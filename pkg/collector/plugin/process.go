@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/protocol"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// messageBufferSize is the number of messages of a plugin buffered while no
+// request is pending.
+const messageBufferSize = 100
+
+// errTimeout is returned by call when the plugin doesn't respond in time.
+var errTimeout = errors.New("timed out waiting for the plugin")
+
+// limits are the resource limits of a plugin process.
+type limits struct {
+	// maxMemory is the maximum memory usage, including the resident memory
+	// and the page cache, in bytes.
+	maxMemory uint64
+	// maxCPUPercent is the maximum CPU usage, in percent of a core.
+	maxCPUPercent uint64
+	// maxOpenFiles is the maximum number of open file descriptors.
+	maxOpenFiles uint64
+	// cgroupPath is the cgroup under which the cgroups enforcing the memory and
+	// CPU limits are created.
+	cgroupPath string
+}
+
+// process is a running plugin process.
+type process struct {
+	name  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	enc   *protocol.Encoder
+
+	// messages are the messages written by the plugin, closed when the plugin
+	// closes its standard output.
+	messages chan protocol.Message
+	// done is closed when the process has exited, after err is set.
+	done chan struct{}
+	err  error
+
+	// calling serializes the calls, so that each reads the messages up to its
+	// own response. It guards nextID and the writes to stdin.
+	calling chan struct{}
+	nextID  uint64
+}
+
+// startProcess starts the plugin at path, limited to l.
+func startProcess(name, path string, l limits) (*process, error) {
+	cmd, release, err := limitedCommand(name, path, l)
+	if err != nil {
+		return nil, fmt.Errorf("could not limit the resources of plugin %s: %w", path, err)
+	}
+	started := false
+	defer func() {
+		if !started {
+			release()
+		}
+	}()
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("could not start plugin %s: %w", path, err)
+	}
+	started = true
+
+	p := &process{
+		name:     name,
+		cmd:      cmd,
+		stdin:    stdin,
+		enc:      protocol.NewEncoder(stdin),
+		messages: make(chan protocol.Message, messageBufferSize),
+		done:     make(chan struct{}),
+		calling:  make(chan struct{}, 1),
+	}
+
+	// forward the standard error to the Agent logger
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		in := bufio.NewScanner(stderr)
+		for in.Scan() {
+			log.Infof("plugin %s: %s", name, in.Text())
+		}
+	}()
+
+	go func() {
+		dec := protocol.NewDecoder(stdout)
+		for {
+			var m protocol.Message
+			if err := dec.Decode(&m); err != nil {
+				if !errors.Is(err, io.EOF) {
+					log.Errorf("plugin %s: could not read message: %s", name, err)
+					// unblock the plugin until it exits
+					_, _ = io.Copy(io.Discard, stdout)
+				}
+				break
+			}
+			p.messages <- m
+		}
+		close(p.messages)
+		<-stderrDone
+		p.err = cmd.Wait()
+		release()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// call sends a request to the plugin and waits for its response, passing the
+// messages submitted in the meantime to submit. The calls are serialized: a
+// call waits for the pending one to complete before sending its request. It
+// returns errTimeout when the plugin doesn't respond within timeout, if
+// positive, including the wait for the pending call.
+func (p *process) call(req protocol.Request, timeout time.Duration, submit func(protocol.Message)) (protocol.Message, error) {
+	var deadline <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	select {
+	case p.calling <- struct{}{}:
+	case <-p.done:
+		return protocol.Message{}, p.exitError()
+	case <-deadline:
+		return protocol.Message{}, errTimeout
+	}
+	defer func() { <-p.calling }()
+
+	p.nextID++
+	req.ID = p.nextID
+	if err := p.enc.Encode(req); err != nil {
+		return protocol.Message{}, fmt.Errorf("could not send request %s: %w", req.Method, err)
+	}
+
+	for {
+		select {
+		case m, ok := <-p.messages:
+			if !ok {
+				return protocol.Message{}, p.exitError()
+			}
+			if m.Type != protocol.TypeResponse {
+				submit(m)
+				continue
+			}
+			if m.ID != req.ID {
+				log.Debugf("plugin %s: ignoring the response to the previous request %d", p.name, m.ID)
+				continue
+			}
+			return m, nil
+		case <-deadline:
+			return protocol.Message{}, errTimeout
+		}
+	}
+}
+
+// exitError waits for the process to exit and returns why it did.
+func (p *process) exitError() error {
+	<-p.done
+	if p.err != nil {
+		return fmt.Errorf("plugin exited: %w", p.err)
+	}
+	return errors.New("plugin exited")
+}
+
+// exited returns whether the process has exited.
+func (p *process) exited() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop asks the plugin to exit, and kills it if it is still running after
+// grace.
+func (p *process) stop(grace time.Duration) {
+	if p.exited() {
+		return
+	}
+	if _, err := p.call(protocol.Request{Method: protocol.MethodCancel}, grace, func(protocol.Message) {}); err != nil {
+		log.Debugf("plugin %s: could not cancel: %s", p.name, err)
+	}
+	_ = p.stdin.Close()
+	select {
+	case <-p.done:
+	case <-time.After(grace):
+		p.kill()
+	}
+}
+
+// kill kills the process and waits for it to exit.
+func (p *process) kill() {
+	if err := p.cmd.Process.Kill(); err != nil && !p.exited() {
+		log.Errorf("plugin %s: could not kill process: %s", p.name, err)
+	}
+	// drain the messages for the process to be waited
+	for range p.messages {
+	}
+	<-p.done
+}
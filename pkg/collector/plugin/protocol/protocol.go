@@ -0,0 +1,217 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package protocol defines the protocol spoken by the agent and the check
+// plugins over the standard input and output of the plugin process.
+//
+// Each message is a JSON object on a single line. The agent writes Requests
+// to the standard input of the plugin, and the plugin writes Messages to its
+// standard output: the metrics, service checks, events and warnings submitted
+// while running, followed by exactly one response to each request. The
+// standard error of the plugin is forwarded to the agent logs.
+//
+// The first request is always MethodConfigure, carrying the Version of the
+// protocol spoken by the agent. The plugin answers with the version it
+// speaks, and the agent refuses the plugin when they differ.
+//
+// This package only depends on the standard library, so that it can be
+// vendored by the plugins.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// Version is the version of the protocol.
+const Version = 1
+
+// MaxMessageSize is the maximum size of a message, in bytes.
+const MaxMessageSize = 1024 * 1024
+
+// Methods of the requests.
+const (
+	// MethodConfigure configures the check with its init_config and instance.
+	MethodConfigure = "configure"
+	// MethodRun runs the check once.
+	MethodRun = "run"
+	// MethodCancel asks the plugin to release its resources and exit.
+	MethodCancel = "cancel"
+)
+
+// Request is a request sent by the agent to the plugin.
+type Request struct {
+	// ID identifies the request in its response.
+	ID uint64 `json:"id"`
+	// Method is one of MethodConfigure, MethodRun and MethodCancel.
+	Method string `json:"method"`
+	// Configure holds the parameters of MethodConfigure.
+	Configure *ConfigureParams `json:"configure,omitempty"`
+}
+
+// ConfigureParams are the parameters of MethodConfigure.
+type ConfigureParams struct {
+	// Version is the version of the protocol spoken by the agent.
+	Version int `json:"version"`
+	// Name is the name of the check.
+	Name string `json:"name"`
+	// CheckID is the ID of the check instance in the agent.
+	CheckID string `json:"check_id"`
+	// InitConfig is the init_config section of the configuration, in YAML.
+	InitConfig string `json:"init_config"`
+	// Instance is the instance configuration, in YAML.
+	Instance string `json:"instance"`
+	// Source is where the configuration was loaded from.
+	Source string `json:"source"`
+}
+
+// Types of the messages.
+const (
+	// TypeResponse is the response to a request.
+	TypeResponse = "response"
+	// TypeMetric submits a metric.
+	TypeMetric = "metric"
+	// TypeServiceCheck submits a service check.
+	TypeServiceCheck = "service_check"
+	// TypeEvent submits an event.
+	TypeEvent = "event"
+	// TypeWarning reports a warning, shown in the agent status.
+	TypeWarning = "warning"
+)
+
+// Message is a message sent by the plugin to the agent.
+type Message struct {
+	// Type is one of the Type constants.
+	Type string `json:"type"`
+
+	// ID is the ID of the request of a response.
+	ID uint64 `json:"id,omitempty"`
+	// Error is the error of a response, empty on success.
+	Error string `json:"error,omitempty"`
+	// Version is the version of the protocol spoken by the plugin, in the
+	// response to MethodConfigure.
+	Version int `json:"version,omitempty"`
+	// CheckVersion is the version of the check, in the response to
+	// MethodConfigure.
+	CheckVersion string `json:"check_version,omitempty"`
+
+	// Metric is the metric of TypeMetric.
+	Metric *Metric `json:"metric,omitempty"`
+	// ServiceCheck is the service check of TypeServiceCheck.
+	ServiceCheck *ServiceCheck `json:"service_check,omitempty"`
+	// Event is the event of TypeEvent.
+	Event *Event `json:"event,omitempty"`
+	// Warning is the warning of TypeWarning.
+	Warning string `json:"warning,omitempty"`
+}
+
+// MetricType is the type of a metric, mapped to the method of the agent sender
+// of the same name.
+type MetricType string
+
+// Types of the metrics.
+const (
+	Gauge          MetricType = "gauge"
+	Rate           MetricType = "rate"
+	Count          MetricType = "count"
+	MonotonicCount MetricType = "monotonic_count"
+	Counter        MetricType = "counter"
+	Histogram      MetricType = "histogram"
+	Historate      MetricType = "historate"
+	Distribution   MetricType = "distribution"
+)
+
+// Metric is a metric sample.
+type Metric struct {
+	Type     MetricType `json:"type"`
+	Name     string     `json:"name"`
+	Value    float64    `json:"value"`
+	Hostname string     `json:"hostname,omitempty"`
+	Tags     []string   `json:"tags,omitempty"`
+	// Timestamp is the Unix time of the sample, in seconds. When set, the
+	// sample of a Gauge or Count is sent as is, without aggregation.
+	Timestamp float64 `json:"timestamp,omitempty"`
+}
+
+// ServiceCheckStatus is the status of a service check.
+type ServiceCheckStatus int
+
+// Statuses of the service checks.
+const (
+	StatusOK       ServiceCheckStatus = 0
+	StatusWarning  ServiceCheckStatus = 1
+	StatusCritical ServiceCheckStatus = 2
+	StatusUnknown  ServiceCheckStatus = 3
+)
+
+// ServiceCheck is a service check.
+type ServiceCheck struct {
+	Name     string             `json:"name"`
+	Status   ServiceCheckStatus `json:"status"`
+	Hostname string             `json:"hostname,omitempty"`
+	Tags     []string           `json:"tags,omitempty"`
+	Message  string             `json:"message,omitempty"`
+}
+
+// Event is an event.
+type Event struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	// Timestamp is the Unix time of the event, in seconds.
+	Timestamp int64    `json:"timestamp,omitempty"`
+	Priority  string   `json:"priority,omitempty"`
+	Hostname  string   `json:"hostname,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// AlertType is one of error, warning, info and success.
+	AlertType      string `json:"alert_type,omitempty"`
+	AggregationKey string `json:"aggregation_key,omitempty"`
+	SourceTypeName string `json:"source_type_name,omitempty"`
+}
+
+// Encoder writes messages, one per line.
+type Encoder struct {
+	enc *json.Encoder
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &Encoder{enc: enc}
+}
+
+// Encode writes v on a line.
+func (e *Encoder) Encode(v interface{}) error {
+	return e.enc.Encode(v)
+}
+
+// Decoder reads messages, one per line.
+type Decoder struct {
+	scanner *bufio.Scanner
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxMessageSize)
+	return &Decoder{scanner: scanner}
+}
+
+// Decode reads the next line into v, skipping the empty lines. It returns
+// io.EOF at the end of the input.
+func (d *Decoder) Decode(v interface{}) error {
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		return json.Unmarshal(line, v)
+	}
+	if err := d.scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
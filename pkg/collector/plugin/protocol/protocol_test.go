@@ -0,0 +1,41 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package protocol
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	require.NoError(t, enc.Encode(Request{ID: 1, Method: MethodConfigure, Configure: &ConfigureParams{Version: Version, Name: "my_check", Instance: "url: http://a<b>\n"}}))
+	require.NoError(t, enc.Encode(Message{Type: TypeMetric, Metric: &Metric{Type: Gauge, Name: "my.metric", Value: 1.5, Tags: []string{"a:b"}}}))
+	assert.Equal(t, 2, strings.Count(buf.String(), "\n"), "one message per line")
+	assert.Contains(t, buf.String(), "http://a<b>", "HTML characters are not escaped")
+
+	// empty lines are skipped
+	dec := NewDecoder(io.MultiReader(strings.NewReader("\n"), &buf))
+	var req Request
+	require.NoError(t, dec.Decode(&req))
+	assert.Equal(t, Request{ID: 1, Method: MethodConfigure, Configure: &ConfigureParams{Version: Version, Name: "my_check", Instance: "url: http://a<b>\n"}}, req)
+	var m Message
+	require.NoError(t, dec.Decode(&m))
+	assert.Equal(t, Message{Type: TypeMetric, Metric: &Metric{Type: Gauge, Name: "my.metric", Value: 1.5, Tags: []string{"a:b"}}}, m)
+	assert.ErrorIs(t, dec.Decode(&m), io.EOF)
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var m Message
+	assert.Error(t, NewDecoder(strings.NewReader("not json\n")).Decode(&m))
+	assert.Error(t, NewDecoder(strings.NewReader(strings.Repeat("a", MaxMessageSize+1))).Decode(&m))
+}
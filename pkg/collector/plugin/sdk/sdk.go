@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package sdk helps writing check plugins in Go: a plugin is a program whose
+// main function calls Serve with its check.
+//
+// Like the protocol package, it only depends on the standard library.
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/protocol"
+)
+
+// Check is a check run by a plugin.
+type Check interface {
+	// Configure configures the check with the init_config section and the
+	// instance of its configuration, in YAML.
+	Configure(initConfig, instance []byte) error
+	// Run runs the check once, submitting its data with s.
+	Run(s Sender) error
+}
+
+// Canceler is implemented by the checks with resources to release when they
+// are unscheduled.
+type Canceler interface {
+	Cancel()
+}
+
+// Versioner is implemented by the checks reporting their version.
+type Versioner interface {
+	Version() string
+}
+
+// Sender submits the data of a check to the agent.
+type Sender interface {
+	// Metric submits a metric sample of type t.
+	Metric(t protocol.MetricType, name string, value float64, hostname string, tags []string) error
+	// ServiceCheck submits a service check.
+	ServiceCheck(name string, status protocol.ServiceCheckStatus, hostname string, tags []string, message string) error
+	// Event submits an event.
+	Event(e protocol.Event) error
+	// Warn reports a warning, shown in the agent status.
+	Warn(format string, args ...interface{}) error
+}
+
+// Serve serves c over the standard input and output until the agent cancels
+// the check or closes the standard input.
+func Serve(c Check) error {
+	return ServeIO(c, os.Stdin, os.Stdout)
+}
+
+// ServeIO serves c, reading the requests from in and writing the messages
+// to out.
+func ServeIO(c Check, in io.Reader, out io.Writer) error {
+	dec := protocol.NewDecoder(in)
+	enc := protocol.NewEncoder(out)
+	s := &sender{enc: enc}
+	for {
+		var req protocol.Request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("could not read request: %w", err)
+		}
+		resp := protocol.Message{Type: protocol.TypeResponse, ID: req.ID}
+		switch req.Method {
+		case protocol.MethodConfigure:
+			resp.Version = protocol.Version
+			if v, ok := c.(Versioner); ok {
+				resp.CheckVersion = v.Version()
+			}
+			if req.Configure == nil {
+				resp.Error = "missing configure parameters"
+			} else if err := c.Configure([]byte(req.Configure.InitConfig), []byte(req.Configure.Instance)); err != nil {
+				resp.Error = err.Error()
+			}
+		case protocol.MethodRun:
+			if err := c.Run(s); err != nil {
+				resp.Error = err.Error()
+			}
+		case protocol.MethodCancel:
+			if cc, ok := c.(Canceler); ok {
+				cc.Cancel()
+			}
+			return enc.Encode(resp)
+		default:
+			resp.Error = fmt.Sprintf("unknown method %q", req.Method)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return fmt.Errorf("could not write response: %w", err)
+		}
+	}
+}
+
+// sender is the Sender writing the data to the agent.
+type sender struct {
+	enc *protocol.Encoder
+}
+
+func (s *sender) Metric(t protocol.MetricType, name string, value float64, hostname string, tags []string) error {
+	return s.enc.Encode(protocol.Message{
+		Type:   protocol.TypeMetric,
+		Metric: &protocol.Metric{Type: t, Name: name, Value: value, Hostname: hostname, Tags: tags},
+	})
+}
+
+func (s *sender) ServiceCheck(name string, status protocol.ServiceCheckStatus, hostname string, tags []string, message string) error {
+	return s.enc.Encode(protocol.Message{
+		Type:         protocol.TypeServiceCheck,
+		ServiceCheck: &protocol.ServiceCheck{Name: name, Status: status, Hostname: hostname, Tags: tags, Message: message},
+	})
+}
+
+func (s *sender) Event(e protocol.Event) error {
+	return s.enc.Encode(protocol.Message{Type: protocol.TypeEvent, Event: &e})
+}
+
+func (s *sender) Warn(format string, args ...interface{}) error {
+	return s.enc.Encode(protocol.Message{Type: protocol.TypeWarning, Warning: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sdk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/plugin/protocol"
+)
+
+type testCheck struct {
+	instance string
+	canceled bool
+}
+
+func (c *testCheck) Configure(_, instance []byte) error {
+	if string(instance) == "invalid" {
+		return errors.New("invalid instance")
+	}
+	c.instance = string(instance)
+	return nil
+}
+
+func (c *testCheck) Run(s Sender) error {
+	if err := s.Metric(protocol.Gauge, "my.metric", 1, "", []string{"instance:" + c.instance}); err != nil {
+		return err
+	}
+	if err := s.ServiceCheck("my.can_connect", protocol.StatusOK, "", nil, ""); err != nil {
+		return err
+	}
+	if err := s.Event(protocol.Event{Title: "title", Text: "text"}); err != nil {
+		return err
+	}
+	if err := s.Warn("warning %d", 1); err != nil {
+		return err
+	}
+	return errors.New("run error")
+}
+
+func (c *testCheck) Cancel() { c.canceled = true }
+
+func (c *testCheck) Version() string { return "1.2.3" }
+
+func serve(t *testing.T, c Check, reqs ...protocol.Request) []protocol.Message {
+	var in, out bytes.Buffer
+	enc := protocol.NewEncoder(&in)
+	for _, req := range reqs {
+		require.NoError(t, enc.Encode(req))
+	}
+	require.NoError(t, ServeIO(c, &in, &out))
+
+	var msgs []protocol.Message
+	dec := protocol.NewDecoder(&out)
+	for {
+		var m protocol.Message
+		err := dec.Decode(&m)
+		if errors.Is(err, io.EOF) {
+			return msgs
+		}
+		require.NoError(t, err)
+		msgs = append(msgs, m)
+	}
+}
+
+func TestServe(t *testing.T) {
+	c := &testCheck{}
+	msgs := serve(t, c,
+		protocol.Request{ID: 1, Method: protocol.MethodConfigure, Configure: &protocol.ConfigureParams{Version: protocol.Version, Instance: "a"}},
+		protocol.Request{ID: 2, Method: protocol.MethodRun},
+		protocol.Request{ID: 3, Method: "unknown"},
+		protocol.Request{ID: 4, Method: protocol.MethodCancel},
+		// not served once canceled
+		protocol.Request{ID: 5, Method: protocol.MethodRun},
+	)
+	assert.True(t, c.canceled)
+	assert.Equal(t, []protocol.Message{
+		{Type: protocol.TypeResponse, ID: 1, Version: protocol.Version, CheckVersion: "1.2.3"},
+		{Type: protocol.TypeMetric, Metric: &protocol.Metric{Type: protocol.Gauge, Name: "my.metric", Value: 1, Tags: []string{"instance:a"}}},
+		{Type: protocol.TypeServiceCheck, ServiceCheck: &protocol.ServiceCheck{Name: "my.can_connect", Status: protocol.StatusOK}},
+		{Type: protocol.TypeEvent, Event: &protocol.Event{Title: "title", Text: "text"}},
+		{Type: protocol.TypeWarning, Warning: "warning 1"},
+		{Type: protocol.TypeResponse, ID: 2, Error: "run error"},
+		{Type: protocol.TypeResponse, ID: 3, Error: `unknown method "unknown"`},
+		{Type: protocol.TypeResponse, ID: 4},
+	}, msgs)
+}
+
+func TestServeConfigureError(t *testing.T) {
+	msgs := serve(t, &testCheck{},
+		protocol.Request{ID: 1, Method: protocol.MethodConfigure, Configure: &protocol.ConfigureParams{Version: protocol.Version, Instance: "invalid"}},
+		protocol.Request{ID: 2, Method: protocol.MethodConfigure},
+	)
+	assert.Equal(t, []protocol.Message{
+		{Type: protocol.TypeResponse, ID: 1, Version: protocol.Version, CheckVersion: "1.2.3", Error: "invalid instance"},
+		{Type: protocol.TypeResponse, ID: 2, Version: protocol.Version, CheckVersion: "1.2.3", Error: "missing configure parameters"},
+	}, msgs)
+}
+
+func TestServeInvalidRequest(t *testing.T) {
+	var out bytes.Buffer
+	assert.Error(t, ServeIO(&testCheck{}, strings.NewReader("not json\n"), &out))
+}
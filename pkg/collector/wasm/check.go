@@ -0,0 +1,248 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// WasmCheck is a check running a WebAssembly module. Each instance has its
+// own runtime, limited to the memory of the loader. The module is kept
+// between the runs, and instantiated again at the next run when a run fails
+// or times out.
+type WasmCheck struct {
+	corechecks.CheckBase
+	code   []byte
+	loader *WasmCheckLoader
+
+	initConfigJSON []byte
+	instanceJSON   []byte
+
+	mu       sync.Mutex // guards runtime, mod, cancel and canceled
+	runtime  wazero.Runtime
+	mod      api.Module
+	cancel   context.CancelFunc
+	canceled bool
+
+	// the state of the current call, only accessed by the host functions
+	sender   sender.Sender
+	callErr  string
+	httpBody []byte
+}
+
+// newWasmCheck returns a check running the module of code.
+func newWasmCheck(name string, code []byte, loader *WasmCheckLoader) *WasmCheck {
+	return &WasmCheck{
+		CheckBase: corechecks.NewCheckBase(name),
+		code:      code,
+		loader:    loader,
+	}
+}
+
+// Loader returns the name of the check loader
+func (*WasmCheck) Loader() string {
+	return WasmCheckLoaderName
+}
+
+// Configure instantiates the module and configures it with the instance
+func (c *WasmCheck) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, data integration.Data, initConfig integration.Data, source string) error {
+	c.BuildID(integrationConfigDigest, data, initConfig)
+	if err := c.CommonConfigure(senderManager, initConfig, data, source); err != nil {
+		return err
+	}
+	var err error
+	if c.initConfigJSON, err = yamlToJSON(initConfig); err != nil {
+		return fmt.Errorf("invalid init_config: %w", err)
+	}
+	if c.instanceJSON, err = yamlToJSON(data); err != nil {
+		return fmt.Errorf("invalid instance: %w", err)
+	}
+	_, err = c.module()
+	return err
+}
+
+// Run runs the check once in the module
+func (c *WasmCheck) Run() error {
+	s, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	mod, err := c.module()
+	if err != nil {
+		return err
+	}
+
+	c.sender = s
+	defer func() { c.sender = nil }()
+	err = c.call(mod, "run")
+	s.Commit()
+	return err
+}
+
+// Cancel releases the runtime of the check, terminating the current run
+func (c *WasmCheck) Cancel() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.canceled = true
+	if c.cancel != nil {
+		c.cancel()
+	}
+	if c.runtime != nil {
+		if err := c.runtime.Close(context.Background()); err != nil {
+			log.Debugf("Error closing the runtime of check %s: %s", c.ID(), err)
+		}
+		c.runtime, c.mod = nil, nil
+	}
+}
+
+// module returns the instantiated module, instantiating and configuring it
+// if needed.
+func (c *WasmCheck) module() (api.Module, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.canceled {
+		return nil, fmt.Errorf("check %s is canceled", c.ID())
+	}
+	if c.mod != nil && !c.mod.IsClosed() {
+		return c.mod, nil
+	}
+	if c.runtime != nil {
+		log.Warnf("Instantiating the module of check %s again", c.ID())
+		_ = c.runtime.Close(context.Background())
+		c.runtime, c.mod = nil, nil
+	}
+
+	ctx := context.Background()
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCompilationCache(c.loader.cache).
+		WithMemoryLimitPages(c.loader.memoryPages).
+		WithCloseOnContextDone(true))
+	mod, err := c.instantiate(ctx, rt)
+	if err != nil {
+		_ = rt.Close(ctx)
+		return nil, err
+	}
+	c.runtime, c.mod = rt, mod
+	if mod.ExportedFunction("configure") != nil {
+		// configure is called with c.mu held, so that Cancel waits for it
+		if err := c.callLocked(mod, "configure"); err != nil {
+			_ = rt.Close(ctx)
+			c.runtime, c.mod = nil, nil
+			return nil, fmt.Errorf("could not configure module: %w", err)
+		}
+	}
+	return mod, nil
+}
+
+// instantiate instantiates the host modules and the module of the check in rt.
+func (c *WasmCheck) instantiate(ctx context.Context, rt wazero.Runtime) (api.Module, error) {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, rt); err != nil {
+		return nil, err
+	}
+	if err := c.instantiateHostModules(ctx, rt); err != nil {
+		return nil, err
+	}
+	compiled, err := rt.CompileModule(ctx, c.code)
+	if err != nil {
+		return nil, fmt.Errorf("could not compile module: %w", err)
+	}
+	if compiled.ExportedFunctions()["run"] == nil {
+		return nil, errors.New("the module doesn't export the function run")
+	}
+	out := &logWriter{check: c}
+	mod, err := rt.InstantiateModule(ctx, compiled, wazero.NewModuleConfig().
+		WithName(string(c.ID())).
+		// reactor modules are initialized by _initialize, _start would exit
+		WithStartFunctions("_initialize").
+		WithStdout(out).
+		WithStderr(out).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader))
+	if err != nil {
+		return nil, fmt.Errorf("could not instantiate module: %w", err)
+	}
+	return mod, nil
+}
+
+// call calls the exported function name of mod, within the run timeout.
+func (c *WasmCheck) call(mod api.Module, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.callLocked(mod, name)
+}
+
+// callLocked calls the exported function name of mod. c.mu must be held, it
+// is released during the call for Cancel to terminate it.
+func (c *WasmCheck) callLocked(mod api.Module, name string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	if c.loader.runTimeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), c.loader.runTimeout)
+	}
+	defer cancel()
+	c.cancel = cancel
+	c.callErr = ""
+	c.mu.Unlock()
+
+	res, err := mod.ExportedFunction(name).Call(ctx)
+
+	c.mu.Lock()
+	c.cancel = nil
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s did not complete within %s and was terminated", name, c.loader.runTimeout)
+	case err != nil:
+		// the state of the module is undefined after a trap
+		_ = mod.Close(context.Background())
+		return fmt.Errorf("%s failed: %w", name, err)
+	case len(res) > 0 && uint32(res[0]) != 0:
+		if c.callErr != "" {
+			return errors.New(c.callErr)
+		}
+		return fmt.Errorf("%s returned %d", name, int32(res[0]))
+	}
+	return nil
+}
+
+// logWriter forwards the output of a module to the agent logs.
+type logWriter struct {
+	check *WasmCheck
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		log.Infof("wasm check %s: %s", w.check.ID(), line)
+	}
+	return len(p), nil
+}
+
+// yamlToJSON converts a configuration section to JSON, for the modules.
+func yamlToJSON(data integration.Data) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		v = map[string]interface{}{}
+	}
+	return json.Marshal(v)
+}
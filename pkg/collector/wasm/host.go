@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// Metric types of submit_metric, the same as in the Python aggregator module.
+const (
+	metricTypeGauge uint32 = iota
+	metricTypeRate
+	metricTypeCount
+	metricTypeMonotonicCount
+	metricTypeCounter
+	metricTypeHistogram
+	metricTypeHistorate
+)
+
+// noData is returned by the host functions when there is no data.
+const noData = ^uint32(0) // -1
+
+// instantiateHostModules instantiates the datadog_agent and aggregator host
+// modules in rt, bound to c.
+func (c *WasmCheck) instantiateHostModules(ctx context.Context, rt wazero.Runtime) error {
+	_, err := rt.NewHostModuleBuilder("datadog_agent").
+		NewFunctionBuilder().WithFunc(c.getVersion).Export("get_version").
+		NewFunctionBuilder().WithFunc(c.getHostname).Export("get_hostname").
+		NewFunctionBuilder().WithFunc(c.getConfig).Export("get_config").
+		NewFunctionBuilder().WithFunc(c.getInitConfig).Export("get_init_config").
+		NewFunctionBuilder().WithFunc(c.getInstance).Export("get_instance").
+		NewFunctionBuilder().WithFunc(c.log).Export("log").
+		NewFunctionBuilder().WithFunc(c.warning).Export("warning").
+		NewFunctionBuilder().WithFunc(c.setError).Export("set_error").
+		NewFunctionBuilder().WithFunc(c.httpRequest).Export("http_request").
+		NewFunctionBuilder().WithFunc(c.httpResponseBody).Export("http_response_body").
+		Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = rt.NewHostModuleBuilder("aggregator").
+		NewFunctionBuilder().WithFunc(c.submitMetric).Export("submit_metric").
+		NewFunctionBuilder().WithFunc(c.submitServiceCheck).Export("submit_service_check").
+		NewFunctionBuilder().WithFunc(c.submitEvent).Export("submit_event").
+		Instantiate(ctx)
+	return err
+}
+
+// readString reads the string at ptr of size n in the memory of m.
+func readString(m api.Module, ptr, n uint32) string {
+	if n == 0 {
+		return ""
+	}
+	b, ok := m.Memory().Read(ptr, n)
+	if !ok {
+		panic("string out of the memory of the module")
+	}
+	return string(b)
+}
+
+// readTags reads the tags, one per line, at ptr of size n in the memory of m.
+func readTags(m api.Module, ptr, n uint32) []string {
+	s := readString(m, ptr, n)
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// writeBuffer copies data to the buffer at ptr of size n in the memory of m
+// if it fits, and returns the size of data.
+func writeBuffer(m api.Module, ptr, n uint32, data []byte) uint32 {
+	if uint32(len(data)) <= n && !m.Memory().Write(ptr, data) {
+		panic("buffer out of the memory of the module")
+	}
+	return uint32(len(data))
+}
+
+func (c *WasmCheck) getVersion(_ context.Context, m api.Module, buf, bufLen uint32) uint32 {
+	av, _ := version.Agent()
+	return writeBuffer(m, buf, bufLen, []byte(av.GetNumber()))
+}
+
+func (c *WasmCheck) getHostname(ctx context.Context, m api.Module, buf, bufLen uint32) uint32 {
+	h, err := hostname.Get(ctx)
+	if err != nil {
+		log.Warnf("Error getting hostname: %s", err)
+	}
+	return writeBuffer(m, buf, bufLen, []byte(h))
+}
+
+func (c *WasmCheck) getConfig(_ context.Context, m api.Module, key, keyLen, buf, bufLen uint32) uint32 {
+	k := readString(m, key, keyLen)
+	if !c.loader.cfg.IsSet(k) {
+		return noData
+	}
+	data, err := json.Marshal(c.loader.cfg.Get(k))
+	if err != nil {
+		log.Errorf("could not convert configuration value of '%s' to JSON: %s", k, err)
+		return noData
+	}
+	if sensitiveConfig(k, data) {
+		log.Warnf("wasm check %s: denied reading the sensitive setting '%s'", c.ID(), k)
+		return noData
+	}
+	return writeBuffer(m, buf, bufLen, data)
+}
+
+// sensitiveConfig returns whether the setting k of JSON value data holds data
+// scrubbed from the flares: the API and application keys, the credentials, and
+// the values resolved from secrets.
+func sensitiveConfig(k string, data []byte) bool {
+	// the scrubber matches the keys of the maps, and the last part of the
+	// setting is the key of its value
+	entry, err := json.Marshal(map[string]json.RawMessage{k[strings.LastIndex(k, ".")+1:]: data})
+	if err != nil {
+		return true
+	}
+	var obj interface{}
+	dec := json.NewDecoder(bytes.NewReader(entry))
+	dec.UseNumber()
+	if err := dec.Decode(&obj); err != nil {
+		return true
+	}
+	scrubber.ScrubDataObj(&obj)
+	scrubbed, err := json.Marshal(obj)
+	if err != nil || !bytes.Equal(scrubbed, entry) {
+		return true
+	}
+	// the credentials of the URLs and the like are scrubbed from the text
+	scrubbed, err = scrubber.ScrubBytes(entry)
+	return err != nil || !bytes.Equal(scrubbed, entry)
+}
+
+func (c *WasmCheck) getInitConfig(_ context.Context, m api.Module, buf, bufLen uint32) uint32 {
+	return writeBuffer(m, buf, bufLen, c.initConfigJSON)
+}
+
+func (c *WasmCheck) getInstance(_ context.Context, m api.Module, buf, bufLen uint32) uint32 {
+	return writeBuffer(m, buf, bufLen, c.instanceJSON)
+}
+
+// log logs with the Python logging levels, like LogMessage of the Python
+// datadog_agent module.
+func (c *WasmCheck) log(_ context.Context, m api.Module, level, msg, msgLen uint32) {
+	s := "wasm check " + string(c.ID()) + ": " + readString(m, msg, msgLen)
+	switch level {
+	case 50: // CRITICAL
+		log.Critical(s)
+	case 40: // ERROR
+		log.Error(s)
+	case 30: // WARNING
+		log.Warn(s)
+	case 20: // INFO
+		log.Info(s)
+	case 10: // DEBUG
+		log.Debug(s)
+	case 7: // TRACE
+		log.Trace(s)
+	default: // unknown log level
+		log.Info(s)
+	}
+}
+
+func (c *WasmCheck) warning(_ context.Context, m api.Module, msg, msgLen uint32) {
+	_ = c.Warn(readString(m, msg, msgLen))
+}
+
+func (c *WasmCheck) setError(_ context.Context, m api.Module, msg, msgLen uint32) {
+	c.callErr = readString(m, msg, msgLen)
+}
+
+func (c *WasmCheck) httpRequest(ctx context.Context, m api.Module, method, methodLen, url, urlLen, headers, headersLen, body, bodyLen uint32) uint32 {
+	c.httpBody = nil
+	status, respBody, err := c.loader.fetch(ctx, readString(m, method, methodLen), readString(m, url, urlLen), readString(m, headers, headersLen), readString(m, body, bodyLen))
+	if err != nil {
+		log.Debugf("wasm check %s: %s", c.ID(), err)
+		c.callErr = err.Error()
+		return noData
+	}
+	c.httpBody = respBody
+	return uint32(status)
+}
+
+func (c *WasmCheck) httpResponseBody(_ context.Context, m api.Module, buf, bufLen uint32) uint32 {
+	if c.httpBody == nil {
+		return noData
+	}
+	return writeBuffer(m, buf, bufLen, c.httpBody)
+}
+
+func (c *WasmCheck) submitMetric(_ context.Context, m api.Module, metricType, name, nameLen uint32, value float64, tags, tagsLen, host, hostLen uint32) {
+	if c.sender == nil {
+		log.Debugf("wasm check %s: ignoring metric submitted outside of a run", c.ID())
+		return
+	}
+	n := readString(m, name, nameLen)
+	t := readTags(m, tags, tagsLen)
+	h := readString(m, host, hostLen)
+	switch metricType {
+	case metricTypeGauge:
+		c.sender.Gauge(n, value, h, t)
+	case metricTypeRate:
+		c.sender.Rate(n, value, h, t)
+	case metricTypeCount:
+		c.sender.Count(n, value, h, t)
+	case metricTypeMonotonicCount:
+		c.sender.MonotonicCount(n, value, h, t)
+	case metricTypeCounter:
+		c.sender.Counter(n, value, h, t)
+	case metricTypeHistogram:
+		c.sender.Histogram(n, value, h, t)
+	case metricTypeHistorate:
+		c.sender.Historate(n, value, h, t)
+	default:
+		log.Debugf("wasm check %s: ignoring metric %s of unknown type %d", c.ID(), n, metricType)
+	}
+}
+
+func (c *WasmCheck) submitServiceCheck(_ context.Context, m api.Module, name, nameLen, status, tags, tagsLen, host, hostLen, msg, msgLen uint32) {
+	if c.sender == nil {
+		log.Debugf("wasm check %s: ignoring service check submitted outside of a run", c.ID())
+		return
+	}
+	c.sender.ServiceCheck(readString(m, name, nameLen), servicecheck.ServiceCheckStatus(status), readString(m, host, hostLen), readTags(m, tags, tagsLen), readString(m, msg, msgLen))
+}
+
+// wasmEvent is an event submitted by a module, with the fields of the events
+// of the Python aggregator module.
+type wasmEvent struct {
+	Title          string   `json:"msg_title"`
+	Text           string   `json:"msg_text"`
+	Timestamp      int64    `json:"timestamp"`
+	Priority       string   `json:"priority"`
+	Host           string   `json:"host"`
+	Tags           []string `json:"tags"`
+	AlertType      string   `json:"alert_type"`
+	AggregationKey string   `json:"aggregation_key"`
+	SourceTypeName string   `json:"source_type_name"`
+}
+
+func (c *WasmCheck) submitEvent(_ context.Context, m api.Module, ev, evLen uint32) {
+	if c.sender == nil {
+		log.Debugf("wasm check %s: ignoring event submitted outside of a run", c.ID())
+		return
+	}
+	var e wasmEvent
+	if err := json.Unmarshal([]byte(readString(m, ev, evLen)), &e); err != nil {
+		log.Errorf("wasm check %s: invalid event: %s", c.ID(), err)
+		return
+	}
+	c.sender.Event(event.Event{
+		Title:          e.Title,
+		Text:           e.Text,
+		Ts:             e.Timestamp,
+		Priority:       event.Priority(e.Priority),
+		Host:           e.Host,
+		Tags:           e.Tags,
+		AlertType:      event.AlertType(e.AlertType),
+		AggregationKey: e.AggregationKey,
+		SourceTypeName: e.SourceTypeName,
+	})
+}
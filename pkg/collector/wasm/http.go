@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxHTTPResponseSize is the maximum size of the body of a response read for
+// a module, in bytes.
+const maxHTTPResponseSize = 10 * 1024 * 1024
+
+// defaultPorts are the ports of the URLs without one, by scheme.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// allowed returns whether u is allowed by the allowlist, whose entries are
+// either hosts, or domains prefixed with "*." matching their subdomains,
+// optionally with a port. The URLs without a port match the entries with the
+// default port of their scheme.
+func (wl *WasmCheckLoader) allowed(u *url.URL) bool {
+	defaultPort, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return false
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		port = defaultPort
+	}
+	for _, entry := range wl.httpAllowlist {
+		entryHost, entryPort := strings.ToLower(entry), ""
+		if h, p, err := net.SplitHostPort(entryHost); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		entryHost = strings.Trim(entryHost, "[]")
+		if strings.HasPrefix(entryHost, "*.") {
+			if strings.HasSuffix(host, entryHost[1:]) {
+				return true
+			}
+		} else if host == entryHost {
+			return true
+		}
+	}
+	return false
+}
+
+// fetch sends an HTTP request for a module, to a host of the allowlist, and
+// returns the status code and the body of the response. The headers are
+// given as "Name: value" lines.
+func (wl *WasmCheckLoader) fetch(ctx context.Context, method, rawURL, headers, body string) (int, []byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if !wl.allowed(u) {
+		return 0, nil, fmt.Errorf("%s is not in wasm_checks.http_allowlist", u.Redacted())
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	for _, line := range strings.Split(headers, "\n") {
+		if name, value, ok := strings.Cut(line, ":"); ok {
+			req.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		}
+	}
+
+	client := &http.Client{
+		Timeout:   wl.httpTimeout,
+		Transport: wl.transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			if !wl.allowed(req.URL) {
+				return fmt.Errorf("redirect to %s is not in wasm_checks.http_allowlist", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPResponseSize+1))
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(respBody) > maxHTTPResponseSize {
+		return 0, nil, fmt.Errorf("response of %s larger than %d bytes", u.Redacted(), maxHTTPResponseSize)
+	}
+	return resp.StatusCode, respBody, nil
+}
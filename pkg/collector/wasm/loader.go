@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package wasm implements the loader of the checks compiled to WebAssembly
+// (WASI preview 1), run in a sandbox without access to the file system, with
+// a memory limit and a maximum duration per run.
+//
+// A check is a WASI reactor module exporting:
+//   - run() -> i32: runs the check once, returning 0 on success,
+//   - configure() -> i32: optional, called once instantiated,
+//
+// and importing the host functions of the "datadog_agent" and "aggregator"
+// modules, which mirror the Python modules of the same names. Strings are
+// passed as a pointer and a length in the memory of the module. The functions
+// returning data copy it to a buffer of the module and return its size: when
+// the buffer is too small nothing is copied, and the module can call again
+// with a large enough buffer. They return -1 when there is no data.
+//
+// datadog_agent:
+//   - get_version(buf, buf_len) -> i32: the version of the agent,
+//   - get_hostname(buf, buf_len) -> i32: the hostname of the agent,
+//   - get_config(key, key_len, buf, buf_len) -> i32: the value of an agent
+//     setting, in JSON, except for the settings scrubbed from the flares, such
+//     as the API keys, the credentials and the secrets, which have no data,
+//   - get_init_config(buf, buf_len) -> i32: the init_config of the check, in JSON,
+//   - get_instance(buf, buf_len) -> i32: the instance of the check, in JSON,
+//   - log(level, msg, msg_len): logs with the Python logging levels,
+//   - warning(msg, msg_len): reports a warning in the agent status,
+//   - set_error(msg, msg_len): sets the error returned by the current call,
+//   - http_request(method, method_len, url, url_len, headers, headers_len,
+//     body, body_len) -> i32: sends an HTTP request to a host of the allowlist
+//     and returns its status code, with the headers given as "Name: value"
+//     lines,
+//   - http_response_body(buf, buf_len) -> i32: the body of the last response.
+//
+// aggregator:
+//   - submit_metric(type, name, name_len, value, tags, tags_len, hostname,
+//     hostname_len): submits a metric of the type of the Python module,
+//     from GAUGE (0) to HISTORATE (6), with the tags given one per line,
+//   - submit_service_check(name, name_len, status, tags, tags_len, hostname,
+//     hostname_len, message, message_len),
+//   - submit_event(event, event_len): submits an event given in JSON, with
+//     the fields of the events of the Python module.
+package wasm
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
+	integrations "github.com/DataDog/datadog-agent/comp/logs/integrations/def"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	"github.com/DataDog/datadog-agent/pkg/collector/loaders"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+// WasmCheckLoaderName is the name of the WebAssembly check loader
+const WasmCheckLoaderName string = "wasm"
+
+// wasmPageSize is the size of a page of WebAssembly memory.
+const wasmPageSize = 64 * 1024
+
+// WasmCheckLoader loads the checks from the WebAssembly modules of the wasm
+// checks directory, named after the checks.
+type WasmCheckLoader struct {
+	cfg           pkgconfigmodel.Reader
+	dir           string
+	memoryPages   uint32
+	runTimeout    time.Duration
+	httpAllowlist []string
+	httpTimeout   time.Duration
+	transport     *http.Transport
+
+	// cache keeps the modules compiled once for all the instances.
+	cache wazero.CompilationCache
+
+	mu      sync.Mutex // guards modules
+	modules map[string][]byte
+}
+
+// NewWasmCheckLoader creates a loader for the WebAssembly checks, if enabled
+func NewWasmCheckLoader(cfg pkgconfigmodel.Reader) (*WasmCheckLoader, error) {
+	if !cfg.GetBool("wasm_checks.enabled") {
+		return nil, fmt.Errorf("WebAssembly checks are disabled: %w", loaders.ErrDisabled)
+	}
+	dir := cfg.GetString("wasm_checks.path")
+	if dir == "" {
+		dir = filepath.Join(cfg.GetString("additional_checksd"), "wasm")
+	}
+	pages := cfg.GetInt64("wasm_checks.max_memory") / wasmPageSize
+	if pages < 1 || pages > 65536 {
+		return nil, fmt.Errorf("invalid wasm_checks.max_memory %d, must be between %d and 4GiB", cfg.GetInt64("wasm_checks.max_memory"), wasmPageSize)
+	}
+	return &WasmCheckLoader{
+		cfg:           cfg,
+		dir:           dir,
+		memoryPages:   uint32(pages),
+		runTimeout:    cfg.GetDuration("wasm_checks.run_timeout"),
+		httpAllowlist: cfg.GetStringSlice("wasm_checks.http_allowlist"),
+		httpTimeout:   time.Duration(cfg.GetInt("default_integration_http_timeout")) * time.Second,
+		transport:     httputils.CreateHTTPTransport(cfg),
+		cache:         wazero.NewCompilationCache(),
+		modules:       make(map[string][]byte),
+	}, nil
+}
+
+// Name returns the WebAssembly loader name
+func (*WasmCheckLoader) Name() string {
+	return WasmCheckLoaderName
+}
+
+// Load returns a check running the WebAssembly module named after the check
+func (wl *WasmCheckLoader) Load(senderManager sender.SenderManager, config integration.Config, instance integration.Data) (check.Check, error) {
+	path, err := loaders.CheckFilePath(wl.dir, config.Name, ".wasm")
+	if err != nil {
+		return nil, err
+	}
+	code, err := wl.module(path)
+	if err != nil {
+		return nil, fmt.Errorf("WebAssembly check %s not found in %s: %s", config.Name, wl.dir, err)
+	}
+
+	c := newWasmCheck(config.Name, code, wl)
+	if err := c.Configure(senderManager, config.FastDigest(), instance, config.InitConfig, config.Source); err != nil {
+		log.Errorf("wasm.loader: could not configure check %s: %s", c, err)
+		return c, fmt.Errorf("Could not configure check %s: %s", c, err)
+	}
+	return c, nil
+}
+
+// module returns the code of the module at path, read once.
+func (wl *WasmCheckLoader) module(path string) ([]byte, error) {
+	wl.mu.Lock()
+	defer wl.mu.Unlock()
+	if code, ok := wl.modules[path]; ok {
+		return code, nil
+	}
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	wl.modules[path] = code
+	return code, nil
+}
+
+func (wl *WasmCheckLoader) String() string {
+	return "WebAssembly Check Loader"
+}
+
+func init() {
+	factory := func(sender.SenderManager, option.Option[integrations.Component], tagger.Component) (check.Loader, error) {
+		return NewWasmCheckLoader(pkgconfigsetup.Datadog())
+	}
+
+	loaders.RegisterLoader(35, factory)
+}
@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build wasip1
+
+// Package main is a WebAssembly check used by the tests, built with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared
+//
+// It behaves as the mode of its instance says.
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"unsafe"
+)
+
+//go:wasmimport datadog_agent get_instance
+func getInstance(buf unsafe.Pointer, bufLen uint32) int32
+
+//go:wasmimport datadog_agent get_config
+func getConfig(key unsafe.Pointer, keyLen uint32, buf unsafe.Pointer, bufLen uint32) int32
+
+//go:wasmimport datadog_agent log
+func logMessage(level uint32, msg unsafe.Pointer, msgLen uint32)
+
+//go:wasmimport datadog_agent warning
+func warning(msg unsafe.Pointer, msgLen uint32)
+
+//go:wasmimport datadog_agent set_error
+func setError(msg unsafe.Pointer, msgLen uint32)
+
+//go:wasmimport datadog_agent http_request
+func httpRequest(method unsafe.Pointer, methodLen uint32, url unsafe.Pointer, urlLen uint32, headers unsafe.Pointer, headersLen uint32, body unsafe.Pointer, bodyLen uint32) int32
+
+//go:wasmimport datadog_agent http_response_body
+func httpResponseBody(buf unsafe.Pointer, bufLen uint32) int32
+
+//go:wasmimport aggregator submit_metric
+func submitMetric(metricType uint32, name unsafe.Pointer, nameLen uint32, value float64, tags unsafe.Pointer, tagsLen uint32, hostname unsafe.Pointer, hostnameLen uint32)
+
+//go:wasmimport aggregator submit_service_check
+func submitServiceCheck(name unsafe.Pointer, nameLen uint32, status uint32, tags unsafe.Pointer, tagsLen uint32, hostname unsafe.Pointer, hostnameLen uint32, message unsafe.Pointer, messageLen uint32)
+
+//go:wasmimport aggregator submit_event
+func submitEvent(event unsafe.Pointer, eventLen uint32)
+
+// ptr returns the pointer and the length of s.
+func ptr(s string) (unsafe.Pointer, uint32) {
+	return unsafe.Pointer(unsafe.StringData(s)), uint32(len(s))
+}
+
+// read calls get with a buffer large enough for its data.
+func read(get func(buf unsafe.Pointer, bufLen uint32) int32) (string, bool) {
+	buf := make([]byte, 16)
+	for {
+		n := get(unsafe.Pointer(&buf[0]), uint32(len(buf)))
+		if n < 0 {
+			return "", false
+		}
+		if int(n) <= len(buf) {
+			return string(buf[:n]), true
+		}
+		buf = make([]byte, n)
+	}
+}
+
+var (
+	instance struct {
+		Mode string `json:"mode"`
+		URL  string `json:"url"`
+		Key  string `json:"key"`
+	}
+	runs  int
+	alloc [][]byte
+)
+
+//go:wasmexport configure
+func configure() int32 {
+	data, _ := read(getInstance)
+	if err := json.Unmarshal([]byte(data), &instance); err != nil {
+		msg, n := ptr(err.Error())
+		setError(msg, n)
+		return 1
+	}
+	if instance.Mode == "invalid" {
+		msg, n := ptr("invalid mode")
+		setError(msg, n)
+		return 1
+	}
+	return 0
+}
+
+//go:wasmexport run
+func run() int32 {
+	runs++
+	switch instance.Mode {
+	case "error":
+		msg, n := ptr("run error")
+		setError(msg, n)
+		return 1
+	case "loop":
+		for {
+		}
+	case "alloc":
+		for {
+			alloc = append(alloc, make([]byte, 1024*1024))
+		}
+	case "http":
+		return fetch()
+	case "config":
+		return config()
+	}
+
+	name, nameLen := ptr("wasm.runs")
+	tags, tagsLen := ptr("a:b\nc:d")
+	submitMetric(0, name, nameLen, float64(runs), tags, tagsLen, nil, 0)
+	name, nameLen = ptr("wasm.total")
+	host, hostLen := ptr("host")
+	submitMetric(3, name, nameLen, 10, nil, 0, host, hostLen)
+
+	key, keyLen := ptr("hostname")
+	hostname, _ := read(func(buf unsafe.Pointer, bufLen uint32) int32 { return getConfig(key, keyLen, buf, bufLen) })
+	name, nameLen = ptr("wasm.can_connect")
+	msg, msgLen := ptr("hostname " + hostname)
+	submitServiceCheck(name, nameLen, 2, nil, 0, nil, 0, msg, msgLen)
+
+	event, eventLen := ptr(`{"msg_title":"title","msg_text":"text","alert_type":"error","tags":["e:f"]}`)
+	submitEvent(event, eventLen)
+
+	msg, msgLen = ptr("warning")
+	warning(msg, msgLen)
+	msg, msgLen = ptr("ran")
+	logMessage(20, msg, msgLen)
+	return 0
+}
+
+// fetch gets the URL of the instance and submits its status and body.
+func fetch() int32 {
+	method, methodLen := ptr("GET")
+	url, urlLen := ptr(instance.URL)
+	headers, headersLen := ptr("X-Check: wasm")
+	status := httpRequest(method, methodLen, url, urlLen, headers, headersLen, nil, 0)
+	if status < 0 {
+		return 1
+	}
+	body, _ := read(httpResponseBody)
+	name, nameLen := ptr("wasm.http.status")
+	tags, tagsLen := ptr("body:" + strings.TrimSpace(body))
+	submitMetric(0, name, nameLen, float64(status), tags, tagsLen, nil, 0)
+	return 0
+}
+
+// config submits the value of the setting of the instance.
+func config() int32 {
+	key, keyLen := ptr(instance.Key)
+	value, ok := read(func(buf unsafe.Pointer, bufLen uint32) int32 { return getConfig(key, keyLen, buf, bufLen) })
+	if !ok {
+		value = "none"
+	}
+	name, nameLen := ptr("wasm.config")
+	tags, tagsLen := ptr("value:" + value)
+	submitMetric(0, name, nameLen, 1, tags, tagsLen, nil, 0)
+	return 0
+}
+
+func main() {}
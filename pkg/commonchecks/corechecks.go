@@ -55,6 +55,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/system/winproc"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/systemd"
 	telemetryCheck "github.com/DataDog/datadog-agent/pkg/collector/corechecks/telemetry"
+	// register the loaders of the check plugins and of the WebAssembly checks
+	_ "github.com/DataDog/datadog-agent/pkg/collector/plugin"
+	_ "github.com/DataDog/datadog-agent/pkg/collector/wasm"
 )
 
 // RegisterChecks registers all core checks
@@ -56,6 +56,7 @@ func NewLogReporter(hostname string, sourceName, sourceType string, endpoints *c
 		hostnameimpl.NewHostnameService(),
 		cfg,
 		compression,
+		nil, // metric sender
 		cfg.GetBool("logs_config.disable_distributed_senders"),
 		false, // serverless
 	)
@@ -45,6 +45,9 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("apm_config.obfuscation.valkey.remove_all_args", false, "DD_APM_OBFUSCATION_VALKEY_REMOVE_ALL_ARGS")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.memcached.enabled", true, "DD_APM_OBFUSCATION_MEMCACHED_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.memcached.keep_command", false, "DD_APM_OBFUSCATION_MEMCACHED_KEEP_COMMAND")
+	config.BindEnvAndSetDefault("apm_config.obfuscation.graphql.enabled", true, "DD_APM_OBFUSCATION_GRAPHQL_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.obfuscation.dynamodb.enabled", true, "DD_APM_OBFUSCATION_DYNAMODB_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.obfuscation.dynamodb.keep_values", []string{}, "DD_APM_OBFUSCATION_DYNAMODB_KEEP_VALUES")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.cache.enabled", true, "DD_APM_OBFUSCATION_CACHE_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.obfuscation.cache.max_size", 5000000, "DD_APM_OBFUSCATION_CACHE_MAX_SIZE")
 	config.SetKnown("apm_config.filter_tags.require")
@@ -137,6 +140,20 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.probabilistic_sampler.sampling_percentage", "DD_APM_PROBABILISTIC_SAMPLER_SAMPLING_PERCENTAGE")
 	config.BindEnv("apm_config.probabilistic_sampler.hash_seed", "DD_APM_PROBABILISTIC_SAMPLER_HASH_SEED")
 	config.BindEnvAndSetDefault("apm_config.error_tracking_standalone.enabled", false, "DD_APM_ERROR_TRACKING_STANDALONE_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.enabled", "DD_APM_TAIL_SAMPLING_ENABLED")
+	config.BindEnv("apm_config.tail_sampling.decision_wait", "DD_APM_TAIL_SAMPLING_DECISION_WAIT")
+	config.BindEnv("apm_config.tail_sampling.max_memory", "DD_APM_TAIL_SAMPLING_MAX_MEMORY")
+	config.BindEnv("apm_config.tail_sampling.policies", "DD_APM_TAIL_SAMPLING_POLICIES")
+	config.BindEnv("apm_config.service_map.enabled", "DD_APM_SERVICE_MAP_ENABLED")
+	config.BindEnv("apm_config.service_map.max_edges", "DD_APM_SERVICE_MAP_MAX_EDGES")
+	config.BindEnv("apm_config.file_export.enabled", "DD_APM_FILE_EXPORT_ENABLED")
+	config.BindEnv("apm_config.file_export.path", "DD_APM_FILE_EXPORT_PATH")
+	config.BindEnv("apm_config.file_export.format", "DD_APM_FILE_EXPORT_FORMAT")
+	config.BindEnv("apm_config.file_export.max_file_size", "DD_APM_FILE_EXPORT_MAX_FILE_SIZE")
+	config.BindEnv("apm_config.file_export.max_file_age", "DD_APM_FILE_EXPORT_MAX_FILE_AGE")
+	config.BindEnv("apm_config.file_export.max_files", "DD_APM_FILE_EXPORT_MAX_FILES")
+	config.BindEnv("apm_config.sds.enabled", "DD_APM_SDS_ENABLED")
+	config.BindEnv("apm_config.sds.rules_file", "DD_APM_SDS_RULES_FILE")
 
 	config.BindEnv("apm_config.max_memory", "DD_APM_MAX_MEMORY")
 	config.BindEnv("apm_config.max_cpu_percent", "DD_APM_MAX_CPU_PERCENT")
@@ -150,6 +167,8 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.profiling_additional_endpoints", "DD_APM_PROFILING_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.additional_endpoints", "DD_APM_ADDITIONAL_ENDPOINTS")
 	config.BindEnv("apm_config.replace_tags", "DD_APM_REPLACE_TAGS")
+	config.BindEnv("apm_config.filter_rules", "DD_APM_FILTER_RULES")
+	config.BindEnv("apm_config.service_budgets", "DD_APM_SERVICE_BUDGETS")
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
 	config.BindEnv("apm_config.instrumentation.targets", "DD_APM_INSTRUMENTATION_TARGETS")
@@ -180,6 +199,8 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("apm_config.telemetry.enabled", true, "DD_APM_TELEMETRY_ENABLED")
 	config.BindEnv("apm_config.telemetry.dd_url", "DD_APM_TELEMETRY_DD_URL")
 	config.BindEnv("apm_config.telemetry.additional_endpoints", "DD_APM_TELEMETRY_ADDITIONAL_ENDPOINTS")
+	config.BindEnvAndSetDefault("apm_config.zipkin.enabled", false, "DD_APM_ZIPKIN_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.jaeger.enabled", false, "DD_APM_JAEGER_ENABLED")
 	config.BindEnv("apm_config.install_id", "DD_INSTRUMENTATION_INSTALL_ID")
 	config.BindEnv("apm_config.install_type", "DD_INSTRUMENTATION_INSTALL_TYPE")
 	config.BindEnv("apm_config.install_time", "DD_INSTRUMENTATION_INSTALL_TIME")
@@ -227,6 +248,30 @@ func setupAPM(config pkgconfigmodel.Setup) {
 		return out
 	})
 
+	config.ParseEnvAsSlice("apm_config.tail_sampling.policies", func(in string) []interface{} {
+		var out []interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.tail_sampling.policies" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.ParseEnvAsSlice("apm_config.filter_rules", func(in string) []interface{} {
+		var out []interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.filter_rules" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
+	config.ParseEnvAsSlice("apm_config.service_budgets", func(in string) []interface{} {
+		var out []interface{}
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.service_budgets" can not be parsed: %v`, err)
+		}
+		return out
+	})
+
 	config.ParseEnvAsMapStringInterface("apm_config.analyzed_spans", func(in string) map[string]interface{} {
 		out, err := parseAnalyzedSpans(in)
 		if err != nil {
@@ -243,6 +288,16 @@ func setupAPM(config pkgconfigmodel.Setup) {
 		}
 		return out
 	})
+
+	config.BindEnv("apm_config.extra_aggregation_tags", "DD_APM_EXTRA_AGGREGATION_TAGS")
+	config.ParseEnvAsStringSlice("apm_config.extra_aggregation_tags", func(in string) []string {
+		var out []string
+		if err := json.Unmarshal([]byte(in), &out); err != nil {
+			log.Warnf(`"apm_config.extra_aggregation_tags" can not be parsed: %v`, err)
+		}
+		return out
+	})
+	config.BindEnv("apm_config.extra_aggregation_tags_max_values", "DD_APM_EXTRA_AGGREGATION_TAGS_MAX_VALUES")
 }
 
 func parseKVList(key string) func(string) []string {
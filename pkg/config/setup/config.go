@@ -1125,6 +1125,20 @@ func agent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("metadata_provider_stop_timeout", 30*time.Second)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
 	config.BindEnvAndSetDefault("check_cancel_timeout", 500*time.Millisecond)
+	// Check plugins, run as subprocesses
+	config.BindEnvAndSetDefault("check_plugins.enabled", false)
+	config.BindEnvAndSetDefault("check_plugins.path", "")
+	config.BindEnvAndSetDefault("check_plugins.run_timeout", 5*time.Minute)
+	config.BindEnvAndSetDefault("check_plugins.max_memory", int64(0))
+	config.BindEnvAndSetDefault("check_plugins.max_cpu_percent", int64(0))
+	config.BindEnvAndSetDefault("check_plugins.max_open_files", int64(0))
+	config.BindEnvAndSetDefault("check_plugins.cgroup_path", "/sys/fs/cgroup/datadog-check-plugins")
+	// WebAssembly checks
+	config.BindEnvAndSetDefault("wasm_checks.enabled", false)
+	config.BindEnvAndSetDefault("wasm_checks.path", "")
+	config.BindEnvAndSetDefault("wasm_checks.max_memory", int64(128*1024*1024))
+	config.BindEnvAndSetDefault("wasm_checks.run_timeout", time.Minute)
+	config.BindEnvAndSetDefault("wasm_checks.http_allowlist", []string{})
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	// used to override the path where the IPC cert/key files are stored/retrieved
 	config.BindEnvAndSetDefault("ipc_cert_file_path", "")
@@ -1652,6 +1666,9 @@ func logsagent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("logs_config.aggregation_timeout", 1000)
 	// Time in seconds
 	config.BindEnvAndSetDefault("logs_config.file_scan_period", 10.0)
+	// Time in seconds the sources of an unscheduled container config are kept,
+	// waiting for an updated config after a change of the annotations or labels.
+	config.BindEnvAndSetDefault("logs_config.container_config_update_grace_period", 5)
 
 	// Controls how wildcard file log source are prioritized when there are more files
 	// that match wildcard log configurations than the `logs_config.open_files_limit`
@@ -1662,6 +1679,14 @@ func logsagent(config pkgconfigmodel.Setup) {
 	// more disk I/O at the wildcard log paths
 	config.BindEnvAndSetDefault("logs_config.file_wildcard_selection_mode", "by_name")
 
+	// If true, the file launcher reads once the gzip and zstd archives rotated from tailed files
+	config.BindEnvAndSetDefault("logs_config.tail_compressed_archives", false)
+	// If true, file tailers identify the content of the files they tail by the hash of its first bytes,
+	// to detect rotations regardless of inodes and to check that offsets are still valid on restart
+	config.BindEnvAndSetDefault("logs_config.fingerprint.enabled", false)
+	// Number of leading bytes of a file hashed to identify its content
+	config.BindEnvAndSetDefault("logs_config.fingerprint.max_bytes", 1024)
+
 	// Max size in MB an integration logs file can use
 	config.BindEnvAndSetDefault("logs_config.integrations_logs_files_max_size", 10)
 	// Max disk usage in MB all integrations logs files are allowed to use in total
@@ -1669,9 +1694,16 @@ func logsagent(config pkgconfigmodel.Setup) {
 	// Do not store logs on disk when the disk usage exceeds 80% of the disk capacity.
 	config.BindEnvAndSetDefault("logs_config.integrations_logs_disk_ratio", 0.80)
 
+	// Spill the payloads to disk rather than blocking the pipeline while the intake is unreachable
+	config.BindEnvAndSetDefault("logs_config.spill_buffer.enabled", false)
+	config.BindEnvAndSetDefault("logs_config.spill_buffer.path", "")
+	config.BindEnvAndSetDefault("logs_config.spill_buffer.max_size", 512*1024*1024)
+
 	// SDS logs blocking mechanism
 	config.BindEnvAndSetDefault("logs_config.sds.wait_for_configuration", "")
 	config.BindEnvAndSetDefault("logs_config.sds.buffer_max_size", 0)
+	// SDS rules loaded from a local file instead of the remote configuration
+	config.BindEnvAndSetDefault("logs_config.sds.rules_file", "")
 
 	// Max size in MB to allow for integrations logs files
 	config.BindEnvAndSetDefault("logs_config.integrations_logs_files_max_size", 100)
@@ -1073,6 +1073,17 @@ func TestPeerTagsEnv(t *testing.T) {
 	require.Equal(t, []string{"aws.s3.bucket", "db.instance", "db.system"}, testConfig.GetStringSlice("apm_config.peer_tags"))
 }
 
+func TestExtraAggregationTagsEnv(t *testing.T) {
+	testConfig := newTestConf()
+	require.Nil(t, testConfig.GetStringSlice("apm_config.extra_aggregation_tags"))
+
+	t.Setenv("DD_APM_EXTRA_AGGREGATION_TAGS", `["tenant","region"]`)
+	t.Setenv("DD_APM_EXTRA_AGGREGATION_TAGS_MAX_VALUES", "50")
+	testConfig = newTestConf()
+	require.Equal(t, []string{"tenant", "region"}, testConfig.GetStringSlice("apm_config.extra_aggregation_tags"))
+	require.Equal(t, 50, testConfig.GetInt("apm_config.extra_aggregation_tags_max_values"))
+}
+
 func TestLogDefaults(t *testing.T) {
 	// New config
 	c := create.NewConfig("test")
@@ -1516,6 +1527,9 @@ func TestAPMObfuscationDefaultValue(t *testing.T) {
 	assert.False(t, conf.GetBool("apm_config.obfuscation.redis.remove_all_args"))
 	assert.True(t, conf.GetBool("apm_config.obfuscation.memcached.enabled"))
 	assert.False(t, conf.GetBool("apm_config.obfuscation.memcached.keep_command"))
+	assert.True(t, conf.GetBool("apm_config.obfuscation.graphql.enabled"))
+	assert.True(t, conf.GetBool("apm_config.obfuscation.dynamodb.enabled"))
+	assert.Len(t, conf.GetStringSlice("apm_config.obfuscation.dynamodb.keep_values"), 0)
 	assert.True(t, conf.GetBool("apm_config.obfuscation.credit_cards.enabled"))
 	assert.False(t, conf.GetBool("apm_config.obfuscation.credit_cards.luhn"))
 	assert.Len(t, conf.GetStringSlice("apm_config.obfuscation.credit_cards.keep_values"), 0)
@@ -33,6 +33,14 @@ const registryAPIVersion = 2
 type Registry interface {
 	GetOffset(identifier string) string
 	GetTailingMode(identifier string) string
+	GetFingerprint(identifier string) string
+}
+
+// RotatedIdentifier returns the identifier under which the registry keeps the
+// entry of a file whose content had the given fingerprint, once the file has
+// been rotated and its identifier is used for the new content.
+func RotatedIdentifier(fingerprint string) string {
+	return "rotated:" + fingerprint
 }
 
 // A RegistryEntry represents an entry in the registry where we keep track
@@ -42,6 +50,7 @@ type RegistryEntry struct {
 	Offset             string
 	TailingMode        string
 	IngestionTimestamp int64
+	Fingerprint        string `json:",omitempty"`
 }
 
 // JSONRegistry represents the registry that will be written on disk
@@ -150,6 +159,17 @@ func (a *RegistryAuditor) GetTailingMode(identifier string) string {
 	return entry.TailingMode
 }
 
+// GetFingerprint returns the fingerprint of the content the last committed
+// offset refers to for a given identifier, returns an empty string if it does
+// not exist.
+func (a *RegistryAuditor) GetFingerprint(identifier string) string {
+	entry, exists := a.readOnlyRegistryEntryCopy(identifier)
+	if !exists {
+		return ""
+	}
+	return entry.Fingerprint
+}
+
 // run keeps up to date the registry depending on different events
 func (a *RegistryAuditor) run() {
 	cleanUpTicker := time.NewTicker(defaultCleanupPeriod)
@@ -172,7 +192,7 @@ func (a *RegistryAuditor) run() {
 			}
 			// update the registry with new entry
 			for _, msg := range payload.MessageMetas {
-				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.IngestionTimestamp)
+				a.updateRegistry(msg.Origin.Identifier, msg.Origin.Offset, msg.Origin.LogSource.Config.TailingMode, msg.Origin.Fingerprint, msg.IngestionTimestamp)
 			}
 		case <-cleanUpTicker.C:
 			// remove expired offsets from registry
@@ -226,7 +246,7 @@ func (a *RegistryAuditor) cleanupRegistry() {
 }
 
 // updateRegistry updates the registry entry matching identifier with new the offset and timestamp
-func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, ingestionTimestamp int64) {
+func (a *RegistryAuditor) updateRegistry(identifier string, offset string, tailingMode string, fingerprint string, ingestionTimestamp int64) {
 	a.registryMutex.Lock()
 	defer a.registryMutex.Unlock()
 	if identifier == "" {
@@ -242,6 +262,11 @@ func (a *RegistryAuditor) updateRegistry(identifier string, offset string, taili
 		if v.IngestionTimestamp > ingestionTimestamp {
 			return
 		}
+		// Keep the entry of the previous content of a rotated file, so that
+		// reading its compressed archive can resume from there
+		if v.Fingerprint != "" && fingerprint != "" && v.Fingerprint != fingerprint {
+			a.registry[RotatedIdentifier(v.Fingerprint)] = v
+		}
 	}
 
 	a.registry[identifier] = &RegistryEntry{
@@ -249,6 +274,7 @@ func (a *RegistryAuditor) updateRegistry(identifier string, offset string, taili
 		Offset:             offset,
 		TailingMode:        tailingMode,
 		IngestionTimestamp: ingestionTimestamp,
+		Fingerprint:        fingerprint,
 	}
 }
 
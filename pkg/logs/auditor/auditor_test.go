@@ -51,14 +51,49 @@ func (suite *AuditorTestSuite) TestAuditorStartStop() {
 func (suite *AuditorTestSuite) TestAuditorUpdatesRegistry() {
 	suite.a.registry = make(map[string]*RegistryEntry)
 	suite.Equal(0, len(suite.a.registry))
-	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "", 0)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("42", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("end", suite.a.registry[suite.source.Config.Path].TailingMode)
-	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", 1)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "beginning", "", 1)
 	suite.Equal(1, len(suite.a.registry))
 	suite.Equal("43", suite.a.registry[suite.source.Config.Path].Offset)
 	suite.Equal("beginning", suite.a.registry[suite.source.Config.Path].TailingMode)
+	suite.Equal("", suite.a.GetFingerprint(suite.source.Config.Path))
+	suite.a.updateRegistry(suite.source.Config.Path, "44", "beginning", "2f0b9c1e4d3a5b68", 2)
+	suite.Equal("44", suite.a.GetOffset(suite.source.Config.Path))
+	suite.Equal("2f0b9c1e4d3a5b68", suite.a.GetFingerprint(suite.source.Config.Path))
+}
+
+func (suite *AuditorTestSuite) TestAuditorKeepsRotatedEntry() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.updateRegistry(suite.source.Config.Path, "42", "end", "2f0b9c1e4d3a5b68", 0)
+	suite.a.updateRegistry(suite.source.Config.Path, "43", "end", "2f0b9c1e4d3a5b68", 1)
+	suite.Equal(1, len(suite.a.registry))
+
+	// the file has been rotated, its new content has another fingerprint
+	suite.a.updateRegistry(suite.source.Config.Path, "7", "end", "8e1d6c0b2a4f3957", 2)
+	suite.Equal(2, len(suite.a.registry))
+	suite.Equal("7", suite.a.GetOffset(suite.source.Config.Path))
+	suite.Equal("43", suite.a.GetOffset(RotatedIdentifier("2f0b9c1e4d3a5b68")))
+	suite.Equal("2f0b9c1e4d3a5b68", suite.a.GetFingerprint(RotatedIdentifier("2f0b9c1e4d3a5b68")))
+}
+
+func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversFingerprint() {
+	suite.a.registry = make(map[string]*RegistryEntry)
+	suite.a.registry[suite.source.Config.Path] = &RegistryEntry{
+		LastUpdated: time.Date(2006, time.January, 12, 1, 1, 1, 1, time.UTC),
+		Offset:      "42",
+		TailingMode: "end",
+		Fingerprint: "2f0b9c1e4d3a5b68",
+	}
+	suite.NoError(suite.a.flushRegistry())
+	r, err := os.ReadFile(suite.testRegistryPath)
+	suite.NoError(err)
+	suite.Equal("{\"Version\":2,\"Registry\":{\"testpath\":{\"LastUpdated\":\"2006-01-12T01:01:01.000000001Z\",\"Offset\":\"42\",\"TailingMode\":\"end\",\"IngestionTimestamp\":0,\"Fingerprint\":\"2f0b9c1e4d3a5b68\"}}}", string(r))
+
+	suite.a.registry = suite.a.recoverRegistry()
+	suite.Equal("2f0b9c1e4d3a5b68", suite.a.GetFingerprint(suite.source.Config.Path))
 }
 
 func (suite *AuditorTestSuite) TestAuditorFlushesAndRecoversRegistry() {
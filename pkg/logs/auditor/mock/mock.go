@@ -10,6 +10,7 @@ package mock
 type Registry struct {
 	offset      string
 	tailingMode string
+	fingerprint string
 }
 
 // NewRegistry returns a new registry.
@@ -36,3 +37,13 @@ func (r *Registry) GetTailingMode(_ string) string {
 func (r *Registry) SetTailingMode(tailingMode string) {
 	r.tailingMode = tailingMode
 }
+
+// GetFingerprint returns the fingerprint.
+func (r *Registry) GetFingerprint(_ string) string {
+	return r.fingerprint
+}
+
+// SetFingerprint sets the fingerprint.
+func (r *Registry) SetFingerprint(fingerprint string) {
+	r.fingerprint = fingerprint
+}
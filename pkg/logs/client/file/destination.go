@@ -0,0 +1,307 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package file implements a destination writing logs to a local file.
+package file
+
+import (
+	"bufio"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/backoff"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// defaultMaxFileSize is the size of the file above which it is rotated.
+	defaultMaxFileSize = 10 * 1024 * 1024
+	// defaultMaxFiles is the number of rotated files kept along with the current one.
+	defaultMaxFiles = 5
+)
+
+// Destination writes logs to a local file, one JSON message per line.  The
+// file is rotated once larger than its maximum size: `path` is renamed
+// `path.1`, `path.1` is renamed `path.2`, and so on up to the number of
+// rotated files to keep.
+//
+// The destinations of all the pipelines writing to the same path share the
+// same writer.
+type Destination struct {
+	path                string
+	writer              *fileWriter
+	destinationsContext *client.DestinationsContext
+	shouldRetry         bool
+	isMRF               bool
+
+	backoff        backoff.Policy
+	nbErrors       int
+	lastRetryError error
+}
+
+// NewDestination returns a new file destination.  When shouldRetry is set, a
+// payload which could not be written is retried until it is, rather than
+// dropped.
+func NewDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, shouldRetry bool) *Destination {
+	metrics.DestinationLogsDropped.Set(endpoint.Path, &expvar.Int{})
+	maxFileSize := endpoint.MaxFileSize
+	if maxFileSize <= 0 {
+		maxFileSize = defaultMaxFileSize
+	}
+	maxFiles := endpoint.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxFiles
+	}
+	return &Destination{
+		path:                endpoint.Path,
+		writer:              sharedWriter(endpoint.Path, maxFileSize, maxFiles),
+		destinationsContext: destinationsContext,
+		shouldRetry:         shouldRetry,
+		isMRF:               endpoint.IsMRF,
+		backoff: backoff.NewExpBackoffPolicy(
+			endpoint.BackoffFactor,
+			endpoint.BackoffBase,
+			endpoint.BackoffMax,
+			endpoint.RecoveryInterval,
+			endpoint.RecoveryReset,
+		),
+	}
+}
+
+// IsMRF indicates that this destination is a Multi-Region Failover destination.
+func (d *Destination) IsMRF() bool {
+	return d.isMRF
+}
+
+// Target is the path of the file.
+func (d *Destination) Target() string {
+	return d.path
+}
+
+// Metadata is not supported for file destinations
+func (d *Destination) Metadata() *client.DestinationMetadata {
+	return client.NewNoopDestinationMetadata()
+}
+
+// Start writes the payloads read from the input to the file, until the input
+// is closed.
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{})
+	go func() {
+		for payload := range input {
+			d.writeAndRetry(payload, output, isRetrying)
+		}
+		d.updateRetryState(nil, isRetrying)
+		d.writer.close()
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+// writeAndRetry writes a payload to the file, and acknowledges it once it has
+// been written, or dropped when it should not be retried.
+func (d *Destination) writeAndRetry(payload *message.Payload, output chan *message.Payload, isRetrying chan bool) {
+	for {
+		if backoffDuration := d.backoff.GetBackoffDuration(d.nbErrors); backoffDuration > 0 {
+			select {
+			case <-time.After(backoffDuration):
+			case <-d.destinationsContext.Context().Done():
+				d.updateRetryState(nil, isRetrying)
+				return
+			}
+			metrics.RetryTimeSpent.Add(int64(backoffDuration))
+			metrics.RetryCount.Add(1)
+			metrics.TlmRetryCount.Add(1)
+		}
+
+		err := d.write(payload)
+		if err != nil {
+			log.Warnf("Could not write logs to %s: %v", d.path, err)
+			metrics.DestinationErrors.Add(1)
+			metrics.TlmDestinationErrors.Inc()
+			if d.shouldRetry {
+				d.updateRetryState(err, isRetrying)
+				continue
+			}
+			metrics.DestinationLogsDropped.Add(d.path, 1)
+			metrics.TlmLogsDropped.Inc(d.path)
+		} else {
+			metrics.LogsSent.Add(payload.Count())
+			metrics.TlmLogsSent.Add(float64(payload.Count()))
+		}
+		d.updateRetryState(nil, isRetrying)
+		output <- payload
+		return
+	}
+}
+
+// write appends the messages of the payload to the file.
+func (d *Destination) write(payload *message.Payload) error {
+	messages, err := client.DecodePayload(payload)
+	if err != nil {
+		return err
+	}
+	return d.writer.write(messages)
+}
+
+func (d *Destination) updateRetryState(err error, isRetrying chan bool) {
+	if err != nil {
+		d.nbErrors = d.backoff.IncError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError == nil {
+			isRetrying <- true
+		}
+	} else {
+		d.nbErrors = d.backoff.DecError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError != nil {
+			isRetrying <- false
+		}
+	}
+	d.lastRetryError = err
+}
+
+var (
+	writersMu sync.Mutex
+	// writers holds the writers shared by the destinations, by path.
+	writers = make(map[string]*fileWriter)
+)
+
+// sharedWriter returns the writer of the file at path, shared by all the
+// destinations writing to it so that appends and rotations don't race.
+func sharedWriter(path string, maxFileSize int64, maxFiles int) *fileWriter {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	w, ok := writers[path]
+	if !ok {
+		w = &fileWriter{path: path}
+		writers[path] = w
+	}
+	w.mu.Lock()
+	w.maxFileSize = maxFileSize
+	w.maxFiles = maxFiles
+	w.mu.Unlock()
+	return w
+}
+
+// fileWriter appends lines to a file and rotates it.
+type fileWriter struct {
+	mu          sync.Mutex
+	path        string
+	maxFileSize int64
+	maxFiles    int
+
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+}
+
+// write appends the messages to the file, one per line.  On error, the lines
+// not flushed yet are discarded and the file is reopened by the next write.
+func (w *fileWriter) write(messages []json.RawMessage) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.writeLines(messages)
+	if err != nil {
+		w.discard()
+	}
+	return err
+}
+
+func (w *fileWriter) writeLines(messages []json.RawMessage) error {
+	for _, msg := range messages {
+		if w.file == nil || w.size >= w.maxFileSize {
+			if err := w.rotate(); err != nil {
+				return err
+			}
+		}
+		// the message may share its memory with the payload, which is sent
+		// to the other destinations concurrently: do not append to it.
+		n, err := w.writer.Write(msg)
+		w.size += int64(n)
+		if err != nil {
+			return err
+		}
+		if err := w.writer.WriteByte('\n'); err != nil {
+			return err
+		}
+		w.size++
+	}
+	return w.writer.Flush()
+}
+
+// rotate opens the file to write to, after rotating it if it is too large.
+func (w *fileWriter) rotate() error {
+	if w.file != nil {
+		if err := w.writer.Flush(); err != nil {
+			return err
+		}
+		w.file.Close()
+		w.file = nil
+		w.writer = nil
+	}
+	if fi, err := os.Stat(w.path); err == nil && fi.Size() >= w.maxFileSize {
+		for i := w.maxFiles - 1; i > 0; i-- {
+			// rotated files may not all exist yet
+			_ = os.Rename(rotatedPath(w.path, i), rotatedPath(w.path, i+1))
+		}
+		if err := os.Rename(w.path, rotatedPath(w.path, 1)); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	w.file = f
+	w.writer = bufio.NewWriter(f)
+	w.size = fi.Size()
+	return nil
+}
+
+// discard closes the file without flushing the lines not written yet.
+func (w *fileWriter) discard() {
+	if w.file == nil {
+		return
+	}
+	w.file.Close()
+	w.file = nil
+	w.writer = nil
+}
+
+// close closes the file, it is reopened by the next write.
+func (w *fileWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return
+	}
+	if err := w.writer.Flush(); err != nil {
+		log.Warnf("Could not write logs to %s: %v", w.path, err)
+	}
+	w.file.Close()
+	w.file = nil
+	w.writer = nil
+}
+
+func rotatedPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
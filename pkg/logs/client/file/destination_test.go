@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func newDestination(t *testing.T, endpoint config.Endpoint, shouldRetry bool) *Destination {
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	t.Cleanup(destinationsContext.Stop)
+	return NewDestination(endpoint, destinationsContext, shouldRetry)
+}
+
+func send(t *testing.T, d *Destination, payloads ...string) {
+	input := make(chan *message.Payload, len(payloads))
+	output := make(chan *message.Payload, len(payloads))
+	for _, p := range payloads {
+		input <- message.NewPayload(nil, []byte(p), "", len(p))
+	}
+	close(input)
+	<-d.Start(input, output, nil)
+	assert.Len(t, output, len(payloads))
+}
+
+func TestDestinationWritesOneMessagePerLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "tee.log")
+	d := newDestination(t, config.Endpoint{Type: config.FileEndpointType, Path: path}, false)
+
+	send(t, d, `[{"message":"a"},{"message":"b"}]`, "raw line")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"message\":\"a\"}\n{\"message\":\"b\"}\n\"raw line\"\n", string(content))
+}
+
+func TestDestinationRotatesFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.log")
+	d := newDestination(t, config.Endpoint{Type: config.FileEndpointType, Path: path, MaxFileSize: 10, MaxFiles: 2}, false)
+
+	send(t, d, `"0123456789"`, `"a"`, `"b"`, `"c"`, `"d"`)
+
+	for p, expected := range map[string]string{
+		path:        "\"d\"\n",
+		path + ".1": "\"a\"\n\"b\"\n\"c\"\n",
+		path + ".2": "\"0123456789\"\n",
+	} {
+		content, err := os.ReadFile(p)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(content), p)
+	}
+	assert.NoFileExists(t, path+".3")
+}
+
+func TestDestinationsShareTheirFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.log")
+	endpoint := config.Endpoint{Type: config.FileEndpointType, Path: path, MaxFileSize: 64, MaxFiles: 100}
+
+	// the destinations of several pipelines write to the file concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		d := newDestination(t, endpoint, false)
+		payloads := make([]string, 50)
+		for j := range payloads {
+			payloads[j] = fmt.Sprintf(`"%d-%d"`, i, j)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(t, d, payloads...)
+		}()
+	}
+	wg.Wait()
+
+	// no line is lost or interleaved by the rotations
+	files, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	var lines []string
+	for _, f := range files {
+		content, err := os.ReadFile(f)
+		require.NoError(t, err)
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")...)
+	}
+	assert.Len(t, lines, 200)
+	for _, line := range lines {
+		assert.Regexp(t, `^"\d-\d+"$`, line)
+	}
+}
+
+func TestDestinationRetriesFailedWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.log")
+	// the file can't be opened while a directory is in its way
+	require.NoError(t, os.Mkdir(path, 0755))
+	d := newDestination(t, config.Endpoint{Type: config.FileEndpointType, Path: path}, true)
+
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+	input <- message.NewPayload(nil, []byte(`"a"`), "", 3)
+	close(input)
+	stop := d.Start(input, output, nil)
+
+	// the payload is not acknowledged until it has been written
+	select {
+	case <-output:
+		assert.Fail(t, "a payload which was not written has been acknowledged")
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.NoError(t, os.Remove(path))
+	<-stop
+	assert.Len(t, output, 1)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "\"a\"\n", string(content))
+}
+
+func TestDestinationDropsFailedWritesWithoutRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tee.log")
+	require.NoError(t, os.Mkdir(path, 0755))
+	d := newDestination(t, config.Endpoint{Type: config.FileEndpointType, Path: path}, false)
+
+	// the payload is dropped, and acknowledged not to block the pipeline
+	send(t, d, `"a"`)
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+// DecodePayload returns the messages held by a payload, one JSON document per
+// message.  The payload is decompressed according to its encoding; payloads
+// built for HTTP intakes hold a JSON array of messages, other payloads hold a
+// single message, which is quoted as a JSON string when it is not JSON.
+func DecodePayload(payload *message.Payload) ([]json.RawMessage, error) {
+	content, err := decompress(payload.Encoded, payload.Encoding)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimSpace(content)
+	if len(content) == 0 {
+		return nil, nil
+	}
+
+	if content[0] == '[' {
+		var messages []json.RawMessage
+		if err := json.Unmarshal(content, &messages); err == nil {
+			return messages, nil
+		}
+	}
+	if json.Valid(content) {
+		return []json.RawMessage{content}, nil
+	}
+	quoted, err := json.Marshal(string(content))
+	if err != nil {
+		return nil, err
+	}
+	return []json.RawMessage{quoted}, nil
+}
+
+// decompress returns the content of a payload compressed with the given
+// content encoding.
+func decompress(encoded []byte, encoding string) ([]byte, error) {
+	var r io.ReadCloser
+	var err error
+	switch encoding {
+	case "", "identity":
+		return encoded, nil
+	case "gzip":
+		r, err = gzip.NewReader(bytes.NewReader(encoded))
+	case "deflate":
+		r, err = zlib.NewReader(bytes.NewReader(encoded))
+	case "zstd":
+		var d *zstd.Decoder
+		d, err = zstd.NewReader(bytes.NewReader(encoded))
+		if err == nil {
+			r = d.IOReadCloser()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported payload encoding %q", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
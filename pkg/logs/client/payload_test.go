@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func TestDecodePayload(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoded  string
+		expected []json.RawMessage
+	}{
+		{"empty", "", nil},
+		{"array", `[{"message":"a"},{"message":"b"}]`, []json.RawMessage{json.RawMessage(`{"message":"a"}`), json.RawMessage(`{"message":"b"}`)}},
+		{"object", "{\"message\":\"a\"}\n", []json.RawMessage{json.RawMessage(`{"message":"a"}`)}},
+		{"raw", "2024-01-01T00:00:00Z INFO hello\n", []json.RawMessage{json.RawMessage(`"2024-01-01T00:00:00Z INFO hello"`)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			messages, err := DecodePayload(message.NewPayload(nil, []byte(test.encoded), "", len(test.encoded)))
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, messages)
+		})
+	}
+}
+
+func TestDecodeCompressedPayload(t *testing.T) {
+	content := []byte(`[{"message":"a"}]`)
+	expected := []json.RawMessage{json.RawMessage(`{"message":"a"}`)}
+
+	var gzipped bytes.Buffer
+	w := gzip.NewWriter(&gzipped)
+	_, err := w.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	messages, err := DecodePayload(message.NewPayload(nil, gzipped.Bytes(), "gzip", len(content)))
+	require.NoError(t, err)
+	assert.Equal(t, expected, messages)
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	messages, err = DecodePayload(message.NewPayload(nil, encoder.EncodeAll(content, nil), "zstd", len(content)))
+	require.NoError(t, err)
+	assert.Equal(t, expected, messages)
+
+	_, err = DecodePayload(message.NewPayload(nil, content, "br", len(content)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,231 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package webhook implements a destination posting logs to a generic HTTP
+// endpoint, with a configurable body and headers.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/backoff"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// DefaultBodyTemplate is the template of the body of the requests when none is
+// configured: a JSON array of the messages.
+const DefaultBodyTemplate = `[{{ join .Messages "," }}]`
+
+// requestTimeout is the timeout of the requests to the webhook.
+const requestTimeout = 10 * time.Second
+
+// templateFuncs are the functions available to body templates, on top of the
+// text/template builtins.
+var templateFuncs = template.FuncMap{
+	// join concatenates the messages with a separator.
+	"join": func(messages []json.RawMessage, sep string) string {
+		s := make([]string, len(messages))
+		for i, m := range messages {
+			s[i] = string(m)
+		}
+		return strings.Join(s, sep)
+	},
+}
+
+// BodyData is the data a body template is executed with.
+type BodyData struct {
+	// Messages holds the JSON encoded messages of the payload.
+	Messages []json.RawMessage
+}
+
+// Destination posts logs to a generic HTTP endpoint.  The body of each request
+// is built by executing the body template of the endpoint with the messages of
+// a payload.
+type Destination struct {
+	url                 string
+	headers             map[string]string
+	body                *template.Template
+	client              *http.Client
+	destinationsContext *client.DestinationsContext
+	shouldRetry         bool
+	isMRF               bool
+
+	backoff        backoff.Policy
+	nbErrors       int
+	lastRetryError error
+}
+
+// NewDestination returns a new webhook destination, or an error if its body
+// template is invalid.
+func NewDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, shouldRetry bool, cfg pkgconfigmodel.Reader) (*Destination, error) {
+	bodyTemplate := endpoint.BodyTemplate
+	if bodyTemplate == "" {
+		bodyTemplate = DefaultBodyTemplate
+	}
+	body, err := template.New("body").Funcs(templateFuncs).Parse(bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template for webhook %s: %w", endpoint.URL, err)
+	}
+
+	metrics.DestinationLogsDropped.Set(endpoint.URL, &expvar.Int{})
+	return &Destination{
+		url:     endpoint.URL,
+		headers: endpoint.Headers,
+		body:    body,
+		client: &http.Client{
+			Timeout:   requestTimeout,
+			Transport: httputils.CreateHTTPTransport(cfg),
+		},
+		destinationsContext: destinationsContext,
+		shouldRetry:         shouldRetry,
+		isMRF:               endpoint.IsMRF,
+		backoff: backoff.NewExpBackoffPolicy(
+			endpoint.BackoffFactor,
+			endpoint.BackoffBase,
+			endpoint.BackoffMax,
+			endpoint.RecoveryInterval,
+			endpoint.RecoveryReset,
+		),
+	}, nil
+}
+
+// IsMRF indicates that this destination is a Multi-Region Failover destination.
+func (d *Destination) IsMRF() bool {
+	return d.isMRF
+}
+
+// Target is the URL of the webhook.
+func (d *Destination) Target() string {
+	return d.url
+}
+
+// Metadata is not supported for webhook destinations
+func (d *Destination) Metadata() *client.DestinationMetadata {
+	return client.NewNoopDestinationMetadata()
+}
+
+// Start posts the payloads read from the input to the webhook, until the input
+// is closed.
+func (d *Destination) Start(input chan *message.Payload, output chan *message.Payload, isRetrying chan bool) (stopChan <-chan struct{}) {
+	stop := make(chan struct{})
+	go func() {
+		for payload := range input {
+			d.sendAndRetry(payload, output, isRetrying)
+		}
+		d.updateRetryState(nil, isRetrying)
+		stop <- struct{}{}
+	}()
+	return stop
+}
+
+func (d *Destination) sendAndRetry(payload *message.Payload, output chan *message.Payload, isRetrying chan bool) {
+	for {
+		if backoffDuration := d.backoff.GetBackoffDuration(d.nbErrors); backoffDuration > 0 {
+			select {
+			case <-time.After(backoffDuration):
+			case <-d.destinationsContext.Context().Done():
+			}
+			metrics.RetryTimeSpent.Add(int64(backoffDuration))
+			metrics.RetryCount.Add(1)
+			metrics.TlmRetryCount.Add(1)
+		}
+
+		err := d.send(payload)
+		if err == context.Canceled {
+			d.updateRetryState(nil, isRetrying)
+			return
+		}
+		if err != nil {
+			log.Warnf("Could not send payload to webhook %s: %v", d.url, err)
+			metrics.DestinationErrors.Add(1)
+			metrics.TlmDestinationErrors.Inc()
+			if _, ok := err.(*client.RetryableError); ok && d.shouldRetry {
+				d.updateRetryState(err, isRetrying)
+				continue
+			}
+			metrics.DestinationLogsDropped.Add(d.url, 1)
+			metrics.TlmLogsDropped.Inc(d.url)
+		} else {
+			metrics.LogsSent.Add(payload.Count())
+			metrics.TlmLogsSent.Add(float64(payload.Count()))
+		}
+		d.updateRetryState(nil, isRetrying)
+		output <- payload
+		return
+	}
+}
+
+// send posts a payload to the webhook.  Network errors and server errors are
+// retryable.
+func (d *Destination) send(payload *message.Payload) error {
+	messages, err := client.DecodePayload(payload)
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	if err := d.body.Execute(&body, BodyData{Messages: messages}); err != nil {
+		return err
+	}
+
+	ctx := d.destinationsContext.Context()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", fmt.Sprintf("datadog-agent/%s", version.AgentVersion))
+	for k, v := range d.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if ctx.Err() == context.Canceled {
+			return ctx.Err()
+		}
+		return client.NewRetryableError(err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests:
+		return client.NewRetryableError(fmt.Errorf("server error: %s", resp.Status))
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("client error: %s", resp.Status)
+	}
+	return nil
+}
+
+func (d *Destination) updateRetryState(err error, isRetrying chan bool) {
+	if err != nil {
+		d.nbErrors = d.backoff.IncError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError == nil {
+			isRetrying <- true
+		}
+	} else {
+		d.nbErrors = d.backoff.DecError(d.nbErrors)
+		if isRetrying != nil && d.lastRetryError != nil {
+			isRetrying <- false
+		}
+	}
+	d.lastRetryError = err
+}
@@ -0,0 +1,98 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+)
+
+type request struct {
+	body    string
+	headers http.Header
+}
+
+func newServer(t *testing.T, statuses ...int) (*httptest.Server, chan request) {
+	requests := make(chan request, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{body: string(body), headers: r.Header}
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+func send(t *testing.T, endpoint config.Endpoint, shouldRetry bool, payload string) {
+	destinationsContext := client.NewDestinationsContext()
+	destinationsContext.Start()
+	defer destinationsContext.Stop()
+
+	d, err := NewDestination(endpoint, destinationsContext, shouldRetry, configmock.New(t))
+	require.NoError(t, err)
+	input := make(chan *message.Payload, 1)
+	output := make(chan *message.Payload, 1)
+	input <- message.NewPayload(nil, []byte(payload), "", len(payload))
+	close(input)
+	<-d.Start(input, output, nil)
+	assert.Len(t, output, 1)
+}
+
+func TestDestinationPostsTemplatedBody(t *testing.T) {
+	server, requests := newServer(t)
+	endpoint := config.Endpoint{
+		Type:         config.WebhookEndpointType,
+		URL:          server.URL,
+		BodyTemplate: `{"events":[{{ join .Messages "," }}],"count":{{ len .Messages }}}`,
+		Headers:      map[string]string{"X-Api-Key": "secret"},
+	}
+
+	send(t, endpoint, false, `[{"message":"a"},{"message":"b"}]`)
+
+	r := <-requests
+	assert.Equal(t, `{"events":[{"message":"a"},{"message":"b"}],"count":2}`, r.body)
+	assert.Equal(t, "secret", r.headers.Get("X-Api-Key"))
+	assert.Equal(t, "application/json", r.headers.Get("Content-Type"))
+}
+
+func TestDestinationRetriesServerErrors(t *testing.T) {
+	server, requests := newServer(t, http.StatusServiceUnavailable)
+	endpoint := config.Endpoint{Type: config.WebhookEndpointType, URL: server.URL}
+
+	send(t, endpoint, true, `{"message":"a"}`)
+
+	assert.Len(t, requests, 2)
+	assert.Equal(t, `[{"message":"a"}]`, (<-requests).body)
+}
+
+func TestDestinationDropsClientErrors(t *testing.T) {
+	server, requests := newServer(t, http.StatusBadRequest)
+	endpoint := config.Endpoint{Type: config.WebhookEndpointType, URL: server.URL}
+
+	send(t, endpoint, true, `{"message":"a"}`)
+
+	assert.Len(t, requests, 1)
+}
+
+func TestNewDestinationRejectsInvalidTemplate(t *testing.T) {
+	_, err := NewDestination(config.Endpoint{URL: "http://localhost", BodyTemplate: "{{ .Unknown"}, client.NewDestinationsContext(), false, configmock.New(t))
+	assert.Error(t, err)
+}
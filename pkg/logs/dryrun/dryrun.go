@@ -0,0 +1,218 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dryrun runs sample logs through the decoder and the processor of a
+// log source, and reports what happens to each of them.
+package dryrun
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	status "github.com/DataDog/datadog-agent/pkg/logs/status/utils"
+)
+
+// chunkSize is the size of the chunks of the sample fed to the decoder, as
+// read by the file tailers.
+const chunkSize = 4096
+
+// Result is the outcome of a log decoded from a sample.
+type Result struct {
+	// FirstLine and LastLine are the lines of the sample, starting at 1, the
+	// log was decoded from.
+	FirstLine int
+	LastLine  int
+	// Lines are the lines of the sample the log was decoded from.
+	Lines []string
+	// Decoded is the content of the log produced by the decoder.
+	Decoded string
+	// Processed is the content of the log as sent, empty when it is dropped.
+	Processed string
+	// Encoded is the log as encoded for the intake, nil when it is dropped.
+	Encoded []byte
+	// Sent is false when the log is dropped by a processing rule.
+	Sent bool
+	// Merged is the reason why several lines were aggregated in the log, empty
+	// when it was decoded from a single line.
+	Merged string
+	// Truncated is true when the log exceeded the maximum message size.
+	Truncated bool
+	// Rules are the rules which dropped or modified the log.
+	Rules []processor.AppliedRule
+}
+
+// Run runs the logs of the sample through the decoder and the processor, as
+// the pipeline of a file source would, and returns the outcome of each log.
+// The processor must not be running.
+func Run(source *sources.LogSource, p *processor.Processor, sample []byte) ([]Result, error) {
+	if len(sample) > 0 && sample[len(sample)-1] != '\n' {
+		// the decoder only outputs complete lines
+		sample = append(sample[:len(sample):len(sample)], '\n')
+	}
+	lines := splitLines(sample)
+
+	d := decoder.NewDecoderFromSource(sources.NewReplaceableSource(source), status.NewInfoRegistry())
+	d.Start()
+	go func() {
+		for start := 0; start < len(sample); start += chunkSize {
+			end := min(start+chunkSize, len(sample))
+			d.InputChan <- decoder.NewInput(sample[start:end])
+		}
+		d.Stop()
+	}()
+
+	var results []Result
+	var err error
+	offset := 0
+	for output := range d.OutputChan {
+		start := offset
+		offset += output.RawDataLen
+		if len(output.GetContent()) == 0 || err != nil {
+			continue
+		}
+
+		first, last := lines.lineAt(start), lines.lineAt(offset-1)
+		result := Result{
+			FirstLine: first + 1,
+			LastLine:  last + 1,
+			Lines:     lines.text[first : last+1],
+			Decoded:   string(output.GetContent()),
+			Truncated: output.ParsingExtra.IsTruncated,
+		}
+		if last > first {
+			result.Merged = mergeReason(source.Config)
+		}
+
+		msg := message.NewMessage(output.GetContent(), message.NewOrigin(source), output.Status, output.IngestionTimestamp)
+		msg.EventTimestamp = output.EventTimestamp
+		var rendered []byte
+		rendered, result.Rules, err = p.DryRun(msg)
+		if rendered != nil {
+			result.Sent = true
+			result.Processed = string(rendered)
+			result.Encoded = msg.GetContent()
+		}
+		results = append(results, result)
+	}
+	return results, err
+}
+
+// mergeReason returns the reason why lines of a source are aggregated.
+func mergeReason(cfg *config.LogsConfig) string {
+	for _, rule := range cfg.ProcessingRules {
+		if rule.Type == config.MultiLine {
+			return fmt.Sprintf("%s rule %q", config.MultiLine, rule.Name)
+		}
+	}
+	return "auto multi-line detection"
+}
+
+// sampleLines are the lines of a sample and their offsets.
+type sampleLines struct {
+	text   []string
+	starts []int
+}
+
+func splitLines(sample []byte) sampleLines {
+	var lines sampleLines
+	for start := 0; start < len(sample); {
+		end := bytes.IndexByte(sample[start:], '\n')
+		if end < 0 {
+			end = len(sample)
+		} else {
+			end += start
+		}
+		lines.text = append(lines.text, strings.TrimSuffix(string(sample[start:end]), "\r"))
+		lines.starts = append(lines.starts, start)
+		start = end + 1
+	}
+	return lines
+}
+
+// lineAt returns the index of the line holding the byte at the given offset.
+func (l sampleLines) lineAt(offset int) int {
+	i := sort.Search(len(l.starts), func(i int) bool { return l.starts[i] > offset }) - 1
+	return max(0, min(i, len(l.starts)-1))
+}
+
+// WriteDiff writes the results as a diff between the lines of the sample and
+// the logs as sent, each hunk annotated with the rules which dropped, modified
+// or merged the log, followed by a summary.  With showEncoded, each log sent
+// is followed by the log as encoded for the intake.
+func WriteDiff(w io.Writer, name string, results []Result, showEncoded bool) error {
+	var sent, dropped, modified, merged int
+	fmt.Fprintf(w, "--- %s\n+++ %s (processed)\n", name, name)
+	for _, r := range results {
+		lines := fmt.Sprintf("line %d", r.FirstLine)
+		if r.LastLine > r.FirstLine {
+			lines = fmt.Sprintf("lines %d-%d", r.FirstLine, r.LastLine)
+		}
+
+		var notes []string
+		if r.Merged != "" {
+			merged++
+			notes = append(notes, fmt.Sprintf("merged %d lines (%s)", r.LastLine-r.FirstLine+1, r.Merged))
+		}
+		if r.Truncated {
+			notes = append(notes, "truncated")
+		}
+		for i, rule := range r.Rules {
+			// a dropped log is dropped by the last rule applied to it
+			notes = append(notes, describeRule(rule, !r.Sent && i == len(r.Rules)-1))
+		}
+
+		header := "@@ " + lines + " @@"
+		if len(notes) > 0 {
+			header += " " + strings.Join(notes, ", ")
+		}
+		fmt.Fprintln(w, header)
+
+		switch {
+		case !r.Sent:
+			dropped++
+			writeLines(w, "-", r.Lines)
+		case r.Processed == strings.Join(r.Lines, "\n"):
+			sent++
+			writeLines(w, " ", r.Lines)
+		default:
+			sent++
+			if r.Processed != r.Decoded {
+				modified++
+			}
+			writeLines(w, "-", r.Lines)
+			writeLines(w, "+", strings.Split(r.Processed, "\n"))
+		}
+		if showEncoded && r.Sent {
+			fmt.Fprintf(w, "\\ encoded: %s\n", r.Encoded)
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d logs: %d sent, %d dropped, %d modified, %d merged\n", len(results), sent, dropped, modified, merged)
+	return err
+}
+
+func describeRule(rule processor.AppliedRule, dropped bool) string {
+	if rule.Type == "sds" {
+		return "redacted by the Sensitive Data Scanner"
+	}
+	action := "masked"
+	if dropped {
+		action = "dropped"
+	}
+	return fmt.Sprintf("%s by %s rule %q", action, rule.Type, rule.Name)
+}
+
+func writeLines(w io.Writer, prefix string, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s %s\n", prefix, line)
+	}
+}
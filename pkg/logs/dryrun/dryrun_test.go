@@ -0,0 +1,128 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dryrun
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func newProcessor(t *testing.T, rules []*config.ProcessingRule) *processor.Processor {
+	cfg := configmock.New(t)
+	require.NoError(t, config.ValidateProcessingRules(rules))
+	require.NoError(t, config.CompileProcessingRules(rules))
+	return processor.New(cfg, make(chan *message.Message), make(chan *message.Message), rules, processor.RawEncoder,
+		diagnostic.NewBufferedMessageReceiver(nil, nil), nil, metrics.NewNoopPipelineMonitor("test"), nil)
+}
+
+func TestRun(t *testing.T) {
+	rules := []*config.ProcessingRule{
+		{Type: config.MultiLine, Name: "stack_traces", Pattern: `\d{4}-\d{2}-\d{2}`},
+		{Type: config.ExcludeAtMatch, Name: "exclude_healthchecks", Pattern: "healthcheck"},
+		{Type: config.MaskSequences, Name: "mask_secrets", Pattern: `secret=\S+`, ReplacePlaceholder: "secret=[masked]"},
+	}
+	source := sources.NewLogSource("test", &config.LogsConfig{Type: config.FileType, ProcessingRules: rules})
+	p := newProcessor(t, rules)
+
+	sample := "2024-05-15 12:00:00 starting\n" +
+		"2024-05-15 12:00:01 GET /healthcheck\n" +
+		"2024-05-15 12:00:02 login secret=hunter2\n" +
+		"2024-05-15 12:00:03 panic\n" +
+		"  at main.go:12\n" +
+		"  at main.go:42"
+	results, err := Run(source, p, []byte(sample))
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.Equal(t, 1, results[0].FirstLine)
+	assert.Equal(t, 1, results[0].LastLine)
+	assert.True(t, results[0].Sent)
+	assert.Equal(t, "2024-05-15 12:00:00 starting", results[0].Processed)
+	assert.Empty(t, results[0].Rules)
+
+	assert.Equal(t, 2, results[1].FirstLine)
+	assert.False(t, results[1].Sent)
+	assert.Nil(t, results[1].Encoded)
+	assert.Equal(t, []processor.AppliedRule{{Name: "exclude_healthchecks", Type: config.ExcludeAtMatch}}, results[1].Rules)
+
+	assert.Equal(t, 3, results[2].FirstLine)
+	assert.True(t, results[2].Sent)
+	assert.Equal(t, "2024-05-15 12:00:02 login secret=[masked]", results[2].Processed)
+	assert.NotEmpty(t, results[2].Encoded)
+	assert.Equal(t, []processor.AppliedRule{{Name: "mask_secrets", Type: config.MaskSequences}}, results[2].Rules)
+
+	assert.Equal(t, 4, results[3].FirstLine)
+	assert.Equal(t, 6, results[3].LastLine)
+	assert.Equal(t, []string{"2024-05-15 12:00:03 panic", "  at main.go:12", "  at main.go:42"}, results[3].Lines)
+	assert.Equal(t, `multi_line rule "stack_traces"`, results[3].Merged)
+	assert.True(t, results[3].Sent)
+}
+
+func TestWriteDiff(t *testing.T) {
+	results := []Result{
+		{FirstLine: 1, LastLine: 1, Lines: []string{"starting"}, Decoded: "starting", Processed: "starting", Sent: true},
+		{FirstLine: 2, LastLine: 2, Lines: []string{"GET /healthcheck"}, Decoded: "GET /healthcheck",
+			Rules: []processor.AppliedRule{{Name: "exclude_healthchecks", Type: config.ExcludeAtMatch}}},
+		{FirstLine: 3, LastLine: 3, Lines: []string{"secret=hunter2"}, Decoded: "secret=hunter2", Processed: "secret=[masked]", Sent: true,
+			Rules: []processor.AppliedRule{{Name: "mask_secrets", Type: config.MaskSequences}}},
+		{FirstLine: 4, LastLine: 5, Lines: []string{"panic", "  at main.go:12"}, Decoded: `panic\n  at main.go:12`,
+			Processed: `panic\n  at main.go:12`, Sent: true, Merged: `multi_line rule "stack_traces"`},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteDiff(&out, "sample.log", results, false))
+	expected := `--- sample.log
++++ sample.log (processed)
+@@ line 1 @@
+  starting
+@@ line 2 @@ dropped by exclude_at_match rule "exclude_healthchecks"
+- GET /healthcheck
+@@ line 3 @@ masked by mask_sequences rule "mask_secrets"
+- secret=hunter2
++ secret=[masked]
+@@ lines 4-5 @@ merged 2 lines (multi_line rule "stack_traces")
+- panic
+-   at main.go:12
++ panic\n  at main.go:12
+
+4 logs: 3 sent, 1 dropped, 1 modified, 1 merged
+`
+	assert.Equal(t, expected, out.String())
+}
+
+func TestWriteDiffShowEncoded(t *testing.T) {
+	results := []Result{
+		{FirstLine: 1, LastLine: 1, Lines: []string{"starting"}, Decoded: "starting", Processed: "starting", Sent: true,
+			Encoded: []byte(`{"message":"starting"}`)},
+		{FirstLine: 2, LastLine: 2, Lines: []string{"GET /healthcheck"}, Decoded: "GET /healthcheck",
+			Rules: []processor.AppliedRule{{Name: "exclude_healthchecks", Type: config.ExcludeAtMatch}}},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, WriteDiff(&out, "sample.log", results, true))
+	expected := `--- sample.log
++++ sample.log (processed)
+@@ line 1 @@
+  starting
+\ encoded: {"message":"starting"}
+@@ line 2 @@ dropped by exclude_at_match rule "exclude_healthchecks"
+- GET /healthcheck
+
+2 logs: 1 sent, 1 dropped, 0 modified, 0 merged
+`
+	assert.Equal(t, expected, out.String())
+}
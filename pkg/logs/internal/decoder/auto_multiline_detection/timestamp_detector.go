@@ -7,6 +7,8 @@
 package automultilinedetection
 
 import (
+	"bytes"
+
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder/auto_multiline_detection/tokens"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -122,3 +124,23 @@ func (t *TimestampDetector) ProcessAndContinue(context *messageContext) bool {
 
 	return true
 }
+
+// Locate returns the part of the input most likely to be a timestamp, or nil
+// if its probability is not above the match threshold.  The input is tokenized
+// with the tokenizer, which is not thread safe.
+func (t *TimestampDetector) Locate(tokenizer *Tokenizer, input []byte) []byte {
+	input = input[:min(len(input), tokenizer.maxEvalBytes)]
+	ts, indicies := tokenizer.tokenize(input)
+	match := t.tokenGraph.MatchProbability(ts)
+	if match.probability <= t.matchThreshold {
+		return nil
+	}
+
+	// The match spans the token transitions [start, end), so the tokens
+	// [start, end].
+	end := len(input)
+	if match.end+1 < len(indicies) {
+		end = indicies[match.end+1]
+	}
+	return bytes.TrimSpace(input[indicies[match.start]:end])
+}
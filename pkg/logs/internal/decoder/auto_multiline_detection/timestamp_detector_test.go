@@ -129,3 +129,25 @@ func printMatchUnderline(context *messageContext, input string, match MatchConte
 	dbgStr += strings.Repeat(printChar, len(evalStr)-last)
 	fmt.Printf("\t\t\t%v\n", dbgStr)
 }
+
+func TestLocate(t *testing.T) {
+	tokenizer := NewTokenizer(1000)
+	timestampDetector := NewTimestampDetector(0.5)
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"2021-03-28 13:45:30 App started successfully", "2021-03-28 13:45:30"},
+		{"[2024-05-15T18:03:23.501Z] Info : All routes applied.", "2024-05-15T18:03:23.501Z"},
+		{"foo bar 13:45:30 2021-03-28", "13:45:30 2021-03-28"},
+		{"some random log line", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			assert.Equal(t, tc.expected, string(timestampDetector.Locate(tokenizer, []byte(tc.input))))
+		})
+	}
+}
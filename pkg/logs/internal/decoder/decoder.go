@@ -117,6 +117,12 @@ func NewDecoderWithFraming(source *sources.ReplaceableSource, parser parsers.Par
 
 func buildLineHandler(source *sources.ReplaceableSource, multiLinePattern *regexp.Regexp, tailerInfo *status.InfoRegistry, outputChan chan *message.Message, detectedPattern *DetectedPattern) LineHandler {
 	outputFn := func(m *message.Message) { outputChan <- m }
+	if extractor := newTimestampExtractor(source.Config().Timestamp, source.UnderlyingSource().Name); extractor != nil {
+		outputFn = func(m *message.Message) {
+			extractor.process(m)
+			outputChan <- m
+		}
+	}
 	maxContentSize := config.MaxMessageSizeBytes(pkgconfigsetup.Datadog())
 
 	// construct the lineHandler
@@ -0,0 +1,198 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	automultilinedetection "github.com/DataDog/datadog-agent/pkg/logs/internal/decoder/auto_multiline_detection"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Results of the timestamp extraction, reported in telemetry
+const (
+	timestampExtracted = "extracted"
+	timestampNotFound  = "not_found"
+	timestampRejected  = "rejected"
+	timestampClamped   = "clamped"
+)
+
+// autoDetectLayouts are the layouts of the common timestamp formats, tried when
+// auto-detection is enabled.  Fractional seconds are accepted after the
+// seconds of any of them.
+var autoDetectLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05-0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"02/Jan/2006:15:04:05 -0700",
+	"02/Jan/2006:15:04:05",
+	"02/Jan/2006 15:04:05",
+	"02-Jan-2006 15:04:05",
+	"02 Jan 2006 15:04:05",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 02 Jan 2006 15:04:05",
+	time.RFC822Z,
+	time.RFC822,
+	time.UnixDate,
+	time.RubyDate,
+	time.ANSIC,
+	"Jan _2 2006 15:04:05",
+	"2006 Jan _2 15:04:05",
+	"Jan _2, 2006 3:04:05 PM",
+	"1/2/2006 3:04:05 PM",
+	"01/02/06 15:04:05",
+	time.Stamp,
+}
+
+// timestampExtractor sets the event timestamp of the messages of a source from
+// their content.  It is not thread safe.
+type timestampExtractor struct {
+	regex      *regexp.Regexp
+	detector   *automultilinedetection.TimestampDetector
+	tokenizer  *automultilinedetection.Tokenizer
+	layouts    []string
+	location   *time.Location
+	maxPast    time.Duration
+	maxFuture  time.Duration
+	clamp      bool
+	sourceName string
+}
+
+// newTimestampExtractor returns the timestamp extractor of a source, or nil if
+// its timestamps are not extracted.
+func newTimestampExtractor(cfg *config.TimestampExtraction, sourceName string) *timestampExtractor {
+	if !cfg.IsEnabled() {
+		return nil
+	}
+	// The config is validated when the source is created, errors are only
+	// possible for sources which were not.
+	regex, err := cfg.Regex()
+	if err != nil {
+		log.Warnf("Timestamps of source %s won't be extracted: %v", sourceName, err)
+		return nil
+	}
+	layouts, err := cfg.GoLayouts()
+	if err != nil {
+		log.Warnf("Timestamps of source %s won't be extracted: %v", sourceName, err)
+		return nil
+	}
+	location, err := cfg.Location()
+	if err != nil {
+		log.Warnf("Timestamps of source %s won't be extracted: %v", sourceName, err)
+		return nil
+	}
+	maxPast, maxFuture, err := cfg.Range()
+	if err != nil {
+		log.Warnf("Timestamps of source %s won't be extracted: %v", sourceName, err)
+		return nil
+	}
+	if cfg.AutoDetect {
+		layouts = append(layouts, autoDetectLayouts...)
+	}
+
+	e := &timestampExtractor{
+		regex:      regex,
+		layouts:    layouts,
+		location:   location,
+		maxPast:    maxPast,
+		maxFuture:  maxFuture,
+		clamp:      cfg.OutOfRangePolicy == config.ClampTimestampPolicy,
+		sourceName: sourceName,
+	}
+	if regex == nil {
+		e.tokenizer = automultilinedetection.NewTokenizer(pkgconfigsetup.Datadog().GetInt("logs_config.auto_multi_line.tokenizer_max_input_bytes"))
+		e.detector = automultilinedetection.NewTimestampDetector(pkgconfigsetup.Datadog().GetFloat64("logs_config.auto_multi_line.timestamp_detector_match_threshold"))
+	}
+	return e
+}
+
+// process sets the event timestamp of a message, it is left unset when no
+// timestamp is found or when it is rejected.
+func (e *timestampExtractor) process(msg *message.Message) {
+	ingestion := time.Now()
+	if msg.IngestionTimestamp > 0 {
+		ingestion = time.Unix(0, msg.IngestionTimestamp)
+	}
+
+	ts, found := e.extract(msg.GetContent(), ingestion)
+	if !found {
+		metrics.TlmTimestampExtraction.Inc(timestampNotFound)
+		return
+	}
+
+	result := timestampExtracted
+	if e.maxPast > 0 && ts.Before(ingestion.Add(-e.maxPast)) {
+		ts, result = ingestion.Add(-e.maxPast), timestampClamped
+	} else if e.maxFuture > 0 && ts.After(ingestion.Add(e.maxFuture)) {
+		ts, result = ingestion.Add(e.maxFuture), timestampClamped
+	}
+	if result == timestampClamped && !e.clamp {
+		log.Tracef("Rejecting out of range timestamp of a log of source %s", e.sourceName)
+		metrics.TlmTimestampExtraction.Inc(timestampRejected)
+		return
+	}
+
+	msg.EventTimestamp = ts
+	metrics.TlmTimestampExtraction.Inc(result)
+}
+
+// extract locates and parses the timestamp of a log.
+func (e *timestampExtractor) extract(content []byte, ingestion time.Time) (time.Time, bool) {
+	var candidate []byte
+	if e.regex != nil {
+		candidate = config.TimestampSubmatch(e.regex, content)
+	} else {
+		candidate = e.detector.Locate(e.tokenizer, content)
+	}
+	candidate = bytes.Trim(candidate, " \t[]()\"'")
+	if len(candidate) == 0 {
+		return time.Time{}, false
+	}
+
+	// The located timestamp may be followed by a few characters which are
+	// not part of it, they are dropped one field at a time.
+	value := string(candidate)
+	for {
+		for _, layout := range e.layouts {
+			if ts, err := time.ParseInLocation(layout, value, e.location); err == nil {
+				return withYear(ts, ingestion), true
+			}
+		}
+		i := strings.LastIndexAny(value, " \t")
+		if i <= 0 {
+			return time.Time{}, false
+		}
+		value = value[:i]
+	}
+}
+
+// withYear sets the year of the timestamps parsed with a layout without year,
+// to the year of the ingestion time or to the previous one if they would be
+// in the future.
+func withYear(ts time.Time, ingestion time.Time) time.Time {
+	if ts.Year() != 0 {
+		return ts
+	}
+	ts = ts.AddDate(ingestion.Year(), 0, 0)
+	if ts.After(ingestion.Add(24 * time.Hour)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+	return ts
+}
@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package decoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+)
+
+func extractTimestamp(t *testing.T, cfg *config.TimestampExtraction, content string, ingestion time.Time) time.Time {
+	require.NoError(t, cfg.Validate())
+	extractor := newTimestampExtractor(cfg, "test")
+	require.NotNil(t, extractor)
+	msg := message.NewMessage([]byte(content), nil, "", ingestion.UnixNano())
+	extractor.process(msg)
+	return msg.EventTimestamp
+}
+
+func TestTimestampExtractorDisabled(t *testing.T) {
+	assert.Nil(t, newTimestampExtractor(nil, "test"))
+	assert.Nil(t, newTimestampExtractor(&config.TimestampExtraction{}, "test"))
+}
+
+func TestTimestampExtractorLayouts(t *testing.T) {
+	ingestion := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		cfg      *config.TimestampExtraction
+		content  string
+		expected time.Time
+	}{
+		{
+			name:     "go layout",
+			cfg:      &config.TimestampExtraction{Layouts: []string{"2006-01-02 15:04:05"}},
+			content:  "2024-05-14 10:30:00 INFO Starting",
+			expected: time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "strftime layout",
+			cfg:      &config.TimestampExtraction{Layouts: []string{"%d/%b/%Y:%H:%M:%S %z"}},
+			content:  `127.0.0.1 - - [14/May/2024:10:30:00 +0200] "GET / HTTP/1.1" 200 512`,
+			expected: time.Date(2024, 5, 14, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "pattern with a timestamp group",
+			cfg:      &config.TimestampExtraction{Pattern: `ts=(?P<timestamp>\S+)`, Layouts: []string{time.RFC3339}},
+			content:  "level=info ts=2024-05-14T10:30:00.5Z msg=started",
+			expected: time.Date(2024, 5, 14, 10, 30, 0, 500000000, time.UTC),
+		},
+		{
+			name:     "pattern without a timestamp group",
+			cfg:      &config.TimestampExtraction{Pattern: `\d+-\d+-\d+ \d+:\d+:\d+`, Layouts: []string{"2006-01-02 15:04:05"}},
+			content:  "[worker-1] 2024-05-14 10:30:00 started",
+			expected: time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "timezone",
+			cfg:      &config.TimestampExtraction{Layouts: []string{"2006-01-02 15:04:05"}, Timezone: "Europe/Paris"},
+			content:  "2024-05-14 10:30:00 INFO Starting",
+			expected: time.Date(2024, 5, 14, 8, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "auto detect",
+			cfg:      &config.TimestampExtraction{AutoDetect: true},
+			content:  "[2024-05-14T10:30:00.123Z] Info : All routes applied.",
+			expected: time.Date(2024, 5, 14, 10, 30, 0, 123000000, time.UTC),
+		},
+		{
+			name:     "auto detect without year",
+			cfg:      &config.TimestampExtraction{AutoDetect: true},
+			content:  "May 14 10:30:00 myhost sshd[42]: Accepted publickey",
+			expected: time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC),
+		},
+		{
+			name:    "no timestamp",
+			cfg:     &config.TimestampExtraction{AutoDetect: true},
+			content: "just some log without a date",
+		},
+		{
+			name:    "unparsable timestamp",
+			cfg:     &config.TimestampExtraction{Layouts: []string{time.RFC3339}},
+			content: "2024-05-14 10:30:00 INFO Starting",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := extractTimestamp(t, tc.cfg, tc.content, ingestion)
+			if tc.expected.IsZero() {
+				assert.True(t, ts.IsZero(), "unexpected timestamp %s", ts)
+				return
+			}
+			assert.True(t, tc.expected.Equal(ts), "expected %s, got %s", tc.expected, ts)
+		})
+	}
+}
+
+func TestTimestampExtractorOutOfRange(t *testing.T) {
+	ingestion := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	layouts := []string{"2006-01-02 15:04:05"}
+
+	reject := &config.TimestampExtraction{Layouts: layouts, MaxPast: "24h", MaxFuture: "1h"}
+	assert.True(t, extractTimestamp(t, reject, "2024-05-01 00:00:00 old", ingestion).IsZero())
+	assert.True(t, extractTimestamp(t, reject, "2024-05-16 00:00:00 future", ingestion).IsZero())
+	assert.True(t, extractTimestamp(t, reject, "2024-05-15 00:00:00 recent", ingestion).Equal(time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)))
+
+	clamp := &config.TimestampExtraction{Layouts: layouts, MaxPast: "24h", MaxFuture: "1h", OutOfRangePolicy: config.ClampTimestampPolicy}
+	assert.True(t, extractTimestamp(t, clamp, "2024-05-01 00:00:00 old", ingestion).Equal(ingestion.Add(-24*time.Hour)))
+	assert.True(t, extractTimestamp(t, clamp, "2024-05-16 00:00:00 future", ingestion).Equal(ingestion.Add(time.Hour)))
+}
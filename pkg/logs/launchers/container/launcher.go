@@ -145,6 +145,14 @@ func (l *Launcher) startSource(source *sourcesPkg.LogSource) {
 		return
 	}
 
+	// the tailer of the replaced source is stopped first, so that the logs of
+	// the container are not collected twice: the new tailer resumes from the
+	// registry.
+	if previous := source.PreviousSource; previous != nil {
+		source.PreviousSource = nil
+		l.stopSource(previous)
+	}
+
 	if _, exists := l.tailers[source]; exists {
 		return
 	}
@@ -87,6 +87,46 @@ func TestAddsRemovesSource(t *testing.T) {
 	require.True(t, tailer.Stopped)
 }
 
+func TestReplacesSource(t *testing.T) {
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+
+	l := NewLauncher(nil, option.None[workloadmeta.Component](), fakeTagger)
+	l.tailerFactory = &testFactory{
+		makeTailer: func(source *sources.LogSource) (tailerfactory.Tailer, error) {
+			return &tailerfactory.TestTailer{Name: source.Name}, nil
+		},
+	}
+	addedSources := make(chan *sources.LogSource, 1)
+	removedSources := make(chan *sources.LogSource, 1)
+
+	previous := sources.NewLogSource("previous-source", &config.LogsConfig{
+		Type:       "docker",
+		Identifier: "abc",
+	})
+	addedSources <- previous
+	require.True(t, l.loop(context.Background(), addedSources, removedSources))
+	previousTailer := l.tailers[previous].(*tailerfactory.TestTailer)
+
+	// the tailer of the replaced source is stopped before the new one starts
+	source := sources.NewLogSource("test-source", &config.LogsConfig{
+		Type:       "docker",
+		Identifier: "abc",
+	})
+	source.PreviousSource = previous
+	addedSources <- source
+	require.True(t, l.loop(context.Background(), addedSources, removedSources))
+
+	require.True(t, previousTailer.Stopped)
+	require.Nil(t, l.tailers[previous])
+	require.True(t, l.tailers[source].(*tailerfactory.TestTailer).Started)
+	require.Nil(t, source.PreviousSource)
+
+	// the removal of the replaced source is a no-op
+	removedSources <- previous
+	require.True(t, l.loop(context.Background(), addedSources, removedSources))
+	require.Len(t, l.tailers, 1)
+}
+
 func TestCannotMakeTailer(t *testing.T) {
 	fakeTagger := taggerfxmock.SetupFakeTagger(t)
 
@@ -0,0 +1,239 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
+	tailer "github.com/DataDog/datadog-agent/pkg/logs/tailers/file"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// archiveCandidate is a compressed archive found on disk, along with the
+// source of the file it has been rotated from.
+type archiveCandidate struct {
+	path   string
+	source *sources.LogSource
+}
+
+// archiveState caches the ID and the fingerprint of an archive, to avoid
+// reading it again on each scan.
+type archiveState struct {
+	size        int64
+	modTime     time.Time
+	id          string
+	fingerprint string
+}
+
+// archiveHandoff records how much of a rotated file has been read by its
+// tailer, so that reading its compressed archive resumes from there.
+type archiveHandoff struct {
+	offset    int64
+	rotatedAt time.Time
+}
+
+// archiveScanner keeps track of the compressed archives read by the launcher.
+//
+// Each archive is read once, from the start of its decompressed content or
+// from where the tailer of the rotated file stopped.  Archives are identified
+// by their size, modification time and first compressed bytes, so that they
+// are not read again when logrotate renames them, and are matched with the
+// rotated file by the fingerprint of their decompressed content.
+type archiveScanner struct {
+	// maxAge is the age after which archives are ignored.  It matches the
+	// auditor TTL, after which the offsets of archives are forgotten.
+	maxAge  time.Duration
+	tailers *tailers.TailerContainer[*tailer.Tailer]
+	// states holds the archives seen during the last scan, by path.
+	states map[string]archiveState
+	// finished holds the time at which archives have been fully read, by scan key.
+	finished map[string]time.Time
+	// handoffs holds the offsets reached by rotated tailers, by fingerprint.
+	handoffs map[string]archiveHandoff
+}
+
+func newArchiveScanner(maxAge time.Duration) *archiveScanner {
+	return &archiveScanner{
+		maxAge:   maxAge,
+		tailers:  tailers.NewTailerContainer[*tailer.Tailer](),
+		states:   make(map[string]archiveState),
+		finished: make(map[string]time.Time),
+		handoffs: make(map[string]archiveHandoff),
+	}
+}
+
+// identify returns the state of the archive described by fi, reusing the one
+// computed during the previous scan if the archive did not change.
+func (a *archiveScanner) identify(path string, fi os.FileInfo, seen map[string]archiveState) (archiveState, error) {
+	state, ok := a.states[path]
+	if !ok || state.size != fi.Size() || !state.modTime.Equal(fi.ModTime()) {
+		id, err := tailer.ArchiveID(path, fi)
+		if err != nil {
+			return archiveState{}, err
+		}
+		fingerprint, err := tailer.ArchiveFingerprint(path)
+		if err != nil {
+			return archiveState{}, err
+		}
+		state = archiveState{size: fi.Size(), modTime: fi.ModTime(), id: id, fingerprint: fingerprint}
+	}
+	seen[path] = state
+	return state, nil
+}
+
+// recordHandoff records the offset reached by a rotated tailer that has
+// finished reading its file.
+func (a *archiveScanner) recordHandoff(t *tailer.Tailer) {
+	if fingerprint := t.ContentFingerprint(); fingerprint != "" {
+		a.handoffs[fingerprint] = archiveHandoff{offset: t.LastReadOffset(), rotatedAt: time.Now()}
+	}
+}
+
+// cleanup forgets about finished tailers and about archives too old to be read.
+func (a *archiveScanner) cleanup() {
+	for _, t := range a.tailers.All() {
+		if t.IsFinished() {
+			a.finished[t.GetId()] = time.Now()
+			a.tailers.Remove(t)
+		}
+	}
+	expireBefore := time.Now().Add(-a.maxAge)
+	for key, finishedAt := range a.finished {
+		if finishedAt.Before(expireBefore) {
+			delete(a.finished, key)
+		}
+	}
+	for fingerprint, handoff := range a.handoffs {
+		if handoff.rotatedAt.Before(expireBefore) {
+			delete(a.handoffs, fingerprint)
+		}
+	}
+}
+
+// findArchives returns the compressed archives rotated from the given files,
+// i.e. the archives located in the same directory whose name starts with the
+// name of the file followed by '.' or '-' (app.log.1.gz, app.log-20240101.zst).
+func findArchives(files []*tailer.File) []archiveCandidate {
+	var candidates []archiveCandidate
+	entriesByDir := make(map[string][]os.DirEntry)
+	for _, file := range files {
+		dir, base := filepath.Split(file.Path)
+		entries, ok := entriesByDir[dir]
+		if !ok {
+			var err error
+			if entries, err = os.ReadDir(filepath.Clean(dir)); err != nil {
+				log.Debugf("Could not look for archives of %s: %v", file.Path, err)
+			}
+			entriesByDir[dir] = entries
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !tailer.IsArchivePath(name) {
+				continue
+			}
+			if strings.HasPrefix(name, base+".") || strings.HasPrefix(name, base+"-") {
+				candidates = append(candidates, archiveCandidate{path: filepath.Join(dir, name), source: file.Source.UnderlyingSource()})
+			}
+		}
+	}
+	return candidates
+}
+
+// scanArchives starts tailers for the archives which have not been read yet.
+// An archive rotated from a file whose tailer is still reading the rotated
+// file is deferred until that tailer is done, to resume from where it stopped.
+func (s *Launcher) scanArchives(candidates []archiveCandidate) {
+	s.archives.cleanup()
+
+	seen := make(map[string]archiveState, len(candidates))
+	defer func() { s.archives.states = seen }()
+
+	for _, candidate := range candidates {
+		if _, ok := seen[candidate.path]; ok {
+			// the archive matched several tailed files
+			continue
+		}
+		fi, err := os.Stat(candidate.path)
+		if err != nil {
+			log.Debugf("Could not stat archive %s: %v", candidate.path, err)
+			continue
+		}
+		if time.Since(fi.ModTime()) > s.archives.maxAge {
+			continue
+		}
+		state, err := s.archives.identify(candidate.path, fi, seen)
+		if err != nil {
+			log.Warnf("Could not read archive %s: %v", candidate.path, err)
+			continue
+		}
+		if state.fingerprint == "" {
+			continue
+		}
+		file := tailer.NewArchiveFile(candidate.path, candidate.source, state.id, state.fingerprint)
+		if _, ok := s.archives.finished[file.GetScanKey()]; ok {
+			continue
+		}
+		if s.archives.tailers.Contains(file.GetScanKey()) || s.isRotatedTailerReading(state.fingerprint) {
+			continue
+		}
+		if s.tailers.Count()+s.archives.tailers.Count() >= s.tailingLimit {
+			return
+		}
+		s.startArchiveTailer(file)
+	}
+}
+
+// isRotatedTailerReading returns true if a rotated tailer is reading the file
+// with the given content fingerprint, or has not been cleaned up yet.
+func (s *Launcher) isRotatedTailerReading(fingerprint string) bool {
+	for _, t := range s.rotatedTailers {
+		if t.ContentFingerprint() == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// startArchiveTailer starts reading an archive from the offset recorded in the
+// registry, or from where the tailer of the rotated file stopped.  The latter
+// is kept by the registry across restarts, under the fingerprint of the rotated
+// content.
+func (s *Launcher) startArchiveTailer(file *tailer.File) {
+	channel, monitor := s.pipelineProvider.NextPipelineChanWithMonitor()
+	t := s.createTailer(file, channel, monitor)
+
+	value := s.registry.GetOffset(t.Identifier())
+	if value == "" {
+		if handoff, ok := s.archives.handoffs[file.ArchiveFingerprint]; ok {
+			value = strconv.FormatInt(handoff.offset, 10)
+		} else if rotated := auditor.RotatedIdentifier(file.ArchiveFingerprint); s.registry.GetFingerprint(rotated) == file.ArchiveFingerprint {
+			value = s.registry.GetOffset(rotated)
+		}
+	}
+	var offset int64
+	if value != "" {
+		var err error
+		if offset, err = strconv.ParseInt(value, 10, 64); err != nil {
+			log.Warnf("Could not recover offset for archive %s: %v", file.Path, err)
+		}
+	}
+	delete(s.archives.handoffs, file.ArchiveFingerprint)
+
+	log.Infof("Starting a new tailer for archive: %s (offset: %d) for tailer key %s", file.Path, offset, file.GetScanKey())
+	if err := t.Start(offset, io.SeekStart); err != nil {
+		log.Warn(err)
+		return
+	}
+	s.archives.tailers.Add(t)
+}
@@ -7,6 +7,7 @@
 package file
 
 import (
+	"io"
 	"regexp"
 	"slices"
 	"time"
@@ -14,6 +15,7 @@ import (
 	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	flareController "github.com/DataDog/datadog-agent/comp/logs/agent/flare"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
@@ -55,6 +57,12 @@ type Launcher struct {
 	scanPeriod             time.Duration
 	flarecontroller        *flareController.FlareController
 	tagger                 tagger.Component
+	// archives tracks the compressed archives of rotated files, it is nil
+	// unless `logs_config.tail_compressed_archives` is enabled.
+	archives *archiveScanner
+	// fingerprintEnabled makes the launcher check that the content of a file did
+	// not change before resuming from its registered offset.
+	fingerprintEnabled bool
 }
 
 // NewLauncher returns a new launcher.
@@ -71,6 +79,11 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		wildcardStrategy = fileprovider.WildcardUseFileName
 	}
 
+	var archives *archiveScanner
+	if pkgconfigsetup.Datadog().GetBool("logs_config.tail_compressed_archives") {
+		archives = newArchiveScanner(time.Duration(pkgconfigsetup.Datadog().GetInt("logs_config.auditor_ttl")) * time.Hour)
+	}
+
 	return &Launcher{
 		tailingLimit:           tailingLimit,
 		fileProvider:           fileprovider.NewFileProvider(tailingLimit, wildcardStrategy),
@@ -83,6 +96,8 @@ func NewLauncher(tailingLimit int, tailerSleepDuration time.Duration, validatePo
 		scanPeriod:             scanPeriod,
 		flarecontroller:        flarecontroller,
 		tagger:                 tagger,
+		archives:               archives,
+		fingerprintEnabled:     pkgconfigsetup.Datadog().GetBool("logs_config.fingerprint.enabled"),
 	}
 }
 
@@ -92,6 +107,9 @@ func (s *Launcher) Start(sourceProvider launchers.SourceProvider, pipelineProvid
 	s.addedSources, s.removedSources = sourceProvider.SubscribeForType(config.FileType)
 	s.registry = registry
 	tracker.Add(s.tailers)
+	if s.archives != nil {
+		tracker.Add(s.archives.tailers)
+	}
 	go s.run()
 }
 
@@ -141,6 +159,12 @@ func (s *Launcher) cleanup() {
 		stopper.Add(tailer)
 		s.tailers.Remove(tailer)
 	}
+	if s.archives != nil {
+		for _, tailer := range s.archives.tailers.All() {
+			stopper.Add(tailer)
+			s.archives.tailers.Remove(tailer)
+		}
+	}
 	stopper.Stop()
 }
 
@@ -153,6 +177,18 @@ func (s *Launcher) scan() {
 	filesTailed := make(map[string]bool)
 	var allFiles []string
 
+	var archives []archiveCandidate
+	if s.archives != nil {
+		// archives matched by the configured paths are read once, not tailed
+		files = slices.DeleteFunc(files, func(file *tailer.File) bool {
+			if tailer.IsArchivePath(file.Path) {
+				archives = append(archives, archiveCandidate{path: file.Path, source: file.Source.UnderlyingSource()})
+				return true
+			}
+			return false
+		})
+	}
+
 	log.Debugf("Scan - got %d files from FilesToTail and currently tailing %d files\n", len(files), s.tailers.Count())
 
 	// Pass 1 - Compare 'files' to our current set of tailed files. If any no longer need to be tailed,
@@ -178,6 +214,11 @@ func (s *Launcher) scan() {
 
 		// If the file is currently being tailed, check for rotation and handle it appropriately.
 		if isTailed {
+			if s.fingerprintEnabled || s.archives != nil {
+				// fingerprint the content before it gets rotated, to record it in
+				// the registry and to match it with its archive
+				tailer.ContentFingerprint()
+			}
 			didRotate, err := tailer.DidRotate()
 			if err != nil {
 				log.Debugf("failed to detect log rotation: %v", err)
@@ -230,6 +271,10 @@ func (s *Launcher) scan() {
 	}
 	log.Debugf("After starting new tailers, there are %d tailers running. Limit is %d.\n", tailersLen, s.tailingLimit)
 
+	if s.archives != nil {
+		s.scanArchives(append(archives, findArchives(files)...))
+	}
+
 	// Check how many file handles the Agent process has open and log a warning if the process is coming close to the OS file limit
 	fileStats, err := procfilestats.GetProcessFileStats()
 	if err == nil {
@@ -243,6 +288,8 @@ func (s *Launcher) cleanUpRotatedTailers() {
 	for _, tailer := range s.rotatedTailers {
 		if !tailer.IsFinished() {
 			pendingTailers = append(pendingTailers, tailer)
+		} else if s.archives != nil {
+			s.archives.recordHandoff(tailer)
 		}
 	}
 	s.rotatedTailers = pendingTailers
@@ -322,6 +369,9 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 	if err != nil {
 		log.Warnf("Could not recover offset for file with path %v: %v", file.Path, err)
 	}
+	if s.fingerprintEnabled && whence == io.SeekStart && offset > 0 {
+		offset = s.resumeOffset(file, tailer.Identifier(), offset)
+	}
 
 	log.Infof("Starting a new tailer for: %s (offset: %d, whence: %d) for tailer key %s", file.Path, offset, whence, file.GetScanKey())
 	err = tailer.Start(offset, whence)
@@ -334,6 +384,28 @@ func (s *Launcher) startNewTailer(file *tailer.File, m config.TailingMode) bool
 	return true
 }
 
+// resumeOffset returns the offset from which a file should be tailed: the
+// registered offset, unless the content of the file changed since the offset
+// was recorded, in which case the file has been rotated while it was not
+// tailed and is read from its beginning.
+func (s *Launcher) resumeOffset(file *tailer.File, identifier string, offset int64) int64 {
+	registered := s.registry.GetFingerprint(identifier)
+	if registered == "" {
+		return offset
+	}
+	current, err := tailer.FileFingerprint(file.Path)
+	if err != nil {
+		log.Debugf("Could not compute the fingerprint of %s: %v", file.Path, err)
+		return offset
+	}
+	if current != registered {
+		log.Infof("The content of %s changed since its offset was recorded, tailing it from the beginning", file.Path)
+		metrics.TlmFileRotations.Inc("content_changed")
+		return 0
+	}
+	return offset
+}
+
 // handleTailingModeChange determines the tailing behaviour when the tailing mode for a given file has its
 // configuration change. Two case may happen we can switch from "end" to "beginning" (1) and from "beginning" to
 // "end" (2). If the tailing mode is set to forceEnd or forceBeginning it will remain unchanged.
@@ -8,8 +8,11 @@
 package file
 
 import (
+	"compress/gzip"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -21,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
 	flareController "github.com/DataDog/datadog-agent/comp/logs/agent/flare"
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	logsauditor "github.com/DataDog/datadog-agent/pkg/logs/auditor"
 	auditor "github.com/DataDog/datadog-agent/pkg/logs/auditor/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/util"
 	"github.com/DataDog/datadog-agent/pkg/logs/launchers"
@@ -743,6 +747,168 @@ func TestLauncherFileDetectionSingleScan(t *testing.T) {
 	assert.True(t, launcher.tailers.Contains(path("b.log")))
 }
 
+func TestLauncherReadsArchivesOnce(t *testing.T) {
+	testDir := t.TempDir()
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+
+	path := fmt.Sprintf("%s/app.log", testDir)
+	archivePath := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	assert.Nil(t, os.WriteFile(path, []byte("current\n"), 0644))
+	archive, err := os.Create(archivePath)
+	assert.Nil(t, err)
+	w := gzip.NewWriter(archive)
+	_, err = w.Write([]byte("rotated 1\nrotated 2\nrotated 3\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	assert.Nil(t, archive.Close())
+
+	fc := flareController.NewFlareController()
+	launcher := NewLauncher(10, 20*time.Millisecond, false, 10*time.Second, "by_name", fc, fakeTagger)
+	launcher.archives = newArchiveScanner(time.Hour)
+	launcher.pipelineProvider = mock.NewMockProvider()
+	launcher.registry = auditor.NewRegistry()
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	launcher.activeSources = append(launcher.activeSources, source)
+	status.Clear()
+	status.InitStatus(pkgconfigsetup.Datadog(), util.CreateSources([]*sources.LogSource{source}))
+	defer status.Clear()
+	defer launcher.cleanup()
+
+	// the first line of the archive has already been read by the tailer of the rotated file
+	fingerprint, err := filetailer.ArchiveFingerprint(archivePath)
+	assert.Nil(t, err)
+	launcher.archives.handoffs[fingerprint] = archiveHandoff{offset: int64(len("rotated 1\n")), rotatedAt: time.Now()}
+
+	launcher.scan()
+	assert.Equal(t, 1, launcher.tailers.Count())
+	assert.Equal(t, 1, launcher.archives.tailers.Count())
+
+	var contents []string
+	for i := 0; i < 3; i++ {
+		msg := <-outputChan
+		contents = append(contents, string(msg.GetContent()))
+	}
+	assert.ElementsMatch(t, []string{"current", "rotated 2", "rotated 3"}, contents)
+
+	// once read, the archive is not read again, even after being renamed by logrotate
+	archiveTailer := launcher.archives.tailers.All()[0]
+	assert.Eventually(t, archiveTailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+	assert.Nil(t, os.Rename(archivePath, fmt.Sprintf("%s/app.log.2.gz", testDir)))
+	launcher.scan()
+	assert.Equal(t, 0, launcher.archives.tailers.Count())
+	assert.Contains(t, launcher.archives.finished, archiveTailer.GetId())
+	select {
+	case msg := <-outputChan:
+		assert.Fail(t, "unexpected message", string(msg.GetContent()))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// registryEntries is a registry holding offsets and fingerprints by identifier.
+type registryEntries map[string]struct{ offset, fingerprint string }
+
+func (r registryEntries) GetOffset(identifier string) string      { return r[identifier].offset }
+func (r registryEntries) GetTailingMode(_ string) string          { return "" }
+func (r registryEntries) GetFingerprint(identifier string) string { return r[identifier].fingerprint }
+
+func TestLauncherResumesArchiveFromRotatedEntry(t *testing.T) {
+	testDir := t.TempDir()
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+
+	path := fmt.Sprintf("%s/app.log", testDir)
+	archivePath := fmt.Sprintf("%s/app.log.1.gz", testDir)
+	assert.Nil(t, os.WriteFile(path, []byte("current\n"), 0644))
+	archive, err := os.Create(archivePath)
+	assert.Nil(t, err)
+	w := gzip.NewWriter(archive)
+	_, err = w.Write([]byte("rotated 1\nrotated 2\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, w.Close())
+	assert.Nil(t, archive.Close())
+
+	// the rotated file had been read up to its first line before a restart
+	fingerprint, err := filetailer.ArchiveFingerprint(archivePath)
+	assert.Nil(t, err)
+	registry := registryEntries{}
+	registry[logsauditor.RotatedIdentifier(fingerprint)] = struct{ offset, fingerprint string }{strconv.Itoa(len("rotated 1\n")), fingerprint}
+
+	fc := flareController.NewFlareController()
+	launcher := NewLauncher(10, 20*time.Millisecond, false, 10*time.Second, "by_name", fc, fakeTagger)
+	launcher.archives = newArchiveScanner(time.Hour)
+	launcher.pipelineProvider = mock.NewMockProvider()
+	launcher.registry = registry
+	outputChan := launcher.pipelineProvider.NextPipelineChan()
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning"})
+	launcher.activeSources = append(launcher.activeSources, source)
+	status.Clear()
+	status.InitStatus(pkgconfigsetup.Datadog(), util.CreateSources([]*sources.LogSource{source}))
+	defer status.Clear()
+	defer launcher.cleanup()
+
+	launcher.scan()
+	var contents []string
+	for i := 0; i < 2; i++ {
+		msg := <-outputChan
+		contents = append(contents, string(msg.GetContent()))
+	}
+	assert.ElementsMatch(t, []string{"current", "rotated 2"}, contents)
+}
+
+func TestLauncherResumesOnlyWhenFingerprintMatches(t *testing.T) {
+	testDir := t.TempDir()
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+	path := fmt.Sprintf("%s/app.log", testDir)
+	content := strings.Repeat("a previously tailed line\n", 50)
+	assert.Nil(t, os.WriteFile(path, []byte(content+"new line\n"), 0644))
+	fingerprint, err := filetailer.FileFingerprint(path)
+	assert.Nil(t, err)
+
+	for name, tc := range map[string]struct {
+		registeredFingerprint string
+		expected              string
+	}{
+		"same content":    {registeredFingerprint: fingerprint, expected: "new line"},
+		"content changed": {registeredFingerprint: "0123456789abcdef", expected: "a previously tailed line"},
+		"no fingerprint":  {registeredFingerprint: "", expected: "new line"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fc := flareController.NewFlareController()
+			launcher := NewLauncher(10, 20*time.Millisecond, false, 10*time.Second, "by_name", fc, fakeTagger)
+			launcher.fingerprintEnabled = true
+			launcher.pipelineProvider = mock.NewMockProvider()
+			registry := auditor.NewRegistry()
+			registry.SetOffset(strconv.Itoa(len(content)))
+			registry.SetTailingMode("beginning")
+			registry.SetFingerprint(tc.registeredFingerprint)
+			launcher.registry = registry
+			outputChan := launcher.pipelineProvider.NextPipelineChan()
+			source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path, TailingMode: "beginning"})
+			status.Clear()
+			status.InitStatus(pkgconfigsetup.Datadog(), util.CreateSources([]*sources.LogSource{source}))
+			defer status.Clear()
+
+			assert.True(t, launcher.startNewTailer(filetailer.NewFile(path, source, false), config.Beginning))
+			msg := <-outputChan
+			assert.Equal(t, tc.expected, string(msg.GetContent()))
+
+			// let the tailer flush the remaining lines when it is stopped
+			done := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-outputChan:
+					case <-done:
+						return
+					}
+				}
+			}()
+			launcher.cleanup()
+			close(done)
+		})
+	}
+}
+
 func getScanKey(path string, source *sources.LogSource) string {
 	return filetailer.NewFile(path, source, false).GetScanKey()
 }
@@ -34,8 +34,12 @@ func (s *SDJournalFactory) NewJournal() (tailer.Journal, error) {
 	return sdjournal.NewJournal()
 }
 
-//nolint:revive // TODO(AML) Fix revive linter
+// NewJournalFromPath opens the journal files of a directory, e.g. the journals
+// collected from remote hosts, or a single journal file.
 func (s *SDJournalFactory) NewJournalFromPath(path string) (tailer.Journal, error) {
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return sdjournal.NewJournalFromFiles(path)
+	}
 	return sdjournal.NewJournalFromDir(path)
 }
 
@@ -84,8 +88,7 @@ func (l *Launcher) run() {
 	for {
 		select {
 		case source := <-l.sources:
-			identifier := tailer.Identifier(source.Config)
-			if _, exists := l.tailers[identifier]; exists {
+			if identifier, exists := l.isTailed(source); exists {
 				log.Warn(identifier, " is already tailed. Use config_id to tail the same journal more than once")
 				continue
 			}
@@ -102,11 +105,12 @@ func (l *Launcher) run() {
 				}
 			}
 
-			tailer, err := l.setupTailer(source)
-			if err != nil {
-				log.Warn("Could not set up journald tailer: ", err)
+			if units := tailer.Units(source.Config); len(units) > 0 {
+				for _, unit := range units {
+					l.startTailer(source, &unit)
+				}
 			} else {
-				l.tailers[identifier] = tailer
+				l.startTailer(source, nil)
 			}
 
 			l.fc.AddToJournalFiles(allJournalSources)
@@ -127,9 +131,35 @@ func (l *Launcher) Stop() {
 	stopper.Stop()
 }
 
+// isTailed returns true and the identifier of the tailer if a tailer of the
+// source is already running.
+func (l *Launcher) isTailed(source *sources.LogSource) (string, bool) {
+	identifiers := []string{tailer.Identifier(source.Config)}
+	for _, unit := range tailer.Units(source.Config) {
+		identifiers = append(identifiers, tailer.UnitIdentifier(source.Config, unit))
+	}
+	for _, identifier := range identifiers {
+		if _, exists := l.tailers[identifier]; exists {
+			return identifier, true
+		}
+	}
+	return "", false
+}
+
+// startTailer starts a tailer for the source, or for a unit of the source when
+// it is tailed with a cursor per unit.
+func (l *Launcher) startTailer(source *sources.LogSource, unit *tailer.Unit) {
+	t, err := l.setupTailer(source, unit)
+	if err != nil {
+		log.Warn("Could not set up journald tailer: ", err)
+		return
+	}
+	l.tailers[t.Identifier()] = t
+}
+
 // setupTailer configures and starts a new tailer,
 // returns the tailer or an error.
-func (l *Launcher) setupTailer(source *sources.LogSource) (*tailer.Tailer, error) {
+func (l *Launcher) setupTailer(source *sources.LogSource, unit *tailer.Unit) (*tailer.Tailer, error) {
 	var journal tailer.Journal
 	var err error
 
@@ -143,12 +173,17 @@ func (l *Launcher) setupTailer(source *sources.LogSource) (*tailer.Tailer, error
 		return nil, err
 	}
 
-	tailer := tailer.NewTailer(source, l.pipelineProvider.NextPipelineChan(), journal, source.Config.ShouldProcessRawMessage(), l.tagger)
-	cursor := l.registry.GetOffset(tailer.Identifier())
+	var t *tailer.Tailer
+	if unit != nil {
+		t = tailer.NewUnitTailer(source, *unit, l.pipelineProvider.NextPipelineChan(), journal, source.Config.ShouldProcessRawMessage(), l.tagger)
+	} else {
+		t = tailer.NewTailer(source, l.pipelineProvider.NextPipelineChan(), journal, source.Config.ShouldProcessRawMessage(), l.tagger)
+	}
+	cursor := l.registry.GetOffset(t.Identifier())
 
-	err = tailer.Start(cursor)
+	err = t.Start(cursor)
 	if err != nil {
 		return nil, err
 	}
-	return tailer, nil
+	return t, nil
 }
@@ -135,6 +135,21 @@ func TestMultipleTailersWithId(t *testing.T) {
 	assert.Equal(t, 2, len(launcher.tailers))
 }
 
+func TestTailersWithCursorPerUnit(t *testing.T) {
+	launcher := newTestLauncher(t)
+
+	source := sources.NewLogSource("testSource", &config.LogsConfig{IncludeSystemUnits: []string{"foo.service", "bar.service"}, CursorPerUnit: true})
+	duplicate := sources.NewLogSource("testSource2", &config.LogsConfig{IncludeSystemUnits: []string{"foo.service"}, CursorPerUnit: true})
+	launcher.sources <- source
+	launcher.sources <- duplicate
+
+	launcher.stop <- struct{}{}
+
+	assert.Equal(t, 2, len(launcher.tailers))
+	assert.ElementsMatch(t, []string{"journald:default:unit=foo.service", "journald:default:unit=bar.service"}, source.GetInputs())
+	assert.Equal(t, 0, len(duplicate.GetInputs()))
+}
+
 func TestStopLauncher(t *testing.T) {
 	launcher := newTestLauncher(t)
 
@@ -83,6 +83,9 @@ type MessageMetadata struct {
 	Origin             *Origin
 	Status             string
 	IngestionTimestamp int64
+	// EventTimestamp is the timestamp extracted from the content of the log,
+	// the log is timestamped at encoding time when it is zero.
+	EventTimestamp time.Time
 	// RawDataLen tracks the original size of the message content before any trimming/transformation.
 	// This is used when calculating the tailer offset - so this will NOT always be equal to `len(Content)`
 	// This is also used to track the original content size before the message is processed and encoded later
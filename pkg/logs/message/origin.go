@@ -16,9 +16,12 @@ type Origin struct {
 	Identifier string
 	LogSource  *sources.LogSource
 	Offset     string
-	service    string
-	source     string
-	tags       []string
+	// Fingerprint identifies the content the offset refers to, it is only set
+	// by file tailers when fingerprinting is enabled.
+	Fingerprint string
+	service     string
+	source      string
+	tags        []string
 }
 
 // NewOrigin returns a new Origin
@@ -63,6 +63,27 @@ var (
 	// TlmBytesMissed is the number of bytes lost before they could be consumed by the agent, such as after log rotation
 	TlmBytesMissed = telemetry.NewCounter("logs", "bytes_missed",
 		nil, "Total number of bytes lost before they could be consumed by the agent, such as after log rotation")
+	// TlmFileRotations is the number of file rotations detected by the file tailers, by reason
+	TlmFileRotations = telemetry.NewCounter("logs", "file_rotations",
+		[]string{"reason"}, "Count of file rotations detected by the file launcher and tailers, by reason (recreated, truncated, content_changed)")
+	// TlmThroughputLimitDropped is the number of logs dropped because their source exceeded its throughput limit
+	TlmThroughputLimitDropped = telemetry.NewCounter("logs", "throughput_limit_dropped",
+		[]string{"source"}, "Count of logs dropped because their source exceeded its throughput limit")
+	// TlmThroughputLimitDroppedBytes is the number of bytes dropped because their source exceeded its throughput limit
+	TlmThroughputLimitDroppedBytes = telemetry.NewCounter("logs", "throughput_limit_dropped_bytes",
+		[]string{"source"}, "Count of bytes dropped because their source exceeded its throughput limit")
+	// TlmThroughputLimitPaused is the time spent by tailers paused because their source exceeded its throughput limit
+	TlmThroughputLimitPaused = telemetry.NewCounter("logs", "throughput_limit_paused_seconds",
+		[]string{"source"}, "Time in seconds spent by tailers paused because their source exceeded its throughput limit")
+	// TlmLogMetricsGenerated is the number of metric samples generated from logs by generate_metric processing rules
+	TlmLogMetricsGenerated = telemetry.NewCounter("logs", "generated_metrics",
+		[]string{"metric_name"}, "Count of metric samples generated from logs by generate_metric processing rules")
+	// TlmLogMetricsErrors is the number of logs matching a generate_metric processing rule without a valid value
+	TlmLogMetricsErrors = telemetry.NewCounter("logs", "generated_metrics_errors",
+		[]string{"metric_name"}, "Count of logs matching a generate_metric processing rule without a valid value")
+	// TlmTimestampExtraction is the number of logs the timestamp extraction of their source was applied to, by result
+	TlmTimestampExtraction = telemetry.NewCounter("logs", "timestamp_extraction",
+		[]string{"result"}, "Count of logs the timestamp extraction of their source was applied to, by result")
 	// SenderLatency the last reported latency value from the http sender (ms)
 	SenderLatency = expvar.Int{}
 	// TlmSenderLatency a histogram of http sender latency (ms)
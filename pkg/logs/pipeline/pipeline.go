@@ -44,6 +44,7 @@ func NewPipeline(
 	hostname hostnameinterface.Component,
 	cfg pkgconfigmodel.Reader,
 	compression logscompression.Component,
+	metricSender processor.MetricSender,
 ) *Pipeline {
 	strategyInput := make(chan *message.Message, pkgconfigsetup.Datadog().GetInt("logs_config.message_channel_size"))
 	flushChan := make(chan struct{})
@@ -62,7 +63,7 @@ func NewPipeline(
 
 	inputChan := make(chan *message.Message, pkgconfigsetup.Datadog().GetInt("logs_config.message_channel_size"))
 	processor := processor.New(cfg, inputChan, strategyInput, processingRules,
-		encoder, diagnosticMessageReceiver, hostname, senderImpl.PipelineMonitor())
+		encoder, diagnosticMessageReceiver, hostname, senderImpl.PipelineMonitor(), metricSender)
 
 	return &Pipeline{
 		InputChan:       inputChan,
@@ -8,6 +8,7 @@ package pipeline
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"go.uber.org/atomic"
@@ -23,6 +24,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/diagnostic"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/sds"
 	"github.com/DataDog/datadog-agent/pkg/logs/sender"
 	httpsender "github.com/DataDog/datadog-agent/pkg/logs/sender/http"
@@ -41,6 +43,10 @@ const (
 
 	// componentName is the name used for destination telemetry
 	componentName = "logs"
+
+	// metricsCommitInterval is the interval at which the metrics generated from
+	// logs are committed to the aggregator.
+	metricsCommitInterval = time.Second
 )
 
 var httpSenderFactory = httpsender.NewHTTPSender
@@ -77,6 +83,12 @@ type provider struct {
 	hostname    hostnameinterface.Component
 	cfg         pkgconfigmodel.Reader
 	compression logscompression.Component
+
+	// metricSender submits the metrics generated from logs, it is committed
+	// periodically while the pipelines run.
+	metricSender processor.MetricSender
+	stopCommits  chan struct{}
+	commitsDone  chan struct{}
 }
 
 // NewProvider returns a new Provider
@@ -91,6 +103,7 @@ func NewProvider(
 	hostname hostnameinterface.Component,
 	cfg pkgconfigmodel.Reader,
 	compression logscompression.Component,
+	metricSender processor.MetricSender,
 	legacyMode bool,
 	serverless bool,
 ) Provider {
@@ -117,6 +130,7 @@ func NewProvider(
 		hostname,
 		cfg,
 		compression,
+		metricSender,
 		flushWg,
 		serverless,
 		senderImpl,
@@ -232,6 +246,7 @@ func newProvider(
 	hostname hostnameinterface.Component,
 	cfg pkgconfigmodel.Reader,
 	compression logscompression.Component,
+	metricSender processor.MetricSender,
 	flushWg *sync.WaitGroup,
 	serverless bool,
 	senderImpl sender.PipelineComponent,
@@ -249,6 +264,7 @@ func newProvider(
 		hostname:                  hostname,
 		cfg:                       cfg,
 		compression:               compression,
+		metricSender:              metricSender,
 	}
 }
 
@@ -267,10 +283,34 @@ func (p *provider) Start() {
 			p.hostname,
 			p.cfg,
 			p.compression,
+			p.metricSender,
 		)
 		pipeline.Start()
 		p.pipelines = append(p.pipelines, pipeline)
 	}
+
+	if p.metricSender != nil {
+		p.stopCommits = make(chan struct{})
+		p.commitsDone = make(chan struct{})
+		go p.commitMetrics()
+	}
+}
+
+// commitMetrics commits the metrics generated from logs periodically, and
+// once more when the pipelines are stopped.
+func (p *provider) commitMetrics() {
+	defer close(p.commitsDone)
+	ticker := time.NewTicker(metricsCommitInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.metricSender.Commit()
+		case <-p.stopCommits:
+			p.metricSender.Commit()
+			return
+		}
+	}
 }
 
 // Stop stops all pipelines in parallel,
@@ -284,6 +324,12 @@ func (p *provider) Stop() {
 	}
 
 	stopper.Stop()
+	if p.stopCommits != nil {
+		// the pipelines are flushed, commit the last metrics generated from logs
+		close(p.stopCommits)
+		<-p.commitsDone
+		p.stopCommits = nil
+	}
 	p.sender.Stop()
 	p.pipelines = p.pipelines[:0]
 }
@@ -240,6 +240,7 @@ func TestProviderConfigurations(t *testing.T) {
 				nil, // hostname
 				cfg,
 				compression,
+				nil, // metric sender
 				tc.legacyMode,
 				tc.serverless,
 			)
@@ -311,6 +312,7 @@ func TestPipelineChannelDistribution(t *testing.T) {
 				nil, // hostname
 				cfg,
 				compression,
+				nil,   // metric sender
 				false, // legacy mode
 				false, // serverless
 			)
@@ -342,3 +344,36 @@ func TestPipelineChannelDistribution(t *testing.T) {
 		})
 	}
 }
+
+type countingMetricSender struct {
+	sync.Mutex
+	commits int
+}
+
+func (s *countingMetricSender) Count(string, float64, string, []string)        {}
+func (s *countingMetricSender) Gauge(string, float64, string, []string)        {}
+func (s *countingMetricSender) Distribution(string, float64, string, []string) {}
+func (s *countingMetricSender) Commit() {
+	s.Lock()
+	defer s.Unlock()
+	s.commits++
+}
+
+func TestProviderCommitsMetricsOnStop(t *testing.T) {
+	cfg := configmock.New(t)
+	endpoints := config.NewMockEndpointsWithOptions([]config.Endpoint{config.NewMockEndpoint()}, map[string]interface{}{
+		"use_http": true,
+	})
+	metricSender := &countingMetricSender{}
+	p := NewProvider(1, auditor.NewNullAuditor(), &diagnostic.BufferedMessageReceiver{}, nil, endpoints,
+		&client.DestinationsContext{}, statusinterface.NewStatusProviderMock(), nil, cfg,
+		compressionfx.NewMockCompressor(), metricSender, false, false)
+
+	p.Start()
+	p.Stop()
+
+	// the metrics generated from logs are committed once the pipelines are flushed
+	metricSender.Lock()
+	defer metricSender.Unlock()
+	assert.Equal(t, 1, metricSender.commits)
+}
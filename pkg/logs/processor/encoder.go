@@ -8,6 +8,7 @@ package processor
 
 import (
 	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -37,3 +38,16 @@ func toValidUtf8(msg []byte) string {
 	}
 	return str.String()
 }
+
+// messageTimestamp returns the timestamp of a message: the timestamp provided
+// by the serverless runtime, the timestamp extracted from its content, or the
+// current time.
+func messageTimestamp(msg *message.Message) time.Time {
+	if !msg.ServerlessExtra.Timestamp.IsZero() {
+		return msg.ServerlessExtra.Timestamp
+	}
+	if !msg.EventTimestamp.IsZero() {
+		return msg.EventTimestamp.UTC()
+	}
+	return time.Now().UTC()
+}
@@ -222,6 +222,33 @@ func TestJsonEncoder(t *testing.T) {
 	assert.NotEmpty(t, log.Timestamp)
 }
 
+func TestEncodersUseEventTimestamp(t *testing.T) {
+	source := sources.NewLogSource("", &config.LogsConfig{})
+	eventTimestamp := time.Date(2024, 5, 14, 10, 30, 0, 0, time.UTC)
+	newEventMessage := func() *message.Message {
+		msg := newMessage([]byte("message"), source, message.StatusInfo)
+		msg.State = message.StateRendered
+		msg.EventTimestamp = eventTimestamp
+		return msg
+	}
+
+	msg := newEventMessage()
+	assert.Nil(t, JSONEncoder.Encode(msg, "unknown"))
+	jsonLog := &jsonPayload{}
+	assert.Nil(t, json.Unmarshal(msg.GetContent(), jsonLog))
+	assert.Equal(t, eventTimestamp.UnixMilli(), jsonLog.Timestamp)
+
+	msg = newEventMessage()
+	assert.Nil(t, ProtoEncoder.Encode(msg, "unknown"))
+	protoLog := &pb.Log{}
+	assert.Nil(t, protoLog.Unmarshal(msg.GetContent()))
+	assert.Equal(t, eventTimestamp.UnixNano(), protoLog.Timestamp)
+
+	msg = newEventMessage()
+	assert.Nil(t, RawEncoder.Encode(msg, "unknown"))
+	assert.Contains(t, string(msg.GetContent()), eventTimestamp.Format(config.DateFormat))
+}
+
 func TestEncoderToValidUTF8(t *testing.T) {
 	// valid utf-8
 	assert.Equal(t, "", toValidUtf8(nil))
@@ -8,7 +8,6 @@ package processor
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
@@ -41,10 +40,7 @@ func (j *jsonEncoder) Encode(msg *message.Message, hostname string) error {
 		return fmt.Errorf("message passed to encoder isn't rendered")
 	}
 
-	ts := time.Now().UTC()
-	if !msg.ServerlessExtra.Timestamp.IsZero() {
-		ts = msg.ServerlessExtra.Timestamp
-	}
+	ts := messageTimestamp(msg)
 
 	encoded, err := json.Marshal(jsonPayload{
 		Message:   toValidUtf8(msg.GetContent()),
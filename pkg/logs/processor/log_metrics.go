@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// MetricSender submits the metrics generated from logs by the generate_metric
+// processing rules, it is implemented by the aggregator senders.
+type MetricSender interface {
+	Count(metric string, value float64, hostname string, tags []string)
+	Gauge(metric string, value float64, hostname string, tags []string)
+	Distribution(metric string, value float64, hostname string, tags []string)
+	Commit()
+}
+
+// generateMetric submits the metric of a generate_metric rule for a message
+// matching it.
+func (p *Processor) generateMetric(rule *config.ProcessingRule, msg *message.Message, content []byte) {
+	sender := p.metricSender
+	if sender == nil {
+		return
+	}
+
+	match := rule.Regex.FindSubmatch(content)
+	if match == nil {
+		return
+	}
+
+	value := 1.0
+	var tags []string
+	for i, group := range rule.Regex.SubexpNames() {
+		if group == "" || match[i] == nil {
+			continue
+		}
+		if group == rule.Metric.Value {
+			v, err := strconv.ParseFloat(string(match[i]), 64)
+			if err != nil {
+				log.Debugf("Can't generate metric %s: invalid value %q", rule.Metric.Name, match[i])
+				metrics.TlmLogMetricsErrors.Inc(rule.Metric.Name)
+				return
+			}
+			value = v
+			continue
+		}
+		tags = append(tags, group+":"+string(match[i]))
+	}
+	tags = append(tags, rule.Metric.Tags...)
+	tags = append(tags, msg.Origin.Tags(nil)...)
+	if service := msg.Origin.Service(); service != "" {
+		tags = append(tags, "service:"+service)
+	}
+	if source := msg.Origin.Source(); source != "" {
+		tags = append(tags, "source:"+source)
+	}
+
+	switch rule.Metric.Type {
+	case config.GaugeMetric:
+		sender.Gauge(rule.Metric.Name, value, msg.Hostname, tags)
+	case config.DistributionMetric:
+		sender.Distribution(rule.Metric.Name, value, msg.Hostname, tags)
+	default:
+		sender.Count(rule.Metric.Name, value, msg.Hostname, tags)
+	}
+	metrics.TlmLogMetricsGenerated.Inc(rule.Metric.Name)
+}
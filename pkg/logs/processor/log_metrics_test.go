@@ -0,0 +1,130 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package processor
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+type sample struct {
+	metricType string
+	name       string
+	value      float64
+	hostname   string
+	tags       []string
+}
+
+type fakeMetricSender struct {
+	sync.Mutex
+	samples []sample
+}
+
+func (s *fakeMetricSender) add(metricType, name string, value float64, hostname string, tags []string) {
+	s.Lock()
+	defer s.Unlock()
+	s.samples = append(s.samples, sample{metricType, name, value, hostname, tags})
+}
+
+func (s *fakeMetricSender) Count(name string, value float64, hostname string, tags []string) {
+	s.add(config.CountMetric, name, value, hostname, tags)
+}
+
+func (s *fakeMetricSender) Gauge(name string, value float64, hostname string, tags []string) {
+	s.add(config.GaugeMetric, name, value, hostname, tags)
+}
+
+func (s *fakeMetricSender) Distribution(name string, value float64, hostname string, tags []string) {
+	s.add(config.DistributionMetric, name, value, hostname, tags)
+}
+
+func (s *fakeMetricSender) Commit() {}
+
+func newMetricSource(t *testing.T, rules ...*config.ProcessingRule) *sources.LogSource {
+	require.NoError(t, config.ValidateProcessingRules(rules))
+	require.NoError(t, config.CompileProcessingRules(rules))
+	return sources.NewLogSource("", &config.LogsConfig{
+		Service:         "checkout",
+		Source:          "java",
+		Tags:            []string{"env:prod"},
+		ProcessingRules: rules,
+	})
+}
+
+func TestGenerateMetric(t *testing.T) {
+	sender := &fakeMetricSender{}
+	source := newMetricSource(t,
+		&config.ProcessingRule{
+			Name:    "errors",
+			Type:    config.GenerateMetric,
+			Pattern: `ERROR .*route=(?P<route>\S+)`,
+			Metric:  &config.MetricRule{Name: "app.errors", Tags: []string{"team:payments"}},
+		},
+		&config.ProcessingRule{
+			Name:    "durations",
+			Type:    config.GenerateMetric,
+			Pattern: `took (?P<duration>[\d.]+)ms`,
+			Metric:  &config.MetricRule{Name: "app.duration", Type: config.DistributionMetric, Value: "duration"},
+		},
+		// metrics are generated before the exclusion of the lines
+		&config.ProcessingRule{Name: "exclude", Type: config.ExcludeAtMatch, Pattern: "ERROR"},
+	)
+	p := &Processor{metricSender: sender}
+
+	assert.False(t, p.applyRedactingRules(newMessage([]byte("ERROR payment failed route=/pay took 12.5ms"), source, "")))
+	assert.True(t, p.applyRedactingRules(newMessage([]byte("INFO payment done took 3ms"), source, "")))
+	assert.True(t, p.applyRedactingRules(newMessage([]byte("INFO nothing to see"), source, "")))
+
+	logTags := []string{"env:prod", "service:checkout", "source:java"}
+	assert.Equal(t, []sample{
+		{config.CountMetric, "app.errors", 1, "", append([]string{"route:/pay", "team:payments"}, logTags...)},
+		{config.DistributionMetric, "app.duration", 12.5, "", logTags},
+		{config.DistributionMetric, "app.duration", 3, "", logTags},
+	}, sender.samples)
+}
+
+func TestGenerateMetricWithoutSender(t *testing.T) {
+	source := newMetricSource(t, &config.ProcessingRule{
+		Name:    "errors",
+		Type:    config.GenerateMetric,
+		Pattern: "ERROR",
+		Metric:  &config.MetricRule{Name: "app.errors"},
+	})
+	p := &Processor{}
+
+	assert.True(t, p.applyRedactingRules(newMessage([]byte("ERROR"), source, "")))
+}
+
+func TestGenerateMetricFromRedactedContent(t *testing.T) {
+	sender := &fakeMetricSender{}
+	source := newMetricSource(t,
+		&config.ProcessingRule{
+			Name:    "logins",
+			Type:    config.GenerateMetric,
+			Pattern: `login user=(?P<user>\S+)`,
+			Metric:  &config.MetricRule{Name: "app.logins"},
+		},
+		&config.ProcessingRule{Name: "exclude", Type: config.ExcludeAtMatch, Pattern: "debug"},
+		// the masking rules following the metric rule apply to its tags
+		&config.ProcessingRule{Name: "emails", Type: config.MaskSequences, Pattern: `[\w.]+@[\w.]+`, ReplacePlaceholder: "[email]"},
+	)
+	p := &Processor{metricSender: sender}
+
+	assert.True(t, p.applyRedactingRules(newMessage([]byte("login user=jane@example.com"), source, "")))
+	assert.False(t, p.applyRedactingRules(newMessage([]byte("debug login user=john@example.com"), source, "")))
+
+	logTags := []string{"env:prod", "service:checkout", "source:java"}
+	assert.Equal(t, []sample{
+		{config.CountMetric, "app.logins", 1, "", append([]string{"user:[email]"}, logTags...)},
+		{config.CountMetric, "app.logins", 1, "", append([]string{"user:[email]"}, logTags...)},
+	}, sender.samples)
+}
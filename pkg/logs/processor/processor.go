@@ -8,6 +8,7 @@ package processor
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"regexp"
 	"sync"
 
@@ -40,6 +41,10 @@ type Processor struct {
 	mu                        sync.Mutex
 	hostname                  hostnameinterface.Component
 
+	// metricSender submits the metrics of the generate_metric rules, they are
+	// ignored when it is nil.
+	metricSender MetricSender
+
 	sds sdsProcessor
 
 	// Telemetry
@@ -63,7 +68,7 @@ type sdsProcessor struct {
 // New returns an initialized Processor.
 func New(cfg pkgconfigmodel.Reader, inputChan, outputChan chan *message.Message, processingRules []*config.ProcessingRule,
 	encoder Encoder, diagnosticMessageReceiver diagnostic.MessageReceiver, hostname hostnameinterface.Component,
-	pipelineMonitor metrics.PipelineMonitor) *Processor {
+	pipelineMonitor metrics.PipelineMonitor, metricSender MetricSender) *Processor {
 
 	waitForSDSConfig := sds.ShouldBufferUntilSDSConfiguration(cfg)
 	maxBufferSize := sds.WaitForConfigurationBufferMaxSize(cfg)
@@ -77,6 +82,7 @@ func New(cfg pkgconfigmodel.Reader, inputChan, outputChan chan *message.Message,
 		done:                      make(chan struct{}),
 		diagnosticMessageReceiver: diagnosticMessageReceiver,
 		hostname:                  hostname,
+		metricSender:              metricSender,
 		pipelineMonitor:           pipelineMonitor,
 		utilization:               pipelineMonitor.MakeUtilizationMonitor("processor"),
 
@@ -257,51 +263,147 @@ func (p *Processor) processMessage(msg *message.Message) {
 
 }
 
+// AppliedRule is a rule which dropped or modified a message, reported by
+// DryRun.
+type AppliedRule struct {
+	// Name is the name of the processing rule, or "sds" for the Sensitive Data
+	// Scanner.
+	Name string
+	// Type is the type of the processing rule, or "sds".
+	Type string
+}
+
+// sdsRule is the AppliedRule reported when the Sensitive Data Scanner modified
+// a message.
+var sdsRule = AppliedRule{Name: "sds", Type: "sds"}
+
+// DryRun processes a message synchronously as the pipeline would, without
+// forwarding it, and returns its rendered content, nil if it would be dropped,
+// and the rules which dropped or modified it.  Messages which would be sent are
+// encoded.  It must not be called on a running processor.
+func (p *Processor) DryRun(msg *message.Message) ([]byte, []AppliedRule, error) {
+	applied := []AppliedRule{}
+	if !p.applyRules(msg, &applied) {
+		return nil, applied, nil
+	}
+	rendered, err := msg.Render()
+	if err != nil {
+		return nil, applied, fmt.Errorf("can't render the message: %v", err)
+	}
+	msg.SetRendered(rendered)
+	if err := p.encoder.Encode(msg, p.GetHostname(msg)); err != nil {
+		return nil, applied, err
+	}
+	return rendered, applied, nil
+}
+
+// ReconfigureSDS applies an SDS reconfiguration synchronously, it must not be
+// called on a running processor.
+func (p *Processor) ReconfigureSDS(order sds.ReconfigureOrder) (bool, error) {
+	return p.sds.scanner.Reconfigure(order)
+}
+
 // applyRedactingRules returns given a message if we should process it or not,
 // it applies the change directly on the Message content.
 func (p *Processor) applyRedactingRules(msg *message.Message) bool {
+	return p.applyRules(msg, nil)
+}
+
+// applyRules applies the processing rules and the SDS scanner to a message,
+// and reports the rules which dropped or modified it in applied when it is not
+// nil.
+func (p *Processor) applyRules(msg *message.Message, applied *[]AppliedRule) bool {
 	var content []byte = msg.GetContent()
+	report := func(rule *config.ProcessingRule) {
+		if applied != nil {
+			*applied = append(*applied, AppliedRule{Name: rule.Name, Type: rule.Type})
+		}
+	}
+	// Metrics are generated once the content has been redacted by the masking
+	// rules and SDS, so that sensitive data does not end up in their tags.  They
+	// are still generated for the messages excluded by the rules following them.
+	var metricRules []*config.ProcessingRule
 
 	// Use the internal scrubbing implementation of the Agent
 	// ---------------------------
 
 	rules := append(p.processingRules, msg.Origin.LogSource.Config.ProcessingRules...)
-	for _, rule := range rules {
+	for i, rule := range rules {
 		switch rule.Type {
 		case config.ExcludeAtMatch:
 			// if this message matches, we ignore it
 			if rule.Regex.Match(content) {
+				report(rule)
+				p.generateExcludedMetrics(metricRules, msg, rules[i+1:], content)
 				return false
 			}
 		case config.IncludeAtMatch:
 			// if this message doesn't match, we ignore it
 			if !rule.Regex.Match(content) {
+				report(rule)
+				p.generateExcludedMetrics(metricRules, msg, rules[i+1:], content)
 				return false
 			}
 		case config.MaskSequences:
 			if isMatchingLiteralPrefix(rule.Regex, content) {
-				content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+				masked := rule.Regex.ReplaceAll(content, rule.Placeholder)
+				if applied != nil && !bytes.Equal(masked, content) {
+					report(rule)
+				}
+				content = masked
 			}
+		case config.GenerateMetric:
+			metricRules = append(metricRules, rule)
 		}
 	}
 
-	// Use the SDS implementation
-	// --------------------------
-
-	// Global SDS scanner, applied on all log sources
-	if p.sds.scanner.IsReady() {
-		mutated, evtProcessed, err := p.sds.scanner.Scan(content, msg)
-		if err != nil {
-			log.Error("while using SDS to scan the log:", err)
-		} else if mutated {
-			content = evtProcessed
-		}
+	content = p.scanSDS(content, msg, applied)
+	for _, rule := range metricRules {
+		p.generateMetric(rule, msg, content)
 	}
 
 	msg.SetContent(content)
 	return true // we want to send this message
 }
 
+// scanSDS redacts the content of a message with the global SDS scanner,
+// applied on all log sources, and reports it in applied when it is not nil.
+func (p *Processor) scanSDS(content []byte, msg *message.Message, applied *[]AppliedRule) []byte {
+	if !p.sds.scanner.IsReady() {
+		return content
+	}
+	mutated, evtProcessed, err := p.sds.scanner.Scan(content, msg)
+	if err != nil {
+		log.Error("while using SDS to scan the log:", err)
+		return content
+	}
+	if mutated {
+		if applied != nil {
+			*applied = append(*applied, sdsRule)
+		}
+		return evtProcessed
+	}
+	return content
+}
+
+// generateExcludedMetrics generates the metrics of the rules preceding the
+// exclusion of a message, once its content has been redacted by the masking
+// rules which remain and by SDS.
+func (p *Processor) generateExcludedMetrics(metricRules []*config.ProcessingRule, msg *message.Message, remaining []*config.ProcessingRule, content []byte) {
+	if len(metricRules) == 0 {
+		return
+	}
+	for _, rule := range remaining {
+		if rule.Type == config.MaskSequences && isMatchingLiteralPrefix(rule.Regex, content) {
+			content = rule.Regex.ReplaceAll(content, rule.Placeholder)
+		}
+	}
+	content = p.scanSDS(content, msg, nil)
+	for _, rule := range metricRules {
+		p.generateMetric(rule, msg, content)
+	}
+}
+
 // isMatchingLiteralPrefix uses a potential literal prefix from the given regex
 // to indicate if the contant even has a chance of matching the regex
 func isMatchingLiteralPrefix(r *regexp.Regexp, content []byte) bool {
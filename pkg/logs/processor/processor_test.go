@@ -279,6 +279,35 @@ func TestMask(t *testing.T) {
 	}
 }
 
+func TestDryRun(t *testing.T) {
+	mask := newProcessingRule(config.MaskSequences, "[masked]", "secret=\\S+")
+	mask.Name = "mask_secrets"
+	exclude := newProcessingRule(config.ExcludeAtMatch, "", "healthcheck")
+	exclude.Name = "exclude_healthchecks"
+	source := sources.LogSource{Config: &config.LogsConfig{ProcessingRules: []*config.ProcessingRule{exclude, mask}}}
+	p := &Processor{encoder: RawEncoder}
+
+	msg := newMessage([]byte("user=foo secret=bar"), &source, message.StatusInfo)
+	rendered, applied, err := p.DryRun(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "user=foo [masked]", string(rendered))
+	assert.Equal(t, []AppliedRule{{Name: "mask_secrets", Type: config.MaskSequences}}, applied)
+	assert.Equal(t, message.StateEncoded, msg.State)
+	assert.Contains(t, string(msg.GetContent()), "user=foo [masked]")
+
+	msg = newMessage([]byte("GET /healthcheck"), &source, message.StatusInfo)
+	rendered, applied, err = p.DryRun(msg)
+	assert.NoError(t, err)
+	assert.Nil(t, rendered)
+	assert.Equal(t, []AppliedRule{{Name: "exclude_healthchecks", Type: config.ExcludeAtMatch}}, applied)
+
+	msg = newMessage([]byte("nothing to see"), &source, message.StatusInfo)
+	rendered, applied, err = p.DryRun(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing to see", string(rendered))
+	assert.Empty(t, applied)
+}
+
 func TestTruncate(t *testing.T) {
 	p := &Processor{}
 	source := sources.NewLogSource("", &config.LogsConfig{})
@@ -7,7 +7,6 @@ package processor
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/DataDog/agent-payload/v5/pb"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
@@ -28,7 +27,7 @@ func (p *protoEncoder) Encode(msg *message.Message, hostname string) error {
 	log := &pb.Log{
 		Message:   toValidUtf8(msg.GetContent()),
 		Status:    msg.GetStatus(),
-		Timestamp: time.Now().UTC().UnixNano(),
+		Timestamp: messageTimestamp(msg).UnixNano(),
 		Hostname:  hostname,
 		Service:   msg.Origin.Service(),
 		Source:    msg.Origin.Source(),
@@ -9,6 +9,8 @@ package ad
 import (
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -29,16 +31,52 @@ import (
 // log collection based on information from autodiscovery.
 //
 // This type implements  pkg/logs/schedulers.Scheduler.
+//
+// When the annotations or labels of a container change, autodiscovery
+// unschedules its previous config and schedules the new one.  The removal of
+// the sources of a container config is delayed by a grace period so that, when
+// the new config arrives in the meantime, its sources are added before the
+// previous ones are removed: the launchers then hand the existing tailers over
+// to the new sources, which keep their position while the processing rules,
+// service and source of the new config apply to the next logs.  The container
+// launcher, whose tailers are bound to their source, stops the tailer of the
+// previous source before tailing the container with the new one.
 type Scheduler struct {
 	mgr      schedulers.SourceManager
 	listener *adlistener.ADListener
+
+	// updateGracePeriod is how long the sources of an unscheduled container
+	// config are kept, waiting for an updated config of the same service.
+	updateGracePeriod time.Duration
+
+	// mu protects the fields below, as pending removals run in timers.
+	mu sync.Mutex
+
+	// scheduled contains the sources created for each config with a service
+	// ID, by service ID and config digest.
+	scheduled map[string]map[string][]*sourcesPkg.LogSource
+
+	// pending contains the removals waiting for the grace period, by service
+	// ID.
+	pending map[string]*pendingRemoval
+}
+
+// pendingRemoval is the delayed removal of the sources of an unscheduled
+// config.
+type pendingRemoval struct {
+	sources []*sourcesPkg.LogSource
+	timer   *time.Timer
 }
 
 var _ schedulers.Scheduler = &Scheduler{}
 
 // New creates a new scheduler.
 func New(ac autodiscovery.Component) schedulers.Scheduler {
-	sch := &Scheduler{}
+	sch := &Scheduler{
+		updateGracePeriod: pkgconfigsetup.Datadog().GetDuration("logs_config.container_config_update_grace_period") * time.Second,
+		scheduled:         make(map[string]map[string][]*sourcesPkg.LogSource),
+		pending:           make(map[string]*pendingRemoval),
+	}
 	sch.listener = adlistener.NewADListener("logs-agent AD scheduler", ac, sch.Schedule, sch.Unschedule)
 	return sch
 }
@@ -52,6 +90,13 @@ func (s *Scheduler) Start(sourceMgr schedulers.SourceManager) {
 // Stop implements schedulers.Scheduler#Stop.
 func (s *Scheduler) Stop() {
 	s.listener.StopListener()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for serviceID, removal := range s.pending {
+		removal.timer.Stop()
+		delete(s.pending, serviceID)
+	}
 	s.mgr = nil
 }
 
@@ -60,6 +105,8 @@ func (s *Scheduler) Stop() {
 // while an integration config can be mapped to a service when it contains an Entity.
 // An entity represents a unique identifier for a process that be reused to query logs.
 func (s *Scheduler) Schedule(configs []integration.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, config := range configs {
 		if !config.IsLogConfig() {
 			continue
@@ -76,9 +123,11 @@ func (s *Scheduler) Schedule(configs []integration.Config) {
 				log.Warnf("Invalid configuration: %v", err)
 				continue
 			}
+			s.linkPreviousSources(config, sources)
 			for _, source := range sources {
 				s.mgr.AddSource(source)
 			}
+			s.track(config, sources)
 		default:
 			log.Debugf("Invalid integration config: %s, ignoring it", configName(config))
 			continue
@@ -88,6 +137,8 @@ func (s *Scheduler) Schedule(configs []integration.Config) {
 
 // Unschedule removes all the sources and services matching the integration configs.
 func (s *Scheduler) Unschedule(configs []integration.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	for _, config := range configs {
 		if !config.IsLogConfig() || config.HasFilter(containers.LogsFilter) {
 			continue
@@ -102,6 +153,11 @@ func (s *Scheduler) Unschedule(configs []integration.Config) {
 				continue
 			}
 
+			if sources, ok := s.untrack(config); ok {
+				s.removeSources(config, sources)
+				continue
+			}
+
 			// remove all the sources for this ServiceID.  This makes the
 			// implicit, and not-quite-correct assumption that we only ever
 			// receive one config for a given ServiceID, and that it generates
@@ -122,6 +178,113 @@ func (s *Scheduler) Unschedule(configs []integration.Config) {
 	}
 }
 
+// track records the sources created for a config with a service ID, and
+// removes the sources of the previous config of the service waiting for an
+// update, now that the new sources took over their tailers.
+func (s *Scheduler) track(config integration.Config, sources []*sourcesPkg.LogSource) {
+	if config.ServiceID == "" {
+		return
+	}
+	if removal, ok := s.pending[config.ServiceID]; ok {
+		log.Infof("Updated logs config for %s, handing its tailers over to the new sources", config.ServiceID)
+		removal.timer.Stop()
+		delete(s.pending, config.ServiceID)
+		for _, source := range removal.sources {
+			s.mgr.RemoveSource(source)
+		}
+	}
+	if s.scheduled[config.ServiceID] == nil {
+		s.scheduled[config.ServiceID] = make(map[string][]*sourcesPkg.LogSource)
+	}
+	s.scheduled[config.ServiceID][config.Digest()] = sources
+}
+
+// linkPreviousSources links the sources of an updated config to the sources of
+// the previous config of the service waiting for removal, with the same type
+// and identifier.  The file launcher hands its tailers over to the new file
+// sources by itself.
+func (s *Scheduler) linkPreviousSources(config integration.Config, sources []*sourcesPkg.LogSource) {
+	removal, ok := s.pending[config.ServiceID]
+	if !ok {
+		return
+	}
+	linked := make(map[*sourcesPkg.LogSource]bool)
+	for _, source := range sources {
+		if source.Config.Type == logsConfig.FileType {
+			continue
+		}
+		for _, previous := range removal.sources {
+			if !linked[previous] && previous.Config.Type == source.Config.Type && previous.Config.Identifier == source.Config.Identifier {
+				source.PreviousSource = previous
+				linked[previous] = true
+				break
+			}
+		}
+	}
+}
+
+// untrack returns the sources created for a config, and forgets them.
+func (s *Scheduler) untrack(config integration.Config) ([]*sourcesPkg.LogSource, bool) {
+	sources, ok := s.scheduled[config.ServiceID][config.Digest()]
+	if !ok {
+		return nil, false
+	}
+	delete(s.scheduled[config.ServiceID], config.Digest())
+	if len(s.scheduled[config.ServiceID]) == 0 {
+		delete(s.scheduled, config.ServiceID)
+	}
+	return sources, true
+}
+
+// removeSources removes the sources of an unscheduled config, once the grace
+// period expired for container configs.  They are removed right away when
+// another config of the service is already scheduled.
+func (s *Scheduler) removeSources(config integration.Config, sources []*sourcesPkg.LogSource) {
+	if s.updateGracePeriod <= 0 || !isContainerProvider(config.Provider) || len(s.scheduled[config.ServiceID]) > 0 {
+		for _, source := range sources {
+			s.mgr.RemoveSource(source)
+		}
+		return
+	}
+
+	if removal, ok := s.pending[config.ServiceID]; ok {
+		// several configs of the service were unscheduled
+		removal.sources = append(removal.sources, sources...)
+		return
+	}
+	serviceID := config.ServiceID
+	s.pending[serviceID] = &pendingRemoval{
+		sources: sources,
+		timer:   time.AfterFunc(s.updateGracePeriod, func() { s.expire(serviceID) }),
+	}
+}
+
+// expire removes the sources of the unscheduled configs of a service which
+// were not updated during the grace period.
+func (s *Scheduler) expire(serviceID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removal, ok := s.pending[serviceID]
+	if !ok || s.mgr == nil {
+		return
+	}
+	delete(s.pending, serviceID)
+	for _, source := range removal.sources {
+		s.mgr.RemoveSource(source)
+	}
+}
+
+// isContainerProvider returns true if the configs of the provider are attached
+// to containers or pods, and may be updated during their lifetime.
+func isContainerProvider(provider string) bool {
+	switch provider {
+	case names.Container, names.Kubernetes, names.KubeContainer:
+		return true
+	default:
+		return false
+	}
+}
+
 // newSources returns true if the config can be mapped to sources.
 func (s *Scheduler) newSources(config integration.Config) bool {
 	return config.Provider != ""
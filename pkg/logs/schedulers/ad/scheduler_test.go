@@ -8,6 +8,7 @@ package ad
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	"github.com/DataDog/datadog-agent/pkg/config/model"
@@ -232,3 +233,86 @@ func TestIgnoreRemoteConfigIfDisabled(t *testing.T) {
 		})
 	}
 }
+
+func containerConfig(logsConfig string) integration.Config {
+	return integration.Config{
+		LogsConfig:    []byte(logsConfig),
+		ADIdentifiers: []string{"docker://a1887023ed72a2b0d083ef465e8edfe4932a25731d4bda2f39f288f70af3405b"},
+		Provider:      names.Kubernetes,
+		TaggerEntity:  "container_id://a1887023ed72a2b0d083ef465e8edfe4932a25731d4bda2f39f288f70af3405b",
+		ServiceID:     "docker://a1887023ed72a2b0d083ef465e8edfe4932a25731d4bda2f39f288f70af3405b",
+	}
+}
+
+func TestUpdateConfigHandsOverSources(t *testing.T) {
+	scheduler, spy := setup()
+	scheduler.updateGracePeriod = time.Hour
+	previous := containerConfig(`[{"service":"foo","source":"bar"}]`)
+	updated := containerConfig(`[{"service":"foo","source":"baz","log_processing_rules":[{"type":"exclude_at_match","name":"exclude_debug","pattern":"DEBUG"}]}]`)
+
+	scheduler.Schedule([]integration.Config{previous})
+	require.Len(t, spy.Events, 1)
+	previousSource := spy.Events[0].Source
+
+	// the removal of the previous sources waits for the updated config
+	scheduler.Unschedule([]integration.Config{previous})
+	require.Len(t, spy.Events, 1)
+
+	// the updated sources are added before the previous ones are removed
+	scheduler.Schedule([]integration.Config{updated})
+	require.Len(t, spy.Events, 3)
+	require.True(t, spy.Events[1].Add)
+	assert.Equal(t, "baz", spy.Events[1].Source.Config.Source)
+	require.Len(t, spy.Events[1].Source.Config.ProcessingRules, 1)
+	// the container launcher stops the tailer of the previous source first
+	assert.Same(t, previousSource, spy.Events[1].Source.PreviousSource)
+	require.False(t, spy.Events[2].Add)
+	assert.Same(t, previousSource, spy.Events[2].Source)
+	assert.Empty(t, scheduler.pending)
+
+	// unscheduling the updated config only removes its sources
+	scheduler.updateGracePeriod = 0
+	scheduler.Unschedule([]integration.Config{updated})
+	require.Len(t, spy.Events, 4)
+	require.False(t, spy.Events[3].Add)
+	assert.Same(t, spy.Events[1].Source, spy.Events[3].Source)
+	assert.Empty(t, scheduler.scheduled)
+}
+
+func TestUpdateConfigScheduledBeforeUnschedule(t *testing.T) {
+	scheduler, spy := setup()
+	scheduler.updateGracePeriod = time.Hour
+	previous := containerConfig(`[{"service":"foo","source":"bar"}]`)
+	updated := containerConfig(`[{"service":"foo","source":"baz"}]`)
+
+	scheduler.Schedule([]integration.Config{previous})
+	scheduler.Schedule([]integration.Config{updated})
+	require.Len(t, spy.Events, 2)
+	spy.Sources = []*sourcesPkg.LogSource{spy.Events[0].Source, spy.Events[1].Source}
+
+	// the previous sources are removed right away, the sources of the updated
+	// config, with the same identifier, are kept
+	scheduler.Unschedule([]integration.Config{previous})
+	require.Len(t, spy.Events, 3)
+	require.False(t, spy.Events[2].Add)
+	assert.Same(t, spy.Events[0].Source, spy.Events[2].Source)
+	assert.Nil(t, spy.Events[1].Source.PreviousSource)
+	assert.Empty(t, scheduler.pending)
+}
+
+func TestUnscheduleConfigWithoutUpdate(t *testing.T) {
+	scheduler, spy := setup()
+	scheduler.updateGracePeriod = time.Hour
+	config := containerConfig(`[{"service":"foo","source":"bar"}]`)
+
+	scheduler.Schedule([]integration.Config{config})
+	scheduler.Unschedule([]integration.Config{config})
+	require.Len(t, spy.Events, 1)
+
+	scheduler.expire(config.ServiceID)
+	require.Len(t, spy.Events, 2)
+	require.False(t, spy.Events[1].Add)
+	assert.Same(t, spy.Events[0].Source, spy.Events[1].Source)
+	assert.Empty(t, scheduler.pending)
+	assert.Empty(t, scheduler.scheduled)
+}
@@ -0,0 +1,176 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//nolint:revive
+package sds
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+const localRulesFileField = "logs_config.sds.rules_file"
+
+// LocalRules is the content of a local SDS rules file, used to configure the
+// scanner when Remote Configuration is not available.
+type LocalRules struct {
+	// StandardRulesFile is the path of a JSON or YAML file holding the
+	// standard rule library, as sent by the Remote Configuration.  A library
+	// is required to reference standard rules.
+	StandardRulesFile string `yaml:"standard_rules_file"`
+	// StandardRules holds the standard rule library, inlined.
+	StandardRules *StandardRulesConfig `yaml:"standard_rules"`
+	// Rules are the rules applied to the logs.
+	Rules []LocalRule `yaml:"rules"`
+}
+
+// LocalRule is a rule of a local SDS rules file.  It either references a rule
+// of the standard rule library or defines its own pattern.
+type LocalRule struct {
+	ID             string   `yaml:"id"`
+	Name           string   `yaml:"name"`
+	Description    string   `yaml:"description"`
+	Tags           []string `yaml:"tags"`
+	StandardRuleID string   `yaml:"standard_rule_id"`
+	Pattern        string   `yaml:"pattern"`
+	// Keywords must be found close to a match for the rule to apply.
+	Keywords               []string `yaml:"keywords"`
+	KeywordsCharacterCount uint32   `yaml:"keywords_character_count"`
+	UseRecommendedKeywords bool     `yaml:"use_recommended_keywords"`
+	MatchAction            struct {
+		Type           string `yaml:"type"`
+		Placeholder    string `yaml:"placeholder"`
+		Direction      string `yaml:"direction"`
+		CharacterCount uint32 `yaml:"character_count"`
+	} `yaml:"match_action"`
+	Disabled bool `yaml:"disabled"`
+}
+
+// LocalRulesFile returns the path of the local SDS rules file, or an empty
+// string when the rules are expected from the Remote Configuration.
+func LocalRulesFile(cfg pkgconfigmodel.Reader) string {
+	if cfg == nil {
+		return ""
+	}
+	return cfg.GetString(localRulesFileField)
+}
+
+// LoadLocalRules reads a local SDS rules file and returns the standard rules
+// and the agent configuration it defines, in the format of the Remote
+// Configuration payloads, ready to be used in `ReconfigureOrder`s.
+func LoadLocalRules(path string) (standardRules []byte, agentConfig []byte, err error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't read SDS rules file: %v", err)
+	}
+	var local LocalRules
+	if err := yaml.Unmarshal(content, &local); err != nil {
+		return nil, nil, fmt.Errorf("can't parse SDS rules file %s: %v", path, err)
+	}
+	return local.toRCPayloads()
+}
+
+func (l LocalRules) toRCPayloads() ([]byte, []byte, error) {
+	stdRules := StandardRulesConfig{Rules: []StandardRuleConfig{}}
+	switch {
+	case l.StandardRulesFile != "" && l.StandardRules != nil:
+		return nil, nil, fmt.Errorf("standard_rules_file and standard_rules can't be both set")
+	case l.StandardRulesFile != "":
+		content, err := os.ReadFile(l.StandardRulesFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't read SDS standard rules file: %v", err)
+		}
+		if err := yaml.Unmarshal(content, &stdRules); err != nil {
+			return nil, nil, fmt.Errorf("can't parse SDS standard rules file %s: %v", l.StandardRulesFile, err)
+		}
+	case l.StandardRules != nil:
+		stdRules = *l.StandardRules
+	}
+
+	knownStdRules := make(map[string]bool, len(stdRules.Rules))
+	for _, rule := range stdRules.Rules {
+		knownStdRules[rule.ID] = true
+	}
+
+	config := RulesConfig{
+		ID:        "local",
+		Name:      "local",
+		IsEnabled: true,
+		Rules:     make([]RuleConfig, 0, len(l.Rules)),
+	}
+	for i, rule := range l.Rules {
+		if err := rule.validate(knownStdRules); err != nil {
+			return nil, nil, fmt.Errorf("invalid SDS rule #%d (%s): %v", i, rule.Name, err)
+		}
+		id := rule.ID
+		if id == "" {
+			id = fmt.Sprintf("local-%d", i)
+		}
+		config.Rules = append(config.Rules, RuleConfig{
+			ID:          id,
+			Name:        rule.Name,
+			Description: rule.Description,
+			Tags:        rule.Tags,
+			Definition: RuleDefinition{
+				StandardRuleID: rule.StandardRuleID,
+				Pattern:        rule.Pattern,
+			},
+			MatchAction: MatchAction{
+				Type:           rule.MatchAction.Type,
+				Placeholder:    rule.MatchAction.Placeholder,
+				Direction:      rule.MatchAction.Direction,
+				CharacterCount: rule.MatchAction.CharacterCount,
+			},
+			IncludedKeywords: ProximityKeywords{
+				Keywords:               rule.Keywords,
+				CharacterCount:         rule.KeywordsCharacterCount,
+				UseRecommendedKeywords: rule.UseRecommendedKeywords,
+			},
+			IsEnabled: !rule.Disabled,
+		})
+	}
+
+	rawStdRules, err := json.Marshal(stdRules)
+	if err != nil {
+		return nil, nil, err
+	}
+	rawConfig, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rawStdRules, rawConfig, nil
+}
+
+func (r LocalRule) validate(knownStdRules map[string]bool) error {
+	if r.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+	switch {
+	case r.StandardRuleID != "" && r.Pattern != "":
+		return fmt.Errorf("standard_rule_id and pattern can't be both set")
+	case r.StandardRuleID != "":
+		if !knownStdRules[r.StandardRuleID] {
+			return fmt.Errorf("unknown standard rule %q", r.StandardRuleID)
+		}
+	case r.Pattern == "":
+		return fmt.Errorf("one of standard_rule_id or pattern is required")
+	}
+	switch strings.ToLower(r.MatchAction.Type) {
+	case matchActionRCHash, matchActionRCNone, matchActionRCRedact:
+	case matchActionRCPartialRedact:
+		if r.MatchAction.CharacterCount == 0 {
+			return fmt.Errorf("partial_redact requires a character_count")
+		}
+	default:
+		return fmt.Errorf("unknown match action %q", r.MatchAction.Type)
+	}
+	return nil
+}
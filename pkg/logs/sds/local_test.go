@@ -0,0 +1,191 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//nolint:revive
+package sds
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadLocalRules(t *testing.T) {
+	require := require.New(t)
+
+	stdRulesPath := writeFile(t, "standard_rules.json", `{
+		"rules": [{"id": "email-id", "name": "Email", "definitions": [{"version": 1, "pattern": "\\w+@\\w+\\.com"}]}],
+		"defaults": {"included_keywords_char_count": 30}
+	}`)
+	path := writeFile(t, "sds.yaml", `
+standard_rules_file: `+stdRulesPath+`
+rules:
+  - name: email
+    standard_rule_id: email-id
+    use_recommended_keywords: true
+    match_action:
+      type: redact
+      placeholder: "[email]"
+  - name: token
+    pattern: "tok_[a-z0-9]{32}"
+    tags: ["sensitive:token"]
+    keywords: ["token"]
+    keywords_character_count: 10
+    match_action:
+      type: partial_redact
+      direction: first
+      character_count: 28
+  - name: disabled
+    pattern: "secret"
+    disabled: true
+    match_action:
+      type: hash
+`)
+
+	rawStdRules, rawConfig, err := LoadLocalRules(path)
+	require.NoError(err)
+
+	var stdRules StandardRulesConfig
+	require.NoError(json.Unmarshal(rawStdRules, &stdRules))
+	require.Len(stdRules.Rules, 1)
+	require.Equal("email-id", stdRules.Rules[0].ID)
+	require.Equal(uint32(30), stdRules.Defaults.IncludedKeywordsCharCount)
+
+	var config RulesConfig
+	require.NoError(json.Unmarshal(rawConfig, &config))
+	require.True(config.IsEnabled)
+	require.Len(config.Rules, 3)
+	require.Equal(RuleConfig{
+		ID:               "local-0",
+		Name:             "email",
+		Definition:       RuleDefinition{StandardRuleID: "email-id"},
+		MatchAction:      MatchAction{Type: "redact", Placeholder: "[email]"},
+		IncludedKeywords: ProximityKeywords{UseRecommendedKeywords: true},
+		IsEnabled:        true,
+	}, config.Rules[0])
+	require.Equal(RuleConfig{
+		ID:               "local-1",
+		Name:             "token",
+		Tags:             []string{"sensitive:token"},
+		Definition:       RuleDefinition{Pattern: "tok_[a-z0-9]{32}"},
+		MatchAction:      MatchAction{Type: "partial_redact", Direction: "first", CharacterCount: 28},
+		IncludedKeywords: ProximityKeywords{Keywords: []string{"token"}, CharacterCount: 10},
+		IsEnabled:        true,
+	}, config.Rules[1])
+	require.False(config.Rules[2].IsEnabled)
+	require.True(config.usesStandardRules())
+}
+
+func TestLoadLocalRulesInlineStandardRules(t *testing.T) {
+	require := require.New(t)
+
+	path := writeFile(t, "sds.yaml", `
+standard_rules:
+  rules:
+    - id: ip-id
+      name: IP
+      definitions:
+        - version: 1
+          pattern: "\\d+\\.\\d+\\.\\d+\\.\\d+"
+          default_included_keywords: ["ip"]
+rules:
+  - name: ip
+    standard_rule_id: ip-id
+    match_action:
+      type: hash
+`)
+
+	rawStdRules, _, err := LoadLocalRules(path)
+	require.NoError(err)
+	var stdRules StandardRulesConfig
+	require.NoError(json.Unmarshal(rawStdRules, &stdRules))
+	require.Len(stdRules.Rules, 1)
+	require.Equal(`\d+\.\d+\.\d+\.\d+`, stdRules.Rules[0].Definitions[0].Pattern)
+	require.Equal([]string{"ip"}, stdRules.Rules[0].Definitions[0].DefaultIncludedKeywords)
+}
+
+func TestLoadLocalRulesCustomRulesOnly(t *testing.T) {
+	require := require.New(t)
+
+	path := writeFile(t, "sds.yaml", `
+rules:
+  - name: token
+    pattern: "tok_[a-z0-9]{32}"
+    match_action:
+      type: redact
+      placeholder: "[token]"
+`)
+
+	rawStdRules, rawConfig, err := LoadLocalRules(path)
+	require.NoError(err)
+	require.JSONEq(`{"rules":[],"defaults":{"included_keywords_char_count":0,"excluded_keywords_char_count":0,"excluded_keywords":null}}`, string(rawStdRules))
+
+	var config RulesConfig
+	require.NoError(json.Unmarshal(rawConfig, &config))
+	require.False(config.usesStandardRules())
+	require.Equal(StandardRuleConfig{
+		ID:          "local-0",
+		Name:        "token",
+		Definitions: []StandardRuleDefinition{{Version: 1, Pattern: "tok_[a-z0-9]{32}"}},
+	}, config.Rules[0].customDefinition())
+}
+
+func TestLoadLocalRulesErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown standard rule": `
+rules:
+  - name: email
+    standard_rule_id: unknown
+    match_action: {type: redact}`,
+		"pattern and standard rule": `
+standard_rules: {rules: [{id: a, name: A}]}
+rules:
+  - name: email
+    standard_rule_id: a
+    pattern: "a"
+    match_action: {type: redact}`,
+		"no definition": `
+rules:
+  - name: email
+    match_action: {type: redact}`,
+		"no name": `
+rules:
+  - pattern: "a"
+    match_action: {type: redact}`,
+		"unknown match action": `
+rules:
+  - name: email
+    pattern: "a"
+    match_action: {type: drop}`,
+		"partial redact without count": `
+rules:
+  - name: email
+    pattern: "a"
+    match_action: {type: partial_redact}`,
+		"both standard rules": `
+standard_rules_file: /tmp/rules.json
+standard_rules: {rules: []}`,
+		"invalid yaml": `rules: {`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := LoadLocalRules(writeFile(t, "sds.yaml", content))
+			require.Error(t, err)
+		})
+	}
+
+	_, _, err := LoadLocalRules(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+}
@@ -6,6 +6,20 @@
 //nolint:revive
 package sds
 
+// MatchActions as exposed by the RC configurations.
+const (
+	matchActionRCHash          = "hash"
+	matchActionRCNone          = "none"
+	matchActionRCPartialRedact = "partial_redact"
+	matchActionRCRedact        = "redact"
+
+	RCPartialRedactFirstCharacters = "first"
+	RCPartialRedactLastCharacters  = "last"
+
+	RCSecondaryValidationChineseIdChecksum = "chinese_id_checksum"
+	RCSecondaryValidationLuhnChecksum      = "luhn_checksum"
+)
+
 // RulesConfig as sent by the Remote Configuration.
 // Equivalent of the groups in the UI.
 type RulesConfig struct {
@@ -26,33 +40,33 @@ type MatchAction struct {
 
 // StandardRuleConfig as sent by the Remote Configuration;
 type StandardRuleConfig struct {
-	ID          string                   `json:"id"`
-	Name        string                   `json:"name"`
-	Tags        []string                 `json:"tags"`
-	Description string                   `json:"description"`
-	Definitions []StandardRuleDefinition `json:"definitions"`
+	ID          string                   `json:"id" yaml:"id"`
+	Name        string                   `json:"name" yaml:"name"`
+	Tags        []string                 `json:"tags" yaml:"tags"`
+	Description string                   `json:"description" yaml:"description"`
+	Definitions []StandardRuleDefinition `json:"definitions" yaml:"definitions"`
 }
 
 // StandardRuleDefinition contains a versioned standard rule definition.
 type StandardRuleDefinition struct {
-	Version                 int      `json:"version"`
-	Pattern                 string   `json:"pattern"`
-	DefaultIncludedKeywords []string `json:"default_included_keywords"`
-	RequiredCapabilities    []string `json:"required_capabilities"`
+	Version                 int      `json:"version" yaml:"version"`
+	Pattern                 string   `json:"pattern" yaml:"pattern"`
+	DefaultIncludedKeywords []string `json:"default_included_keywords" yaml:"default_included_keywords"`
+	RequiredCapabilities    []string `json:"required_capabilities" yaml:"required_capabilities"`
 }
 
 // StandardRulesConfig contains standard rules.
 type StandardRulesConfig struct {
-	Rules    []StandardRuleConfig  `json:"rules"`
-	Defaults StandardRulesDefaults `json:"defaults"`
+	Rules    []StandardRuleConfig  `json:"rules" yaml:"rules"`
+	Defaults StandardRulesDefaults `json:"defaults" yaml:"defaults"`
 }
 
 // StandardRulesDefaults contains consts defaults information for
 // standard rules.
 type StandardRulesDefaults struct {
-	IncludedKeywordsCharCount uint32   `json:"included_keywords_char_count"`
-	ExcludedKeywordsCharCount uint32   `json:"excluded_keywords_char_count"`
-	ExcludedKeywords          []string `json:"excluded_keywords"`
+	IncludedKeywordsCharCount uint32   `json:"included_keywords_char_count" yaml:"included_keywords_char_count"`
+	ExcludedKeywordsCharCount uint32   `json:"excluded_keywords_char_count" yaml:"excluded_keywords_char_count"`
+	ExcludedKeywords          []string `json:"excluded_keywords" yaml:"excluded_keywords"`
 }
 
 // RuleConfig of rule as sent by the Remote Configuration.
@@ -98,3 +112,29 @@ func (r RulesConfig) OnlyEnabled() RulesConfig {
 		Rules: rules,
 	}
 }
+
+// usesStandardRules returns true if one of the enabled rules references a
+// standard rule.
+func (r RulesConfig) usesStandardRules() bool {
+	for _, rule := range r.OnlyEnabled().Rules {
+		if rule.Definition.StandardRuleID != "" || rule.Definition.Pattern == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// customDefinition returns the definition of a custom rule, which defines its
+// own pattern instead of referencing a standard rule.
+func (r RuleConfig) customDefinition() StandardRuleConfig {
+	return StandardRuleConfig{
+		ID:          r.ID,
+		Name:        r.Name,
+		Tags:        r.Tags,
+		Description: r.Description,
+		Definitions: []StandardRuleDefinition{{
+			Version: 1,
+			Pattern: r.Definition.Pattern,
+		}},
+	}
+}
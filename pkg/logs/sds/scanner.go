@@ -69,20 +69,6 @@ func CreateScanner(pipelineID string) *Scanner {
 	return scanner
 }
 
-// MatchActions as exposed by the RC configurations.
-const (
-	matchActionRCHash          = "hash"
-	matchActionRCNone          = "none"
-	matchActionRCPartialRedact = "partial_redact"
-	matchActionRCRedact        = "redact"
-
-	RCPartialRedactFirstCharacters = "first"
-	RCPartialRedactLastCharacters  = "last"
-
-	RCSecondaryValidationChineseIdChecksum = "chinese_id_checksum"
-	RCSecondaryValidationLuhnChecksum      = "luhn_checksum"
-)
-
 // Reconfigure uses the given `ReconfigureOrder` to reconfigure in-memory
 // standard rules or user configuration.
 // The order contains both the kind of reconfiguration to do and the raw bytes
@@ -175,7 +161,14 @@ func (s *Scanner) reconfigureRules(rawConfig []byte) (bool, error) {
 		return s.Scanner != nil, fmt.Errorf("Invalid nil raw configuration received for user configuration")
 	}
 
-	if s.standardRules == nil || len(s.standardRules) == 0 {
+	var config RulesConfig
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		tlmSDSReconfigError.Inc(s.pipelineID, string(AgentConfig), "cant_unmarshal")
+		return s.Scanner != nil, fmt.Errorf("Can't unmarshal raw configuration: %v", err)
+	}
+
+	// custom rules defining their own pattern can be used without standard rules.
+	if len(s.standardRules) == 0 && config.usesStandardRules() {
 		// store it for the next try
 		s.rawConfig = rawConfig
 		tlmSDSReconfigError.Inc(s.pipelineID, string(AgentConfig), "no_std_rules")
@@ -183,12 +176,6 @@ func (s *Scanner) reconfigureRules(rawConfig []byte) (bool, error) {
 		return s.Scanner != nil, nil
 	}
 
-	var config RulesConfig
-	if err := json.Unmarshal(rawConfig, &config); err != nil {
-		tlmSDSReconfigError.Inc(s.pipelineID, string(AgentConfig), "cant_unmarshal")
-		return s.Scanner != nil, fmt.Errorf("Can't unmarshal raw configuration: %v", err)
-	}
-
 	// ignore disabled rules
 	totalRulesReceived := len(config.Rules)
 	config = config.OnlyEnabled()
@@ -214,8 +201,12 @@ func (s *Scanner) reconfigureRules(rawConfig []byte) (bool, error) {
 	var malformedRulesCount int
 	var unknownStdRulesCount int
 	for _, userRule := range config.Rules {
-		// read the rule in the standard rules
+		// read the rule in the standard rules, custom rules come with their
+		// own definition.
 		standardRule, found := s.standardRules[userRule.Definition.StandardRuleID]
+		if userRule.Definition.StandardRuleID == "" && userRule.Definition.Pattern != "" {
+			standardRule, found = userRule.customDefinition(), true
+		}
 		if !found {
 			log.Warnf("Referencing an unknown standard rule, id: %v", userRule.Definition.StandardRuleID)
 			unknownStdRulesCount += 1
@@ -371,31 +362,41 @@ func interpretRCRule(userRule RuleConfig, standardRule StandardRuleConfig, defau
 // one should be used instead.
 // This method is thread safe, a reconfiguration can't happen at the same time.
 func (s *Scanner) Scan(event []byte, msg *message.Message) (bool, []byte, error) {
+	mutated, processed, rules, err := s.ScanRules(event)
+	for _, rc := range rules {
+		msg.ProcessingTags = append(msg.ProcessingTags, rc.Tags...)
+	}
+	// TODO(remy): in the future, we might want to do it differently than
+	// using a tag.
+	msg.ProcessingTags = append(msg.ProcessingTags, ScannedTag)
+	return mutated, processed, err
+}
+
+// ScanRules scans the given `event` like `Scan`, returning the configured rules
+// which matched it, once per match, instead of tagging a message.
+// This method is thread safe, a reconfiguration can't happen at the same time.
+func (s *Scanner) ScanRules(event []byte) (bool, []byte, []RuleConfig, error) {
 	s.Lock()
 	defer s.Unlock()
 	start := time.Now()
 
 	if s.Scanner == nil {
-		return false, nil, fmt.Errorf("can't Scan with an unitialized scanner")
+		return false, nil, nil, fmt.Errorf("can't Scan with an unitialized scanner")
 	}
 
 	// scanning
 	scanResult, err := s.Scanner.Scan(event)
-	if len(scanResult.Matches) > 0 {
-		for _, match := range scanResult.Matches {
-			if rc, err := s.GetRuleByIdx(match.RuleIdx); err != nil {
-				log.Warnf("can't apply rule tags: %v", err)
-			} else {
-				msg.ProcessingTags = append(msg.ProcessingTags, rc.Tags...)
-			}
+	var rules []RuleConfig
+	for _, match := range scanResult.Matches {
+		if rc, err := s.GetRuleByIdx(match.RuleIdx); err != nil {
+			log.Warnf("can't apply rule tags: %v", err)
+		} else {
+			rules = append(rules, rc)
 		}
 	}
-	// TODO(remy): in the future, we might want to do it differently than
-	// using a tag.
-	msg.ProcessingTags = append(msg.ProcessingTags, ScannedTag)
 
 	tlmSDSProcessingLatency.Observe(float64(time.Since(start) / 1000))
-	return scanResult.Mutated, scanResult.Event, err
+	return scanResult.Mutated, scanResult.Event, rules, err
 }
 
 // GetRuleByIdx returns the configured rule by its idx, referring to the idx
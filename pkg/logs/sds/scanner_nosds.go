@@ -12,6 +12,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 )
 
+const ScannedTag = "sds_agent:true"
+
 const SDSEnabled = false
 
 // Scanner mock.
@@ -48,3 +50,8 @@ func (s *Scanner) IsReady() bool { return false }
 func (s *Scanner) Scan(_ []byte, _ *message.Message) (bool, []byte, error) {
 	return false, nil, nil
 }
+
+// ScanRules mocks the ScanRules function.
+func (s *Scanner) ScanRules(_ []byte) (bool, []byte, []RuleConfig, error) {
+	return false, nil, nil, nil
+}
@@ -749,3 +749,41 @@ func TestInterpretRC(t *testing.T) {
 	require.Equal(rxRule.ProximityKeywords.LookAheadCharacterCount, uint32(10))
 	require.Equal(rxRule.ProximityKeywords.ExcludedKeywords, []string{"trace-id"})
 }
+
+func TestScanCustomRules(t *testing.T) {
+	require := require.New(t)
+
+	agentConfig := []byte(`
+        {"is_enabled":true,"rules":[
+            {
+                "id":"custom-0",
+                "definition":{"pattern":"tok_[a-z0-9]{8}"},
+                "name":"token",
+                "tags":["sensitive:token"],
+                "match_action":{"type":"Redact","placeholder":"[token]"},
+                "is_enabled":true
+            }
+        ]}
+    `)
+
+	s := CreateScanner("")
+	require.NotNil(s, "the returned scanner should not be nil")
+
+	// custom rules don't need standard rules to be configured
+	isActive, err := s.Reconfigure(ReconfigureOrder{
+		Type:   AgentConfig,
+		Config: agentConfig,
+	})
+	require.NoError(err)
+	require.True(isActive, "custom rules are configured, the scanner should be active")
+	require.True(s.IsReady(), "at this stage, the scanner should be considered ready")
+
+	msg := message.Message{}
+	matched, processed, err := s.Scan([]byte("auth with tok_abcd1234 ok"), &msg)
+	require.NoError(err)
+	require.True(matched)
+	require.Equal("auth with [token] ok", string(processed))
+	require.Equal([]string{"sensitive:token", ScannedTag}, msg.ProcessingTags)
+
+	s.Delete()
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/file"
+	"github.com/DataDog/datadog-agent/pkg/logs/client/webhook"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// NewCustomDestination returns the destination of an endpoint which does not
+// ship logs to a Datadog intake (see config.Endpoint.Type), or nil if the
+// endpoint is misconfigured.  Custom destinations do not depend on the
+// transport used to reach Datadog, and are shared by HTTP and TCP senders.
+func NewCustomDestination(endpoint config.Endpoint, destinationsContext *client.DestinationsContext, shouldRetry bool, cfg pkgconfigmodel.Reader) client.Destination {
+	switch endpoint.Type {
+	case config.FileEndpointType:
+		if endpoint.Path == "" {
+			log.Errorf("Ignoring logs file endpoint without path")
+			return nil
+		}
+		return file.NewDestination(endpoint, destinationsContext, shouldRetry)
+	case config.WebhookEndpointType:
+		if endpoint.URL == "" {
+			log.Errorf("Ignoring logs webhook endpoint without url")
+			return nil
+		}
+		destination, err := webhook.NewDestination(endpoint, destinationsContext, shouldRetry, cfg)
+		if err != nil {
+			log.Errorf("Ignoring logs webhook endpoint: %v", err)
+			return nil
+		}
+		return destination
+	default:
+		log.Errorf("Ignoring logs endpoint of unknown type %q", endpoint.Type)
+		return nil
+	}
+}
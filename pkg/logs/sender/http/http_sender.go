@@ -89,6 +89,12 @@ func httpDestinationFactory(
 		reliable := []client.Destination{}
 		additionals := []client.Destination{}
 		for i, endpoint := range endpoints.GetReliableEndpoints() {
+			if endpoint.IsCustom() {
+				if destination := sender.NewCustomDestination(endpoint, destinationsContext, !serverless, cfg); destination != nil {
+					reliable = append(reliable, destination)
+				}
+				continue
+			}
 			destMeta := client.NewDestinationMetadata(componentName, pipelineMonitor.ID(), "reliable", strconv.Itoa(i))
 			if serverless {
 				reliable = append(reliable, http.NewSyncDestination(endpoint, contentyType, destinationsContext, senderDoneChan, destMeta, cfg))
@@ -97,6 +103,12 @@ func httpDestinationFactory(
 			}
 		}
 		for i, endpoint := range endpoints.GetUnReliableEndpoints() {
+			if endpoint.IsCustom() {
+				if destination := sender.NewCustomDestination(endpoint, destinationsContext, false, cfg); destination != nil {
+					additionals = append(additionals, destination)
+				}
+				continue
+			}
 			destMeta := client.NewDestinationMetadata(componentName, pipelineMonitor.ID(), "unreliable", strconv.Itoa(i))
 			if serverless {
 				additionals = append(additionals, http.NewSyncDestination(endpoint, contentyType, destinationsContext, senderDoneChan, destMeta, cfg))
@@ -0,0 +1,378 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/logs/client"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	spillBufferEnabledKey = "logs_config.spill_buffer.enabled"
+	spillBufferPathKey    = "logs_config.spill_buffer.path"
+	spillBufferMaxSizeKey = "logs_config.spill_buffer.max_size"
+
+	// spillDirName is the directory owned by the agent under the configured
+	// path, or under the logs run path when no path is configured.
+	spillDirName = "logs-spill"
+	// spillQueuePrefix prefixes the directory of the spill queue of each
+	// worker, followed by the key of its reliable destinations.
+	spillQueuePrefix = "worker-"
+)
+
+var (
+	tlmSpilledPayloads  = telemetry.NewCounter("logs_sender", "spilled_payloads", []string{}, "Payloads written to the spill buffer while the reliable destinations were unavailable")
+	tlmSpilledBytes     = telemetry.NewGauge("logs_sender", "spilled_bytes", []string{}, "Size in bytes of the payloads held in the spill buffer")
+	tlmReplayedPayloads = telemetry.NewCounter("logs_sender", "replayed_spilled_payloads", []string{}, "Payloads recovered from the spill buffer of a previous run")
+
+	// the spill queues left by a previous run are listed once, and are
+	// recovered by the first queue of this run with the same destinations.
+	listPreviousSpillQueuesOnce sync.Once
+	previousSpillQueuesMu       sync.Mutex
+	previousSpillQueues         map[string][]string
+
+	// replayedSource is the source of the messages of the payloads recovered
+	// from a previous run, which are not tracked by the auditor.
+	replayedSource = sources.NewLogSource("", &config.LogsConfig{})
+)
+
+// spillHeader is written on the first line of a spilled payload file, before
+// its encoded content, so that the payload can be recovered after a restart.
+type spillHeader struct {
+	Encoding      string `json:"encoding"`
+	UnencodedSize int    `json:"unencoded_size"`
+	// Messages is the number of messages of the payload.
+	Messages int `json:"messages"`
+	// IngestionTimestamp is the ingestion timestamp of the last message.
+	IngestionTimestamp int64 `json:"ingestion_timestamp"`
+	// Untracked is set when some messages of the payload have no registry
+	// offset from which they can be read again.
+	Untracked bool `json:"untracked"`
+}
+
+// spillEntry is a payload whose encoded content is written to disk.  The
+// metadata of its messages are kept in memory, so that the auditor is updated
+// once the payload is sent.
+type spillEntry struct {
+	path          string
+	size          int64
+	messageMetas  []*message.MessageMetadata
+	encoding      string
+	unencodedSize int
+}
+
+// spillQueue is a bounded FIFO queue of payloads spilled to disk while the
+// reliable destinations are unavailable.  It is not thread safe: it is owned
+// by a single worker.
+//
+// The payloads still spilled when the queue is closed are kept on disk, and
+// are recovered when the agent starts again.
+type spillQueue struct {
+	dir     string
+	maxSize int64
+	size    int64
+	seq     uint64
+	entries []spillEntry
+	// head is the payload of the first entry, once read back from disk
+	head *message.Payload
+}
+
+// spillQueueKey returns the key of the reliable destinations of a worker, so
+// that the payloads it spilled are only recovered by a worker sending to the
+// same destinations.
+func spillQueueKey(destinations []client.Destination) string {
+	targets := make([]string, 0, len(destinations))
+	for _, d := range destinations {
+		targets = append(targets, d.Target())
+	}
+	sort.Strings(targets)
+	h := fnv.New64a()
+	h.Write([]byte(strings.Join(targets, "\n")))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// newSpillQueue returns the spill queue of a worker sending to the reliable
+// destinations with the given key, or nil if spilling to disk is disabled or
+// misconfigured.
+func newSpillQueue(cfg pkgconfigmodel.Reader, key string) *spillQueue {
+	if cfg == nil || !cfg.GetBool(spillBufferEnabledKey) {
+		return nil
+	}
+	maxSize := cfg.GetInt64(spillBufferMaxSizeKey)
+	if maxSize <= 0 {
+		log.Warnf("Invalid %s: %d, payloads won't be spilled to disk", spillBufferMaxSizeKey, maxSize)
+		return nil
+	}
+	root := cfg.GetString(spillBufferPathKey)
+	if root == "" {
+		root = cfg.GetString("logs_config.run_path")
+	}
+	root = filepath.Join(root, spillDirName)
+
+	listPreviousSpillQueuesOnce.Do(func() { previousSpillQueues = listSpillQueues(root) })
+	if err := os.MkdirAll(root, 0700); err != nil {
+		log.Errorf("Could not create the spill buffer directory, payloads won't be spilled to disk: %v", err)
+		return nil
+	}
+	dir, err := os.MkdirTemp(root, spillQueuePrefix+key+"-")
+	if err != nil {
+		log.Errorf("Could not create the spill buffer directory, payloads won't be spilled to disk: %v", err)
+		return nil
+	}
+	q := &spillQueue{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+	q.recover(takePreviousSpillQueues(key))
+	return q
+}
+
+// listSpillQueues returns the directories of the spill queues left in root by
+// a previous run, by key.  The other files of the directory are left untouched.
+func listSpillQueues(root string) map[string][]string {
+	dirs, err := filepath.Glob(filepath.Join(root, spillQueuePrefix+"*"))
+	if err != nil {
+		log.Warnf("Could not list the previous spill buffers from %s: %v", root, err)
+		return nil
+	}
+	queues := make(map[string][]string)
+	for _, dir := range dirs {
+		name := strings.TrimPrefix(filepath.Base(dir), spillQueuePrefix)
+		key, _, ok := strings.Cut(name, "-")
+		if !ok {
+			// a spill queue of a version which did not recover them
+			if err := os.RemoveAll(dir); err != nil {
+				log.Warnf("Could not remove the previous spill buffer %s: %v", dir, err)
+			}
+			continue
+		}
+		queues[key] = append(queues[key], dir)
+	}
+	return queues
+}
+
+// takePreviousSpillQueues returns the directories of the spill queues of the
+// previous run with the given key, once.
+func takePreviousSpillQueues(key string) []string {
+	previousSpillQueuesMu.Lock()
+	defer previousSpillQueuesMu.Unlock()
+	dirs := previousSpillQueues[key]
+	delete(previousSpillQueues, key)
+	return dirs
+}
+
+// recover moves the payloads spilled by a previous run in dirs to the queue,
+// before the payloads of this run.  The payloads whose messages can all be
+// read again from their registry offsets are dropped, as their tailers send
+// them again.
+func (q *spillQueue) recover(dirs []string) {
+	for _, dir := range dirs {
+		files, err := filepath.Glob(filepath.Join(dir, "*.payload"))
+		if err != nil {
+			log.Warnf("Could not list the previous spill buffer %s: %v", dir, err)
+		}
+		// the names of the files are zero-padded sequence numbers
+		sort.Strings(files)
+		for _, file := range files {
+			if err := q.recoverFile(file); err != nil {
+				log.Warnf("Could not recover spilled payload %s, dropping it: %v", file, err)
+				tlmPayloadsDropped.Inc("true", "spill")
+			}
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("Could not remove the previous spill buffer %s: %v", dir, err)
+		}
+	}
+	if len(q.entries) > 0 {
+		log.Infof("Recovered %d payloads from the spill buffer of the previous run", len(q.entries))
+	}
+}
+
+func (q *spillQueue) recoverFile(file string) error {
+	header, size, err := readSpillHeader(file)
+	if err != nil {
+		return err
+	}
+	if !header.Untracked {
+		return os.Remove(file)
+	}
+
+	q.seq++
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d.payload", q.seq))
+	if err := os.Rename(file, path); err != nil {
+		return err
+	}
+	messageMetas := make([]*message.MessageMetadata, header.Messages)
+	for i := range messageMetas {
+		messageMetas[i] = &message.MessageMetadata{Origin: message.NewOrigin(replayedSource)}
+	}
+	if len(messageMetas) > 0 {
+		messageMetas[len(messageMetas)-1].IngestionTimestamp = header.IngestionTimestamp
+	}
+	// the recovered payloads are kept even beyond the maximum size
+	q.entries = append(q.entries, spillEntry{
+		path:          path,
+		size:          size,
+		messageMetas:  messageMetas,
+		encoding:      header.Encoding,
+		unencodedSize: header.UnencodedSize,
+	})
+	q.size += size
+	tlmReplayedPayloads.Inc()
+	tlmSpilledBytes.Add(float64(size))
+	return nil
+}
+
+// readSpillHeader returns the header of a spilled payload file, and the size
+// of its encoded content.
+func readSpillHeader(path string) (spillHeader, int64, error) {
+	var header spillHeader
+	f, err := os.Open(path)
+	if err != nil {
+		return header, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return header, 0, err
+	}
+	line, err := bufio.NewReader(f).ReadBytes('\n')
+	if err != nil {
+		return header, 0, fmt.Errorf("invalid spilled payload header: %w", err)
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, 0, fmt.Errorf("invalid spilled payload header: %w", err)
+	}
+	return header, fi.Size() - int64(len(line)), nil
+}
+
+// empty returns true if no payload is spilled.
+func (q *spillQueue) empty() bool {
+	return len(q.entries) == 0
+}
+
+// push writes a payload at the end of the queue.  It returns false if the
+// queue is full or the payload could not be written.
+func (q *spillQueue) push(payload *message.Payload) bool {
+	size := int64(len(payload.Encoded))
+	if q.size+size > q.maxSize {
+		return false
+	}
+
+	header := spillHeader{
+		Encoding:      payload.Encoding,
+		UnencodedSize: payload.UnencodedSize,
+		Messages:      len(payload.MessageMetas),
+	}
+	for _, meta := range payload.MessageMetas {
+		if meta.Origin == nil || meta.Origin.Identifier == "" {
+			header.Untracked = true
+		}
+		header.IngestionTimestamp = meta.IngestionTimestamp
+	}
+	line, err := json.Marshal(header)
+	if err != nil {
+		log.Warnf("Could not spill payload to disk: %v", err)
+		return false
+	}
+
+	q.seq++
+	path := filepath.Join(q.dir, fmt.Sprintf("%020d.payload", q.seq))
+	content := make([]byte, 0, len(line)+1+len(payload.Encoded))
+	content = append(append(append(content, line...), '\n'), payload.Encoded...)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		log.Warnf("Could not spill payload to disk: %v", err)
+		return false
+	}
+	q.entries = append(q.entries, spillEntry{
+		path:          path,
+		size:          size,
+		messageMetas:  payload.MessageMetas,
+		encoding:      payload.Encoding,
+		unencodedSize: payload.UnencodedSize,
+	})
+	q.size += size
+	tlmSpilledPayloads.Inc()
+	tlmSpilledBytes.Add(float64(size))
+	return true
+}
+
+// peek returns the first payload of the queue, read back from disk.  Payloads
+// which can't be read back are dropped.
+func (q *spillQueue) peek() *message.Payload {
+	for q.head == nil && !q.empty() {
+		entry := q.entries[0]
+		content, err := os.ReadFile(entry.path)
+		if err == nil {
+			if i := bytes.IndexByte(content, '\n'); i >= 0 {
+				content = content[i+1:]
+			} else {
+				err = errors.New("missing header")
+			}
+		}
+		if err != nil {
+			log.Warnf("Could not read spilled payload, dropping it: %v", err)
+			tlmPayloadsDropped.Inc("true", "spill")
+			tlmMessagesDropped.Add(float64(len(entry.messageMetas)), "true", "spill")
+			q.pop()
+			continue
+		}
+		q.head = &message.Payload{
+			MessageMetas:  entry.messageMetas,
+			Encoded:       content,
+			Encoding:      entry.encoding,
+			UnencodedSize: entry.unencodedSize,
+		}
+	}
+	return q.head
+}
+
+// pop removes the first payload of the queue.
+func (q *spillQueue) pop() {
+	if q.empty() {
+		return
+	}
+	entry := q.entries[0]
+	if err := os.Remove(entry.path); err != nil && !os.IsNotExist(err) {
+		log.Debugf("Could not remove spilled payload: %v", err)
+	}
+	q.entries[0] = spillEntry{}
+	q.entries = q.entries[1:]
+	q.size -= entry.size
+	q.head = nil
+	tlmSpilledBytes.Sub(float64(entry.size))
+}
+
+// close releases the queue.  The payloads it still holds are kept on disk, to
+// be recovered when the agent starts again, otherwise its directory is removed.
+func (q *spillQueue) close() {
+	if len(q.entries) > 0 {
+		log.Infof("Keeping %d payloads in the spill buffer until the next start", len(q.entries))
+	} else if err := os.RemoveAll(q.dir); err != nil {
+		log.Debugf("Could not remove the spill buffer directory: %v", err)
+	}
+	tlmSpilledBytes.Sub(float64(q.size))
+	q.entries = nil
+	q.size = 0
+	q.head = nil
+}
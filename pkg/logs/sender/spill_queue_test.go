@@ -0,0 +1,179 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sender
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+// resetPreviousSpillQueues lists the spill queues of the previous run again
+// on the next call to newSpillQueue.
+func resetPreviousSpillQueues(t *testing.T) {
+	listPreviousSpillQueuesOnce = sync.Once{}
+	t.Cleanup(func() { listPreviousSpillQueuesOnce = sync.Once{} })
+}
+
+func newSpillQueueConfig(t *testing.T, root string, maxSize int) pkgconfigmodel.Reader {
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource(spillBufferEnabledKey, true)
+	cfg.SetWithoutSource(spillBufferPathKey, root)
+	cfg.SetWithoutSource(spillBufferMaxSizeKey, maxSize)
+	return cfg
+}
+
+func newTestSpillQueue(t *testing.T, maxSize int) (*spillQueue, string) {
+	resetPreviousSpillQueues(t)
+	root := t.TempDir()
+	q := newSpillQueue(newSpillQueueConfig(t, root, maxSize), "key")
+	require.NotNil(t, q)
+	t.Cleanup(q.close)
+	return q, root
+}
+
+func TestSpillQueueDisabled(t *testing.T) {
+	cfg := configmock.New(t)
+	assert.Nil(t, newSpillQueue(cfg, "key"))
+
+	cfg.SetWithoutSource(spillBufferEnabledKey, true)
+	cfg.SetWithoutSource(spillBufferMaxSizeKey, 0)
+	assert.Nil(t, newSpillQueue(cfg, "key"))
+}
+
+func TestSpillQueueOrder(t *testing.T) {
+	q, _ := newTestSpillQueue(t, 1024)
+	assert.True(t, q.empty())
+	assert.Nil(t, q.peek())
+
+	metas := []*message.MessageMetadata{{Hostname: "host"}}
+	require.True(t, q.push(&message.Payload{MessageMetas: metas, Encoded: []byte("first"), Encoding: "gzip", UnencodedSize: 10}))
+	require.True(t, q.push(&message.Payload{Encoded: []byte("second")}))
+	assert.False(t, q.empty())
+
+	head := q.peek()
+	assert.Equal(t, &message.Payload{MessageMetas: metas, Encoded: []byte("first"), Encoding: "gzip", UnencodedSize: 10}, head)
+	// the head is read once
+	assert.Same(t, head, q.peek())
+
+	q.pop()
+	assert.Equal(t, []byte("second"), q.peek().Encoded)
+	q.pop()
+	assert.True(t, q.empty())
+	assert.Equal(t, int64(0), q.size)
+
+	entries, err := os.ReadDir(q.dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpillQueueMaxSize(t *testing.T) {
+	q, _ := newTestSpillQueue(t, 10)
+
+	assert.True(t, q.push(&message.Payload{Encoded: []byte("12345")}))
+	assert.True(t, q.push(&message.Payload{Encoded: []byte("12345")}))
+	assert.False(t, q.push(&message.Payload{Encoded: []byte("1")}))
+
+	q.pop()
+	assert.True(t, q.push(&message.Payload{Encoded: []byte("1")}))
+}
+
+func TestSpillQueueDropsUnreadablePayloads(t *testing.T) {
+	q, _ := newTestSpillQueue(t, 1024)
+
+	require.True(t, q.push(&message.Payload{Encoded: []byte("lost")}))
+	require.True(t, q.push(&message.Payload{Encoded: []byte("kept")}))
+	require.NoError(t, os.Remove(q.entries[0].path))
+
+	assert.Equal(t, []byte("kept"), q.peek().Encoded)
+	assert.Len(t, q.entries, 1)
+}
+
+func TestSpillQueueClose(t *testing.T) {
+	q, _ := newTestSpillQueue(t, 1024)
+	require.True(t, q.push(&message.Payload{Encoded: []byte("kept")}))
+	path := q.entries[0].path
+
+	// the spilled payloads are kept until the next start
+	q.close()
+	assert.True(t, q.empty())
+	assert.FileExists(t, path)
+
+	empty, _ := newTestSpillQueue(t, 1024)
+	empty.close()
+	assert.NoDirExists(t, empty.dir)
+}
+
+func TestSpillQueueRecoversPreviousRun(t *testing.T) {
+	resetPreviousSpillQueues(t)
+	root := t.TempDir()
+	cfg := newSpillQueueConfig(t, root, 1024)
+
+	previous := newSpillQueue(cfg, "key")
+	require.NotNil(t, previous)
+	tracked := message.NewOrigin(sources.NewLogSource("", &config.LogsConfig{}))
+	tracked.Identifier = "file:/var/log/app.log"
+	// the payloads whose messages are read again by their tailers are dropped
+	require.True(t, previous.push(&message.Payload{
+		MessageMetas: []*message.MessageMetadata{{Origin: tracked}},
+		Encoded:      []byte("tracked"),
+	}))
+	require.True(t, previous.push(&message.Payload{
+		MessageMetas:  []*message.MessageMetadata{{Origin: tracked}, {IngestionTimestamp: 42}},
+		Encoded:       []byte("untracked"),
+		Encoding:      "gzip",
+		UnencodedSize: 20,
+	}))
+	previous.close()
+
+	// the spill queues of older versions are removed, the files the agent
+	// did not create are kept
+	legacy := filepath.Join(root, spillDirName, "worker-1234")
+	require.NoError(t, os.MkdirAll(legacy, 0700))
+	unrelated := filepath.Join(root, spillDirName, "README")
+	require.NoError(t, os.WriteFile(unrelated, []byte("keep"), 0600))
+
+	resetPreviousSpillQueues(t)
+	// the queues sending to other destinations don't recover the payloads
+	other := newSpillQueue(cfg, "other")
+	require.NotNil(t, other)
+	defer other.close()
+	assert.True(t, other.empty())
+
+	q := newSpillQueue(cfg, "key")
+	require.NotNil(t, q)
+	defer q.close()
+	assert.NoDirExists(t, previous.dir)
+	assert.NoDirExists(t, legacy)
+	assert.FileExists(t, unrelated)
+
+	require.Len(t, q.entries, 1)
+	payload := q.peek()
+	assert.Equal(t, []byte("untracked"), payload.Encoded)
+	assert.Equal(t, "gzip", payload.Encoding)
+	assert.Equal(t, 20, payload.UnencodedSize)
+	require.Len(t, payload.MessageMetas, 2)
+	assert.Equal(t, int64(42), payload.MessageMetas[1].IngestionTimestamp)
+	// the recovered messages are not tracked by the auditor
+	assert.Empty(t, payload.MessageMetas[0].Origin.Identifier)
+
+	// the queues of the other workers of this run are kept
+	again := newSpillQueue(cfg, "key")
+	require.NotNil(t, again)
+	defer again.close()
+	assert.True(t, again.empty())
+	assert.DirExists(t, q.dir)
+}
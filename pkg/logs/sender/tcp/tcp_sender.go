@@ -38,7 +38,7 @@ func NewTCPSender(
 	log.Debugf("Creating a new sender for component %s with %d queues, %d tcp workers", componentName, queueCount, workersPerQueue)
 	pipelineMonitor := metrics.NewTelemetryPipelineMonitor("tcp_sender")
 
-	destinationFactory := tcpDestinationFactory(endpoints, destinationsCtx, serverless, status)
+	destinationFactory := tcpDestinationFactory(endpoints, destinationsCtx, serverless, status, config)
 
 	return sender.NewSenderV2(
 		config,
@@ -58,14 +58,27 @@ func tcpDestinationFactory(
 	destinationsContext *client.DestinationsContext,
 	serverless bool,
 	status statusinterface.Status,
+	cfg pkgconfigmodel.Reader,
 ) sender.DestinationFactory {
 	return func() *client.Destinations {
 		reliable := []client.Destination{}
 		additionals := []client.Destination{}
 		for _, endpoint := range endpoints.GetReliableEndpoints() {
+			if endpoint.IsCustom() {
+				if destination := sender.NewCustomDestination(endpoint, destinationsContext, !serverless, cfg); destination != nil {
+					reliable = append(reliable, destination)
+				}
+				continue
+			}
 			reliable = append(reliable, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, !serverless, status))
 		}
 		for _, endpoint := range endpoints.GetUnReliableEndpoints() {
+			if endpoint.IsCustom() {
+				if destination := sender.NewCustomDestination(endpoint, destinationsContext, false, cfg); destination != nil {
+					additionals = append(additionals, destination)
+				}
+				continue
+			}
 			additionals = append(additionals, tcp.NewDestination(endpoint, endpoints.UseProto, destinationsContext, false, status))
 		}
 
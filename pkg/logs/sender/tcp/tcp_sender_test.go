@@ -10,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
 	"github.com/DataDog/datadog-agent/pkg/logs/client"
 	"github.com/DataDog/datadog-agent/pkg/logs/client/tcp"
 	"github.com/DataDog/datadog-agent/pkg/logs/status/statusinterface"
@@ -92,6 +93,7 @@ func TestTCPDestinationFactory(t *testing.T) {
 				destinationsCtx,
 				tc.serverless,
 				status,
+				configmock.New(t),
 			)
 
 			// Test 1: Verify first call creates destinations
@@ -24,6 +24,10 @@ var (
 	tlmSendWaitTime    = telemetry.NewCounter("logs_sender", "send_wait", []string{}, "Time spent waiting for all sends to finish")
 )
 
+// spillDrainInterval is the interval at which a worker tries to send its
+// spilled payloads when no new payload comes in.
+const spillDrainInterval = 100 * time.Millisecond
+
 // worker sends logs to different destinations. Destinations can be either
 // reliable or unreliable. The worker ensures that logs are sent to at least
 // one reliable destination and will block the pipeline if they are in an
@@ -31,6 +35,9 @@ var (
 // one reliable destination is also sending logs. However they do not update
 // the auditor or block the pipeline if they fail. There will always be at
 // least 1 reliable destination (the main destination).
+// When the spill buffer is enabled, payloads are written to disk instead of
+// blocking the pipeline while the reliable destinations are unavailable, and
+// sent in order once they recover.
 type worker struct {
 	auditor        auditor.Auditor
 	config         pkgconfigmodel.Reader
@@ -42,6 +49,9 @@ type worker struct {
 	bufferSize     int
 	senderDoneChan chan *sync.WaitGroup
 	flushWg        *sync.WaitGroup
+	// spill holds the payloads written to disk while the reliable
+	// destinations are unavailable, nil when disabled.
+	spill *spillQueue
 
 	pipelineMonitor metrics.PipelineMonitor
 	utilization     metrics.UtilizationMonitor
@@ -92,6 +102,7 @@ func newWorker(
 		bufferSize:     bufferSize,
 		senderDoneChan: senderDoneChan,
 		flushWg:        flushWg,
+		spill:          newSpillQueue(config, spillQueueKey(destinations.Reliable)),
 		done:           make(chan struct{}),
 		finished:       make(chan struct{}),
 
@@ -122,71 +133,34 @@ func (s *worker) run() {
 
 	sink := additionalDestinationsSink(s.bufferSize)
 	unreliableDestinations := buildDestinationSenders(s.config, s.destinations.Unreliable, sink, s.bufferSize)
+
+	// spilled payloads are drained as soon as the destinations recover, even
+	// when no new payload comes in.
+	var drainTicker <-chan time.Time
+	if s.spill != nil {
+		ticker := time.NewTicker(spillDrainInterval)
+		defer ticker.Stop()
+		drainTicker = ticker.C
+	}
+
 	continueLoop := true
 	for continueLoop {
 		select {
 		case payload := <-s.inputChan:
 			s.utilization.Start()
 			var startInUse = time.Now()
-			senderDoneWg := &sync.WaitGroup{}
-
-			sent := false
-			for !sent {
-				for _, destSender := range reliableDestinations {
-					if destSender.Send(payload) {
-						if destSender.destination.Metadata().ReportingEnabled {
-							s.pipelineMonitor.ReportComponentIngress(payload, destSender.destination.Metadata().MonitorTag())
-						}
-						sent = true
-						if s.senderDoneChan != nil {
-							senderDoneWg.Add(1)
-							s.senderDoneChan <- senderDoneWg
-						}
-					}
-				}
-
-				if !sent {
-					// Throttle the poll loop while waiting for a send to succeed
-					// This will only happen when all reliable destinations
-					// are blocked so logs have no where to go.
-					time.Sleep(100 * time.Millisecond)
-				}
-			}
-
-			for i, destSender := range reliableDestinations {
-				// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
-				// loss on intermittent failures.
-				if !destSender.lastSendSucceeded {
-					if !destSender.NonBlockingSend(payload) {
-						tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
-						tlmMessagesDropped.Add(float64(payload.Count()), "true", strconv.Itoa(i))
-					}
-				}
-			}
 
-			// Attempt to send to unreliable destinations
-			for i, destSender := range unreliableDestinations {
-				if !destSender.NonBlockingSend(payload) {
-					tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
-					tlmMessagesDropped.Add(float64(payload.Count()), "false", strconv.Itoa(i))
-					if s.senderDoneChan != nil {
-						senderDoneWg.Add(1)
-						s.senderDoneChan <- senderDoneWg
-					}
-				}
+			if s.spill == nil {
+				s.send(payload, reliableDestinations, unreliableDestinations, true)
+			} else {
+				s.sendOrSpill(payload, reliableDestinations, unreliableDestinations)
 			}
 
 			inUse := float64(time.Since(startInUse) / time.Millisecond)
 			tlmSendWaitTime.Add(inUse)
 			s.utilization.Stop()
-
-			if s.senderDoneChan != nil && s.flushWg != nil {
-				// Wait for all destinations to finish sending the payload
-				senderDoneWg.Wait()
-				// Decrement the wait group when this payload has been sent
-				s.flushWg.Done()
-			}
-			s.pipelineMonitor.ReportComponentEgress(payload, "sender")
+		case <-drainTicker:
+			s.drainSpill(reliableDestinations, unreliableDestinations)
 		case <-s.done:
 			continueLoop = false
 		}
@@ -199,10 +173,116 @@ func (s *worker) run() {
 	for _, destSender := range unreliableDestinations {
 		destSender.Stop()
 	}
+	if s.spill != nil {
+		s.spill.close()
+	}
 	close(sink)
 	s.finished <- struct{}{}
 }
 
+// send sends a payload to a reliable destination, then to the unreliable ones.
+// When blocking, it waits for a reliable destination to accept the payload,
+// otherwise it returns false if none of them accepts it right away.
+func (s *worker) send(payload *message.Payload, reliableDestinations, unreliableDestinations []*DestinationSender, blocking bool) bool {
+	senderDoneWg := &sync.WaitGroup{}
+
+	sent := false
+	for !sent {
+		for _, destSender := range reliableDestinations {
+			if destSender.Send(payload) {
+				if destSender.destination.Metadata().ReportingEnabled {
+					s.pipelineMonitor.ReportComponentIngress(payload, destSender.destination.Metadata().MonitorTag())
+				}
+				sent = true
+				if s.senderDoneChan != nil {
+					senderDoneWg.Add(1)
+					s.senderDoneChan <- senderDoneWg
+				}
+			}
+		}
+
+		if !sent {
+			if !blocking {
+				return false
+			}
+			// Throttle the poll loop while waiting for a send to succeed
+			// This will only happen when all reliable destinations
+			// are blocked so logs have no where to go.
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+
+	for i, destSender := range reliableDestinations {
+		// If an endpoint is stuck in the previous step, try to buffer the payloads if we have room to mitigate
+		// loss on intermittent failures.
+		if !destSender.lastSendSucceeded {
+			if !destSender.NonBlockingSend(payload) {
+				tlmPayloadsDropped.Inc("true", strconv.Itoa(i))
+				tlmMessagesDropped.Add(float64(payload.Count()), "true", strconv.Itoa(i))
+			}
+		}
+	}
+
+	// Attempt to send to unreliable destinations
+	for i, destSender := range unreliableDestinations {
+		if !destSender.NonBlockingSend(payload) {
+			tlmPayloadsDropped.Inc("false", strconv.Itoa(i))
+			tlmMessagesDropped.Add(float64(payload.Count()), "false", strconv.Itoa(i))
+			if s.senderDoneChan != nil {
+				senderDoneWg.Add(1)
+				s.senderDoneChan <- senderDoneWg
+			}
+		}
+	}
+
+	if s.senderDoneChan != nil && s.flushWg != nil {
+		// Wait for all destinations to finish sending the payload
+		senderDoneWg.Wait()
+		// Decrement the wait group when this payload has been sent
+		s.flushWg.Done()
+	}
+	s.pipelineMonitor.ReportComponentEgress(payload, "sender")
+	return true
+}
+
+// sendOrSpill sends a payload, or spills it to disk when the reliable
+// destinations are unavailable.  Payloads are sent in order: while payloads
+// are spilled, new ones are spilled after them.  When the spill buffer is
+// full, it blocks until the destinations drain it.
+func (s *worker) sendOrSpill(payload *message.Payload, reliableDestinations, unreliableDestinations []*DestinationSender) {
+	s.drainSpill(reliableDestinations, unreliableDestinations)
+	if s.spill.empty() && s.send(payload, reliableDestinations, unreliableDestinations, false) {
+		return
+	}
+
+	for !s.spill.push(payload) {
+		time.Sleep(100 * time.Millisecond)
+		s.drainSpill(reliableDestinations, unreliableDestinations)
+		if s.spill.empty() {
+			s.send(payload, reliableDestinations, unreliableDestinations, true)
+			return
+		}
+	}
+}
+
+// drainSpill sends the spilled payloads, in order, until a payload is not
+// accepted by the reliable destinations.
+func (s *worker) drainSpill(reliableDestinations, unreliableDestinations []*DestinationSender) {
+	if s.spill == nil {
+		return
+	}
+	for !s.spill.empty() {
+		payload := s.spill.peek()
+		if payload == nil {
+			return
+		}
+		if !s.send(payload, reliableDestinations, unreliableDestinations, false) {
+			return
+		}
+		s.spill.pop()
+	}
+}
+
 // Drains the output channel from destinations that don't update the auditor.
 func additionalDestinationsSink(bufferSize int) chan *message.Payload {
 	sink := make(chan *message.Payload, bufferSize)
@@ -7,6 +7,7 @@ package sender
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -35,6 +36,7 @@ func (a *testAuditor) Stop() {
 }
 func (a *testAuditor) GetOffset(_ string) string      { return "" }
 func (a *testAuditor) GetTailingMode(_ string) string { return "" }
+func (a *testAuditor) GetFingerprint(_ string) string { return "" }
 
 func newMessage(content []byte, source *sources.LogSource, status string) *message.Payload {
 	return &message.Payload{
@@ -345,3 +347,46 @@ func TestSenderReliableWhenOneFailsAndRecovers(t *testing.T) {
 	reliableServer2.Stop()
 	worker.stop()
 }
+
+func TestSenderSpillsWhileReliableDestinationFails(t *testing.T) {
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource(spillBufferEnabledKey, true)
+	cfg.SetWithoutSource(spillBufferPathKey, t.TempDir())
+	cfg.SetWithoutSource(spillBufferMaxSizeKey, 1024)
+
+	input := make(chan *message.Payload, 1)
+	auditor := &testAuditor{
+		output: make(chan *message.Payload, 10),
+	}
+
+	server := http.NewTestServer(500, cfg)
+	destinations := client.NewDestinations([]client.Destination{server.Destination}, nil)
+
+	worker := newWorker(cfg, input, auditor, destinations, 0, nil, nil, metrics.NewNoopPipelineMonitor(""))
+	worker.start()
+
+	// the pipeline is not blocked while the destination fails
+	payloads := []string{"1", "2", "3", "4", "5"}
+	for _, p := range payloads {
+		select {
+		case input <- &message.Payload{Encoded: []byte(p)}:
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "the sender blocked the pipeline")
+		}
+	}
+	assert.Empty(t, auditor.output)
+
+	// once the destination recovers, the payloads are sent in order
+	server.ChangeStatus(200)
+	for _, p := range payloads {
+		select {
+		case payload := <-auditor.output:
+			assert.Equal(t, p, string(payload.Encoded))
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "payload not sent")
+		}
+	}
+
+	server.Stop()
+	worker.stop()
+}
@@ -46,6 +46,9 @@ type LogSource struct {
 	info       *status.InfoRegistry
 	// In the case that the source is overridden, keep a reference to the parent for bubbling up information about the child
 	ParentSource *LogSource
+	// In the case that the source replaces the source of the previous config of a container, keep a reference to
+	// the previous source so that the container launcher stops its tailer before tailing the container again
+	PreviousSource *LogSource
 	// LatencyStats tracks internal stats on the time spent by messages from this source in a processing pipeline, i.e.
 	// the duration between when a message is decoded by the tailer/listener/decoder and when the message is handled by a sender
 	LatencyStats     *statstracker.Tracker
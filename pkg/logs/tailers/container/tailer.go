@@ -27,6 +27,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/internal/tag"
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -81,6 +82,15 @@ type Tailer struct {
 	Source          *sources.LogSource
 	tagProvider     tag.Provider
 
+	// throughputLimiter enforces the throughput limit of the source, it is nil
+	// if the source has no limit.
+	throughputLimiter *tailers.ThroughputLimiter
+
+	// limiterContext is cancelled when the tailer is stopped, so that a tailer
+	// paused by its throughput limit does not block Stop.
+	limiterContext context.Context
+	stopLimiter    context.CancelFunc
+
 	readTimeout   time.Duration
 	sleepDuration time.Duration
 
@@ -107,12 +117,16 @@ type Tailer struct {
 
 // NewAPITailer returns a new Tailer that streams logs by querying the Kubelet's API
 func NewAPITailer(client kubelet.KubeUtilInterface, containerID, containerName, podName, podNamespace string, source *sources.LogSource, outputChan chan *message.Message, erroredContainerID chan string, readTimeout time.Duration, tagger tagger.Component) *Tailer {
+	limiterContext, stopLimiter := context.WithCancel(context.Background())
 	return &Tailer{
 		ContainerID:        containerID,
 		outputChan:         outputChan,
 		decoder:            decoder.NewDecoderWithFraming(sources.NewReplaceableSource(source), dockerstream.New(containerID), framer.DockerStream, nil, status.NewInfoRegistry()),
 		Source:             source,
 		tagProvider:        tag.NewProvider(types.NewEntityID(types.ContainerID, containerID), tagger),
+		throughputLimiter:  tailers.GetThroughputLimiter(source),
+		limiterContext:     limiterContext,
+		stopLimiter:        stopLimiter,
 		unsafeLogReader:    newAPILogReader(client, podNamespace, podName, containerName),
 		readTimeout:        readTimeout,
 		sleepDuration:      defaultSleepDuration,
@@ -125,12 +139,16 @@ func NewAPITailer(client kubelet.KubeUtilInterface, containerID, containerName,
 
 // NewDockerTailer returns a new Tailer that streams logs by connecting directly to the Docker socket
 func NewDockerTailer(cli DockerContainerLogInterface, containerID string, source *sources.LogSource, outputChan chan *message.Message, erroredContainerID chan string, readTimeout time.Duration, tagger tagger.Component) *Tailer {
+	limiterContext, stopLimiter := context.WithCancel(context.Background())
 	return &Tailer{
 		ContainerID:        containerID,
 		outputChan:         outputChan,
 		decoder:            decoder.NewDecoderWithFraming(sources.NewReplaceableSource(source), dockerstream.New(containerID), framer.DockerStream, nil, status.NewInfoRegistry()),
 		Source:             source,
 		tagProvider:        tag.NewProvider(types.NewEntityID(types.ContainerID, containerID), tagger),
+		throughputLimiter:  tailers.GetThroughputLimiter(source),
+		limiterContext:     limiterContext,
+		stopLimiter:        stopLimiter,
 		unsafeLogReader:    newDockerLogReader(cli, containerID),
 		readTimeout:        readTimeout,
 		sleepDuration:      defaultSleepDuration,
@@ -163,6 +181,10 @@ func (t *Tailer) Stop() {
 
 	t.Source.RemoveInput(t.ContainerID)
 
+	// unblock the message-forwarder component if it is paused by the
+	// throughput limit of the source.
+	t.stopLimiter()
+
 	// the closed readForever component will eventually close its channel to the decoder,
 	// which will eventually close its channel to the message-forwarder component,
 	// which will indicate it's done with this channel.
@@ -349,6 +371,9 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.GetContent()) > 0 {
+			if !t.throughputLimiter.Allow(t.limiterContext, len(output.GetContent())) {
+				continue
+			}
 			origin := message.NewOrigin(t.Source)
 			origin.Offset = output.ParsingExtra.Timestamp
 			t.setLastSince(output.ParsingExtra.Timestamp)
@@ -358,7 +383,9 @@ func (t *Tailer) forwardMessages() {
 			tags = append(tags, t.tagProvider.GetTags()...)
 			origin.SetTags(tags)
 			// XXX(remy): is it OK recreating a message here?
-			t.outputChan <- message.NewMessage(output.GetContent(), origin, output.Status, output.IngestionTimestamp)
+			msg := message.NewMessage(output.GetContent(), origin, output.Status, output.IngestionTimestamp)
+			msg.EventTimestamp = output.EventTimestamp
+			t.outputChan <- msg
 		}
 	}
 }
@@ -83,6 +83,7 @@ func NewTestDecoder() *decoder.Decoder {
 func NewTestTailer(reader io.ReadCloser, unsafeReader io.ReadCloser, cancelFunc context.CancelFunc) *Tailer {
 	containerID := "1234567890abcdef"
 	source := sources.NewLogSource("foo", nil)
+	limiterContext, stopLimiter := context.WithCancel(context.Background())
 	tailer := &Tailer{
 		ContainerID: containerID,
 		outputChan:  make(chan *message.Message, 100),
@@ -99,6 +100,8 @@ func NewTestTailer(reader io.ReadCloser, unsafeReader io.ReadCloser, cancelFunc
 		erroredContainerID: make(chan string, 1),
 		reader:             newSafeReader(),
 		readerCancelFunc:   cancelFunc,
+		limiterContext:     limiterContext,
+		stopLimiter:        stopLimiter,
 	}
 	tailer.reader.setUnsafeReader(reader)
 
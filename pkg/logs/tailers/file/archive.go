@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// archiveDecompressors maps the extensions of the compressed archives the
+// tailer is able to read to the function building their decompressing reader.
+var archiveDecompressors = map[string]func(io.Reader) (io.ReadCloser, error){
+	".gz": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	".zst":  newZstdReader,
+	".zstd": newZstdReader,
+}
+
+func newZstdReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// IsArchivePath returns true if path has the extension of a compressed archive
+// that can be read by the tailer.
+func IsArchivePath(path string) bool {
+	_, ok := archiveDecompressors[strings.ToLower(filepath.Ext(path))]
+	return ok
+}
+
+// ArchiveFingerprint returns the fingerprint of the decompressed content of the
+// archive at path.  An empty fingerprint is returned for empty archives.
+func ArchiveFingerprint(path string) (string, error) {
+	r, err := openArchive(path)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	return contentFingerprint(r, fingerprintSize())
+}
+
+// ArchiveID returns an identifier of the archive at path, described by fi,
+// which doesn't change when logrotate renames it.  It is made of the hash of
+// the first compressed bytes of the archive, of its size and of its
+// modification time, so that archives whose decompressed content starts the
+// same way are told apart, and that it does not depend on the fingerprint size.
+func ArchiveID(path string, fi os.FileInfo) (string, error) {
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head, err := contentFingerprint(f, defaultFingerprintSize)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s-%d-%d", head, fi.Size(), fi.ModTime().UnixNano()), nil
+}
+
+// archiveIdentifier returns the registry identifier of the archive with the
+// given ID.
+func archiveIdentifier(id string) string {
+	return "archive:" + id
+}
+
+// archiveReader reads the decompressed content of an archive and closes both
+// the decompressor and the underlying file.
+type archiveReader struct {
+	io.ReadCloser
+	file io.Closer
+}
+
+func (r *archiveReader) Close() error {
+	err := r.ReadCloser.Close()
+	if ferr := r.file.Close(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// openArchive opens the archive at path and returns a reader over its
+// decompressed content.
+func openArchive(path string) (io.ReadCloser, error) {
+	newDecompressor, ok := archiveDecompressors[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("unsupported archive format for %q", path)
+	}
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := newDecompressor(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("decompress %q: %w", path, err)
+	}
+	return &archiveReader{ReadCloser: r, file: f}, nil
+}
+
+// setupArchive opens the archive read by the tailer and skips the first
+// `offset` bytes of its decompressed content, which have already been sent.
+func (t *Tailer) setupArchive(offset int64) error {
+	fullpath, err := filepath.Abs(t.file.Path)
+	if err != nil {
+		return err
+	}
+	t.fullpath = fullpath
+	t.tags = t.buildTailerTags()
+
+	log.Info("Opening archive", t.file.Path, "for tailer key", t.file.GetScanKey())
+	r, err := openArchive(fullpath)
+	if err != nil {
+		return err
+	}
+	skipped, err := io.CopyN(io.Discard, r, offset)
+	if err != nil && err != io.EOF {
+		r.Close()
+		return fmt.Errorf("seek %q to offset %d: %w", t.file.Path, offset, err)
+	}
+
+	t.archive = r
+	t.lastReadOffset.Store(skipped)
+	t.decodedOffset.Store(skipped)
+	return nil
+}
+
+// readArchive reads the next chunk of the decompressed archive.  It returns
+// io.EOF once the whole archive has been read, which stops the tailer:
+// archives are not expected to grow.
+func (t *Tailer) readArchive() (int, error) {
+	inBuf := make([]byte, 4096)
+	n, err := t.archive.Read(inBuf)
+	if n > 0 {
+		t.lastReadOffset.Add(int64(n))
+		t.decoder.InputChan <- decoder.NewInput(inBuf[:n])
+	}
+	if err == io.EOF {
+		log.Infof("Reached the end of archive %s after %d bytes", t.file.Path, t.lastReadOffset.Load())
+		return n, err
+	}
+	if err != nil {
+		t.file.Source.Status().Error(err)
+		return n, log.Error("Unexpected error occurred while reading archive: ", err)
+	}
+	return n, nil
+}
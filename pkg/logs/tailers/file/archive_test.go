@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	status "github.com/DataDog/datadog-agent/pkg/logs/status/utils"
+)
+
+func writeGzipArchive(t *testing.T, path string, content string) {
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	w := gzip.NewWriter(f)
+	_, err = w.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+}
+
+func newArchiveTailer(t *testing.T, path string, outputChan chan *message.Message) *Tailer {
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	id, err := ArchiveID(path, fi)
+	require.NoError(t, err)
+	fingerprint, err := ArchiveFingerprint(path)
+	require.NoError(t, err)
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	info := status.NewInfoRegistry()
+	return NewTailer(&TailerOptions{
+		OutputChan:      outputChan,
+		File:            NewArchiveFile(path, source, id, fingerprint),
+		SleepDuration:   10 * time.Millisecond,
+		Decoder:         decoder.NewDecoderFromSource(sources.NewReplaceableSource(source), info),
+		Info:            info,
+		PipelineMonitor: metrics.NewNoopPipelineMonitor(""),
+	})
+}
+
+func TestIsArchivePath(t *testing.T) {
+	assert.True(t, IsArchivePath("/var/log/app.log.1.gz"))
+	assert.True(t, IsArchivePath("/var/log/app.log-20240101.ZST"))
+	assert.True(t, IsArchivePath("/var/log/app.log.2.zstd"))
+	assert.False(t, IsArchivePath("/var/log/app.log.1"))
+	assert.False(t, IsArchivePath("/var/log/app.log"))
+}
+
+func TestArchiveFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	content := strings.Repeat("hello world\n", 200)
+
+	gzipPath := filepath.Join(dir, "app.log.1.gz")
+	writeGzipArchive(t, gzipPath, content)
+	zstdPath := filepath.Join(dir, "app.log.2.zst")
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(zstdPath, encoder.EncodeAll([]byte(content), nil), 0644))
+	plainPath := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(plainPath, []byte(content), 0644))
+
+	gzipFingerprint, err := ArchiveFingerprint(gzipPath)
+	require.NoError(t, err)
+	zstdFingerprint, err := ArchiveFingerprint(zstdPath)
+	require.NoError(t, err)
+	plain, err := os.Open(plainPath)
+	require.NoError(t, err)
+	defer plain.Close()
+	plainFingerprint, err := contentFingerprint(plain, fingerprintSize())
+	require.NoError(t, err)
+
+	// the fingerprint only depends on the decompressed content
+	assert.NotEmpty(t, gzipFingerprint)
+	assert.Equal(t, gzipFingerprint, zstdFingerprint)
+	assert.Equal(t, gzipFingerprint, plainFingerprint)
+
+	emptyPath := filepath.Join(dir, "empty.log.1.gz")
+	writeGzipArchive(t, emptyPath, "")
+	emptyFingerprint, err := ArchiveFingerprint(emptyPath)
+	require.NoError(t, err)
+	assert.Empty(t, emptyFingerprint)
+}
+
+func TestArchiveID(t *testing.T) {
+	dir := t.TempDir()
+	header := strings.Repeat("timestamp,level,message\n", 100)
+
+	first := filepath.Join(dir, "app.log.1.gz")
+	writeGzipArchive(t, first, header+"first\n")
+	second := filepath.Join(dir, "app.log.2.gz")
+	writeGzipArchive(t, second, header+"second\n")
+	archiveID := func(path string) string {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		id, err := ArchiveID(path, fi)
+		require.NoError(t, err)
+		return id
+	}
+
+	// archives sharing a header have the same fingerprint, but not the same ID
+	firstFingerprint, err := ArchiveFingerprint(first)
+	require.NoError(t, err)
+	secondFingerprint, err := ArchiveFingerprint(second)
+	require.NoError(t, err)
+	assert.Equal(t, firstFingerprint, secondFingerprint)
+	assert.NotEqual(t, archiveID(first), archiveID(second))
+
+	// the ID is kept when the archive is renamed, whatever the fingerprint size
+	id := archiveID(first)
+	renamed := filepath.Join(dir, "app.log.3.gz")
+	require.NoError(t, os.Rename(first, renamed))
+	pkgconfigsetup.Datadog().SetWithoutSource("logs_config.fingerprint.max_bytes", 64)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("logs_config.fingerprint.max_bytes", defaultFingerprintSize)
+	assert.Equal(t, id, archiveID(renamed))
+}
+
+func TestTailArchiveFromOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log.1.gz")
+	writeGzipArchive(t, path, "first\nsecond\nthird\n")
+
+	outputChan := make(chan *message.Message, 10)
+	tailer := newArchiveTailer(t, path, outputChan)
+	assert.True(t, strings.HasPrefix(tailer.Identifier(), "archive:"))
+	assert.Equal(t, tailer.Identifier(), tailer.GetId())
+
+	require.NoError(t, tailer.Start(int64(len("first\n")), io.SeekStart))
+
+	msg := <-outputChan
+	assert.Equal(t, "second", string(msg.GetContent()))
+	assert.Equal(t, "13", msg.Origin.Offset)
+	assert.Equal(t, tailer.Identifier(), msg.Origin.Identifier)
+	msg = <-outputChan
+	assert.Equal(t, "third", string(msg.GetContent()))
+	assert.Equal(t, "19", msg.Origin.Offset)
+
+	// the tailer stops by itself once the archive has been read
+	assert.Eventually(t, tailer.IsFinished, 5*time.Second, 10*time.Millisecond)
+	tailer.Stop()
+}
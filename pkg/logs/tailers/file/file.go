@@ -23,6 +23,15 @@ type File struct {
 
 	// Source is the ReplaceableSource that led to this File.
 	Source *sources.ReplaceableSource
+
+	// ArchiveID is set when the File is a compressed, rotated archive which is
+	// read once to its end rather than tailed.  It identifies the archive
+	// independently of its path.
+	ArchiveID string
+
+	// ArchiveFingerprint is the fingerprint of the decompressed content of the
+	// archive, which matches the one of the file it has been rotated from.
+	ArchiveFingerprint string
 }
 
 // NewFile returns a new File
@@ -34,10 +43,31 @@ func NewFile(path string, source *sources.LogSource, isWildcardPath bool) *File
 	}
 }
 
+// NewArchiveFile returns a new File for the compressed archive at path,
+// identified by id, and whose content has the given fingerprint.
+func NewArchiveFile(path string, source *sources.LogSource, id string, fingerprint string) *File {
+	return &File{
+		Path:               path,
+		Source:             sources.NewReplaceableSource(source),
+		ArchiveID:          id,
+		ArchiveFingerprint: fingerprint,
+	}
+}
+
+// IsArchive returns true if the File is a compressed archive.
+func (t *File) IsArchive() bool {
+	return t.ArchiveID != ""
+}
+
 // GetScanKey returns a key used by the scanner to index the scanned file.  The
 // string uniquely identifies this File, even if sources for multiple
 // containers use the same Path.
 func (t *File) GetScanKey() string {
+	// Archives are keyed by their ID, as logrotate keeps renaming them
+	// (app.log.1.gz -> app.log.2.gz) while they may still be read.
+	if t.IsArchive() {
+		return archiveIdentifier(t.ArchiveID)
+	}
 	// If it is a file scanned for a container, it will use the format: <filepath>/<container_id>
 	// Otherwise, it will simply use the format: <filepath>
 	if t.Source != nil && t.Source.Config() != nil && t.Source.Config().Identifier != "" {
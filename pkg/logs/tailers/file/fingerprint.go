@@ -0,0 +1,120 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package file
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/twmb/murmur3"
+
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// defaultFingerprintSize is the number of leading content bytes hashed to
+// identify a file independently of its path and inode, when
+// `logs_config.fingerprint.max_bytes` is not valid.
+const defaultFingerprintSize = 1024
+
+// fingerprintSize returns the number of leading content bytes hashed to
+// identify a file.
+func fingerprintSize() int64 {
+	size := pkgconfigsetup.Datadog().GetInt64("logs_config.fingerprint.max_bytes")
+	if size <= 0 {
+		return defaultFingerprintSize
+	}
+	return size
+}
+
+// contentFingerprint returns the fingerprint of the first `size` bytes read
+// from r, or an empty string if r has no content.
+func contentFingerprint(r io.Reader, size int64) (string, error) {
+	buf := make([]byte, size)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if n == 0 {
+		return "", nil
+	}
+	return fmt.Sprintf("%016x", murmur3.Sum64(buf[:n])), nil
+}
+
+// fileFingerprint returns the fingerprint of f, and whether f was large enough
+// for the fingerprint not to change as more content is appended to it.
+func fileFingerprint(f *os.File, size int64) (string, bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return "", false, err
+	}
+	fingerprint, err := contentFingerprint(io.NewSectionReader(f, 0, size), size)
+	if err != nil {
+		return "", false, err
+	}
+	return fingerprint, fi.Size() >= size, nil
+}
+
+// FileFingerprint returns the fingerprint of the file at path.  The fingerprint
+// of a file smaller than the fingerprint size will change as it grows.
+func FileFingerprint(path string) (string, error) {
+	f, err := filesystem.OpenShared(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	fingerprint, _, err := fileFingerprint(f, fingerprintSize())
+	return fingerprint, err
+}
+
+// ContentFingerprint returns the fingerprint of the first bytes of the file
+// read by the tailer, or an empty string if it is not available.  The
+// fingerprint is cached once the file is large enough for it not to change
+// anymore, and is kept after a rotated file has been closed.
+//
+// On platforms not keeping the file open, the fingerprint is only available
+// once cached by DidRotate.
+func (t *Tailer) ContentFingerprint() string {
+	if fingerprint := t.fingerprint.Load(); fingerprint != "" || t.IsFinished() || t.osFile == nil {
+		return fingerprint
+	}
+	fingerprint, complete, err := fileFingerprint(t.osFile, t.fingerprintSize)
+	if err != nil {
+		log.Debugf("Could not compute the fingerprint of %q: %v", t.file.Path, err)
+		return ""
+	}
+	if complete {
+		t.fingerprint.Store(fingerprint)
+	}
+	return fingerprint
+}
+
+// didContentChange compares the fingerprint of f, the file currently found at
+// the tailed path, with the fingerprint of the content being tailed.  It
+// returns false for `known` when the fingerprints cannot be compared yet,
+// because the file is still smaller than the fingerprint size.
+func (t *Tailer) didContentChange(f *os.File) (changed bool, known bool) {
+	current, complete, err := fileFingerprint(f, t.fingerprintSize)
+	if err != nil {
+		log.Debugf("Could not compute the fingerprint of %q: %v", t.file.Path, err)
+		return false, false
+	}
+	if !complete {
+		return false, false
+	}
+	// fingerprint the tailed content after the file at the tailed path, so
+	// that content appended in between does not make them differ.
+	tailed := t.ContentFingerprint()
+	if tailed == "" {
+		// The tailed file is not kept open: the file at the tailed path is the
+		// tailed content until the fingerprint changes.
+		t.fingerprint.Store(current)
+		return false, false
+	}
+	return tailed != current, true
+}
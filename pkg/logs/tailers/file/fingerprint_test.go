@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/internal/decoder"
+	"github.com/DataDog/datadog-agent/pkg/logs/message"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	status "github.com/DataDog/datadog-agent/pkg/logs/status/utils"
+)
+
+// startFingerprintingTailer starts a tailer with fingerprinting enabled on the
+// file at path, and waits for it to read its content.
+func startFingerprintingTailer(t *testing.T, path string) *Tailer {
+	source := sources.NewLogSource("", &config.LogsConfig{Type: config.FileType, Path: path})
+	info := status.NewInfoRegistry()
+	tailer := NewTailer(&TailerOptions{
+		OutputChan:      make(chan *message.Message, 10),
+		File:            NewFile(path, source, false),
+		SleepDuration:   10 * time.Millisecond,
+		Decoder:         decoder.NewDecoderFromSource(sources.NewReplaceableSource(source), info),
+		Info:            info,
+		PipelineMonitor: metrics.NewNoopPipelineMonitor(""),
+	})
+	tailer.fingerprintEnabled = true
+	tailer.recordFingerprint = true
+	tailer.fingerprintSize = 16
+	require.NoError(t, tailer.StartFromBeginning())
+	t.Cleanup(tailer.Stop)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return tailer.LastReadOffset() == fi.Size() }, 5*time.Second, 10*time.Millisecond)
+	return tailer
+}
+
+func TestDidRotateCopyTruncateGrownPastOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("2024-01-01 first line\n"), 0644))
+	tailer := startFingerprintingTailer(t, path)
+
+	didRotate, err := tailer.DidRotate()
+	require.NoError(t, err)
+	assert.False(t, didRotate)
+
+	// the file is truncated in place, and grows past the last read offset
+	// before the next rotation check
+	require.NoError(t, os.WriteFile(path, []byte("2024-01-02 a much longer line\n"), 0644))
+
+	didRotate, err = tailer.DidRotate()
+	require.NoError(t, err)
+	assert.True(t, didRotate)
+}
+
+func TestTailerRecordsFingerprint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("2024-01-01 first line\n"), 0644))
+	tailer := startFingerprintingTailer(t, path)
+	fingerprint := tailer.ContentFingerprint()
+	require.NotEmpty(t, fingerprint)
+
+	// the lines read once the fingerprint is known carry it to the registry
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("2024-01-01 second line\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	var msg *message.Message
+	for msg == nil || string(msg.GetContent()) != "2024-01-01 second line" {
+		msg = <-tailer.outputChan
+	}
+	assert.Equal(t, fingerprint, msg.Origin.Fingerprint)
+}
+
+func TestDidRotateRecreationWithSameContent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	content := []byte("2024-01-01 first line\n")
+	require.NoError(t, os.WriteFile(path, content, 0644))
+	tailer := startFingerprintingTailer(t, path)
+
+	didRotate, err := tailer.DidRotate()
+	require.NoError(t, err)
+	assert.False(t, didRotate)
+
+	// the file is deleted and recreated with the same header: the tailed
+	// inode is gone, whatever the fingerprint
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.WriteFile(path, content, 0644))
+
+	didRotate, err = tailer.DidRotate()
+	require.NoError(t, err)
+	assert.True(t, didRotate)
+}
+
+func TestFileFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	require.NoError(t, os.WriteFile(path, []byte("hello\n"), 0644))
+
+	small, err := FileFingerprint(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, small)
+
+	// the fingerprint of a small file changes as it grows
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("world\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	grown, err := FileFingerprint(path)
+	require.NoError(t, err)
+	assert.NotEqual(t, small, grown)
+
+	require.NoError(t, os.WriteFile(path, nil, 0644))
+	empty, err := FileFingerprint(path)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -21,6 +22,11 @@ import (
 // - renamed and recreated
 // - removed and recreated
 // - truncated
+//
+// When fingerprinting is enabled, a change of the content at the beginning of
+// the file kept at the same inode also denotes a rotation: this handles the
+// copytruncate rotations after which the file grew past the last read offset
+// before being checked.  The inode and the size still take precedence.
 func (t *Tailer) DidRotate() (bool, error) {
 	f, err := filesystem.OpenShared(t.fullpath)
 	if err != nil {
@@ -44,11 +50,25 @@ func (t *Tailer) DidRotate() (bool, error) {
 	recreated := !os.SameFile(fi1, fi2)
 	truncated := fileSize < lastReadOffset
 
+	if truncated {
+		log.Debugf("File rotation detected due to size change, lastReadOffset=%d, fileSize=%d", lastReadOffset, fileSize)
+		metrics.TlmFileRotations.Inc("truncated")
+		return true, nil
+	}
+
 	if recreated {
 		log.Debugf("File rotation detected due to recreation, f1: %+v, f2: %+v", fi1, fi2)
-	} else if truncated {
-		log.Debugf("File rotation detected due to size change, lastReadOffset=%d, fileSize=%d", lastReadOffset, fileSize)
+		metrics.TlmFileRotations.Inc("recreated")
+		return true, nil
+	}
+
+	if t.fingerprintEnabled {
+		if changed, _ := t.didContentChange(f); changed {
+			log.Debugf("File rotation detected due to content change, f1: %+v, f2: %+v", fi1, fi2)
+			metrics.TlmFileRotations.Inc("content_changed")
+			return true, nil
+		}
 	}
 
-	return recreated || truncated, nil
+	return false, nil
 }
@@ -10,6 +10,7 @@ package file
 import (
 	"fmt"
 
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -17,7 +18,8 @@ import (
 // DidRotate returns true if the file has been log-rotated.
 //
 // On Windows, log rotation is identified by the file size being smaller
-// than the last offset read.
+// than the last offset read, or, when fingerprinting is enabled, by a change of
+// the content at the beginning of the file.
 func (t *Tailer) DidRotate() (bool, error) {
 	f, err := filesystem.OpenShared(t.fullpath)
 	if err != nil {
@@ -40,8 +42,17 @@ func (t *Tailer) DidRotate() (bool, error) {
 
 	if sz < offset {
 		log.Debugf("File rotation detected due to size change, lastReadOffset=%d, fileSize=%d", offset, sz)
+		metrics.TlmFileRotations.Inc("truncated")
 		return true, nil
 	}
 
+	if t.fingerprintEnabled {
+		if changed, _ := t.didContentChange(f); changed {
+			log.Debugf("File rotation detected due to content change")
+			metrics.TlmFileRotations.Inc("content_changed")
+			return true, nil
+		}
+	}
+
 	return false, nil
 }
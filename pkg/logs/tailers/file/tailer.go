@@ -30,6 +30,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 	status "github.com/DataDog/datadog-agent/pkg/logs/status/utils"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 )
 
 // Tailer tails a file, decodes the messages it contains, and passes them to a
@@ -62,6 +63,27 @@ type Tailer struct {
 	// is platform-specific, and not every platform will have a non-nil value here.
 	osFile *os.File
 
+	// archive is the decompressed content of the file, when the file is a
+	// compressed archive.  Archives are read until their end and then closed.
+	archive io.ReadCloser
+
+	// fingerprint caches the fingerprint of the content of the file, once it
+	// no longer changes.  See ContentFingerprint.
+	fingerprint *atomic.String
+
+	// fingerprintSize is the number of leading bytes of the file hashed into
+	// its fingerprint.
+	fingerprintSize int64
+
+	// fingerprintEnabled enables the detection of rotations through the
+	// fingerprint of the file.
+	fingerprintEnabled bool
+
+	// recordFingerprint enables the recording of the fingerprint of the file
+	// in the registry, to validate the offset from which it is resumed and to
+	// resume reading its compressed archive once rotated.
+	recordFingerprint bool
+
 	// tags are the tags to be attached to each log message, excluding tags provided
 	// by the tag provider.
 	tags []string
@@ -70,6 +92,16 @@ type Tailer struct {
 	// is called once for each log message.
 	tagProvider tag.Provider
 
+	// throughputLimiter enforces the throughput limit of the source, it is nil
+	// if the source has no limit.
+	throughputLimiter *tailers.ThroughputLimiter
+
+	// limiterContext is derived from forwardContext, and is also cancelled when
+	// the tailer is stopped, so that a tailer paused by its throughput limit does
+	// not block Stop.
+	limiterContext context.Context
+	stopLimiter    context.CancelFunc
+
 	// outputChan is the channel to which fully-decoded messages are written.
 	outputChan chan *message.Message
 
@@ -152,8 +184,11 @@ func NewTailer(opts *TailerOptions) *Tailer {
 	}
 
 	forwardContext, stopForward := context.WithCancel(context.Background())
+	limiterContext, stopLimiter := context.WithCancel(forwardContext)
 	closeTimeout := pkgconfigsetup.Datadog().GetDuration("logs_config.close_timeout") * time.Second
 	windowsOpenFileTimeout := pkgconfigsetup.Datadog().GetDuration("logs_config.windows_open_file_timeout") * time.Second
+	fingerprintEnabled := pkgconfigsetup.Datadog().GetBool("logs_config.fingerprint.enabled")
+	recordFingerprint := fingerprintEnabled || pkgconfigsetup.Datadog().GetBool("logs_config.tail_compressed_archives")
 
 	bytesRead := status.NewCountInfo("Bytes Read")
 	fileRotated := opts.Rotated
@@ -170,6 +205,7 @@ func NewTailer(opts *TailerOptions) *Tailer {
 		outputChan:             opts.OutputChan,
 		decoder:                opts.Decoder,
 		tagProvider:            tagProvider,
+		throughputLimiter:      tailers.GetThroughputLimiter(opts.File.Source.UnderlyingSource()),
 		lastReadOffset:         atomic.NewInt64(0),
 		decodedOffset:          atomic.NewInt64(0),
 		sleepDuration:          opts.SleepDuration,
@@ -179,8 +215,14 @@ func NewTailer(opts *TailerOptions) *Tailer {
 		done:                   make(chan struct{}, 1),
 		forwardContext:         forwardContext,
 		stopForward:            stopForward,
+		limiterContext:         limiterContext,
+		stopLimiter:            stopLimiter,
 		isFinished:             atomic.NewBool(false),
 		didFileRotate:          atomic.NewBool(false),
+		fingerprint:            atomic.NewString(""),
+		fingerprintSize:        fingerprintSize(),
+		fingerprintEnabled:     fingerprintEnabled,
+		recordFingerprint:      recordFingerprint,
 		info:                   opts.Info,
 		bytesRead:              bytesRead,
 		movingSum:              movingSum,
@@ -227,12 +269,21 @@ func (t *Tailer) Identifier() string {
 	//
 	// This is the identifier used in the registry, so changing it will invalidate existing
 	// registry entries on upgrade.
+	if t.file.IsArchive() {
+		return archiveIdentifier(t.file.ArchiveID)
+	}
 	return fmt.Sprintf("file:%s", t.file.Path)
 }
 
 // Start begins the tailer's operation in a dedicated goroutine.
 func (t *Tailer) Start(offset int64, whence int) error {
-	err := t.setup(offset, whence)
+	var err error
+	if t.file.IsArchive() {
+		// archives can only be read forward, from the start of their content
+		err = t.setupArchive(offset)
+	} else {
+		err = t.setup(offset, whence)
+	}
 	if err != nil {
 		t.file.Source.Status().Error(err)
 		return err
@@ -258,6 +309,7 @@ func (t *Tailer) StartFromBeginning() error {
 func (t *Tailer) Stop() {
 	t.stop <- struct{}{}
 	t.file.Source.RemoveInput(t.file.Path)
+	t.stopLimiter()
 	// wait for the decoder to be flushed
 	<-t.done
 }
@@ -293,19 +345,33 @@ func (t *Tailer) StopAfterFileRotation() {
 func (t *Tailer) readForever() {
 	defer func() {
 		if t.osFile != nil {
+			if t.didFileRotate.Load() && t.fingerprint.Load() == "" {
+				// keep the fingerprint of the rotated content available once the
+				// file is closed, it may be matched against its compressed archive.
+				fingerprint, _, _ := fileFingerprint(t.osFile, t.fingerprintSize)
+				t.fingerprint.Store(fingerprint)
+			}
 			t.osFile.Close()
 		}
+		if t.archive != nil {
+			t.archive.Close()
+		}
 		t.decoder.Stop()
 		log.Info("Closed", t.file.Path, "for tailer key", t.file.GetScanKey(), "read", t.Source().BytesRead.Get(), "bytes and", t.decoder.GetLineCount(), "lines")
 	}()
 
+	read := t.read
+	if t.archive != nil {
+		read = t.readArchive
+	}
+
 	for {
-		n, err := t.read()
+		n, err := read()
+		t.recordBytes(int64(n))
+		t.movingSum.Add(int64(n))
 		if err != nil {
 			return
 		}
-		t.recordBytes(int64(n))
-		t.movingSum.Add(int64(n))
 
 		select {
 		case <-t.stop:
@@ -323,6 +389,11 @@ func (t *Tailer) readForever() {
 	}
 }
 
+// LastReadOffset returns the offset up to which the file has been read.
+func (t *Tailer) LastReadOffset() int64 {
+	return t.lastReadOffset.Load()
+}
+
 // buildTailerTags groups the file tag, directory (if wildcard path) and user tags
 func (t *Tailer) buildTailerTags() []string {
 	tags := []string{
@@ -357,6 +428,9 @@ func (t *Tailer) forwardMessages() {
 		origin := message.NewOrigin(t.file.Source.UnderlyingSource())
 		origin.Identifier = identifier
 		origin.Offset = strconv.FormatInt(offset, 10)
+		if t.recordFingerprint && identifier != "" {
+			origin.Fingerprint = t.fingerprint.Load()
+		}
 
 		tags := make([]string, len(t.tags))
 		copy(tags, t.tags)
@@ -367,8 +441,14 @@ func (t *Tailer) forwardMessages() {
 		if len(output.GetContent()) == 0 {
 			continue
 		}
+		// Pausing on the throughput limit is cancellable for the same reason
+		// as the write to the output chan below, and when the tailer is stopped.
+		if !t.throughputLimiter.Allow(t.limiterContext, len(output.GetContent())) {
+			continue
+		}
 
 		msg := message.NewMessage(output.GetContent(), origin, output.Status, output.IngestionTimestamp)
+		msg.EventTimestamp = output.EventTimestamp
 		// Make the write to the output chan cancellable to be able to stop the tailer
 		// after a file rotation when it is stuck on it.
 		// We don't return directly to keep the same shutdown sequence that in the
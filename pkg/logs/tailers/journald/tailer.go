@@ -8,10 +8,12 @@
 package journald
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/coreos/go-systemd/sdjournal"
@@ -23,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -33,16 +36,44 @@ const (
 	defaultApplicationName = "docker"
 )
 
+// Unit is a systemd unit tailed with its own cursor.
+type Unit struct {
+	// Field is the journal field identifying the unit, _SYSTEMD_UNIT or
+	// _SYSTEMD_USER_UNIT.
+	Field string
+	Name  string
+}
+
+// Units returns the units of a source tailed with their own cursor, none if
+// the source is tailed with a single cursor.
+func Units(config *config.LogsConfig) []Unit {
+	if !config.CursorPerUnit {
+		return nil
+	}
+	var units []Unit
+	for _, unit := range config.IncludeSystemUnits {
+		units = append(units, Unit{Field: sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT, Name: unit})
+	}
+	for _, unit := range config.IncludeUserUnits {
+		units = append(units, Unit{Field: sdjournal.SD_JOURNAL_FIELD_SYSTEMD_USER_UNIT, Name: unit})
+	}
+	return units
+}
+
 // Tailer collects logs from a journal.
 type Tailer struct {
 	decoder    *decoder.Decoder
 	source     *sources.LogSource
 	outputChan chan *message.Message
 	journal    Journal
-	exclude    struct {
+	// unit is the only unit tailed, when the source has a cursor per unit.
+	unit    *Unit
+	exclude struct {
 		systemUnits map[string]bool
 		userUnits   map[string]bool
 		matches     map[string]map[string]bool
+		// patterns are the values of the matches containing wildcards.
+		patterns map[string][]*regexp.Regexp
 	}
 	stop chan struct{}
 	done chan struct{}
@@ -54,6 +85,15 @@ type Tailer struct {
 	// is called once for each log message.
 	tagProvider tag.Provider
 	tagger      tagger.Component
+
+	// throughputLimiter enforces the throughput limit of the source, it is nil
+	// if the source has no limit.
+	throughputLimiter *tailers.ThroughputLimiter
+
+	// limiterContext is cancelled when the tailer is stopped, so that a tailer
+	// paused by its throughput limit does not block Stop.
+	limiterContext context.Context
+	stopLimiter    context.CancelFunc
 }
 
 // NewTailer returns a new tailer.
@@ -65,6 +105,7 @@ func NewTailer(source *sources.LogSource, outputChan chan *message.Message, jour
 		telemetry.GetStatsTelemetryProvider().Gauge(processor.UnstructuredProcessingMetricName, 1, []string{"tailer:journald"})
 	}
 
+	limiterContext, stopLimiter := context.WithCancel(context.Background())
 	return &Tailer{
 		decoder:           decoder.NewNoopDecoder(),
 		source:            source,
@@ -75,9 +116,20 @@ func NewTailer(source *sources.LogSource, outputChan chan *message.Message, jour
 		processRawMessage: processRawMessage,
 		tagProvider:       tag.NewLocalProvider([]string{}),
 		tagger:            tagger,
+		throughputLimiter: tailers.GetThroughputLimiter(source),
+		limiterContext:    limiterContext,
+		stopLimiter:       stopLimiter,
 	}
 }
 
+// NewUnitTailer returns a new tailer collecting the logs of a single unit of
+// the source, with its own cursor.
+func NewUnitTailer(source *sources.LogSource, unit Unit, outputChan chan *message.Message, journal Journal, processRawMessage bool, tagger tagger.Component) *Tailer {
+	t := NewTailer(source, outputChan, journal, processRawMessage, tagger)
+	t.unit = &unit
+	return t
+}
+
 // Start starts tailing the journal from a given offset.
 func (t *Tailer) Start(cursor string) error {
 	if err := t.setup(); err != nil {
@@ -108,6 +160,7 @@ func (t *Tailer) Stop() {
 
 	t.source.RemoveInput(t.Identifier())
 
+	t.stopLimiter()
 	<-t.done
 }
 
@@ -117,10 +170,18 @@ func (t *Tailer) setup() error {
 
 	matchRe := regexp.MustCompile("^([^=]+)=(.+)$")
 
+	if t.unit != nil {
+		// collect only the logs of the unit tailed with its own cursor.
+		match := t.unit.Field + "=" + t.unit.Name
+		if err := t.journal.AddMatch(match); err != nil {
+			return fmt.Errorf("could not add filter %s: %s", match, err)
+		}
+	}
+
 	// add filters to collect only the logs of the units defined in the configuration,
 	// if no units for both System and User, and no matches are defined,
 	// collect all the logs of the journal by default.
-	for _, unit := range config.IncludeSystemUnits {
+	for _, unit := range t.includedUnits(config.IncludeSystemUnits) {
 		// add filters to collect only the logs of the system-level units defined in the configuration.
 		match := sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT + "=" + unit
 		err := t.journal.AddMatch(match)
@@ -129,7 +190,7 @@ func (t *Tailer) setup() error {
 		}
 	}
 
-	if len(config.IncludeSystemUnits) > 0 && len(config.IncludeUserUnits) > 0 {
+	if len(t.includedUnits(config.IncludeSystemUnits)) > 0 && len(t.includedUnits(config.IncludeUserUnits)) > 0 {
 		// add Logical OR if both System and User include filters are used.
 		err := t.journal.AddDisjunction()
 		if err != nil {
@@ -137,7 +198,7 @@ func (t *Tailer) setup() error {
 		}
 	}
 
-	for _, unit := range config.IncludeUserUnits {
+	for _, unit := range t.includedUnits(config.IncludeUserUnits) {
 		// add filters to collect only the logs of the user-level units defined in the configuration.
 		match := sdjournal.SD_JOURNAL_FIELD_SYSTEMD_USER_UNIT + "=" + unit
 		err := t.journal.AddMatch(match)
@@ -171,6 +232,7 @@ func (t *Tailer) setup() error {
 	}
 
 	t.exclude.matches = make(map[string]map[string]bool)
+	t.exclude.patterns = make(map[string][]*regexp.Regexp)
 	for _, match := range config.ExcludeMatches {
 		// add filters to drop all the logs related to the matches to exclude.
 		submatches := matchRe.FindStringSubmatch(match)
@@ -179,16 +241,31 @@ func (t *Tailer) setup() error {
 		}
 
 		key := submatches[1]
+		value := submatches[2]
+		if strings.Contains(value, "*") {
+			// values with wildcards match any sequence of characters in place of the '*'.
+			pattern := "^" + strings.ReplaceAll(regexp.QuoteMeta(value), `\*`, ".*") + "$"
+			t.exclude.patterns[key] = append(t.exclude.patterns[key], regexp.MustCompile(pattern))
+			continue
+		}
 		if t.exclude.matches[key] == nil {
 			t.exclude.matches[key] = map[string]bool{}
 		}
-		value := submatches[2]
 		t.exclude.matches[key][value] = true
 	}
 
 	return nil
 }
 
+// includedUnits returns the units to filter the journal on, none when the
+// tailer collects the logs of a single unit.
+func (t *Tailer) includedUnits(units []string) []string {
+	if t.unit != nil {
+		return nil
+	}
+	return units
+}
+
 func (t *Tailer) forwardMessages() {
 	defer func() {
 		// the decoder has successfully been flushed
@@ -197,6 +274,9 @@ func (t *Tailer) forwardMessages() {
 
 	for decodedMessage := range t.decoder.OutputChan {
 		if len(decodedMessage.GetContent()) > 0 {
+			if !t.throughputLimiter.Allow(t.limiterContext, len(decodedMessage.GetContent())) {
+				continue
+			}
 			t.outputChan <- decodedMessage
 		}
 	}
@@ -279,6 +359,7 @@ func (t *Tailer) tail() {
 				continue
 			}
 
+			hostname := t.getHostname(entry)
 			structuredContent, jsonMarshaled := t.getContent(entry)
 			var msg *message.Message
 			if t.processRawMessage {
@@ -296,6 +377,7 @@ func (t *Tailer) tail() {
 					time.Now().UnixNano(),
 				)
 			}
+			msg.Hostname = hostname
 
 			select {
 			case <-t.stop:
@@ -316,6 +398,15 @@ func (t *Tailer) shouldDrop(entry *sdjournal.JournalEntry) bool {
 			}
 		}
 	}
+	for key, patterns := range t.exclude.patterns {
+		if value, ok := entry.Fields[key]; ok {
+			for _, pattern := range patterns {
+				if pattern.MatchString(value) {
+					return true
+				}
+			}
+		}
+	}
 
 	sysUnit, exists := entry.Fields[sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT]
 	if !exists {
@@ -442,9 +533,23 @@ func (t *Tailer) getTags(entry *sdjournal.JournalEntry) []string {
 	if t.isContainerEntry(entry) {
 		tags = t.getContainerTags(t.getContainerID(entry))
 	}
+	for field, tagName := range t.source.Config.TagFields {
+		if value, exists := entry.Fields[field]; exists && value != "" {
+			tags = append(tags, tagName+":"+value)
+		}
+	}
 	return tags
 }
 
+// getHostname returns the hostname the entry is reported with, empty for the
+// hostname of the agent.
+func (t *Tailer) getHostname(entry *sdjournal.JournalEntry) string {
+	if !t.source.Config.UseEntryHostname {
+		return ""
+	}
+	return entry.Fields[sdjournal.SD_JOURNAL_FIELD_HOSTNAME]
+}
+
 // priorityStatusMapping represents the 1:1 mapping between journal entry priorities and statuses.
 var priorityStatusMapping = map[string]string{
 	"0": message.StatusEmergency,
@@ -477,6 +582,9 @@ const journaldIntegration = "journald"
 
 // Identifier returns the unique identifier of the current journal being tailed.
 func (t *Tailer) Identifier() string {
+	if t.unit != nil {
+		return UnitIdentifier(t.source.Config, *t.unit)
+	}
 	return Identifier(t.source.Config)
 }
 
@@ -491,6 +599,16 @@ func Identifier(config *config.LogsConfig) string {
 	return journaldIntegration + ":" + id
 }
 
+// UnitIdentifier returns the unique identifier of a unit of the current
+// journald config tailed with its own cursor.
+func UnitIdentifier(config *config.LogsConfig, unit Unit) string {
+	kind := "unit"
+	if unit.Field == sdjournal.SD_JOURNAL_FIELD_SYSTEMD_USER_UNIT {
+		kind = "user_unit"
+	}
+	return Identifier(config) + ":" + kind + "=" + unit.Name
+}
+
 // journalPath returns the path of the journal
 func (t *Tailer) journalPath() string {
 	if t.source.Config.Path != "" {
@@ -32,10 +32,12 @@ type MockJournal struct {
 	previous int
 	cursor   string
 	entries  []*sdjournal.JournalEntry
+	matches  []string
 }
 
 //nolint:revive // TODO(AML) Fix revive linter
 func (m *MockJournal) AddMatch(match string) error {
+	m.matches = append(m.matches, match)
 	return nil
 }
 
@@ -130,6 +132,71 @@ func TestIdentifier(t *testing.T) {
 	assert.Equal(t, "journald:any_path", tailer.Identifier())
 }
 
+func TestUnitTailer(t *testing.T) {
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+	source := sources.NewLogSource("", &config.LogsConfig{
+		IncludeSystemUnits: []string{"foo.service", "bar.service"},
+		IncludeUserUnits:   []string{"baz.service"},
+		IncludeMatches:     []string{"_TRANSPORT=journal"},
+		CursorPerUnit:      true,
+	})
+
+	units := Units(source.Config)
+	assert.Equal(t, []Unit{
+		{Field: sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT, Name: "foo.service"},
+		{Field: sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT, Name: "bar.service"},
+		{Field: sdjournal.SD_JOURNAL_FIELD_SYSTEMD_USER_UNIT, Name: "baz.service"},
+	}, units)
+
+	mockJournal := &MockJournal{m: &sync.Mutex{}}
+	tailer := NewUnitTailer(source, units[2], nil, mockJournal, true, fakeTagger)
+	assert.Equal(t, "journald:default:user_unit=baz.service", tailer.Identifier())
+	assert.NoError(t, tailer.setup())
+	assert.Equal(t, []string{"_SYSTEMD_USER_UNIT=baz.service", "_TRANSPORT=journal"}, mockJournal.matches)
+
+	// without a cursor per unit, the source has a single tailer
+	source.Config.CursorPerUnit = false
+	assert.Empty(t, Units(source.Config))
+}
+
+func TestShouldDropEntryWithWildcards(t *testing.T) {
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+	source := sources.NewLogSource("", &config.LogsConfig{ExcludeMatches: []string{"_COMM=cron*", "PRIORITY=7", "MESSAGE=*health check*"}})
+	tailer := NewTailer(source, nil, nil, true, fakeTagger)
+	assert.NoError(t, tailer.setup())
+
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "crond"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "cron"}}))
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"_COMM": "anacron"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"PRIORITY": "7"}}))
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"PRIORITY": "3"}}))
+	assert.True(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"MESSAGE": "GET /health check ok"}}))
+	assert.False(t, tailer.shouldDrop(&sdjournal.JournalEntry{Fields: map[string]string{"MESSAGE": "GET /api"}}))
+}
+
+func TestTailerTagFieldsAndEntryHostname(t *testing.T) {
+	mockJournal := &MockJournal{m: &sync.Mutex{}}
+	source := sources.NewLogSource("", &config.LogsConfig{
+		Path:             "/var/log/journal/remote",
+		TagFields:        map[string]string{"_COMM": "comm", "_MACHINE_ID": "machine_id"},
+		UseEntryHostname: true,
+	})
+	fakeTagger := taggerfxmock.SetupFakeTagger(t)
+	tailer := NewTailer(source, make(chan *message.Message, 1), mockJournal, false, fakeTagger)
+	mockJournal.entries = append(mockJournal.entries, &sdjournal.JournalEntry{Fields: map[string]string{
+		sdjournal.SD_JOURNAL_FIELD_MESSAGE:  "foobar",
+		sdjournal.SD_JOURNAL_FIELD_COMM:     "sshd",
+		sdjournal.SD_JOURNAL_FIELD_HOSTNAME: "remote-host-1",
+	}})
+
+	tailer.Start("")
+	defer tailer.Stop()
+
+	msg := <-tailer.outputChan
+	assert.Equal(t, "remote-host-1", msg.Hostname)
+	assert.Equal(t, []string{"comm:sshd"}, msg.Tags())
+}
+
 func TestShouldDropEntry(t *testing.T) {
 	// System-level service units do not have SD_JOURNAL_FIELD_SYSTEMD_USER_UNIT
 	// User-level service units may have a common value for SD_JOURNAL_FIELD_SYSTEMD_UNIT
@@ -7,6 +7,7 @@
 package socket
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -18,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
 	status "github.com/DataDog/datadog-agent/pkg/logs/status/utils"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -31,10 +33,20 @@ type Tailer struct {
 	decoder    *decoder.Decoder
 	stop       chan struct{}
 	done       chan struct{}
+
+	// throughputLimiter enforces the throughput limit of the source, it is nil
+	// if the source has no limit.
+	throughputLimiter *tailers.ThroughputLimiter
+
+	// limiterContext is cancelled when the tailer is stopped, so that a tailer
+	// paused by its throughput limit does not block Stop.
+	limiterContext context.Context
+	stopLimiter    context.CancelFunc
 }
 
 // NewTailer returns a new Tailer
 func NewTailer(source *sources.LogSource, conn net.Conn, outputChan chan *message.Message, read func(*Tailer) ([]byte, string, error)) *Tailer {
+	limiterContext, stopLimiter := context.WithCancel(context.Background())
 	return &Tailer{
 		source:     source,
 		Conn:       conn,
@@ -44,6 +56,10 @@ func NewTailer(source *sources.LogSource, conn net.Conn, outputChan chan *messag
 		decoder: decoder.InitializeDecoder(sources.NewReplaceableSource(source), noop.New(), status.NewInfoRegistry()),
 		stop:    make(chan struct{}, 1),
 		done:    make(chan struct{}, 1),
+
+		throughputLimiter: tailers.GetThroughputLimiter(source),
+		limiterContext:    limiterContext,
+		stopLimiter:       stopLimiter,
 	}
 }
 
@@ -58,6 +74,7 @@ func (t *Tailer) Start() {
 func (t *Tailer) Stop() {
 	t.stop <- struct{}{}
 	t.Conn.Close()
+	t.stopLimiter()
 	<-t.done
 }
 
@@ -69,9 +86,14 @@ func (t *Tailer) forwardMessages() {
 	}()
 	for output := range t.decoder.OutputChan {
 		if len(output.GetContent()) > 0 {
+			if !t.throughputLimiter.Allow(t.limiterContext, len(output.GetContent())) {
+				continue
+			}
 			origin := message.NewOrigin(t.source)
 			origin.SetTags(output.ParsingExtra.Tags)
-			t.outputChan <- message.NewMessage(output.GetContent(), origin, output.Status, output.IngestionTimestamp)
+			msg := message.NewMessage(output.GetContent(), origin, output.Status, output.IngestionTimestamp)
+			msg.EventTimestamp = output.EventTimestamp
+			t.outputChan <- msg
 		}
 	}
 }
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -41,6 +42,32 @@ func TestReadAndForwardShouldSucceedWithSuccessfulRead(t *testing.T) {
 	tailer.Stop()
 }
 
+func TestStopWhilePausedByThroughputLimit(t *testing.T) {
+	msgChan := make(chan *message.Message, 10)
+	r, w := net.Pipe()
+	source := sources.NewLogSource("", &config.LogsConfig{
+		ThroughputLimit: &config.ThroughputLimit{LinesPerSecond: 1, Policy: config.PausePolicy},
+	})
+	tailer := NewTailer(source, r, msgChan, read)
+	tailer.Start()
+
+	// the second line waits for the limit to refill
+	w.Write([]byte("foo\nbar\n"))
+	msg := <-msgChan
+	assert.Equal(t, "foo", string(msg.GetContent()))
+
+	stopped := make(chan struct{})
+	go func() {
+		tailer.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(500 * time.Millisecond):
+		assert.Fail(t, "Stop blocked on the throughput limit")
+	}
+}
+
 func TestReadShouldFailWithError(t *testing.T) {
 	msgChan := make(chan *message.Message)
 	r, w := net.Pipe()
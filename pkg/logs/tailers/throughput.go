@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tailers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/metrics"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+// throughputLimiterInfoKey is the key of the throughput limiters in the info
+// registry of their source.
+const throughputLimiterInfoKey = "Throughput Limit"
+
+// throughputLimitersLock guards the creation of the throughput limiters, so
+// that all the tailers of a source share the same limiter.
+var throughputLimitersLock sync.Mutex
+
+// ThroughputLimiter enforces the throughput limit of a log source.  It is
+// shared by all the tailers of the source, and is displayed along with the
+// source on the status page.
+//
+// A nil ThroughputLimiter does not limit anything.
+type ThroughputLimiter struct {
+	limit        *config.ThroughputLimit
+	sourceName   string
+	bytes        *rate.Limiter
+	lines        *rate.Limiter
+	droppedLines *atomic.Int64
+	droppedBytes *atomic.Int64
+	pausedFor    *atomic.Duration
+}
+
+// GetThroughputLimiter returns the throughput limiter of the source, or nil if
+// no throughput limit is configured for it.
+func GetThroughputLimiter(source *sources.LogSource) *ThroughputLimiter {
+	if source == nil || !source.Config.ThroughputLimit.IsEnabled() {
+		return nil
+	}
+
+	throughputLimitersLock.Lock()
+	defer throughputLimitersLock.Unlock()
+	if limiter, ok := source.GetInfo(throughputLimiterInfoKey).(*ThroughputLimiter); ok {
+		return limiter
+	}
+	limiter := newThroughputLimiter(source.Name, source.Config.ThroughputLimit)
+	source.RegisterInfo(limiter)
+	return limiter
+}
+
+func newThroughputLimiter(sourceName string, limit *config.ThroughputLimit) *ThroughputLimiter {
+	l := &ThroughputLimiter{
+		limit:        limit,
+		sourceName:   sourceName,
+		droppedLines: atomic.NewInt64(0),
+		droppedBytes: atomic.NewInt64(0),
+		pausedFor:    atomic.NewDuration(0),
+	}
+	// allow bursts of up to one second worth of logs
+	if limit.BytesPerSecond > 0 {
+		l.bytes = rate.NewLimiter(rate.Limit(limit.BytesPerSecond), limit.BytesPerSecond)
+	}
+	if limit.LinesPerSecond > 0 {
+		l.lines = rate.NewLimiter(rate.Limit(limit.LinesPerSecond), limit.LinesPerSecond)
+	}
+	return l
+}
+
+// Allow returns whether a log of `size` bytes can be forwarded.  Depending on
+// the policy of the source, logs exceeding the limit are either dropped, or
+// Allow blocks until the log fits in the limit, which pauses the tailer.  When
+// paused, Allow returns false if ctx is done before the log can be forwarded.
+func (l *ThroughputLimiter) Allow(ctx context.Context, size int) bool {
+	if l == nil {
+		return true
+	}
+	// logs larger than a burst only consume a full burst
+	bytes := size
+	if l.bytes != nil && bytes > l.bytes.Burst() {
+		bytes = l.bytes.Burst()
+	}
+
+	if l.limit.ShouldPause() {
+		return l.wait(ctx, bytes)
+	}
+
+	now := time.Now()
+	var reservations []*rate.Reservation
+	if l.lines != nil {
+		reservations = append(reservations, l.lines.ReserveN(now, 1))
+	}
+	if l.bytes != nil {
+		reservations = append(reservations, l.bytes.ReserveN(now, bytes))
+	}
+	for _, r := range reservations {
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			l.droppedLines.Inc()
+			l.droppedBytes.Add(int64(size))
+			metrics.TlmThroughputLimitDropped.Inc(l.sourceName)
+			metrics.TlmThroughputLimitDroppedBytes.Add(float64(size), l.sourceName)
+			return false
+		}
+	}
+	return true
+}
+
+// wait blocks until a log of `bytes` bytes fits in the limit.
+func (l *ThroughputLimiter) wait(ctx context.Context, bytes int) bool {
+	start := time.Now()
+	defer func() {
+		if paused := time.Since(start); paused >= time.Millisecond {
+			l.pausedFor.Add(paused)
+			metrics.TlmThroughputLimitPaused.Add(paused.Seconds(), l.sourceName)
+		}
+	}()
+	if l.lines != nil && l.lines.Wait(ctx) != nil {
+		return false
+	}
+	if l.bytes != nil && l.bytes.WaitN(ctx, bytes) != nil {
+		return false
+	}
+	return true
+}
+
+// InfoKey returns the key of the limiter on the status page.
+func (l *ThroughputLimiter) InfoKey() string {
+	return throughputLimiterInfoKey
+}
+
+// Info returns the limits and their effect, for the status page.
+func (l *ThroughputLimiter) Info() []string {
+	limits := []string{}
+	if l.limit.LinesPerSecond > 0 {
+		limits = append(limits, fmt.Sprintf("%d lines/s", l.limit.LinesPerSecond))
+	}
+	if l.limit.BytesPerSecond > 0 {
+		limits = append(limits, fmt.Sprintf("%d bytes/s", l.limit.BytesPerSecond))
+	}
+
+	if l.limit.ShouldPause() {
+		return []string{
+			fmt.Sprintf("Limit: %s, paused when exceeded", strings.Join(limits, ", ")),
+			fmt.Sprintf("Paused for: %s", l.pausedFor.Load().Round(time.Millisecond)),
+		}
+	}
+	return []string{
+		fmt.Sprintf("Limit: %s, dropped when exceeded", strings.Join(limits, ", ")),
+		fmt.Sprintf("Dropped: %d logs (%d bytes)", l.droppedLines.Load(), l.droppedBytes.Load()),
+	}
+}
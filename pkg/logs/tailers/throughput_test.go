@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tailers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/logs/agent/config"
+	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+)
+
+func TestGetThroughputLimiter(t *testing.T) {
+	unlimited := sources.NewLogSource("unlimited", &config.LogsConfig{Type: config.FileType})
+	assert.Nil(t, GetThroughputLimiter(unlimited))
+	assert.True(t, GetThroughputLimiter(unlimited).Allow(context.Background(), 1<<20))
+
+	limited := sources.NewLogSource("limited", &config.LogsConfig{
+		Type:            config.FileType,
+		ThroughputLimit: &config.ThroughputLimit{LinesPerSecond: 10},
+	})
+	limiter := GetThroughputLimiter(limited)
+	assert.NotNil(t, limiter)
+	// all the tailers of a source share the same limiter
+	assert.Same(t, limiter, GetThroughputLimiter(limited))
+	assert.Contains(t, limited.GetInfoStatus(), throughputLimiterInfoKey)
+}
+
+func TestThroughputLimiterDropsLines(t *testing.T) {
+	limiter := newThroughputLimiter("test", &config.ThroughputLimit{LinesPerSecond: 2, Policy: config.DropPolicy})
+
+	assert.True(t, limiter.Allow(context.Background(), 10))
+	assert.True(t, limiter.Allow(context.Background(), 10))
+	assert.False(t, limiter.Allow(context.Background(), 10))
+	assert.Equal(t, []string{"Limit: 2 lines/s, dropped when exceeded", "Dropped: 1 logs (10 bytes)"}, limiter.Info())
+}
+
+func TestThroughputLimiterDropsBytes(t *testing.T) {
+	limiter := newThroughputLimiter("test", &config.ThroughputLimit{BytesPerSecond: 100, LinesPerSecond: 100})
+
+	assert.True(t, limiter.Allow(context.Background(), 60))
+	assert.False(t, limiter.Allow(context.Background(), 60))
+	// a dropped log does not consume the line limit
+	assert.True(t, limiter.Allow(context.Background(), 40))
+	// logs larger than the limit consume the whole burst
+	limiter = newThroughputLimiter("test", &config.ThroughputLimit{BytesPerSecond: 100})
+	assert.True(t, limiter.Allow(context.Background(), 1000))
+	assert.False(t, limiter.Allow(context.Background(), 1))
+}
+
+func TestThroughputLimiterPauses(t *testing.T) {
+	limiter := newThroughputLimiter("test", &config.ThroughputLimit{LinesPerSecond: 20, Policy: config.PausePolicy})
+
+	start := time.Now()
+	for i := 0; i < 25; i++ {
+		assert.True(t, limiter.Allow(context.Background(), 10))
+	}
+	// the burst is consumed by the 20 first lines, the 5 others take 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+	assert.Greater(t, limiter.pausedFor.Load(), time.Duration(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.False(t, limiter.Allow(ctx, 10))
+}
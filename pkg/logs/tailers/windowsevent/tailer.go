@@ -21,6 +21,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/message"
 	"github.com/DataDog/datadog-agent/pkg/logs/processor"
 	"github.com/DataDog/datadog-agent/pkg/logs/sources"
+	"github.com/DataDog/datadog-agent/pkg/logs/tailers"
 	"github.com/DataDog/datadog-agent/pkg/logs/util/windowsevent"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -46,6 +47,10 @@ type Tailer struct {
 	decoder    *decoder.Decoder
 	outputChan chan *message.Message
 
+	// throughputLimiter enforces the throughput limit of the source, it is nil
+	// if the source has no limit.
+	throughputLimiter *tailers.ThroughputLimiter
+
 	cancelTail context.CancelFunc
 	doneTail   chan struct{}
 	done       chan struct{}
@@ -71,6 +76,8 @@ func NewTailer(evtapi evtapi.API, source *sources.LogSource, config *Config, out
 		config:     config,
 		decoder:    decoder.NewNoopDecoder(),
 		outputChan: outputChan,
+
+		throughputLimiter: tailers.GetThroughputLimiter(source),
 	}
 }
 
@@ -95,7 +102,7 @@ func (t *Tailer) Start(bookmark string) {
 	t.done = make(chan struct{})
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	t.cancelTail = ctxCancel
-	go t.forwardMessages()
+	go t.forwardMessages(ctx)
 	t.decoder.Start()
 	go t.tail(ctx, bookmark)
 }
@@ -113,7 +120,9 @@ func (t *Tailer) Stop() {
 	<-t.done
 }
 
-func (t *Tailer) forwardMessages() {
+// forwardMessages forwards the decoded messages to the output channel.  A
+// tailer paused by its throughput limit is resumed once ctx is done.
+func (t *Tailer) forwardMessages(ctx context.Context) {
 	defer func() {
 		// the decoder has successfully been flushed
 		close(t.done)
@@ -121,6 +130,9 @@ func (t *Tailer) forwardMessages() {
 
 	for decodedMessage := range t.decoder.OutputChan {
 		if len(decodedMessage.GetContent()) > 0 {
+			if !t.throughputLimiter.Allow(ctx, len(decodedMessage.GetContent())) {
+				continue
+			}
 			t.outputChan <- decodedMessage
 		}
 	}
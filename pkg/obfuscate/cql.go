@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// ObfuscateCQLString quantizes and obfuscates the given Cassandra CQL query. CQL is
+// close enough to SQL to go through the SQL tokenizer, which additionally recognizes
+// the CQL constants (UUIDs, durations, NaN and Infinity) and collection literals when
+// the DBMS is Cassandra.
+func (o *Obfuscator) ObfuscateCQLString(in string) (*ObfuscatedQuery, error) {
+	opts := o.opts.SQL
+	opts.DBMS = DBMSCassandra
+	// go-sqllexer has no CQL dialect, the CQL queries always use the tokenizer.
+	opts.ObfuscationMode = ""
+	return o.ObfuscateSQLStringWithOptions(in, &opts)
+}
+
+// cqlDurationUnits holds the units of CQL duration literals, the longest first.
+var cqlDurationUnits = [][]byte{
+	[]byte("mo"), []byte("ms"), []byte("us"), []byte("µs"), []byte("ns"),
+	[]byte("y"), []byte("w"), []byte("d"), []byte("h"), []byte("m"), []byte("s"),
+}
+
+// cqlConstantLen returns the byte length of the CQL constant starting at the
+// current character, or 0 if there is none. It only looks for the constants
+// that the SQL tokenizer doesn't know about.
+func (tkn *SQLTokenizer) cqlConstantLen() int {
+	if tkn.lastChar >= utf8.RuneSelf {
+		// also covers EndChar
+		return 0
+	}
+	b := tkn.buf[tkn.off-1:]
+	n := cqlUUIDLen(b)
+	if n == 0 {
+		n = cqlDurationLen(b)
+	}
+	if n == 0 {
+		n = cqlFloatNameLen(b)
+	}
+	if n == 0 {
+		return 0
+	}
+	if r, _ := utf8.DecodeRune(b[n:]); isLetter(r) || isDigit(r) {
+		// the constant is only the prefix of an identifier
+		return 0
+	}
+	return n
+}
+
+// scanCQLConstant scans the n bytes long CQL constant starting at the current character.
+func (tkn *SQLTokenizer) scanCQLConstant(n int) (TokenKind, []byte) {
+	for end := tkn.off - 1 + n; tkn.off <= end && tkn.lastChar != EndChar; {
+		tkn.advance()
+	}
+	return Number, tkn.bytes()
+}
+
+// scanCQLCollection scans a CQL set, map or user-defined type literal, the opening
+// curly brace having already been consumed. Nested literals and strings are skipped.
+func (tkn *SQLTokenizer) scanCQLCollection() (TokenKind, []byte) {
+	depth := 1
+	var quote rune
+	for ; depth > 0; tkn.advance() {
+		switch ch := tkn.lastChar; {
+		case ch == EndChar:
+			tkn.setErr("unexpected EOF in collection literal")
+			return LexError, tkn.bytes()
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+		}
+	}
+	return EscapeSequence, tkn.bytes()
+}
+
+// cqlUUIDLen returns the length of the UUID at the start of b, 0 if there is none.
+func cqlUUIDLen(b []byte) int {
+	const n = 36
+	if len(b) < n {
+		return 0
+	}
+	for i := 0; i < n; i++ {
+		switch i {
+		case 8, 13, 18, 23:
+			if b[i] != '-' {
+				return 0
+			}
+		default:
+			if digitVal(rune(b[i])) >= 16 {
+				return 0
+			}
+		}
+	}
+	return n
+}
+
+// cqlDurationLen returns the length of the duration literal (e.g. 1h30m) at the
+// start of b, 0 if there is none.
+func cqlDurationLen(b []byte) int {
+	n := 0
+	for {
+		digits := 0
+		for n+digits < len(b) && isDigit(rune(b[n+digits])) {
+			digits++
+		}
+		if digits == 0 {
+			return n
+		}
+		unit := 0
+		for _, u := range cqlDurationUnits {
+			if bytes.HasPrefix(b[n+digits:], u) {
+				unit = len(u)
+				break
+			}
+		}
+		if unit == 0 {
+			return n
+		}
+		n += digits + unit
+	}
+}
+
+// cqlFloatNameLen returns the length of the NaN or Infinity float constant at the
+// start of b, 0 if there is none.
+func cqlFloatNameLen(b []byte) int {
+	for _, name := range []string{"NaN", "Infinity"} {
+		if len(b) >= len(name) && bytes.EqualFold(b[:len(name)], []byte(name)) {
+			return len(name)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,77 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObfuscateCQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			"INSERT INTO users (id, name, emails) VALUES (123e4567-e89b-12d3-a456-426614174000, 'bob', {'a@b.c', 'd@e.f'}) USING TTL 86400",
+			"INSERT INTO users ( id, name, emails ) VALUES ( ? ) USING TTL ?",
+		},
+		{
+			"SELECT * FROM ks.users WHERE id = 550E8400-E29B-41D4-A716-446655440000",
+			"SELECT * FROM ks.users WHERE id = ?",
+		},
+		{
+			"UPDATE users SET props = {'k': {1, 2}, 'l': 'a}b'} WHERE id = 5 IF EXISTS",
+			"UPDATE users SET props = ? WHERE id = ? IF EXISTS",
+		},
+		{
+			"UPDATE users SET tags = tags + ['x', 'y'] WHERE id = 5",
+			"UPDATE users SET tags = tags + [ ? ] WHERE id = ?",
+		},
+		{
+			"SELECT * FROM events WHERE duration > 1h30m AND window < 2mo AND latency < 250ms",
+			"SELECT * FROM events WHERE duration > ? AND window < ? AND latency < ?",
+		},
+		{
+			"SELECT * FROM metrics WHERE value != NaN AND ratio < -Infinity AND blob = 0xCAFEBABE",
+			"SELECT * FROM metrics WHERE value != ? AND ratio < - ? AND blob = ?",
+		},
+		{
+			"SELECT * FROM t WHERE id IN ? AND k = :name AND nancy = 1 LIMIT 10 ALLOW FILTERING",
+			"SELECT * FROM t WHERE id IN ? AND k = :name AND nancy = ? LIMIT ? ALLOW FILTERING",
+		},
+		{
+			"BEGIN BATCH INSERT INTO t (a) VALUES (1); UPDATE t SET b = 2 WHERE a = 1; APPLY BATCH",
+			"BEGIN BATCH INSERT INTO t ( a ) VALUES ( ? ) UPDATE t SET b = ? WHERE a = ? APPLY BATCH",
+		},
+		{
+			"select key, status from org_check_run where org_id = %s and check in (%s, %s, %s)",
+			"select key, status from org_check_run where org_id = ? and check in ( ? )",
+		},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			o := NewObfuscator(Config{})
+			oq, err := o.ObfuscateCQLString(tt.in)
+			require.NoError(t, err)
+			assert.Equal(t, tt.out, oq.Query)
+		})
+	}
+
+	t.Run("unterminated collection", func(t *testing.T) {
+		o := NewObfuscator(Config{})
+		_, err := o.ObfuscateCQLString("INSERT INTO t (s) VALUES ({'a', 'b')")
+		assert.Error(t, err)
+	})
+
+	t.Run("table names", func(t *testing.T) {
+		o := NewObfuscator(Config{SQL: SQLConfig{TableNames: true, ObfuscationMode: ObfuscateOnly}})
+		oq, err := o.ObfuscateCQLString("SELECT * FROM ks.users WHERE id = 550e8400-e29b-41d4-a716-446655440000")
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM ks.users WHERE id = ?", oq.Query)
+		assert.Equal(t, "ks.users", oq.Metadata.TablesCSV)
+	})
+}
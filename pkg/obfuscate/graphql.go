@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+)
+
+// ObfuscateGraphQLString obfuscates the given GraphQL query by replacing its literal
+// values (strings, numbers and booleans passed as arguments, variable default values
+// or directive arguments) with "?". Comments are removed, while the variables, the
+// enum values and the structure of the query are kept.
+func (o *Obfuscator) ObfuscateGraphQLString(query string) string {
+	var (
+		out    strings.Builder
+		parens int // depth of parentheses, where the arguments are
+	)
+	out.Grow(len(query))
+	for i := 0; i < len(query); {
+		ch := query[i]
+		switch {
+		case ch == '#':
+			// comment, up to the end of the line
+			for i < len(query) && query[i] != '\n' && query[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(query[i:], `"""`):
+			i = graphQLBlockStringEnd(query, i+3)
+			out.WriteByte('?')
+		case ch == '"':
+			i = graphQLStringEnd(query, i+1)
+			out.WriteByte('?')
+		case isDigit(rune(ch)) || (ch == '-' && i+1 < len(query) && isDigit(rune(query[i+1]))):
+			i = graphQLNumberEnd(query, i+1)
+			out.WriteByte('?')
+		case isGraphQLNameStart(ch):
+			start := i
+			for i < len(query) && isGraphQLNameChar(query[i]) {
+				i++
+			}
+			name := query[start:i]
+			if parens > 0 && (name == "true" || name == "false") && !graphQLNextIs(query, i, ':') && !graphQLPrevIs(query, start, '$') {
+				out.WriteByte('?')
+			} else {
+				out.WriteString(name)
+			}
+		default:
+			switch ch {
+			case '(':
+				parens++
+			case ')':
+				if parens > 0 {
+					parens--
+				}
+			}
+			out.WriteByte(ch)
+			i++
+		}
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// graphQLStringEnd returns the index following the end of the string starting at i,
+// right after its opening quote.
+func graphQLStringEnd(query string, i int) int {
+	for i < len(query) {
+		switch query[i] {
+		case '\\':
+			i += 2
+		case '"':
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+// graphQLBlockStringEnd returns the index following the end of the block string
+// starting at i, right after its opening triple quote.
+func graphQLBlockStringEnd(query string, i int) int {
+	for i < len(query) {
+		switch {
+		case strings.HasPrefix(query[i:], `\"""`):
+			i += 4
+		case strings.HasPrefix(query[i:], `"""`):
+			return i + 3
+		default:
+			i++
+		}
+	}
+	return len(query)
+}
+
+// graphQLNumberEnd returns the index following the end of the int or float value
+// whose first character is right before i.
+func graphQLNumberEnd(query string, i int) int {
+	for i < len(query) && (isDigit(rune(query[i])) || strings.IndexByte(".eE", query[i]) >= 0 ||
+		((query[i] == '+' || query[i] == '-') && (query[i-1] == 'e' || query[i-1] == 'E'))) {
+		i++
+	}
+	return i
+}
+
+// graphQLNextIs reports whether the first character following i, ignoring the
+// blanks, is ch.
+func graphQLNextIs(query string, i int, ch byte) bool {
+	for i < len(query) && isGraphQLIgnored(query[i]) {
+		i++
+	}
+	return i < len(query) && query[i] == ch
+}
+
+// graphQLPrevIs reports whether the first character preceding i, ignoring the
+// blanks, is ch.
+func graphQLPrevIs(query string, i int, ch byte) bool {
+	for i > 0 && isGraphQLIgnored(query[i-1]) {
+		i--
+	}
+	return i > 0 && query[i-1] == ch
+}
+
+// isGraphQLIgnored reports whether ch is one of the insignificant characters of
+// GraphQL. Commas are insignificant too.
+func isGraphQLIgnored(ch byte) bool {
+	switch ch {
+	case ' ', '\t', '\n', '\r', ',':
+		return true
+	}
+	return false
+}
+
+func isGraphQLNameStart(ch byte) bool {
+	return ch == '_' || ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+func isGraphQLNameChar(ch byte) bool {
+	return isGraphQLNameStart(ch) || isDigit(rune(ch))
+}
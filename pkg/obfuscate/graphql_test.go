@@ -0,0 +1,52 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQL(t *testing.T) {
+	for _, tt := range []struct {
+		in, out string
+	}{
+		{
+			`query { user(id: "5", email: "bob@example.com") { name } }`,
+			`query { user(id: ?, email: ?) { name } }`,
+		},
+		{
+			`query GetUsers($first: Int = 10, $active: Boolean = true) { users(first: $first, active: $active, role: ADMIN) { id } }`,
+			`query GetUsers($first: Int = ?, $active: Boolean = ?) { users(first: $first, active: $active, role: ADMIN) { id } }`,
+		},
+		{
+			`mutation { createUser(input: {name: "Bob", age: 42, score: -1.5e3, tags: ["a", "b"], admin: false, manager: null}) { id } }`,
+			`mutation { createUser(input: {name: ?, age: ?, score: ?, tags: [?, ?], admin: ?, manager: null}) { id } }`,
+		},
+		{
+			"query {\n  # fetch user 5\n  user(bio: \"\"\"multi \\\"\"\" \"quoted\"\n line\"\"\") { true: name @include(if: true) }\n}",
+			"query {\n  \n  user(bio: ?) { true: name @include(if: ?) }\n}",
+		},
+		{
+			`{ search(text: "escaped \" quote", first: 3) { edges { node { id } } } }`,
+			`{ search(text: ?, first: ?) { edges { node { id } } } }`,
+		},
+		{
+			`query Q($true: Boolean) { a(true: $true) { b1 } }`,
+			`query Q($true: Boolean) { a(true: $true) { b1 } }`,
+		},
+		{
+			`{ unterminated(text: "abc`,
+			`{ unterminated(text: ?`,
+		},
+	} {
+		t.Run(tt.in, func(t *testing.T) {
+			o := NewObfuscator(Config{})
+			assert.Equal(t, tt.out, o.ObfuscateGraphQLString(tt.in))
+		})
+	}
+}
@@ -27,6 +27,35 @@ func (o *Obfuscator) ObfuscateOpenSearchString(cmd string) string {
 	return obfuscateJSONString(cmd, o.openSearch)
 }
 
+// ObfuscateDynamoDBString obfuscates the given DynamoDB JSON request.
+func (o *Obfuscator) ObfuscateDynamoDBString(cmd string) string {
+	return obfuscateJSONString(cmd, o.dynamoDB)
+}
+
+// dynamoDBKeepValues holds the keys of the DynamoDB requests whose values never
+// hold items: the names of the tables, indexes and attributes, the expressions,
+// whose values are passed as placeholders, and the request options.
+var dynamoDBKeepValues = []string{
+	"TableName",
+	"IndexName",
+	"KeyConditionExpression",
+	"FilterExpression",
+	"ProjectionExpression",
+	"UpdateExpression",
+	"ConditionExpression",
+	"ExpressionAttributeNames",
+	"AttributesToGet",
+	"Select",
+	"Limit",
+	"ConsistentRead",
+	"ScanIndexForward",
+	"ReturnValues",
+	"ReturnConsumedCapacity",
+	"ReturnItemCollectionMetrics",
+	"Segment",
+	"TotalSegments",
+}
+
 // obfuscateJSONString obfuscates the given span's tag using the given obfuscator. If the obfuscator is
 // nil it is considered disabled.
 func obfuscateJSONString(cmd string, obfuscator *jsonObfuscator) string {
@@ -190,3 +190,16 @@ func BenchmarkObfuscateJSONConcurrently(b *testing.B) {
 		close(rs)
 	}
 }
+
+func TestObfuscateDynamoDB(t *testing.T) {
+	in := `{"TableName": "users", "IndexName": "by_email", "KeyConditionExpression": "#e = :email", "ExpressionAttributeNames": {"#e": "email"}, "ExpressionAttributeValues": {":email": {"S": "bob@example.com"}}, "Limit": 10, "ExclusiveStartKey": {"id": {"N": "42"}}, "Tenant": "acme"}`
+
+	o := NewObfuscator(Config{DynamoDB: JSONConfig{Enabled: true, KeepValues: []string{"Tenant"}}})
+	assert.Equal(t,
+		`{"TableName":"users","IndexName":"by_email","KeyConditionExpression":"#e = :email","ExpressionAttributeNames":{"#e":"email"},"ExpressionAttributeValues":{":email":{"S":"?"}},"Limit":10,"ExclusiveStartKey":{"id":{"N":"?"}},"Tenant":"acme"}`,
+		o.ObfuscateDynamoDBString(in),
+	)
+
+	o = NewObfuscator(Config{})
+	assert.Equal(t, in, o.ObfuscateDynamoDBString(in), "disabled")
+}
@@ -33,6 +33,7 @@ type Obfuscator struct {
 	mongo                *jsonObfuscator // nil if disabled
 	sqlExecPlan          *jsonObfuscator // nil if disabled
 	sqlExecPlanNormalize *jsonObfuscator // nil if disabled
+	dynamoDB             *jsonObfuscator // nil if disabled
 	ccObfuscator         *creditCard     // nil if disabled
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
 	// Different SQL engines behave in different ways and the tokenizer needs to be generic.
@@ -104,6 +105,13 @@ type Config struct {
 	// Memcached holds the obfuscation settings for obfuscation of CC numbers in meta.
 	CreditCard CreditCardsConfig `mapstructure:"credit_cards"`
 
+	// GraphQL holds the obfuscation settings for GraphQL queries.
+	GraphQL GraphQLConfig `mapstructure:"graphql"`
+
+	// DynamoDB holds the obfuscation configuration for DynamoDB request bodies.
+	// The keys naming tables, indexes, attributes and expressions are always kept.
+	DynamoDB JSONConfig `mapstructure:"dynamodb"`
+
 	// Statsd specifies the statsd client to use for reporting metrics.
 	Statsd StatsClient
 
@@ -150,6 +158,12 @@ type SQLConfig struct {
 	// CollectProcedures specifies whether the obfuscator should extract and return procedure names as SQL metadata when obfuscating.
 	CollectProcedures bool `json:"collect_procedures" yaml:"collect_procedures"`
 
+	// Fingerprint specifies whether the obfuscator should compute and return a fingerprint of the
+	// obfuscated query as SQL metadata. The fingerprint is the same for queries differing only by
+	// their whitespace, their letter case or the number of values of their lists. It is not computed
+	// in the NormalizeOnly mode, which does not obfuscate the query.
+	Fingerprint bool `json:"fingerprint" yaml:"fingerprint"`
+
 	// ReplaceDigits specifies whether digits in table names and identifiers should be obfuscated.
 	ReplaceDigits bool `json:"replace_digits" yaml:"replace_digits"`
 
@@ -218,6 +232,8 @@ type SQLMetadata struct {
 	Comments []string `json:"comments"`
 	// Procedures holds procedure names in an SQL statement.
 	Procedures []string `json:"procedures"`
+	// Fingerprint holds the hexadecimal hash identifying the normalized query.
+	Fingerprint string `json:"fingerprint"`
 }
 
 // HTTPConfig holds the configuration settings for HTTP obfuscation.
@@ -259,6 +275,12 @@ type MemcachedConfig struct {
 	KeepCommand bool `mapstructure:"keep_command"`
 }
 
+// GraphQLConfig holds the configuration settings for GraphQL obfuscation
+type GraphQLConfig struct {
+	// Enabled specifies whether this feature should be enabled.
+	Enabled bool `mapstructure:"enabled"`
+}
+
 // JSONConfig holds the obfuscation configuration for sensitive
 // data found in JSON objects.
 type JSONConfig struct {
@@ -325,6 +347,12 @@ func NewObfuscator(cfg Config) *Obfuscator {
 	if cfg.SQLExecPlanNormalize.Enabled {
 		o.sqlExecPlanNormalize = newJSONObfuscator(&cfg.SQLExecPlanNormalize, &o)
 	}
+	if cfg.DynamoDB.Enabled {
+		o.dynamoDB = newJSONObfuscator(&JSONConfig{
+			KeepValues:         append(append([]string{}, dynamoDBKeepValues...), cfg.DynamoDB.KeepValues...),
+			ObfuscateSQLValues: cfg.DynamoDB.ObfuscateSQLValues,
+		}, &o)
+	}
 	if cfg.CreditCard.Enabled {
 		o.ccObfuscator = newCCObfuscator(&cfg.CreditCard)
 	}
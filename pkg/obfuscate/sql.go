@@ -24,6 +24,7 @@ type metadataFinderFilter struct {
 	collectTableNames bool
 	collectCommands   bool
 	collectComments   bool
+	collectProcedures bool
 	replaceDigits     bool
 
 	// size holds the byte size of the metadata collected by the filter.
@@ -36,8 +37,53 @@ type metadataFinderFilter struct {
 	commands []string
 	// comments keeps track of comments encountered by the filter.
 	comments []string
+	// procedures keeps track of unique procedure names encountered by the filter.
+	procedures []string
+
+	// expect holds the kind of object named by the next identifier, if any.
+	expect sqlObjectKind
+	// expectList reports whether the expected table starts a list of tables (FROM a, b).
+	expectList bool
+	// name holds the possibly qualified name being read (e.g. schema.table) of an object of kind nameKind.
+	name     []byte
+	nameKind sqlObjectKind
+	nameList bool
+	// nameDot reports whether name ends with a dot, and thus continues with the next identifier.
+	nameDot bool
+	// bracket reports whether name continues with an MSSQL bracketed identifier, e.g. [dbo].
+	bracket bool
+	// tableList is 1 right after a table of a list, 2 after its alias; a comma then introduces
+	// another table.
+	tableList int
+	// ddl reports whether the statement creates, alters or drops an object.
+	ddl bool
+	// usingTables reports whether USING introduces a table (DELETE ... USING, MERGE ... USING).
+	usingTables bool
+	// block reports whether we are in an anonymous block (BEGIN ... END) calling procedures.
+	block bool
+	// depth holds the depth of parentheses.
+	depth int
+	// with reports whether we are in the WITH clause defining the common table expressions,
+	// at depth withDepth. cte holds the name of the one being defined.
+	with      bool
+	withDepth int
+	cte       string
+	// ctes holds the lower-cased names of the common table expressions, which aren't tables.
+	ctes map[string]struct{}
 }
 
+// sqlObjectKind specifies the kind of database object an identifier names.
+type sqlObjectKind uint8
+
+const (
+	noObject sqlObjectKind = iota
+	tableObject
+	procedureObject
+	// blockObject is a procedure called by an anonymous block, e.g. BEGIN pkg.proc(?); END;
+	// known as such only when followed by its arguments.
+	blockObject
+)
+
 func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (TokenKind, []byte, error) {
 	if f.collectComments && token == Comment {
 		// A comment with line-breaks will be brought to a single line.
@@ -53,34 +99,262 @@ func (f *metadataFinderFilter) Filter(token, lastToken TokenKind, buffer []byte)
 			f.commands = append(f.commands, command)
 		}
 	}
-	if f.collectTableNames {
-		switch lastToken {
-		case From, Join:
-			// SELECT ... FROM [tableName]
-			// DELETE FROM [tableName]
-			// ... JOIN [tableName]
-			if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) {
-				// first character in buffer is not a letter; we might have a nested
-				// query like SELECT * FROM (SELECT ...)
-				break
-			}
-			fallthrough
-		case Update, Into:
-			// UPDATE [tableName]
-			// INSERT INTO [tableName]
-			tableName := string(buffer)
-			if f.replaceDigits {
-				tableNameCopy := make([]byte, len(buffer))
-				copy(tableNameCopy, buffer)
-				tableName = string(replaceDigits(tableNameCopy))
-			}
-			f.storeTableName(tableName)
-			return TableName, buffer, nil
-		}
+	if f.collectTableNames || f.collectProcedures {
+		return f.findObjects(token, lastToken, buffer), buffer, nil
 	}
 	return token, buffer, nil
 }
 
+// findObjects looks for the names of the tables and procedures the query addresses and
+// returns the kind of the given token, TableName if it is part of a table name.
+func (f *metadataFinderFilter) findObjects(token, lastToken TokenKind, buffer []byte) TokenKind {
+	if f.nameKind != noObject {
+		if kind, ok := f.continueName(token, buffer); ok {
+			return kind
+		}
+		f.endName(token)
+	}
+	if f.expect != noObject {
+		if kind, ok := f.startName(token, buffer); ok {
+			return kind
+		}
+	}
+	f.findCTE(token, lastToken, buffer)
+
+	listed := f.tableList
+	f.tableList = 0
+	switch {
+	case listed == 1 && token == As:
+		f.tableList = 1
+	case listed == 1 && isNamePart(token, buffer) && !isClauseWord(buffer):
+		// the alias of the table
+		f.tableList = 2
+	case listed > 0 && token == ',':
+		f.expect, f.expectList = tableObject, true
+		return token
+	}
+
+	switch token {
+	case From, Join, Into:
+		// SELECT ... FROM [tableName]
+		// DELETE FROM [tableName]
+		// ... JOIN [tableName]
+		// INSERT INTO [tableName]
+		f.expect, f.expectList = tableObject, token == From
+	case Truncate:
+		// TRUNCATE [TABLE] [tableName]
+		f.expect, f.expectList = tableObject, true
+		f.ddl = true
+	case Update:
+		// UPDATE [tableName]
+		f.expect, f.expectList = tableObject, true
+	case Delete:
+		// DELETE [tableName], as allowed by Oracle
+		f.expect, f.expectList = tableObject, false
+		f.usingTables = true
+	case Create, Alter, Drop:
+		f.ddl = true
+	case Begin:
+		f.block = true
+		f.expect = blockObject
+	case ';':
+		f.ddl, f.usingTables = false, false
+		if f.block {
+			f.expect = blockObject
+		}
+	case '(':
+		f.depth++
+	case ')':
+		f.depth--
+	case ID:
+		switch {
+		case f.ddl && isWord(buffer, "TABLE"):
+			// CREATE [TEMPORARY] TABLE [IF NOT EXISTS] [tableName]
+			// DROP TABLE [IF EXISTS] [tableName], ...
+			f.expect, f.expectList = tableObject, true
+			f.ddl = false
+		case f.ddl && isWord(buffer, "PROCEDURE", "PROC"):
+			// CREATE PROCEDURE [procedureName]
+			f.expect = procedureObject
+			f.ddl = false
+		case isWord(buffer, "REFERENCES"):
+			// FOREIGN KEY (...) REFERENCES [tableName]
+			f.expect, f.expectList = tableObject, false
+		case f.usingTables && isWord(buffer, "USING"):
+			// DELETE FROM ... USING [tableName]
+			// MERGE INTO ... USING [tableName]
+			f.expect, f.expectList = tableObject, true
+		case isWord(buffer, "MERGE"):
+			f.usingTables = true
+		case isWord(buffer, "CALL", "EXEC", "EXECUTE"):
+			// CALL [procedureName]
+			// EXEC [@result =] [procedureName]
+			f.expect = procedureObject
+		case isWord(buffer, "END"):
+			f.block = false
+		}
+	}
+	return token
+}
+
+// startName starts reading the name of the expected object if the token is its first part,
+// and returns the kind of the token. It cancels the expectation if the token names no object.
+func (f *metadataFinderFilter) startName(token TokenKind, buffer []byte) (TokenKind, bool) {
+	switch {
+	case token == ID && f.ddl && isWord(buffer, "TABLE"):
+		// TRUNCATE TABLE [tableName]
+		f.ddl = false
+		return token, true
+	case token == ID && isWord(buffer, "ONLY", "IF", "NOT", "EXISTS", "LATERAL", "LOW_PRIORITY", "QUICK", "IGNORE"):
+		// DELETE FROM ONLY [tableName]
+		// DROP TABLE IF EXISTS [tableName]
+		return token, true
+	case f.expect == procedureObject && (token == '=' || (token == ID && buffer[0] == '@')):
+		// EXEC @result = [procedureName]
+		return token, true
+	case token == '[':
+		f.nameKind, f.nameList = f.expect, f.expectList
+		f.nameDot, f.bracket = true, true
+		f.expect = noObject
+		return token, true
+	case isNamePart(token, buffer) && !isClauseWord(buffer):
+		f.nameKind, f.nameList = f.expect, f.expectList
+		f.expect = noObject
+		f.appendName(buffer)
+		return f.namePartKind(token), true
+	}
+	f.expect = noObject
+	return token, false
+}
+
+// continueName adds the token to the name being read if it continues it, and returns the kind
+// of the token.
+func (f *metadataFinderFilter) continueName(token TokenKind, buffer []byte) (TokenKind, bool) {
+	switch {
+	case f.bracket:
+		if token == ']' {
+			f.bracket = false
+		} else if f.nameDot && token == ID {
+			f.appendName(buffer)
+			return f.namePartKind(token), true
+		}
+		return token, true
+	case token == '.' && !f.nameDot:
+		// "schema"."table"
+		f.name = append(f.name, '.')
+		f.nameDot = true
+		return token, true
+	case f.nameDot && token == '[':
+		// [dbo].[table]
+		f.bracket = true
+		return token, true
+	case f.nameDot && (token == ID || token == DoubleQuotedString):
+		f.appendName(buffer)
+		return f.namePartKind(token), true
+	}
+	return token, false
+}
+
+// appendName appends the given identifier to the name being read.
+func (f *metadataFinderFilter) appendName(buffer []byte) {
+	f.name = append(f.name, buffer...)
+	f.nameDot = len(buffer) > 0 && buffer[len(buffer)-1] == '.'
+}
+
+// namePartKind returns the kind of a token which is part of the name being read.
+func (f *metadataFinderFilter) namePartKind(token TokenKind) TokenKind {
+	if f.nameKind == tableObject {
+		return TableName
+	}
+	return token
+}
+
+// endName stores the name which has been read, the given token being the one following it.
+func (f *metadataFinderFilter) endName(next TokenKind) {
+	name := strings.TrimSuffix(string(f.name), ".")
+	kind, list := f.nameKind, f.nameList
+	f.name = f.name[:0]
+	f.nameKind, f.nameList, f.nameDot, f.bracket = noObject, false, false, false
+	if name == "" {
+		return
+	}
+	switch kind {
+	case tableObject:
+		if list {
+			f.tableList = 1
+		}
+		if _, ok := f.ctes[strings.ToLower(name)]; ok || !f.collectTableNames {
+			return
+		}
+		if f.replaceDigits {
+			name = string(replaceDigits([]byte(name)))
+		}
+		f.storeTableName(name)
+	case blockObject:
+		if next != '(' {
+			// not a procedure call, e.g. BEGIN TRANSACTION
+			return
+		}
+		fallthrough
+	case procedureObject:
+		if f.collectProcedures {
+			f.storeProcedure(name)
+		}
+	}
+}
+
+// findCTE keeps track of the names of the common table expressions defined by a WITH clause,
+// e.g. WITH [cteName] [(columns)] AS (...).
+func (f *metadataFinderFilter) findCTE(token, lastToken TokenKind, buffer []byte) {
+	switch {
+	case token == ID && isWord(buffer, "WITH"):
+		f.with, f.withDepth, f.cte = true, f.depth, ""
+	case !f.with || f.depth != f.withDepth:
+	case token == ID && f.cte == "" && !isWord(buffer, "RECURSIVE"):
+		f.cte = strings.ToLower(string(buffer))
+	case token == '(' && lastToken == As && f.cte != "":
+		if f.ctes == nil {
+			f.ctes = make(map[string]struct{}, 1)
+		}
+		f.ctes[f.cte] = struct{}{}
+	case token == ',':
+		f.cte = ""
+	case token == Select || token == Insert || token == Update || token == Delete:
+		f.with = false
+	}
+}
+
+// isNamePart reports whether the token may be the first part of the name of a table or procedure.
+func isNamePart(token TokenKind, buffer []byte) bool {
+	switch token {
+	case ID:
+		// not a variable (@var) nor a nested query
+		r, _ := utf8.DecodeRune(buffer)
+		return unicode.IsLetter(r) || r == '_' || r == '#'
+	case DoubleQuotedString:
+		return len(buffer) > 0
+	}
+	return false
+}
+
+// isClauseWord reports whether the identifier is a keyword following a table in a list of tables,
+// as opposed to an alias.
+func isClauseWord(buffer []byte) bool {
+	return isWord(buffer, "WHERE", "ON", "USING", "SET", "GROUP", "ORDER", "HAVING", "WINDOW", "INNER", "LEFT", "RIGHT",
+		"FULL", "CROSS", "OUTER", "NATURAL", "UNION", "EXCEPT", "INTERSECT", "MINUS", "RETURNING", "VALUES", "FOR",
+		"WITH", "OFFSET", "FETCH")
+}
+
+// isWord reports whether the identifier is one of the given upper-cased words, regardless of its case.
+func isWord(buffer []byte, words ...string) bool {
+	for _, w := range words {
+		if len(buffer) == len(w) && bytes.EqualFold(buffer, []byte(w)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *metadataFinderFilter) storeTableName(name string) {
 	if _, ok := f.tablesSeen[name]; ok {
 		return
@@ -97,13 +371,28 @@ func (f *metadataFinderFilter) storeTableName(name string) {
 	f.tablesCSV.WriteString(name)
 }
 
+func (f *metadataFinderFilter) storeProcedure(name string) {
+	for _, p := range f.procedures {
+		if p == name {
+			return
+		}
+	}
+	f.size += int64(len(name))
+	f.procedures = append(f.procedures, name)
+}
+
 // Results returns metadata collected by the filter for an SQL statement.
 func (f *metadataFinderFilter) Results() SQLMetadata {
+	if f.nameKind != noObject {
+		// the query ends with a name
+		f.endName(EndChar)
+	}
 	return SQLMetadata{
-		Size:      f.size,
-		TablesCSV: f.tablesCSV.String(),
-		Commands:  f.commands,
-		Comments:  f.comments,
+		Size:       f.size,
+		TablesCSV:  f.tablesCSV.String(),
+		Commands:   f.commands,
+		Comments:   f.comments,
+		Procedures: f.procedures,
 	}
 }
 
@@ -112,10 +401,20 @@ func (f *metadataFinderFilter) Reset() {
 	for k := range f.tablesSeen {
 		delete(f.tablesSeen, k)
 	}
+	for k := range f.ctes {
+		delete(f.ctes, k)
+	}
 	f.size = 0
 	f.tablesCSV.Reset()
 	f.commands = f.commands[:0]
 	f.comments = f.comments[:0]
+	f.procedures = f.procedures[:0]
+	f.expect, f.expectList = noObject, false
+	f.name = f.name[:0]
+	f.nameKind, f.nameList, f.nameDot, f.bracket = noObject, false, false, false
+	f.tableList, f.depth = 0, 0
+	f.ddl, f.usingTables, f.block = false, false, false
+	f.with, f.withDepth, f.cte = false, 0, ""
 }
 
 // discardFilter is a token filter which discards certain elements from a query, such as
@@ -329,6 +628,11 @@ func (o *Obfuscator) ObfuscateSQLStringWithOptions(in string, opts *SQLConfig) (
 	if err != nil {
 		return oq, err
 	}
+	if opts.Fingerprint && opts.ObfuscationMode != NormalizeOnly {
+		// the fingerprint is only computed on obfuscated queries
+		oq.Metadata.Fingerprint = sqlFingerprint(oq.Query)
+		oq.Metadata.Size += sqlFingerprintLen
+	}
 
 	o.queryCache.Set(cacheKey, oq, oq.Cost())
 	return oq, nil
@@ -363,14 +667,14 @@ type ObfuscatedQuery struct {
 func (oq *ObfuscatedQuery) Cost() int64 {
 	// The cost of the ObfuscatedQuery struct is the sum of the length of the query string,
 	// the size of the metadata content, and the size of the struct itself and its fields headers.
-	// 320 bytes come from
-	// - 112 bytes for the ObfuscatedQuery struct itself, measured by unsafe.Sizeof(ObfuscatedQuery{})
-	// - 96 bytes for the Metadata struct itself, measured by unsafe.Sizeof(SQLMetadata{})
+	// 368 bytes come from
+	// - 128 bytes for the ObfuscatedQuery struct itself, measured by unsafe.Sizeof(ObfuscatedQuery{})
+	// - 112 bytes for the Metadata struct itself, measured by unsafe.Sizeof(SQLMetadata{})
 	// - 16 bytes for the Query string header
-	// - 16 bytes for the TablesCSV string header
+	// - 16 * 2 bytes for the TablesCSV and Fingerprint string headers
 	// - 24 * 3 bytes for the Comments, Commands, and Procedures slices headers
 	// - 8 bytes for the Size int64 field
-	return int64(len(oq.Query)) + oq.Metadata.Size + 320
+	return int64(len(oq.Query)) + oq.Metadata.Size + 368
 }
 
 // attemptObfuscation attempts to obfuscate the SQL query loaded into the tokenizer, using the given set of filters.
@@ -383,6 +687,7 @@ func attemptObfuscation(tokenizer *SQLTokenizer) (*ObfuscatedQuery, error) {
 			collectTableNames: tokenizer.cfg.TableNames,
 			collectCommands:   tokenizer.cfg.CollectCommands,
 			collectComments:   tokenizer.cfg.CollectComments,
+			collectProcedures: tokenizer.cfg.CollectProcedures,
 			replaceDigits:     tokenizer.cfg.ReplaceDigits,
 		}
 		discard  = discardFilter{keepSQLAlias: tokenizer.cfg.KeepSQLAlias}
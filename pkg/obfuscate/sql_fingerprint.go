@@ -0,0 +1,83 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package obfuscate
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sqlFingerprintLen is the length of the SQL fingerprints, 64 bits in hexadecimal.
+const sqlFingerprintLen = 16
+
+// sqlFingerprint returns the fingerprint of the given obfuscated query: the hash of the
+// query normalized so that it doesn't depend on its whitespace, on the letter case of its
+// keywords and unquoted identifiers, nor on the number of values of its lists, e.g.
+// "IN ( ? )" and "IN (?, ?, ?)" or "VALUES (?, ?), (?, ?)" and "VALUES (?)".
+func sqlFingerprint(query string) string {
+	tokens := make([]string, 0, 32)
+	for i := 0; i < len(query); {
+		r, size := utf8.DecodeRuneInString(query[i:])
+		start := i
+		i += size
+		switch {
+		case unicode.IsSpace(r):
+			continue
+		case r == '\'' || r == '"' || r == '`' || r == '[':
+			// quoted string or identifier, kept as is
+			end := r
+			if r == '[' {
+				end = ']'
+			}
+			if n := strings.IndexRune(query[i:], end); n >= 0 {
+				i += n + utf8.RuneLen(end)
+			} else {
+				i = len(query)
+			}
+		case isFingerprintWordChar(r):
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if !isFingerprintWordChar(r) {
+					break
+				}
+				i += size
+			}
+			tokens = appendFingerprintToken(tokens, strings.ToLower(query[start:i]))
+			continue
+		}
+		tokens = appendFingerprintToken(tokens, query[start:i])
+	}
+	h := fnv.New64a()
+	for i, t := range tokens {
+		if i > 0 {
+			h.Write([]byte{' '})
+		}
+		h.Write([]byte(t))
+	}
+	s := strconv.FormatUint(h.Sum64(), 16)
+	return strings.Repeat("0", sqlFingerprintLen-len(s)) + s
+}
+
+// appendFingerprintToken appends the token to the normalized tokens of a query, collapsing
+// the lists of values: "?, ?" becomes "?" and "( ? ), ( ? )" becomes "( ? )".
+func appendFingerprintToken(tokens []string, t string) []string {
+	n := len(tokens)
+	switch {
+	case t == "?" && n >= 2 && tokens[n-1] == "," && tokens[n-2] == "?":
+		return tokens[:n-1]
+	case t == ")" && n >= 6 && tokens[n-1] == "?" && tokens[n-2] == "(" && tokens[n-3] == "," &&
+		tokens[n-4] == ")" && tokens[n-5] == "?" && tokens[n-6] == "(":
+		return tokens[:n-3]
+	}
+	return append(tokens, t)
+}
+
+func isFingerprintWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '#' || r == '@'
+}
@@ -249,7 +249,7 @@ GROUP BY sales1828.product_key`,
 				ReplaceDigits:   true,
 			},
 			SQLMetadata{
-				TablesCSV: "gosalesdw?.sls_order_method_dim,gosalesdw?.go_branch_dim,sales?",
+				TablesCSV: "gosalesdw?.sls_order_method_dim,gosalesdw?.sls_product_dim?,gosalesdw?.emp_employee_dim,gosalesdw?.sls_sales_fact?,gosalesdw?.go_branch_dim,gosalesdw.dist_inventory_fact,sales?",
 				Commands:  []string{"SELECT", "SELECT", "SELECT"},
 				Comments:  []string{"/* Multi-line comment with line breaks */"},
 			},
@@ -316,7 +316,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 				ReplaceDigits:   false,
 			},
 			SQLMetadata{
-				TablesCSV: "",
+				TablesCSV: "table",
 				Commands:  []string{"ALTER", "DROP"},
 			},
 		},
@@ -344,7 +344,7 @@ SELECT * FROM (VALUES (1, 'dog')) AS d (id, animal)`,
 				ReplaceDigits:   false,
 			},
 			SQLMetadata{
-				TablesCSV: "",
+				TablesCSV: "datadog",
 				Commands:  []string{"TRUNCATE"},
 			},
 		},
@@ -365,7 +365,7 @@ TABLE T4 UNION CORRESPONDING TABLE T3`,
 				ReplaceDigits:   false,
 			},
 			SQLMetadata{
-				TablesCSV: "P,T1,T2",
+				TablesCSV: "P",
 				Commands:  []string{"SELECT", "SELECT", "SELECT"},
 				Comments: []string{
 					"-- Testing explicit table SQL expression",
@@ -381,7 +381,7 @@ TABLE T4 UNION CORRESPONDING TABLE T3`,
 			assert.Equal(tt.metadata.Commands, oq.Metadata.Commands)
 			assert.Equal(tt.metadata.Comments, oq.Metadata.Comments)
 			// Cost() includes the query text size, metadata size and struct overhead
-			assert.Equal(oq.Cost()-int64(len(oq.Query))-oq.Metadata.Size, int64(320))
+			assert.Equal(oq.Cost()-int64(len(oq.Query))-oq.Metadata.Size, int64(368))
 		})
 	}
 }
@@ -754,6 +754,83 @@ func TestSQLTableFinderAndReplaceDigits(t *testing.T) {
 	})
 }
 
+func TestSQLTableAndProcedureNames(t *testing.T) {
+	for _, tt := range []struct {
+		dbms       string
+		query      string
+		tables     string
+		procedures []string
+	}{
+		{"", `SELECT * FROM users u, orders AS o, items WHERE u.id = o.user_id`, "users,orders,items", nil},
+		{DBMSPostgres, `SELECT * FROM "public"."users" JOIN public.orders ON orders.user_id = users.id`, "public.users,public.orders", nil},
+		{DBMSPostgres, `DELETE FROM ONLY films USING producers WHERE producer_id = producers.id`, "films,producers", nil},
+		{DBMSPostgres, `WITH RECURSIVE tree (id) AS (SELECT id FROM nodes UNION ALL SELECT n.id FROM nodes n JOIN tree ON tree.id = n.parent) SELECT * FROM tree`, "nodes", nil},
+		{DBMSPostgres, `CALL refresh_stats(1, 'daily')`, "", []string{"refresh_stats"}},
+		{DBMSMySQL, "SELECT `shop`.`orders`.id FROM `shop`.`orders`", "shop.orders", nil},
+		{DBMSMySQL, `UPDATE t1 a, t2 b SET a.x = b.x WHERE a.id = b.id`, "t1,t2", nil},
+		{DBMSMySQL, `CREATE TABLE IF NOT EXISTS orders (id int, user_id int REFERENCES users(id))`, "orders,users", nil},
+		{DBMSMySQL, `DROP TABLE IF EXISTS tmp_a, tmp_b`, "tmp_a,tmp_b", nil},
+		{DBMSSQLServer, `SELECT * FROM [dbo].[users] WITH (NOLOCK)`, "dbo.users", nil},
+		{DBMSSQLServer, `MERGE INTO target t USING source s ON (t.id = s.id) WHEN MATCHED THEN UPDATE SET t.v = s.v`, "target,source", nil},
+		{DBMSSQLServer, `EXEC @ret = dbo.GetUser @id = 1`, "", []string{"dbo.GetUser"}},
+		{"", `TRUNCATE TABLE events`, "events", nil},
+		{"", `DELETE employees WHERE id = 1`, "employees", nil},
+		{"", `SELECT * FROM hr.employees@remote`, "hr.employees@remote", nil},
+		{"", `BEGIN billing.charge(:1, :2); audit(3); END;`, "", []string{"billing.charge", "audit"}},
+		{"", `BEGIN TRANSACTION; UPDATE accounts SET balance = 0; COMMIT`, "accounts", nil},
+	} {
+		t.Run("", func(t *testing.T) {
+			oq, err := NewObfuscator(Config{
+				SQL: SQLConfig{
+					DBMS:              tt.dbms,
+					TableNames:        true,
+					CollectProcedures: true,
+				},
+			}).ObfuscateSQLString(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.tables, oq.Metadata.TablesCSV)
+			assert.Equal(t, tt.procedures, oq.Metadata.Procedures)
+		})
+	}
+}
+
+func TestSQLFingerprint(t *testing.T) {
+	fingerprint := func(t *testing.T, mode ObfuscationMode, query string) string {
+		oq, err := NewObfuscator(Config{
+			SQL: SQLConfig{Fingerprint: true, ObfuscationMode: mode},
+		}).ObfuscateSQLString(query)
+		require.NoError(t, err)
+		assert.Len(t, oq.Metadata.Fingerprint, 16)
+		return oq.Metadata.Fingerprint
+	}
+	for _, mode := range []ObfuscationMode{"", ObfuscateOnly, ObfuscateAndNormalize} {
+		t.Run(string(mode), func(t *testing.T) {
+			in := fingerprint(t, mode, "SELECT * FROM users WHERE id IN (1, 2, 3)")
+			assert.Equal(t, in, fingerprint(t, mode, "select *\n\tfrom users where id in (4)"))
+			assert.NotEqual(t, in, fingerprint(t, mode, "SELECT * FROM orders WHERE id IN (1, 2, 3)"))
+
+			values := fingerprint(t, mode, "INSERT INTO users (id, name) VALUES (1, 'a'), (2, 'b')")
+			assert.Equal(t, values, fingerprint(t, mode, "INSERT INTO users (id, name) VALUES (3, 'c')"))
+			assert.NotEqual(t, values, in)
+		})
+	}
+
+	t.Run("off", func(t *testing.T) {
+		oq, err := NewObfuscator(Config{}).ObfuscateSQLString("SELECT * FROM users")
+		require.NoError(t, err)
+		assert.Empty(t, oq.Metadata.Fingerprint)
+	})
+
+	t.Run("normalize-only", func(t *testing.T) {
+		// the query is not obfuscated
+		oq, err := NewObfuscator(Config{
+			SQL: SQLConfig{Fingerprint: true, ObfuscationMode: NormalizeOnly},
+		}).ObfuscateSQLString("SELECT * FROM users WHERE name = 'jane'")
+		require.NoError(t, err)
+		assert.Empty(t, oq.Metadata.Fingerprint)
+	})
+}
+
 func TestSQLQuantizer(t *testing.T) {
 	cases := []sqlTestCase{
 		{
@@ -182,6 +182,8 @@ const (
 	DBMSMySQL = "mysql"
 	// DBMSOracle is an Oracle Server
 	DBMSOracle = "oracle"
+	// DBMSCassandra is a Cassandra cluster, queried in CQL
+	DBMSCassandra = "cassandra"
 )
 
 const escapeCharacter = '\\'
@@ -271,6 +273,12 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	}
 	tkn.SkipBlank()
 
+	if tkn.cfg.DBMS == DBMSCassandra {
+		if n := tkn.cqlConstantLen(); n > 0 {
+			return tkn.scanCQLConstant(n)
+		}
+	}
+
 	switch ch := tkn.lastChar; {
 	case isLeadingLetter(ch) &&
 		!(tkn.cfg.DBMS == DBMSPostgres && ch == '@'):
@@ -524,6 +532,9 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			}
 			fallthrough
 		case '{':
+			if tkn.cfg.DBMS == DBMSCassandra {
+				return tkn.scanCQLCollection()
+			}
 			if tkn.pos == 1 || tkn.curlys > 0 {
 				// Do not fully obfuscate top-level SQL escape sequences like {{[?=]call procedure-name[([parameter][,parameter]...)]}.
 				// We want these to display a bit more context than just a plain '?'
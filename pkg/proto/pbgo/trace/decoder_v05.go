@@ -33,38 +33,49 @@ func dictionaryString(bts []byte, dict []string) (string, []byte, error) {
 // UnmarshalMsgDictionary decodes a trace using the specification from the v0.5 endpoint.
 // For details, see the documentation for endpoint v0.5 in pkg/trace/api/version.go
 func (t *Traces) UnmarshalMsgDictionary(bts []byte) error {
+	_, err := t.UnmarshalMsgDictionaryKept(bts, nil)
+	return err
+}
+
+// UnmarshalMsgDictionaryKept decodes traces like UnmarshalMsgDictionary, but discards
+// each trace for which keep returns false as soon as it is decoded, reusing its spans
+// to decode the next ones. It returns the number of traces discarded. A nil keep
+// keeps all the traces.
+func (t *Traces) UnmarshalMsgDictionaryKept(bts []byte, keep func(Trace) bool) (int, error) {
 	var err error
 	if _, bts, err = safeReadHeaderBytes(bts, msgp.ReadArrayHeaderBytes); err != nil {
-		return err
+		return 0, err
 	}
 	// read dictionary
 	var sz uint32
 	if sz, bts, err = safeReadHeaderBytes(bts, msgp.ReadArrayHeaderBytes); err != nil {
-		return err
+		return 0, err
 	}
 	dict := make([]string, sz)
 	for i := range dict {
 		var str string
 		str, bts, err = parseStringBytes(bts)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		dict[i] = str
 	}
 	// read traces
 	sz, bts, err = safeReadHeaderBytes(bts, msgp.ReadArrayHeaderBytes)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if cap(*t) >= int(sz) {
 		*t = (*t)[:sz]
 	} else {
 		*t = make(Traces, sz)
 	}
-	for i := range *t {
+	// i is the index of the next trace kept
+	var i, discarded int
+	for range sz {
 		sz, bts, err = safeReadHeaderBytes(bts, msgp.ReadArrayHeaderBytes)
 		if err != nil {
-			return err
+			return discarded, err
 		}
 		if cap((*t)[i]) >= int(sz) {
 			(*t)[i] = (*t)[i][:sz]
@@ -76,11 +87,17 @@ func (t *Traces) UnmarshalMsgDictionary(bts []byte) error {
 				(*t)[i][j] = new(Span)
 			}
 			if bts, err = (*t)[i][j].UnmarshalMsgDictionary(bts, dict); err != nil {
-				return err
+				return discarded, err
 			}
 		}
+		if keep == nil || keep((*t)[i]) {
+			i++
+		} else {
+			discarded++
+		}
 	}
-	return nil
+	*t = (*t)[:i]
+	return discarded, nil
 }
 
 // spanPropertyCount specifies the number of top-level properties that a span
@@ -80,6 +80,30 @@ func TestUnmarshalMsgDictionary(t *testing.T) {
 	})
 }
 
+func TestUnmarshalMsgDictionaryKept(t *testing.T) {
+	span := func(traceID uint64) [12]interface{} {
+		return [12]interface{}{0, 0, 0, traceID, uint64(1), uint64(0), int64(0), int64(0), 0, map[interface{}]interface{}{}, map[interface{}]float64{}, 0}
+	}
+	b, err := vmsgp.Marshal(&[2]interface{}{
+		[]string{""},
+		[][][12]interface{}{{span(1)}, {span(2), span(2)}, {span(3)}, {span(4)}},
+	})
+	assert.NoError(t, err)
+
+	var traces Traces
+	discarded, err := traces.UnmarshalMsgDictionaryKept(b, func(trace Trace) bool {
+		return trace[0].TraceID%2 == 0
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, discarded)
+	assert.Len(t, traces, 2)
+	assert.Len(t, traces[0], 2)
+	assert.EqualValues(t, 2, traces[0][0].TraceID)
+	assert.EqualValues(t, 2, traces[0][1].TraceID)
+	assert.Len(t, traces[1], 1)
+	assert.EqualValues(t, 4, traces[1][0].TraceID)
+}
+
 func TestUnmarshalMsgDictionaryLimitsSize(t *testing.T) {
 	ps := [][]byte{
 		[]byte("\x9e\xdd\xff\xff\xff\xff"),
@@ -143,6 +143,25 @@ func (z *ClientGroupedStats) DecodeMsg(dc *msgp.Reader) (err error) {
 				err = msgp.WrapError(err, "GRPCStatusCode")
 				return
 			}
+		case "ExtraAggregationTags":
+			var zb0004 uint32
+			zb0004, err = dc.ReadArrayHeader()
+			if err != nil {
+				err = msgp.WrapError(err, "ExtraAggregationTags")
+				return
+			}
+			if cap(z.ExtraAggregationTags) >= int(zb0004) {
+				z.ExtraAggregationTags = (z.ExtraAggregationTags)[:zb0004]
+			} else {
+				z.ExtraAggregationTags = make([]string, zb0004)
+			}
+			for za0002 := range z.ExtraAggregationTags {
+				z.ExtraAggregationTags[za0002], err = dc.ReadString()
+				if err != nil {
+					err = msgp.WrapError(err, "ExtraAggregationTags", za0002)
+					return
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -156,9 +175,9 @@ func (z *ClientGroupedStats) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *ClientGroupedStats) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 17
+	// map header, size 18
 	// write "Service"
-	err = en.Append(0xde, 0x0, 0x11, 0xa7, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
+	err = en.Append(0xde, 0x0, 0x12, 0xa7, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
 	if err != nil {
 		return
 	}
@@ -334,15 +353,32 @@ func (z *ClientGroupedStats) EncodeMsg(en *msgp.Writer) (err error) {
 		err = msgp.WrapError(err, "GRPCStatusCode")
 		return
 	}
+	// write "ExtraAggregationTags"
+	err = en.Append(0xb4, 0x45, 0x78, 0x74, 0x72, 0x61, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x61, 0x67, 0x73)
+	if err != nil {
+		return
+	}
+	err = en.WriteArrayHeader(uint32(len(z.ExtraAggregationTags)))
+	if err != nil {
+		err = msgp.WrapError(err, "ExtraAggregationTags")
+		return
+	}
+	for za0002 := range z.ExtraAggregationTags {
+		err = en.WriteString(z.ExtraAggregationTags[za0002])
+		if err != nil {
+			err = msgp.WrapError(err, "ExtraAggregationTags", za0002)
+			return
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *ClientGroupedStats) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 17
+	// map header, size 18
 	// string "Service"
-	o = append(o, 0xde, 0x0, 0x11, 0xa7, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
+	o = append(o, 0xde, 0x0, 0x12, 0xa7, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65)
 	o = msgp.AppendString(o, z.Service)
 	// string "Name"
 	o = append(o, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
@@ -395,6 +431,12 @@ func (z *ClientGroupedStats) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "GRPCStatusCode"
 	o = append(o, 0xae, 0x47, 0x52, 0x50, 0x43, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x43, 0x6f, 0x64, 0x65)
 	o = msgp.AppendString(o, z.GRPCStatusCode)
+	// string "ExtraAggregationTags"
+	o = append(o, 0xb4, 0x45, 0x78, 0x74, 0x72, 0x61, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x54, 0x61, 0x67, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.ExtraAggregationTags)))
+	for za0002 := range z.ExtraAggregationTags {
+		o = msgp.AppendString(o, z.ExtraAggregationTags[za0002])
+	}
 	return
 }
 
@@ -535,6 +577,25 @@ func (z *ClientGroupedStats) UnmarshalMsg(bts []byte) (o []byte, err error) {
 				err = msgp.WrapError(err, "GRPCStatusCode")
 				return
 			}
+		case "ExtraAggregationTags":
+			var zb0004 uint32
+			zb0004, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				err = msgp.WrapError(err, "ExtraAggregationTags")
+				return
+			}
+			if cap(z.ExtraAggregationTags) >= int(zb0004) {
+				z.ExtraAggregationTags = (z.ExtraAggregationTags)[:zb0004]
+			} else {
+				z.ExtraAggregationTags = make([]string, zb0004)
+			}
+			for za0002 := range z.ExtraAggregationTags {
+				z.ExtraAggregationTags[za0002], bts, err = msgp.ReadStringBytes(bts)
+				if err != nil {
+					err = msgp.WrapError(err, "ExtraAggregationTags", za0002)
+					return
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -553,7 +614,10 @@ func (z *ClientGroupedStats) Msgsize() (s int) {
 	for za0001 := range z.PeerTags {
 		s += msgp.StringPrefixSize + len(z.PeerTags[za0001])
 	}
-	s += 12 + msgp.Int32Size + 15 + msgp.StringPrefixSize + len(z.GRPCStatusCode)
+	s += 12 + msgp.Int32Size + 15 + msgp.StringPrefixSize + len(z.GRPCStatusCode) + 21 + msgp.ArrayHeaderSize
+	for za0002 := range z.ExtraAggregationTags {
+		s += msgp.StringPrefixSize + len(z.ExtraAggregationTags[za0002])
+	}
 	return
 }
 
@@ -10,6 +10,8 @@ package apmsampling
 type SamplerConfig struct {
 	AllEnvs SamplerEnvConfig `json:"all_envs"`
 	ByEnv   []EnvAndConfig   `json:"by_env"`
+	// ServiceBudgets replace the service budgets configured locally when set.
+	ServiceBudgets []ServiceBudget `json:"service_budgets"`
 }
 
 // SamplerEnvConfig contains the configuration for all environments
@@ -24,3 +26,11 @@ type EnvAndConfig struct {
 	Env    string           `json:"env"`
 	Config SamplerEnvConfig `json:"config"`
 }
+
+// ServiceBudget is the maximum number of spans per second kept for a service
+// and/or an env
+type ServiceBudget struct {
+	Service           string  `json:"service"`
+	Env               string  `json:"env"`
+	MaxSpansPerSecond float64 `json:"max_spans_per_second"`
+}
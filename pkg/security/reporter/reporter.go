@@ -64,6 +64,7 @@ func newReporter(hostname string, stopper startstop.Stopper, sourceName, sourceT
 		hostnameimpl.NewHostnameService(),
 		cfg,
 		compression,
+		nil, // metric sender
 		cfg.GetBool("logs_config.disable_distributed_senders"),
 		false, // serverless
 	)
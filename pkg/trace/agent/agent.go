@@ -30,6 +30,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/version"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/trace/writer"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
@@ -49,6 +50,10 @@ const (
 	// manualSampling is the value for _dd.p.dm when user sets sampling priority directly in code.
 	manualSampling = "-4"
 
+	// shedSamplersFactor scales the budgets of the rare and errors samplers
+	// while the load shedding lowers them.
+	shedSamplersFactor = 0.1
+
 	// probabilitySampling is the value for _dd.p.dm when the agent is configured to use the ProbabilitySampler.
 	probabilitySampling = "-9"
 
@@ -89,11 +94,15 @@ type Agent struct {
 	ClientStatsAggregator *stats.ClientStatsAggregator
 	Blacklister           *filters.Blacklister
 	Replacer              *filters.Replacer
+	RuleFilter            *filters.RuleFilter
 	PrioritySampler       *sampler.PrioritySampler
 	ErrorsSampler         *sampler.ErrorsSampler
 	RareSampler           *sampler.RareSampler
 	NoPrioritySampler     *sampler.NoPrioritySampler
 	ProbabilisticSampler  *sampler.ProbabilisticSampler
+	TailSampler           *TailSampler
+	ServiceMap            *ServiceMap
+	ServiceBudgets        *sampler.ServiceBudgets
 	SamplerMetrics        *sampler.Metrics
 	EventProcessor        *event.Processor
 	TraceWriter           TraceWriter
@@ -117,6 +126,10 @@ type Agent struct {
 	// subsequent SpanModifier calls.
 	SpanModifier SpanModifier
 
+	// SensitiveDataScanner, if non-nil, redacts the sensitive data of the
+	// span tag values.
+	SensitiveDataScanner SensitiveDataScanner
+
 	// In takes incoming payloads to be processed by the agent.
 	In chan *api.Payload
 
@@ -139,6 +152,7 @@ type SpanModifier interface {
 // which may be cancelled in order to gracefully stop the agent.
 func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector telemetry.TelemetryCollector, statsd statsd.ClientInterface, comp compression.Component) *Agent {
 	dynConf := sampler.NewDynamicConfig()
+	dynConf.ServiceBudgets = sampler.NewServiceBudgets(conf)
 	log.Infof("Starting Agent with processor trace buffer of size %d", conf.TraceBuffer)
 	in := make(chan *api.Payload, conf.TraceBuffer)
 	oconf := conf.Obfuscation.Export(conf)
@@ -152,11 +166,13 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsWriter, statsd),
 		Blacklister:           filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:              filters.NewReplacer(conf.ReplaceTags),
+		RuleFilter:            filters.NewRuleFilter(conf.FilterRules),
 		PrioritySampler:       sampler.NewPrioritySampler(conf, dynConf),
 		ErrorsSampler:         sampler.NewErrorsSampler(conf),
 		RareSampler:           sampler.NewRareSampler(conf),
 		NoPrioritySampler:     sampler.NewNoPrioritySampler(conf),
 		ProbabilisticSampler:  sampler.NewProbabilisticSampler(conf),
+		ServiceBudgets:        dynConf.ServiceBudgets,
 		SamplerMetrics:        sampler.NewMetrics(statsd),
 		EventProcessor:        newEventProcessor(conf, statsd),
 		StatsWriter:           statsWriter,
@@ -170,9 +186,18 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 	}
 	agnt.SamplerMetrics.Add(agnt.PrioritySampler, agnt.ErrorsSampler, agnt.NoPrioritySampler, agnt.RareSampler)
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt, telemetryCollector, statsd, timing)
+	agnt.Receiver.Shedder.OnChange(agnt.shedSamplers)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf, statsd, timing)
-	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler)
+	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler, agnt.ServiceBudgets)
 	agnt.TraceWriter = writer.NewTraceWriter(conf, agnt.PrioritySampler, agnt.ErrorsSampler, agnt.RareSampler, telemetryCollector, statsd, timing, comp)
+	if conf.TailSampling.Enabled {
+		log.Infof("Tail sampling enabled with a decision wait of %s and %d policies", conf.TailSampling.DecisionWait, len(conf.TailSampling.Policies))
+		agnt.TailSampler = NewTailSampler(conf.TailSampling, func(c *writer.SampledChunks) { agnt.TraceWriter.WriteChunks(c) }, statsd)
+	}
+	if conf.ServiceMap.Enabled {
+		log.Infof("Service map enabled with up to %d edges", conf.ServiceMap.MaxEdges)
+		agnt.ServiceMap = NewServiceMap(conf, statsd)
+	}
 	return agnt
 }
 
@@ -192,6 +217,12 @@ func (a *Agent) Run() {
 	} {
 		starter.Start()
 	}
+	if a.TailSampler != nil {
+		a.TailSampler.Start()
+	}
+	if a.ServiceMap != nil {
+		a.ServiceMap.Start()
+	}
 
 	go a.StatsWriter.Run()
 
@@ -239,6 +270,17 @@ func (a *Agent) UpdateAPIKey(oldKey, newKey string) {
 	a.StatsWriter.UpdateAPIKey(oldKey, newKey)
 }
 
+// shedSamplers lowers the budgets of the rare and errors samplers from the
+// ShedSamplers load shedding stage, and restores them below it.
+func (a *Agent) shedSamplers(stage watchdog.ShedStage) {
+	factor := 1.0
+	if stage >= watchdog.ShedSamplers {
+		factor = shedSamplersFactor
+	}
+	a.ErrorsSampler.ShedLoad(factor)
+	a.RareSampler.ShedLoad(factor)
+}
+
 func (a *Agent) work() {
 	for {
 		p, ok := <-a.In
@@ -261,6 +303,8 @@ func (a *Agent) loop() {
 	for _, stopper := range []interface{ Stop() }{
 		a.Concentrator,
 		a.ClientStatsAggregator,
+		a.TailSampler, // flushes the buffered traces to the TraceWriter
+		a.ServiceMap,
 		a.TraceWriter,
 		a.StatsWriter,
 		a.SamplerMetrics,
@@ -356,6 +400,17 @@ func (a *Agent) Process(p *api.Payload) {
 			continue
 		}
 
+		if allowed, rule := a.RuleFilter.Allows(chunk.Spans); rule != nil {
+			ts.FilterRuleHits.Inc(rule.Name)
+			if !allowed {
+				log.Debugf("Trace rejected by filter rule %q. root: %v", rule.Name, root)
+				ts.TracesFiltered.Inc()
+				ts.SpansFiltered.Add(tracen)
+				p.RemoveChunk(i)
+				continue
+			}
+		}
+
 		// Extra sanitization steps of the trace.
 		for _, span := range chunk.Spans {
 			for k, v := range a.conf.GlobalTags {
@@ -369,12 +424,14 @@ func (a *Agent) Process(p *api.Payload) {
 				a.SpanModifier.ModifySpan(chunk, span)
 			}
 			a.obfuscateSpan(span)
+			a.scanSensitiveData(span, ts.SDSRuleHits)
 			a.Truncate(span)
 			if p.ClientComputedTopLevel {
 				traceutil.UpdateTracerTopLevel(span)
 			}
 		}
 		a.Replacer.Replace(chunk.Spans)
+		a.RuleFilter.Rewrite(chunk.Spans, ts.FilterRuleHits.Inc)
 
 		a.setRootSpanTags(root)
 		if !p.ClientComputedTopLevel {
@@ -386,11 +443,26 @@ func (a *Agent) Process(p *api.Payload) {
 		a.setPayloadAttributes(p, root, chunk)
 
 		pt := processedTrace(p, chunk, root, p.TracerPayload.ContainerID, a.conf)
+		// The edges are counted before sampling, to report all of them.
+		a.ServiceMap.Add(pt.TracerEnv, chunk, root, pt.ClientDroppedP0sWeight)
 		if !p.ClientComputedStats {
 			statsInput.Traces = append(statsInput.Traces, *pt.Clone())
 		}
 
+		var unsampled *pb.TraceChunk
+		if a.TailSampler != nil {
+			unsampled = pt.TraceChunk.ShallowCopy()
+		}
 		keep, numEvents := a.sample(now, ts, pt)
+		if a.TailSampler != nil {
+			if keep {
+				a.TailSampler.Keep(now, root.TraceID)
+			} else if a.TailSampler.Add(now, root.TraceID, p.TracerPayload, unsampled, pt.TraceChunk, int64(numEvents)) {
+				// The chunk is held until the tail sampling decision of its trace.
+				p.RemoveChunk(i)
+				continue
+			}
+		}
 		if !keep && len(pt.TraceChunk.Spans) == 0 {
 			// The entire trace was dropped and no spans were kept.
 			p.RemoveChunk(i)
@@ -613,7 +685,9 @@ func isManualUserDrop(pt *traceutil.ProcessedTrace) bool {
 
 // traceSampling reports whether the chunk should be kept as a trace, setting "DroppedTrace" on the chunk
 func (a *Agent) traceSampling(now time.Time, ts *info.TagStats, pt *traceutil.ProcessedTrace) (keep bool, checkAnalyticsEvents bool) {
-	sampled, check := a.runSamplers(now, ts, *pt)
+	sampled, check, samplerName := a.runSamplers(now, ts, *pt)
+	// the budgets drop the excess of the traces kept by the samplers
+	sampled = a.ServiceBudgets.Sample(now, pt.TraceChunk, pt.Root, pt.TracerEnv, pt.ClientDroppedP0sWeight, sampled, samplerName)
 	pt.TraceChunk.DroppedTrace = !sampled
 	return sampled, check
 }
@@ -634,9 +708,10 @@ func (a *Agent) getAnalyzedEvents(pt *traceutil.ProcessedTrace, ts *info.TagStat
 // enabled, it is run on the trace, followed by the error sampler. Otherwise, If the trace has a
 // priority set, the sampling priority is used with the Priority Sampler. When there is no priority
 // set, the NoPrioritySampler is run. Finally, if the trace has not been sampled by the other
-// samplers, the error sampler is run.
-func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) (keep bool, checkAnalyticsEvents bool) {
-	samplerName := sampler.NameUnknown
+// samplers, the error sampler is run. The name of the sampler making the decision is returned
+// along with it.
+func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.ProcessedTrace) (keep bool, checkAnalyticsEvents bool, samplerName sampler.Name) {
+	samplerName = sampler.NameUnknown
 	samplingPriority := sampler.PriorityNone
 	defer func() {
 		a.SamplerMetrics.RecordMetricsKey(keep, sampler.NewMetricsKey(pt.Root.Service, pt.TracerEnv, samplerName, samplingPriority))
@@ -646,9 +721,9 @@ func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.Proce
 		samplerName = sampler.NameError
 		if traceContainsError(pt.TraceChunk.Spans, true) {
 			pt.TraceChunk.Tags["_dd.error_tracking_standalone.error"] = "true"
-			return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), false
+			return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), false, samplerName
 		}
-		return false, false
+		return false, false, samplerName
 	}
 
 	// Run this early to make sure the signature gets counted by the RareSampler.
@@ -658,17 +733,17 @@ func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.Proce
 		samplerName = sampler.NameProbabilistic
 		if rare {
 			samplerName = sampler.NameRare
-			return true, true
+			return true, true, samplerName
 		}
 		if a.ProbabilisticSampler.Sample(pt.Root) {
 			pt.TraceChunk.Tags[tagDecisionMaker] = probabilitySampling
-			return true, true
+			return true, true, samplerName
 		}
 		if traceContainsError(pt.TraceChunk.Spans, false) {
 			samplerName = sampler.NameError
-			return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), true
+			return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), true, samplerName
 		}
-		return false, true
+		return false, true, samplerName
 	}
 
 	priority, hasPriority := sampler.GetSamplingPriority(pt.TraceChunk)
@@ -688,33 +763,33 @@ func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.Proce
 		// Note that we DON'T skip single span sampling. We only do this for historical
 		// reasons and analytics events are deprecated so hopefully this can all go away someday.
 		if isManualUserDrop(&pt) {
-			return false, false
+			return false, false, samplerName
 		}
 	} else { // This path to be deleted once manualUserDrop detection is available on all tracers for P < 1.
 		if priority < 0 {
-			return false, false
+			return false, false, samplerName
 		}
 	}
 
 	if rare {
 		samplerName = sampler.NameRare
-		return true, true
+		return true, true, samplerName
 	}
 
 	if hasPriority {
 		if a.PrioritySampler.Sample(now, pt.TraceChunk, pt.Root, pt.TracerEnv, pt.ClientDroppedP0sWeight) {
-			return true, true
+			return true, true, samplerName
 		}
 	} else if a.NoPrioritySampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv) {
-		return true, true
+		return true, true, samplerName
 	}
 
 	if traceContainsError(pt.TraceChunk.Spans, false) {
 		samplerName = sampler.NameError
-		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), true
+		return a.ErrorsSampler.Sample(now, pt.TraceChunk.Spans, pt.Root, pt.TracerEnv), true, samplerName
 	}
 
-	return false, true
+	return false, true, samplerName
 }
 
 func traceContainsError(trace pb.Trace, considerExceptionEvents bool) bool {
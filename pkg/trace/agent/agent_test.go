@@ -40,6 +40,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats"
 	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/teststatsd"
 	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/timing"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
@@ -255,6 +256,139 @@ func TestProcess(t *testing.T) {
 		assert.EqualValues(2, want.SpansFiltered.Load())
 	})
 
+	t.Run("FilterRules", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.FilterRules = []*config.FilterRule{
+			{Name: "drop_health_checks", Action: config.FilterDrop, ServiceRe: regexp.MustCompile("^web$"), ResourceRe: regexp.MustCompile("^GET /health")},
+			{Name: "strip_customer_ids", Action: config.FilterReplaceTag, Tag: "customer.id", Re: regexp.MustCompile("[0-9]+"), Repl: "?"},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+
+		now := time.Now()
+		healthCheck := &pb.Span{
+			TraceID:  1,
+			SpanID:   1,
+			Service:  "web",
+			Resource: "GET /health",
+			Type:     "web",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+		}
+		checkout := &pb.Span{
+			TraceID:  2,
+			SpanID:   2,
+			Service:  "web",
+			Resource: "POST /checkout",
+			Type:     "web",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Meta:     map[string]string{"customer.id": "cust-1234"},
+		}
+
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		assert := assert.New(t)
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpan(healthCheck)),
+			Source:        want,
+		})
+		assert.EqualValues(1, want.TracesFiltered.Load())
+		assert.EqualValues(1, want.SpansFiltered.Load())
+
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpan(checkout)),
+			Source:        want,
+		})
+		assert.EqualValues(1, want.TracesFiltered.Load())
+		assert.Equal("cust-?", checkout.Meta["customer.id"])
+		assert.Equal(map[string]int64{"drop_health_checks": 1, "strip_customer_ids": 1}, want.FilterRuleHits.Values())
+	})
+
+	t.Run("SensitiveDataScanner", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+		agnt.SensitiveDataScanner = &mockSensitiveDataScanner{rule: "email", re: regexp.MustCompile(`[a-z.]+@[a-z.]+`)}
+
+		now := time.Now()
+		span := &pb.Span{
+			TraceID:  1,
+			SpanID:   1,
+			Service:  "web",
+			Resource: "POST /signup",
+			Type:     "web",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Meta: map[string]string{
+				"user.email":   "john.doe@example.com",
+				"user.contact": "john@example.com or jane@example.com",
+				"user.id":      "1234",
+				"_dd.origin":   "ci@example.com",
+			},
+		}
+
+		want := agnt.Receiver.Stats.GetTagStats(info.Tags{})
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(testutil.TraceChunkWithSpan(span)),
+			Source:        want,
+		})
+		assert.Equal(t, "[REDACTED]", span.Meta["user.email"])
+		assert.Equal(t, "[REDACTED] or [REDACTED]", span.Meta["user.contact"])
+		assert.Equal(t, "1234", span.Meta["user.id"])
+		assert.Equal(t, "ci@example.com", span.Meta["_dd.origin"])
+		assert.Equal(t, map[string]int64{"email": 3}, want.SDSRuleHits.Values())
+
+		// the traces dropped by the samplers are scanned too, as they are
+		// used for the stats and the service map
+		dropped := &pb.Span{
+			TraceID:  2,
+			SpanID:   2,
+			Service:  "web",
+			Resource: "POST /signup",
+			Type:     "web",
+			Start:    now.Add(-time.Second).UnixNano(),
+			Duration: (500 * time.Millisecond).Nanoseconds(),
+			Meta:     map[string]string{"user.email": "jane.doe@example.com"},
+		}
+		chunk := testutil.TraceChunkWithSpan(dropped)
+		chunk.Priority = int32(sampler.PriorityUserDrop)
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        want,
+		})
+		assert.Equal(t, "[REDACTED]", dropped.Meta["user.email"])
+		assert.Equal(t, map[string]int64{"email": 4}, want.SDSRuleHits.Values())
+	})
+
+	t.Run("ServiceMap", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		cfg.ServiceMap.Enabled = true
+		ctx, cancel := context.WithCancel(context.Background())
+		agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+		defer cancel()
+		stats := &teststatsd.Client{}
+		agnt.ServiceMap = NewServiceMap(cfg, stats)
+
+		now := time.Now()
+		root := &pb.Span{TraceID: 1, SpanID: 1, Service: "web", Start: now.UnixNano(), Duration: int64(time.Second)}
+		child := &pb.Span{TraceID: 1, SpanID: 2, ParentID: 1, Service: "auth", Start: now.UnixNano(), Duration: int64(time.Millisecond)}
+		chunk := testutil.TraceChunkWithSpans([]*pb.Span{root, child})
+		chunk.Priority = int32(sampler.PriorityUserDrop)
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+		agnt.ServiceMap.flush()
+		// the edges of the dropped traces are reported
+		assert.EqualValues(t, 1, stats.GetCountSummaries()["datadog.trace_agent.service_map.requests"].Sum)
+	})
+
 	t.Run("Block-all", func(t *testing.T) {
 		cfg := config.New()
 		cfg.Endpoints[0].APIKey = "test"
@@ -1994,6 +2128,20 @@ func TestSampleManualUserDropNoAnalyticsEvents(t *testing.T) {
 	assert.Empty(t, pt.Root.Metrics["_dd.analyzed"])
 }
 
+// mockSensitiveDataScanner redacts the matches of a single rule.
+type mockSensitiveDataScanner struct {
+	rule string
+	re   *regexp.Regexp
+}
+
+func (m *mockSensitiveDataScanner) Scan(value string) (string, []string) {
+	var rules []string
+	for range m.re.FindAllStringIndex(value, -1) {
+		rules = append(rules, m.rule)
+	}
+	return m.re.ReplaceAllString(value, "[REDACTED]"), rules
+}
+
 func TestPartialSamplingFree(t *testing.T) {
 	cfg := &config.AgentConfig{RareSamplerEnabled: false, BucketInterval: 10 * time.Second}
 	dynConf := sampler.NewDynamicConfig()
@@ -2003,6 +2151,7 @@ func TestPartialSamplingFree(t *testing.T) {
 		Concentrator:      &mockConcentrator{},
 		Blacklister:       filters.NewBlacklister(cfg.Ignore["resource"]),
 		Replacer:          filters.NewReplacer(cfg.ReplaceTags),
+		RuleFilter:        filters.NewRuleFilter(cfg.FilterRules),
 		NoPrioritySampler: sampler.NewNoPrioritySampler(cfg),
 		ErrorsSampler:     sampler.NewErrorsSampler(cfg),
 		PrioritySampler:   sampler.NewPrioritySampler(cfg, &sampler.DynamicConfig{}),
@@ -3435,3 +3584,38 @@ func TestUpdateAPIKey(t *testing.T) {
 	tw := agnt.TraceWriter.(*mockTraceWriter)
 	assert.Equal(t, "foo", tw.apiKey)
 }
+
+func TestTailSampling(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.TailSampling.Enabled = true
+	cfg.TailSampling.Policies = []*config.TailSamplingPolicy{{Name: "slow", Type: config.TailSamplingLatency, ThresholdMS: 1000}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewTestAgent(ctx, cfg, telemetry.NewNoopCollector())
+	require.NotNil(t, agnt.TailSampler)
+
+	now := time.Now()
+	process := func(traceID uint64, duration time.Duration) {
+		span := &pb.Span{TraceID: traceID, SpanID: 1, Service: "web", Name: "http.request", Resource: "GET /", Start: now.UnixNano(), Duration: duration.Nanoseconds()}
+		chunk := testutil.TraceChunkWithSpan(span)
+		chunk.Priority = int32(sampler.PriorityAutoDrop)
+		agnt.Process(&api.Payload{
+			TracerPayload: testutil.TracerPayloadWithChunk(chunk),
+			Source:        info.NewReceiverStats().GetTagStats(info.Tags{}),
+		})
+	}
+	process(1, 2*time.Second)
+	process(2, time.Millisecond)
+
+	// the chunks dropped by the head samplers wait for the tail sampling decision
+	writer := agnt.TraceWriter.(*mockTraceWriter)
+	assert.Empty(t, writer.payloads)
+
+	agnt.TailSampler.flush(now, true)
+	require.Len(t, writer.payloads, 1)
+	chunk := writer.payloads[0].TracerPayload.Chunks[0]
+	assert.EqualValues(t, 1, chunk.Spans[0].TraceID)
+	assert.False(t, chunk.DroppedTrace)
+	assert.Equal(t, "slow", chunk.Tags[tagTailSamplingPolicy])
+}
@@ -24,6 +24,7 @@ const (
 	tagSQLQuery         = transform.TagSQLQuery
 	tagHTTPURL          = transform.TagHTTPURL
 	tagDBMS             = transform.TagDBMS
+	tagDynamoDBRequest  = transform.TagDynamoDBRequest
 )
 
 const (
@@ -48,7 +49,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 	}
 
 	switch span.Type {
-	case "sql", "cassandra":
+	case "sql":
 		if span.Resource == "" {
 			return
 		}
@@ -62,6 +63,27 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 			// no error was thrown but no query was found/sanitized either
 			return
 		}
+	case "cassandra":
+		if span.Resource == "" {
+			return
+		}
+		if _, err := transform.ObfuscateCQLSpan(o, span); err != nil {
+			// we have an error, discard the CQL to avoid polluting user resources.
+			log.Debugf("Error parsing CQL query: %v. Resource: %q", err, span.Resource)
+		}
+	case "graphql":
+		if !a.conf.Obfuscation.GraphQL.Enabled {
+			return
+		}
+		transform.ObfuscateGraphQLSpan(o, span)
+	case "dynamodb", "aws":
+		if !a.conf.Obfuscation.DynamoDB.Enabled {
+			return
+		}
+		if span.Meta == nil || span.Meta[tagDynamoDBRequest] == "" {
+			return
+		}
+		span.Meta[tagDynamoDBRequest] = o.ObfuscateDynamoDBString(span.Meta[tagDynamoDBRequest])
 	case "redis", "valkey":
 		// if a span is redis/valkey type, it should be quantized regardless of obfuscation setting.
 		// valkey is a folk of redis, so we can use the same logic for both.
@@ -161,13 +183,25 @@ func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 
 	switch b.Type {
 	case "sql", "cassandra":
-		oq, err := o.ObfuscateSQLStringForDBMS(b.Resource, b.DBType)
+		var (
+			oq  *obfuscate.ObfuscatedQuery
+			err error
+		)
+		if b.Type == "cassandra" {
+			oq, err = o.ObfuscateCQLString(b.Resource)
+		} else {
+			oq, err = o.ObfuscateSQLStringForDBMS(b.Resource, b.DBType)
+		}
 		if err != nil {
 			log.Errorf("Error obfuscating stats group resource %q: %v", b.Resource, err)
 			b.Resource = textNonParsable
 		} else {
 			b.Resource = oq.Query
 		}
+	case "graphql":
+		if a.conf.Obfuscation != nil && a.conf.Obfuscation.GraphQL.Enabled {
+			b.Resource = o.ObfuscateGraphQLString(b.Resource)
+		}
 	case "redis", "valkey":
 		b.Resource = o.QuantizeRedisString(b.Resource)
 	}
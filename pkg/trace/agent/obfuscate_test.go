@@ -45,6 +45,8 @@ func TestObfuscateStatsGroup(t *testing.T) {
 		{statsGroup("sql", "SELECT 1\nFROM Blogs AS [b\nORDER BY [b]"), textNonParsable},
 		{statsGroup("redis", "ADD 1, 2"), "ADD"},
 		{statsGroup("valkey", "ADD 1, 2"), "ADD"},
+		{statsGroup("cassandra", "SELECT * FROM users WHERE id = 550e8400-e29b-41d4-a716-446655440000"), "SELECT * FROM users WHERE id = ?"},
+		{statsGroup("graphql", `query { user(id: "5") { name } }`), `query { user(id: "5") { name } }`},
 		{statsGroup("other", "ADD 1, 2"), "ADD 1, 2"},
 	} {
 		agnt, stop := agentWithDefaults()
@@ -98,6 +100,20 @@ func TestObfuscateDefaults(t *testing.T) {
 		assert.Equal(t, "UPDATE users ( name ) SET ( ? )", span.Meta["sql.query"])
 		assert.Equal(t, "UPDATE users ( name ) SET ( ? )", span.Resource)
 	})
+
+	t.Run("cassandra", func(t *testing.T) {
+		query := "UPDATE users SET emails = {'bob@example.com'} WHERE id = 550e8400-e29b-41d4-a716-446655440000"
+		span := &pb.Span{
+			Type:     "cassandra",
+			Resource: query,
+			Meta:     map[string]string{"sql.query": query},
+		}
+		agnt, stop := agentWithDefaults()
+		defer stop()
+		agnt.obfuscateSpan(span)
+		assert.Equal(t, "UPDATE users SET emails = ? WHERE id = ?", span.Meta["sql.query"])
+		assert.Equal(t, "UPDATE users SET emails = ? WHERE id = ?", span.Resource)
+	})
 }
 
 func agentWithDefaults(features ...string) (agnt *Agent, stop func()) {
@@ -296,6 +312,54 @@ func TestObfuscateConfig(t *testing.T) {
 		&config.ObfuscationConfig{},
 	))
 
+	t.Run("graphql/enabled", testConfig(
+		"graphql",
+		"graphql.source",
+		`query { user(email: "bob@example.com", active: true) { name } }`,
+		`query { user(email: ?, active: ?) { name } }`,
+		&config.ObfuscationConfig{GraphQL: obfuscate.GraphQLConfig{Enabled: true}},
+	))
+
+	t.Run("graphql/variables", testConfig(
+		"graphql",
+		"graphql.variables.email",
+		"bob@example.com",
+		"?",
+		&config.ObfuscationConfig{GraphQL: obfuscate.GraphQLConfig{Enabled: true}},
+	))
+
+	t.Run("graphql/disabled", testConfig(
+		"graphql",
+		"graphql.source",
+		`query { user(email: "bob@example.com") { name } }`,
+		`query { user(email: "bob@example.com") { name } }`,
+		&config.ObfuscationConfig{},
+	))
+
+	t.Run("dynamodb/enabled", testConfig(
+		"dynamodb",
+		"dynamodb.request",
+		`{"TableName": "users", "Key": {"email": {"S": "bob@example.com"}}}`,
+		`{"TableName":"users","Key":{"email":{"S":"?"}}}`,
+		&config.ObfuscationConfig{DynamoDB: obfuscate.JSONConfig{Enabled: true}},
+	))
+
+	t.Run("dynamodb/aws-type", testConfig(
+		"aws",
+		"dynamodb.request",
+		`{"TableName": "users", "Key": {"email": {"S": "bob@example.com"}}}`,
+		`{"TableName":"users","Key":{"email":{"S":"?"}}}`,
+		&config.ObfuscationConfig{DynamoDB: obfuscate.JSONConfig{Enabled: true}},
+	))
+
+	t.Run("dynamodb/disabled", testConfig(
+		"dynamodb",
+		"dynamodb.request",
+		`{"TableName": "users", "Key": {"email": {"S": "bob@example.com"}}}`,
+		`{"TableName": "users", "Key": {"email": {"S": "bob@example.com"}}}`,
+		&config.ObfuscationConfig{},
+	))
+
 	t.Run("creditcard", func(t *testing.T) {
 		for _, tt := range []struct {
 			k, v string
@@ -436,6 +500,49 @@ func TestSQLTableNames(t *testing.T) {
 		agnt.obfuscateSpan(span)
 		assert.Empty(t, span.Meta["sql.tables"])
 	})
+
+	t.Run("procedures", func(t *testing.T) {
+		span := &pb.Span{
+			Resource: "CALL billing.charge(42, 'EUR')",
+			Type:     "sql",
+		}
+		agnt, stop := agentWithDefaults("sql_procedures")
+		defer stop()
+		agnt.obfuscateSpan(span)
+		assert.Equal(t, "billing.charge", span.Meta["sql.procedures"])
+	})
+
+	t.Run("procedures-off", func(t *testing.T) {
+		span := &pb.Span{
+			Resource: "CALL billing.charge(42, 'EUR')",
+			Type:     "sql",
+		}
+		agnt, stop := agentWithDefaults("table_names")
+		defer stop()
+		agnt.obfuscateSpan(span)
+		assert.Empty(t, span.Meta["sql.procedures"])
+	})
+}
+
+func TestSQLFingerprint(t *testing.T) {
+	t.Run("on", func(t *testing.T) {
+		agnt, stop := agentWithDefaults("sql_fingerprint")
+		defer stop()
+		span1 := &pb.Span{Resource: "SELECT * FROM users WHERE id IN (1, 2)", Type: "sql"}
+		span2 := &pb.Span{Resource: "select * from users\nwhere id in (3, 4, 5, 6)", Type: "sql"}
+		agnt.obfuscateSpan(span1)
+		agnt.obfuscateSpan(span2)
+		assert.Len(t, span1.Meta["sql.fingerprint"], 16)
+		assert.Equal(t, span1.Meta["sql.fingerprint"], span2.Meta["sql.fingerprint"])
+	})
+
+	t.Run("off", func(t *testing.T) {
+		span := &pb.Span{Resource: "SELECT * FROM users WHERE id IN (1, 2)", Type: "sql"}
+		agnt, stop := agentWithDefaults()
+		defer stop()
+		agnt.obfuscateSpan(span)
+		assert.Empty(t, span.Meta["sql.fingerprint"])
+	})
 }
 
 func BenchmarkCCObfuscation(b *testing.B) {
@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"strings"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+)
+
+// SensitiveDataScanner scans the span tag values for sensitive data, such as
+// the Sensitive Data Scanner rules of the logs.
+type SensitiveDataScanner interface {
+	// Scan returns the value with its sensitive data redacted, and the names
+	// of the rules which matched it.
+	Scan(value string) (string, []string)
+}
+
+// scanSensitiveData redacts the sensitive data found in the meta of the span by
+// the SensitiveDataScanner, counting the hits of its rules.
+func (a *Agent) scanSensitiveData(s *pb.Span, hits *info.FilterRuleHits) {
+	if a.SensitiveDataScanner == nil {
+		return
+	}
+	for k, v := range s.Meta {
+		// internal tags are set by the tracers and the agent
+		if strings.HasPrefix(k, "_dd.") {
+			continue
+		}
+		redacted, rules := a.SensitiveDataScanner.Scan(v)
+		for _, rule := range rules {
+			hits.Inc(rule)
+		}
+		if redacted != v {
+			s.Meta[k] = redacted
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/sketches-go/ddsketch"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+const (
+	// serviceMapFlushPeriod is the period at which the edges are reported.
+	serviceMapFlushPeriod = 10 * time.Second
+
+	// calleeSourceSpan is the callee source of the edges found between a
+	// parent span and its child span of another service.
+	calleeSourceSpan = "span"
+
+	// serviceMapRelativeAccuracy is the relative accuracy of the latency
+	// percentiles of the edges.
+	serviceMapRelativeAccuracy = 0.01
+	// serviceMapMaxNumBins is the maximum number of bins of the latency sketches.
+	serviceMapMaxNumBins = 2048
+
+	// tagSpanKind is the tag holding the kind of a span, e.g. client.
+	tagSpanKind = "span.kind"
+	// tagBaseService is the peer tag precursor holding the service a span
+	// overrode, which doesn't name a callee.
+	tagBaseService = "_dd.base_service"
+)
+
+// serviceMapPercentiles are the latency percentiles reported for each edge, as
+// gauges: they are computed over a flush period, and can't be aggregated further.
+var serviceMapPercentiles = []struct {
+	name     string
+	quantile float64
+}{
+	{"datadog.trace_agent.service_map.latency.p50", 0.5},
+	{"datadog.trace_agent.service_map.latency.p95", 0.95},
+	{"datadog.trace_agent.service_map.latency.p99", 0.99},
+}
+
+// ServiceMap derives the caller to callee edges between services from the
+// received spans and reports the requests, errors and latency of each edge
+// as metrics. The spans are counted before sampling and weighted by the
+// sampling rate of their tracer and the chunks it dropped, so that the edges
+// of the services whose traces are mostly dropped are reported too.
+//
+// An edge is found for each span whose parent span in the same chunk belongs
+// to another service, and for each client or producer span without such a
+// child, whose callee is then named by its peer tags.
+type ServiceMap struct {
+	conf     config.ServiceMapConfig
+	agentEnv string
+	peerTags []string
+	statsd   statsd.ClientInterface
+
+	mu    sync.Mutex
+	edges map[serviceEdge]*serviceEdgeStats
+	// dropped is the weighted count of the spans of the edges beyond MaxEdges.
+	dropped float64
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// serviceEdge identifies an edge of the service map.
+type serviceEdge struct {
+	env    string
+	caller string
+	callee string
+	// source is how the callee was found: calleeSourceSpan, or the peer tag
+	// naming it.
+	source string
+}
+
+// serviceEdgeStats aggregates the spans of an edge.
+type serviceEdgeStats struct {
+	hits    float64
+	errors  float64
+	latency *ddsketch.DDSketch
+}
+
+// NewServiceMap returns a ServiceMap reporting the edges with statsd.
+func NewServiceMap(conf *config.AgentConfig, statsd statsd.ClientInterface) *ServiceMap {
+	var peerTags []string
+	for _, t := range conf.ConfiguredPeerTags() {
+		if t != tagBaseService {
+			peerTags = append(peerTags, t)
+		}
+	}
+	return &ServiceMap{
+		conf:     conf.ServiceMap,
+		agentEnv: conf.DefaultEnv,
+		peerTags: peerTags,
+		statsd:   statsd,
+		edges:    make(map[serviceEdge]*serviceEdgeStats),
+		exit:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start starts reporting the edges every serviceMapFlushPeriod.
+func (m *ServiceMap) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(serviceMapFlushPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.flush()
+			case <-m.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the service map, reporting the pending edges.
+func (m *ServiceMap) Stop() {
+	close(m.exit)
+	<-m.done
+	m.flush()
+}
+
+// Add counts the edges of the chunk, received from a tracer in env which
+// dropped clientDroppedP0sWeight chunks per chunk received.
+func (m *ServiceMap) Add(env string, chunk *pb.TraceChunk, root *pb.Span, clientDroppedP0sWeight float64) {
+	if m == nil || len(chunk.Spans) == 0 {
+		return
+	}
+	if env == "" {
+		env = m.agentEnv
+	}
+	w := serviceMapWeight(root, clientDroppedP0sWeight)
+	byID := make(map[uint64]*pb.Span, len(chunk.Spans))
+	for _, s := range chunk.Spans {
+		byID[s.SpanID] = s
+	}
+	// callers are the spans with a child of another service, whose callee is
+	// already known.
+	callers := make(map[uint64]struct{})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range chunk.Spans {
+		parent, ok := byID[s.ParentID]
+		if !ok || parent.Service == s.Service || s.SpanID == s.ParentID {
+			continue
+		}
+		callers[parent.SpanID] = struct{}{}
+		if traceutil.IsPartialSnapshot(s) {
+			continue
+		}
+		m.add(serviceEdge{env: env, caller: parent.Service, callee: s.Service, source: calleeSourceSpan}, s, w)
+	}
+	for _, s := range chunk.Spans {
+		if _, ok := callers[s.SpanID]; ok || traceutil.IsPartialSnapshot(s) {
+			continue
+		}
+		if kind := strings.ToLower(s.Meta[tagSpanKind]); kind != "client" && kind != "producer" {
+			continue
+		}
+		if callee, source := m.peer(s); callee != "" {
+			m.add(serviceEdge{env: env, caller: s.Service, callee: callee, source: source}, s, w)
+		}
+	}
+}
+
+// peer returns the callee of a client or producer span and the peer tag it
+// was read from: peer.service when set, otherwise the first peer tag set.
+func (m *ServiceMap) peer(s *pb.Span) (callee, source string) {
+	if v := s.Meta[peerServiceKey]; v != "" {
+		return v, peerServiceKey
+	}
+	for _, t := range m.peerTags {
+		if v := s.Meta[t]; v != "" {
+			return obfuscate.QuantizePeerIPAddresses(v), t
+		}
+	}
+	return "", ""
+}
+
+// add counts the span s of weight w in the edge e. m must be locked.
+func (m *ServiceMap) add(e serviceEdge, s *pb.Span, w float64) {
+	es, ok := m.edges[e]
+	if !ok {
+		if m.conf.MaxEdges > 0 && len(m.edges) >= m.conf.MaxEdges {
+			m.dropped += w
+			return
+		}
+		sketch, err := ddsketch.LogCollapsingLowestDenseDDSketch(serviceMapRelativeAccuracy, serviceMapMaxNumBins)
+		if err != nil {
+			log.Errorf("Error when creating ddsketch: %v", err)
+			return
+		}
+		es = &serviceEdgeStats{latency: sketch}
+		m.edges[e] = es
+	}
+	es.hits += w
+	if s.Error != 0 {
+		es.errors += w
+	}
+	if s.Duration > 0 {
+		_ = es.latency.AddWithCount(float64(s.Duration)/float64(time.Second), w)
+	}
+}
+
+// flush reports the edges counted since the previous flush.
+func (m *ServiceMap) flush() {
+	m.mu.Lock()
+	edges, dropped := m.edges, m.dropped
+	m.edges = make(map[serviceEdge]*serviceEdgeStats, len(edges))
+	m.dropped = 0
+	m.mu.Unlock()
+
+	for e, es := range edges {
+		tags := []string{"env:" + e.env, "caller:" + e.caller, "callee:" + e.callee, "callee_source:" + e.source}
+		_ = m.statsd.Count("datadog.trace_agent.service_map.requests", int64(math.Round(es.hits)), tags, 1)
+		_ = m.statsd.Count("datadog.trace_agent.service_map.errors", int64(math.Round(es.errors)), tags, 1)
+		if es.latency.IsEmpty() {
+			continue
+		}
+		for _, p := range serviceMapPercentiles {
+			if v, err := es.latency.GetValueAtQuantile(p.quantile); err == nil {
+				_ = m.statsd.Gauge(p.name, v, tags, 1)
+			}
+		}
+	}
+	_ = m.statsd.Gauge("datadog.trace_agent.service_map.edges", float64(len(edges)), nil, 1)
+	if dropped > 0 {
+		_ = m.statsd.Count("datadog.trace_agent.service_map.dropped_spans", int64(math.Round(dropped)), nil, 1)
+	}
+}
+
+// serviceMapWeight returns the number of spans a span of the chunk of root
+// stands for, i.e. the inverse of the sampling rate of the tracer, plus the
+// chunks dropped by the tracer per chunk received.
+func serviceMapWeight(root *pb.Span, clientDroppedP0sWeight float64) float64 {
+	w := 1.0
+	if root != nil {
+		if rate, ok := root.Metrics[sampler.KeySamplingRateGlobal]; ok && rate > 0 && rate <= 1 {
+			w = 1 / rate
+		}
+	}
+	return w + clientDroppedP0sWeight
+}
@@ -0,0 +1,142 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/teststatsd"
+)
+
+func newTestServiceMap(maxEdges int) (*ServiceMap, *teststatsd.Client) {
+	conf := config.New()
+	conf.DefaultEnv = "agent-env"
+	conf.ServiceMap = config.ServiceMapConfig{Enabled: true, MaxEdges: maxEdges}
+	stats := &teststatsd.Client{}
+	return NewServiceMap(conf, stats), stats
+}
+
+// serviceMapCounts returns the counts reported for the metric by edge tags.
+func serviceMapCounts(stats *teststatsd.Client, name string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, c := range stats.CountCalls {
+		if c.Name == name {
+			counts[c.Tags[1]+"->"+c.Tags[2]+" "+c.Tags[3]] += c.Value
+		}
+	}
+	return counts
+}
+
+func TestServiceMap(t *testing.T) {
+	m, stats := newTestServiceMap(100)
+	chunk := &pb.TraceChunk{Spans: []*pb.Span{
+		{Service: "web", SpanID: 1, Duration: int64(time.Second), Metrics: map[string]float64{"_sample_rate": 0.25}},
+		// a child span of another service
+		{Service: "auth", SpanID: 2, ParentID: 1, Duration: int64(100 * time.Millisecond), Error: 1},
+		// a client span whose callee is traced in the chunk
+		{Service: "web", SpanID: 3, ParentID: 1, Meta: map[string]string{"span.kind": "client", "peer.service": "billing"}},
+		{Service: "billing", SpanID: 4, ParentID: 3, Duration: int64(200 * time.Millisecond)},
+		// client spans named by their peer tags
+		{Service: "web", SpanID: 5, ParentID: 1, Meta: map[string]string{"span.kind": "client", "peer.service": "users-db", "db.instance": "users"}},
+		{Service: "web", SpanID: 6, ParentID: 1, Meta: map[string]string{"span.kind": "producer", "messaging.destination": "orders"}},
+		{Service: "web", SpanID: 7, ParentID: 1, Meta: map[string]string{"span.kind": "client", "out.host": "10.0.0.1"}},
+		// not an exit span
+		{Service: "web", SpanID: 8, ParentID: 1, Meta: map[string]string{"span.kind": "internal", "db.instance": "users"}},
+	}}
+	m.Add("", chunk, chunk.Spans[0], 0)
+	m.Add("prod", chunk, chunk.Spans[0], 0)
+	m.flush()
+
+	assert.Equal(t, map[string]float64{
+		"caller:web->callee:auth callee_source:span":                    8,
+		"caller:web->callee:billing callee_source:span":                 8,
+		"caller:web->callee:users-db callee_source:peer.service":        8,
+		"caller:web->callee:orders callee_source:messaging.destination": 8,
+		"caller:web->callee:blocked-ip-address callee_source:out.host":  8,
+	}, serviceMapCounts(stats, "datadog.trace_agent.service_map.requests"))
+	assert.Equal(t, map[string]float64{
+		"caller:web->callee:auth callee_source:span":                    8,
+		"caller:web->callee:billing callee_source:span":                 0,
+		"caller:web->callee:users-db callee_source:peer.service":        0,
+		"caller:web->callee:orders callee_source:messaging.destination": 0,
+		"caller:web->callee:blocked-ip-address callee_source:out.host":  0,
+	}, serviceMapCounts(stats, "datadog.trace_agent.service_map.errors"))
+
+	envs := make(map[string]bool)
+	for _, c := range stats.CountCalls {
+		envs[c.Tags[0]] = true
+	}
+	assert.Equal(t, map[string]bool{"env:agent-env": true, "env:prod": true}, envs)
+
+	var latency []float64
+	for _, c := range stats.GaugeCalls {
+		if c.Name == "datadog.trace_agent.service_map.latency.p50" && c.Tags[0] == "env:prod" && c.Tags[2] == "callee:auth" {
+			latency = append(latency, c.Value)
+		}
+	}
+	require.Len(t, latency, 1)
+	assert.InEpsilon(t, 0.1, latency[0], 0.02)
+	assert.Equal(t, 10.0, stats.GetGaugeSummaries()["datadog.trace_agent.service_map.edges"].Last)
+
+	// the edges are reset by the flush
+	stats.Reset()
+	m.flush()
+	assert.Empty(t, serviceMapCounts(stats, "datadog.trace_agent.service_map.requests"))
+}
+
+func TestServiceMapClientDroppedP0s(t *testing.T) {
+	m, stats := newTestServiceMap(100)
+	chunk := &pb.TraceChunk{Spans: []*pb.Span{
+		{Service: "web", SpanID: 1, Metrics: map[string]float64{"_sample_rate": 0.5}},
+		{Service: "auth", SpanID: 2, ParentID: 1},
+	}}
+	// the chunks dropped by the tracer are accounted for in the edges of the
+	// chunks received
+	m.Add("prod", chunk, chunk.Spans[0], 3)
+	m.flush()
+
+	assert.Equal(t, map[string]float64{
+		"caller:web->callee:auth callee_source:span": 5,
+	}, serviceMapCounts(stats, "datadog.trace_agent.service_map.requests"))
+	assert.Equal(t, 1.0, serviceMapWeight(nil, 0))
+}
+
+func TestServiceMapMaxEdges(t *testing.T) {
+	m, stats := newTestServiceMap(1)
+	chunk := &pb.TraceChunk{Spans: []*pb.Span{
+		{Service: "web", SpanID: 1},
+		{Service: "auth", SpanID: 2, ParentID: 1},
+		{Service: "users", SpanID: 3, ParentID: 1},
+		{Service: "auth", SpanID: 4, ParentID: 1},
+	}}
+	m.Add("prod", chunk, chunk.Spans[0], 0)
+	m.flush()
+
+	assert.Equal(t, map[string]float64{
+		"caller:web->callee:auth callee_source:span": 2,
+	}, serviceMapCounts(stats, "datadog.trace_agent.service_map.requests"))
+	assert.EqualValues(t, 1, stats.GetCountSummaries()["datadog.trace_agent.service_map.dropped_spans"].Sum)
+}
+
+func TestServiceMapPartialSnapshot(t *testing.T) {
+	m, stats := newTestServiceMap(100)
+	chunk := &pb.TraceChunk{Spans: []*pb.Span{
+		{Service: "web", SpanID: 1},
+		{Service: "auth", SpanID: 2, ParentID: 1, Metrics: map[string]float64{"_dd.partial_version": 1}},
+	}}
+	m.Add("prod", chunk, chunk.Spans[0], 0)
+	m.flush()
+	assert.Empty(t, serviceMapCounts(stats, "datadog.trace_agent.service_map.requests"))
+
+	var nilMap *ServiceMap
+	nilMap.Add("prod", chunk, chunk.Spans[0], 0)
+}
@@ -0,0 +1,355 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+const (
+	// tagTailSamplingPolicy is set on the chunks of the traces kept by the
+	// tail sampler, with the name of the matching policy.
+	tagTailSamplingPolicy = "_dd.tail_sampling.policy"
+
+	// tailSamplingHeadKeep is the policy reported when a chunk of the trace
+	// was kept by the head samplers.
+	tailSamplingHeadKeep = "head_sampling"
+
+	// tailSamplerFlushPeriod is the period at which the traces whose decision
+	// wait expired are sampled.
+	tailSamplerFlushPeriod = time.Second
+
+	// tailDecisionSize is the approximate size in bytes of a decision, counted
+	// in the memory of the tail sampler.
+	tailDecisionSize = 64
+)
+
+// TailSampler buffers by trace ID the chunks dropped by the head samplers, and
+// applies the tail sampling policies to each trace once all its chunks are
+// expected to be received, i.e. after the decision wait. The chunks of a trace
+// kept by a policy are written as sampled, the others as they were left by
+// the head samplers (single spans and analyzed events only). A trace is also
+// kept as soon as one of its chunks is kept by the head samplers, so that the
+// chunks of the upstream services are kept along an error in a downstream one.
+type TailSampler struct {
+	conf   config.TailSamplingConfig
+	write  func(*writer.SampledChunks)
+	statsd statsd.ClientInterface
+
+	mu sync.Mutex
+	// traces are the buffered traces by trace ID.
+	traces map[uint64]*tailTrace
+	// order holds the buffered traces, oldest first.
+	order *list.List
+	// size is the size in bytes of the buffered chunks and of the decisions.
+	size int64
+	// decisions are the decisions taken for the recently sampled traces, so
+	// that their late chunks follow the same decision.
+	decisions map[uint64]*tailDecision
+	// decisionOrder holds the decisions, oldest first.
+	decisionOrder *list.List
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// tailTrace is a trace buffered by the tail sampler.
+type tailTrace struct {
+	traceID  uint64
+	received time.Time
+	chunks   []*tailChunk
+	size     int64
+	elem     *list.Element
+}
+
+// tailChunk is a chunk dropped by the head samplers.
+type tailChunk struct {
+	// payload holds the attributes of the tracer payload of the chunk.
+	payload *pb.TracerPayload
+	// full is the chunk as received.
+	full *pb.TraceChunk
+	// sampled is the chunk as left by the head samplers.
+	sampled *pb.TraceChunk
+	// events is the number of analyzed events in sampled.
+	events int64
+}
+
+// tailDecision is the decision taken for a trace.
+type tailDecision struct {
+	traceID uint64
+	policy  string
+	keep    bool
+	expires time.Time
+	elem    *list.Element
+}
+
+// NewTailSampler returns a tail sampler writing the sampled chunks with write.
+func NewTailSampler(conf config.TailSamplingConfig, write func(*writer.SampledChunks), statsd statsd.ClientInterface) *TailSampler {
+	return &TailSampler{
+		conf:          conf,
+		write:         write,
+		statsd:        statsd,
+		traces:        make(map[uint64]*tailTrace),
+		order:         list.New(),
+		decisions:     make(map[uint64]*tailDecision),
+		decisionOrder: list.New(),
+		exit:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Start starts sampling the buffered traces whose decision wait expired.
+func (s *TailSampler) Start() {
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(tailSamplerFlushPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				s.flush(now, false)
+			case <-s.exit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the tail sampler, sampling all the buffered traces.
+func (s *TailSampler) Stop() {
+	close(s.exit)
+	<-s.done
+	s.flush(time.Now(), true)
+}
+
+// Keep records that a chunk of the trace was kept by the head samplers, the
+// buffered chunks of the trace, and the ones received later, are kept too.
+func (s *TailSampler) Keep(now time.Time, traceID uint64) {
+	s.mu.Lock()
+	var out []*writer.SampledChunks
+	if t, ok := s.traces[traceID]; ok {
+		out = s.decide(now, t, tailSamplingHeadKeep, true)
+	} else if _, ok := s.decisions[traceID]; !ok {
+		s.setDecision(now, traceID, tailSamplingHeadKeep, true)
+	}
+	out = append(out, s.shrink(now)...)
+	s.mu.Unlock()
+	s.writeAll(out)
+}
+
+// Add takes over a chunk dropped by the head samplers, given as received
+// (full) and as left by the samplers (sampled). It returns false if the chunk
+// should be handled as sampled by the head samplers, i.e. when the trace was
+// dropped by a user or already dropped by the tail sampler.
+func (s *TailSampler) Add(now time.Time, traceID uint64, payload *pb.TracerPayload, full, sampled *pb.TraceChunk, events int64) bool {
+	if priority, ok := sampler.GetSamplingPriority(full); ok && priority < 0 {
+		// the user asked to drop the trace
+		return false
+	}
+	chunk := &tailChunk{payload: payloadAttributes(payload), full: full, sampled: sampled, events: events}
+
+	s.mu.Lock()
+	if d, ok := s.decisions[traceID]; ok {
+		s.mu.Unlock()
+		if !d.keep {
+			return false
+		}
+		s.writeAll([]*writer.SampledChunks{keptChunk(chunk, d.policy)})
+		return true
+	}
+
+	t, ok := s.traces[traceID]
+	if !ok {
+		t = &tailTrace{traceID: traceID, received: now}
+		t.elem = s.order.PushBack(t)
+		s.traces[traceID] = t
+	}
+	size := int64(full.Msgsize())
+	t.chunks = append(t.chunks, chunk)
+	t.size += size
+	s.size += size
+
+	out := s.shrink(now)
+	s.mu.Unlock()
+	s.writeAll(out)
+	return true
+}
+
+// flush samples the traces whose decision wait expired, or all of them when
+// force is true, and forgets the expired decisions.
+func (s *TailSampler) flush(now time.Time, force bool) {
+	s.mu.Lock()
+	var out []*writer.SampledChunks
+	for s.order.Len() > 0 {
+		t := s.order.Front().Value.(*tailTrace)
+		if !force && now.Sub(t.received) < s.conf.DecisionWait {
+			break
+		}
+		policy, keep := s.evaluate(t)
+		out = append(out, s.decide(now, t, policy, keep)...)
+	}
+	for s.decisionOrder.Len() > 0 {
+		d := s.decisionOrder.Front().Value.(*tailDecision)
+		if !now.After(d.expires) {
+			break
+		}
+		s.forget(d)
+	}
+	_ = s.statsd.Gauge("datadog.trace_agent.tail_sampler.buffered_traces", float64(len(s.traces)), nil, 1)
+	_ = s.statsd.Gauge("datadog.trace_agent.tail_sampler.buffered_bytes", float64(s.size), nil, 1)
+	s.mu.Unlock()
+	s.writeAll(out)
+}
+
+// decide removes a trace from the buffer, records the decision taken for it
+// and returns its chunks to write. It must be called with the lock held.
+func (s *TailSampler) decide(now time.Time, t *tailTrace, policy string, keep bool) []*writer.SampledChunks {
+	s.order.Remove(t.elem)
+	delete(s.traces, t.traceID)
+	s.size -= t.size
+	s.setDecision(now, t.traceID, policy, keep)
+
+	decision := "decision:dropped"
+	if keep {
+		decision = "decision:kept"
+	}
+	_ = s.statsd.Count("datadog.trace_agent.tail_sampler.traces", 1, []string{decision, "policy:" + policy}, 1)
+
+	out := make([]*writer.SampledChunks, 0, len(t.chunks))
+	for _, c := range t.chunks {
+		if keep {
+			out = append(out, keptChunk(c, policy))
+		} else if len(c.sampled.Spans) > 0 {
+			out = append(out, sampledChunks(c.payload, c.sampled, c.events))
+		}
+	}
+	return out
+}
+
+// setDecision records the decision taken for a trace. It must be called with
+// the lock held.
+func (s *TailSampler) setDecision(now time.Time, traceID uint64, policy string, keep bool) {
+	if d, ok := s.decisions[traceID]; ok {
+		s.forget(d)
+	}
+	d := &tailDecision{traceID: traceID, policy: policy, keep: keep, expires: now.Add(s.conf.DecisionWait)}
+	d.elem = s.decisionOrder.PushBack(d)
+	s.decisions[traceID] = d
+	s.size += tailDecisionSize
+}
+
+// forget removes a decision. It must be called with the lock held.
+func (s *TailSampler) forget(d *tailDecision) {
+	s.decisionOrder.Remove(d.elem)
+	delete(s.decisions, d.traceID)
+	s.size -= tailDecisionSize
+}
+
+// shrink forgets the oldest decisions, then applies the policies to the
+// oldest traces early, while over budget. It returns the chunks to write and
+// must be called with the lock held.
+func (s *TailSampler) shrink(now time.Time) []*writer.SampledChunks {
+	var out []*writer.SampledChunks
+	for s.conf.MaxMemory > 0 && s.size > s.conf.MaxMemory {
+		if s.decisionOrder.Len() > 0 {
+			// the late chunks of the trace are sampled on their own
+			s.forget(s.decisionOrder.Front().Value.(*tailDecision))
+			continue
+		}
+		if s.order.Len() == 0 {
+			break
+		}
+		oldest := s.order.Front().Value.(*tailTrace)
+		policy, keep := s.evaluate(oldest)
+		out = append(out, s.decide(now, oldest, policy, keep)...)
+		_ = s.statsd.Count("datadog.trace_agent.tail_sampler.evicted", 1, nil, 1)
+	}
+	return out
+}
+
+// evaluate returns the first policy matching a trace, and whether the trace
+// is kept.
+func (s *TailSampler) evaluate(t *tailTrace) (string, bool) {
+	for _, p := range s.conf.Policies {
+		for _, c := range t.chunks {
+			for _, span := range c.full.Spans {
+				if policyMatches(p, span) {
+					return p.Name, true
+				}
+			}
+		}
+	}
+	return "none", false
+}
+
+// policyMatches returns true if the span matches the policy.
+func policyMatches(p *config.TailSamplingPolicy, span *pb.Span) bool {
+	if p.Service != "" && span.Service != p.Service {
+		return false
+	}
+	switch p.Type {
+	case config.TailSamplingLatency:
+		return float64(span.Duration) >= p.ThresholdMS*float64(time.Millisecond)
+	case config.TailSamplingError:
+		return span.Error != 0
+	case config.TailSamplingResource:
+		return p.Re != nil && p.Re.MatchString(span.Resource)
+	case config.TailSamplingTag:
+		v, ok := span.Meta[p.Key]
+		return ok && (p.Re == nil || p.Re.MatchString(v))
+	default:
+		return false
+	}
+}
+
+func (s *TailSampler) writeAll(out []*writer.SampledChunks) {
+	for _, c := range out {
+		s.write(c)
+	}
+}
+
+// keptChunk returns the chunk as received, marked as kept by the policy.
+func keptChunk(c *tailChunk, policy string) *writer.SampledChunks {
+	chunk := c.full
+	chunk.DroppedTrace = false
+	chunk.Priority = int32(sampler.PriorityAutoKeep)
+	if chunk.Tags == nil {
+		chunk.Tags = make(map[string]string)
+	}
+	chunk.Tags[tagTailSamplingPolicy] = policy
+	return sampledChunks(c.payload, chunk, 0)
+}
+
+// sampledChunks returns the SampledChunks holding a single chunk.
+func sampledChunks(payload *pb.TracerPayload, chunk *pb.TraceChunk, events int64) *writer.SampledChunks {
+	tp := payloadAttributes(payload)
+	tp.Chunks = []*pb.TraceChunk{chunk}
+	sc := &writer.SampledChunks{
+		TracerPayload: tp,
+		Size:          chunk.Msgsize(),
+		EventCount:    events,
+	}
+	if !chunk.DroppedTrace {
+		sc.SpanCount = int64(len(chunk.Spans))
+	}
+	return sc
+}
+
+// payloadAttributes returns a copy of the payload without its chunks.
+func payloadAttributes(p *pb.TracerPayload) *pb.TracerPayload {
+	attrs := p.Cut(0)
+	attrs.Chunks = nil
+	return attrs
+}
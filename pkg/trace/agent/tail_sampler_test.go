@@ -0,0 +1,183 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+func newTestTailSampler(maxMemory int64, policies ...*config.TailSamplingPolicy) (*TailSampler, *[]*writer.SampledChunks) {
+	var written []*writer.SampledChunks
+	s := NewTailSampler(config.TailSamplingConfig{
+		Enabled:      true,
+		DecisionWait: 10 * time.Second,
+		MaxMemory:    maxMemory,
+		Policies:     policies,
+	}, func(c *writer.SampledChunks) { written = append(written, c) }, &statsd.NoOpClient{})
+	return s, &written
+}
+
+// addChunk adds to the tail sampler a chunk of the trace dropped by the head
+// samplers, with one span per service.
+func addChunk(s *TailSampler, now time.Time, traceID uint64, spans ...*pb.Span) bool {
+	for _, span := range spans {
+		span.TraceID = traceID
+	}
+	full := &pb.TraceChunk{Priority: int32(sampler.PriorityAutoDrop), Spans: spans, DroppedTrace: true}
+	sampled := &pb.TraceChunk{Priority: full.Priority, DroppedTrace: true}
+	return s.Add(now, traceID, &pb.TracerPayload{Hostname: "host", Env: "prod"}, full, sampled, 0)
+}
+
+func TestTailSamplerPolicies(t *testing.T) {
+	policies := []*config.TailSamplingPolicy{
+		{Name: "slow", Type: config.TailSamplingLatency, ThresholdMS: 500},
+		{Name: "errors", Type: config.TailSamplingError},
+		{Name: "checkout", Type: config.TailSamplingResource, Service: "web", Re: regexp.MustCompile("^POST /checkout")},
+		{Name: "gold", Type: config.TailSamplingTag, Key: "customer.tier", Re: regexp.MustCompile("^gold$")},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		span   *pb.Span
+		policy string
+	}{
+		{name: "slow", span: &pb.Span{Service: "db", Duration: int64(time.Second)}, policy: "slow"},
+		{name: "error", span: &pb.Span{Service: "db", Error: 1}, policy: "errors"},
+		{name: "resource", span: &pb.Span{Service: "web", Resource: "POST /checkout/cart"}, policy: "checkout"},
+		{name: "resource of another service", span: &pb.Span{Service: "api", Resource: "POST /checkout/cart"}},
+		{name: "tag", span: &pb.Span{Service: "web", Meta: map[string]string{"customer.tier": "gold"}}, policy: "gold"},
+		{name: "other tag value", span: &pb.Span{Service: "web", Meta: map[string]string{"customer.tier": "silver"}}},
+		{name: "none", span: &pb.Span{Service: "web", Duration: int64(time.Millisecond)}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s, written := newTestTailSampler(0, policies...)
+			now := time.Now()
+			require.True(t, addChunk(s, now, 42, &pb.Span{Service: "web", Resource: "GET /"}))
+			require.True(t, addChunk(s, now, 42, tt.span))
+
+			// the decision is taken once the decision wait expired
+			s.flush(now.Add(time.Second), false)
+			assert.Empty(t, *written)
+			s.flush(now.Add(10*time.Second), false)
+
+			if tt.policy == "" {
+				assert.Empty(t, *written)
+				return
+			}
+			require.Len(t, *written, 2)
+			for _, c := range *written {
+				require.Len(t, c.TracerPayload.Chunks, 1)
+				chunk := c.TracerPayload.Chunks[0]
+				assert.False(t, chunk.DroppedTrace)
+				assert.Equal(t, tt.policy, chunk.Tags[tagTailSamplingPolicy])
+				assert.Equal(t, "host", c.TracerPayload.Hostname)
+				assert.EqualValues(t, 1, c.SpanCount)
+			}
+			assert.Empty(t, s.traces)
+			// only the decision of the trace is left
+			assert.EqualValues(t, tailDecisionSize, s.size)
+		})
+	}
+}
+
+func TestTailSamplerHeadKeep(t *testing.T) {
+	s, written := newTestTailSampler(0, &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingError})
+	now := time.Now()
+
+	// the upstream chunk is kept as soon as the downstream one is kept by
+	// the head samplers
+	require.True(t, addChunk(s, now, 42, &pb.Span{Service: "web"}))
+	s.Keep(now, 42)
+	require.Len(t, *written, 1)
+	assert.Equal(t, tailSamplingHeadKeep, (*written)[0].TracerPayload.Chunks[0].Tags[tagTailSamplingPolicy])
+
+	// and so are the late chunks
+	require.True(t, addChunk(s, now, 42, &pb.Span{Service: "cache"}))
+	require.Len(t, *written, 2)
+
+	// the decision is forgotten once expired
+	s.flush(now.Add(time.Minute), false)
+	assert.Empty(t, s.decisions)
+	assert.Zero(t, s.decisionOrder.Len())
+	assert.Zero(t, s.size)
+}
+
+func TestTailSamplerMaxDecisions(t *testing.T) {
+	s, _ := newTestTailSampler(3 * tailDecisionSize)
+	now := time.Now()
+
+	// the decisions count in the memory of the sampler, the oldest ones are
+	// forgotten first
+	for traceID := uint64(1); traceID <= 10; traceID++ {
+		s.Keep(now, traceID)
+	}
+	assert.Len(t, s.decisions, 3)
+	assert.Contains(t, s.decisions, uint64(8))
+	assert.Contains(t, s.decisions, uint64(9))
+	assert.Contains(t, s.decisions, uint64(10))
+	assert.EqualValues(t, 3*tailDecisionSize, s.size)
+}
+
+func TestTailSamplerDroppedTrace(t *testing.T) {
+	s, written := newTestTailSampler(0, &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingError})
+	now := time.Now()
+
+	// the spans kept by the head samplers are written when the trace is dropped
+	full := &pb.TraceChunk{Spans: []*pb.Span{{Service: "web"}, {Service: "db"}}, DroppedTrace: true}
+	sampled := &pb.TraceChunk{Spans: full.Spans[1:], DroppedTrace: true}
+	require.True(t, s.Add(now, 42, &pb.TracerPayload{}, full, sampled, 1))
+	s.flush(now.Add(10*time.Second), false)
+	require.Len(t, *written, 1)
+	assert.Same(t, sampled, (*written)[0].TracerPayload.Chunks[0])
+	assert.EqualValues(t, 1, (*written)[0].EventCount)
+
+	// the late chunks of a dropped trace are left to the head samplers
+	assert.False(t, addChunk(s, now, 42, &pb.Span{Service: "web", Error: 1}))
+
+	// traces dropped by the user are not buffered
+	userDrop := &pb.TraceChunk{Priority: int32(sampler.PriorityUserDrop), Spans: []*pb.Span{{Error: 1}}}
+	assert.False(t, s.Add(now, 43, &pb.TracerPayload{}, userDrop, userDrop, 0))
+	assert.Empty(t, s.traces)
+}
+
+func TestTailSamplerMaxMemory(t *testing.T) {
+	span := &pb.Span{Service: "web", Error: 1}
+	chunkSize := int64((&pb.TraceChunk{Priority: int32(sampler.PriorityAutoDrop), Spans: []*pb.Span{span}, DroppedTrace: true}).Msgsize())
+	s, written := newTestTailSampler(2*chunkSize, &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingError})
+	now := time.Now()
+
+	require.True(t, addChunk(s, now, 1, &pb.Span{Service: "web", Error: 1}))
+	require.True(t, addChunk(s, now, 2, &pb.Span{Service: "web", Error: 1}))
+	assert.Empty(t, *written)
+
+	// the oldest trace is sampled early
+	require.True(t, addChunk(s, now, 3, &pb.Span{Service: "web", Error: 1}))
+	require.Len(t, *written, 1)
+	assert.EqualValues(t, 1, (*written)[0].TracerPayload.Chunks[0].Spans[0].TraceID)
+	assert.Len(t, s.traces, 2)
+	assert.Equal(t, 2*chunkSize, s.size)
+}
+
+func TestTailSamplerStop(t *testing.T) {
+	s, written := newTestTailSampler(0, &config.TailSamplingPolicy{Name: "errors", Type: config.TailSamplingError})
+	s.Start()
+	require.True(t, addChunk(s, time.Now(), 42, &pb.Span{Service: "web", Error: 1}))
+	s.Stop()
+	require.Len(t, *written, 1)
+	assert.Empty(t, s.traces)
+}
@@ -90,6 +90,10 @@ func reserveBodySize(buf *bytes.Buffer, req *http.Request) {
 type HTTPReceiver struct {
 	Stats *info.ReceiverStats
 
+	// Shedder grades the load shedding from the resource usage measured by
+	// the watchdog.
+	Shedder *watchdog.Shedder
+
 	out                 chan *Payload
 	conf                *config.AgentConfig
 	dynConf             *sampler.DynamicConfig
@@ -115,6 +119,9 @@ type HTTPReceiver struct {
 	// outOfCPUCounter is counter to throttle the out of cpu warning log
 	outOfCPUCounter *atomic.Uint32
 
+	// capture records the received payloads while a capture is in progress
+	capture *payloadCapture
+
 	statsd   statsd.ClientInterface
 	timing   timing.Reporter
 	info     *watchdog.CurrentInfo
@@ -145,7 +152,8 @@ func NewHTTPReceiver(
 	containerIDProvider := NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo)
 	telemetryForwarder := NewTelemetryForwarder(conf, containerIDProvider, statsd)
 	return &HTTPReceiver{
-		Stats: info.NewReceiverStats(),
+		Stats:   info.NewReceiverStats(),
+		Shedder: watchdog.NewShedder(conf.MaxCPU, conf.MaxMemory),
 
 		out:                 out,
 		statsProcessor:      statsProcessor,
@@ -170,6 +178,8 @@ func NewHTTPReceiver(
 
 		outOfCPUCounter: atomic.NewUint32(0),
 
+		capture: newPayloadCapture(conf.MaxRequestBytes),
+
 		statsd:   statsd,
 		timing:   timing,
 		info:     watchdog.NewCurrentInfo(),
@@ -202,7 +212,7 @@ func (r *HTTPReceiver) buildMux() *http.ServeMux {
 		if e.TimeoutOverride != nil {
 			timeout = e.TimeoutOverride(r.conf)
 		}
-		h := replyWithVersion(hash, r.conf.AgentVersion, timeoutMiddleware(timeout, e.Handler(r)))
+		h := replyWithVersion(hash, r.conf.AgentVersion, r.capture.middleware(e.Pattern, timeoutMiddleware(timeout, e.Handler(r))))
 		r.Handlers[e.Pattern] = h
 		mux.Handle(e.Pattern, h)
 	}
@@ -384,6 +394,7 @@ func (r *HTTPReceiver) Stop() error {
 	r.wg.Wait()
 	close(r.out)
 	r.telemetryForwarder.Stop()
+	r.capture.stop()
 	return nil
 }
 
@@ -463,50 +474,63 @@ func (r *HTTPReceiver) tagStats(v Version, httpHeader http.Header, service strin
 
 // decodeTracerPayload decodes the payload in http request `req`.
 // - tp is the decoded payload
-// - ranHook reports whether the decoder was able to run the pb.MetaHook
+// - shed is the number of chunks not kept by the tracer discarded when shedUnkept is set
 // - err is the first error encountered
-func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, lang, langVersion, tracerVersion string) (tp *pb.TracerPayload, err error) {
+func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, lang, langVersion, tracerVersion string, shedUnkept bool) (tp *pb.TracerPayload, shed int64, err error) {
 	switch v {
 	case v01:
 		var spans []*pb.Span
 		if err = json.NewDecoder(req.Body).Decode(&spans); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
-		return &pb.TracerPayload{
+		tp = &pb.TracerPayload{
 			LanguageName:    lang,
 			LanguageVersion: langVersion,
 			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
 			Chunks:          traceChunksFromSpans(spans),
 			TracerVersion:   tracerVersion,
-		}, nil
+		}
+		if shedUnkept {
+			shed = shedUnkeptChunks(tp)
+		}
+		return tp, shed, nil
 	case v05:
 		buf := getBuffer()
 		defer putBuffer(buf)
 		if _, err = copyRequestBody(buf, req); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		var traces pb.Traces
-		err = traces.UnmarshalMsgDictionary(buf.Bytes())
+		var keep func(pb.Trace) bool
+		if shedUnkept {
+			keep = keptTrace
+		}
+		var discarded int
+		discarded, err = traces.UnmarshalMsgDictionaryKept(buf.Bytes(), keep)
 		return &pb.TracerPayload{
 			LanguageName:    lang,
 			LanguageVersion: langVersion,
 			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
 			Chunks:          traceChunksFromTraces(traces),
 			TracerVersion:   tracerVersion,
-		}, err
+		}, int64(discarded), err
 	case V07:
 		buf := getBuffer()
 		defer putBuffer(buf)
 		if _, err = copyRequestBody(buf, req); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		var tracerPayload pb.TracerPayload
-		_, err = tracerPayload.UnmarshalMsg(buf.Bytes())
-		return &tracerPayload, err
+		if shedUnkept {
+			shed, err = unmarshalKeptTracerPayload(buf.Bytes(), &tracerPayload)
+		} else {
+			_, err = tracerPayload.UnmarshalMsg(buf.Bytes())
+		}
+		return &tracerPayload, shed, err
 	default:
 		var traces pb.Traces
-		if err = decodeRequest(req, &traces); err != nil {
-			return nil, err
+		if shed, err = decodeRequest(req, &traces, shedUnkept); err != nil {
+			return nil, 0, err
 		}
 		return &pb.TracerPayload{
 			LanguageName:    lang,
@@ -514,7 +538,7 @@ func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, l
 			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
 			Chunks:          traceChunksFromTraces(traces),
 			TracerVersion:   tracerVersion,
-		}, nil
+		}, shed, nil
 	}
 }
 
@@ -579,6 +603,12 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	}
 	defer req.Body.Close()
 
+	if r.Shedder.Reject() {
+		log.Debugf("trace-agent is over its resource limits, a payload has been rejected")
+		r.refusePayload(v, w, req)
+		return
+	}
+
 	select {
 	// Wait for the semaphore to become available, allowing the handler to
 	// decode its payload.
@@ -587,21 +617,7 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	case r.recvsem <- struct{}{}:
 	case <-time.After(time.Duration(r.conf.DecoderTimeout) * time.Millisecond):
 		log.Debugf("trace-agent is overwhelmed, a payload has been rejected")
-		// this payload can not be accepted
-		io.Copy(io.Discard, req.Body) //nolint:errcheck
-		switch v {
-		case v01, v02, v03:
-			// do nothing
-		default:
-			w.Header().Set("Content-Type", "application/json")
-		}
-		if isHeaderTrue(header.SendRealHTTPStatus, req.Header.Get(header.SendRealHTTPStatus)) {
-			w.WriteHeader(http.StatusTooManyRequests)
-		} else {
-			w.WriteHeader(r.rateLimiterResponse)
-		}
-		r.replyOK(req, v, w)
-		r.tagStats(v, req.Header, "").PayloadRefused.Inc()
+		r.refusePayload(v, w, req)
 		return
 	}
 	defer func() {
@@ -618,7 +634,8 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	}
 
 	start := time.Now()
-	tp, err := decodeTracerPayload(v, req, r.containerIDProvider, req.Header.Get(header.Lang), req.Header.Get(header.LangVersion), req.Header.Get(header.TracerVersion))
+	shedUnkept := r.Shedder.Stage() >= watchdog.ShedUnkept
+	tp, shed, err := decodeTracerPayload(v, req, r.containerIDProvider, req.Header.Get(header.Lang), req.Header.Get(header.LangVersion), req.Header.Get(header.TracerVersion), shedUnkept)
 	ts := r.tagStats(v, req.Header, firstService(tp))
 	defer func(err error) {
 		tags := append(ts.AsTags(), fmt.Sprintf("success:%v", err == nil))
@@ -648,9 +665,10 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 		_ = r.statsd.Histogram("datadog.trace_agent.receiver.rate_response_bytes", float64(n), tags, 1)
 	}
 
-	ts.TracesReceived.Add(int64(len(tp.Chunks)))
+	ts.TracesReceived.Add(int64(len(tp.Chunks)) + shed)
 	ts.TracesBytes.Add(req.Body.(*apiutil.LimitedReader).Count)
 	ts.PayloadAccepted.Inc()
+	ts.TracesDropped.LoadShedding.Add(shed)
 
 	if ctags := getContainerTags(r.conf.ContainerTags, tp.ContainerID); ctags != "" {
 		if tp.Tags == nil {
@@ -676,6 +694,24 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 	r.out <- payload
 }
 
+// refusePayload responds to a trace request without ingesting its payload.
+func (r *HTTPReceiver) refusePayload(v Version, w http.ResponseWriter, req *http.Request) {
+	io.Copy(io.Discard, req.Body) //nolint:errcheck
+	switch v {
+	case v01, v02, v03:
+		// do nothing
+	default:
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if isHeaderTrue(header.SendRealHTTPStatus, req.Header.Get(header.SendRealHTTPStatus)) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	} else {
+		w.WriteHeader(r.rateLimiterResponse)
+	}
+	r.replyOK(req, v, w)
+	r.tagStats(v, req.Header, "").PayloadRefused.Inc()
+}
+
 // isHeaderTrue returns true if value is non-empty and not a "false"-like value as defined by strconv.ParseBool
 // e.g. (0, f, F, FALSE, False, false) will be considered false while all other values will be true.
 func isHeaderTrue(key, value string) bool {
@@ -795,6 +831,7 @@ func (r *HTTPReceiver) loop() {
 				// Also publish rates by service (they are updated by receiver)
 				rates := r.dynConf.RateByService.GetNewState("").Rates
 				info.UpdateRateByService(rates)
+				info.UpdateServiceBudgets(r.dynConf.ServiceBudgets.Usage())
 			}
 		}
 	}
@@ -806,10 +843,9 @@ var killProcess = func(format string, a ...interface{}) {
 	os.Exit(1)
 }
 
-// watchdog checks the trace-agent's heap and CPU usage and updates the rate limiter using a correct
-// sampling rate to maintain resource usage within set thresholds. These thresholds are defined by
-// the configuration MaxMemory and MaxCPU. If these values are 0, all limits are disabled and the rate
-// limiter will accept everything.
+// watchdog checks the trace-agent's heap and CPU usage and grades the load shedding to maintain
+// resource usage within set thresholds. These thresholds are defined by the configuration MaxMemory
+// and MaxCPU. If these values are 0, all limits are disabled and no load is shed.
 func (r *HTTPReceiver) watchdog(now time.Time) {
 	cpu, _ := r.info.CPU(now)
 	wi := watchdog.Info{
@@ -826,10 +862,15 @@ func (r *HTTPReceiver) watchdog(now time.Time) {
 			killProcess("OOM")
 		}
 	}
+	stage := r.Shedder.Update(wi)
+	if stage > watchdog.ShedNone {
+		wi.ShedStage = stage.String()
+	}
 	info.UpdateWatchdogInfo(wi)
 
 	_ = r.statsd.Gauge("datadog.trace_agent.heap_alloc", float64(wi.Mem.Alloc), nil, 1)
 	_ = r.statsd.Gauge("datadog.trace_agent.cpu_percent", wi.CPU.UserAvg*100, nil, 1)
+	_ = r.statsd.Gauge("datadog.trace_agent.receiver.shed_stage", float64(stage), nil, 1)
 }
 
 // Languages returns the list of the languages used in the traces the agent receives.
@@ -855,23 +896,25 @@ func (r *HTTPReceiver) Languages() string {
 // It handles only v02, v03, v04 requests.
 // - ranHook reports whether the decoder was able to run the pb.MetaHook
 // - err is the first error encountered
-func decodeRequest(req *http.Request, dest *pb.Traces) error {
+func decodeRequest(req *http.Request, dest *pb.Traces, shedUnkept bool) (shed int64, err error) {
 	switch mediaType := getMediaType(req); mediaType {
 	case "application/msgpack":
 		buf := getBuffer()
 		defer putBuffer(buf)
 		_, err := copyRequestBody(buf, req)
 		if err != nil {
-			return err
+			return 0, err
 		}
-		_, err = dest.UnmarshalMsg(buf.Bytes())
-		return err
+		return unmarshalTraces(buf.Bytes(), dest, shedUnkept)
 	case "application/json":
 		fallthrough
 	case "text/json":
 		fallthrough
 	case "":
-		return json.NewDecoder(req.Body).Decode(&dest)
+		if err := json.NewDecoder(req.Body).Decode(&dest); err != nil {
+			return 0, err
+		}
+		return shedUnkeptTraces(dest, shedUnkept), nil
 	default:
 		// do our best
 		if err1 := json.NewDecoder(req.Body).Decode(&dest); err1 != nil {
@@ -879,13 +922,22 @@ func decodeRequest(req *http.Request, dest *pb.Traces) error {
 			defer putBuffer(buf)
 			_, err2 := copyRequestBody(buf, req)
 			if err2 != nil {
-				return err2
+				return 0, err2
 			}
-			_, err2 = dest.UnmarshalMsg(buf.Bytes())
-			return err2
+			return unmarshalTraces(buf.Bytes(), dest, shedUnkept)
 		}
-		return nil
+		return shedUnkeptTraces(dest, shedUnkept), nil
+	}
+}
+
+// unmarshalTraces decodes the msgpack traces of bts into dest, discarding
+// those not kept by the tracer when shedUnkept is set.
+func unmarshalTraces(bts []byte, dest *pb.Traces, shedUnkept bool) (int64, error) {
+	if shedUnkept {
+		return unmarshalKeptTraces(bts, dest)
 	}
+	_, err := dest.UnmarshalMsg(bts)
+	return 0, err
 }
 
 func traceChunksFromSpans(spans []*pb.Span) []*pb.TraceChunk {
@@ -29,6 +29,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/timing"
+	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -589,9 +590,9 @@ func TestDecodeV05(t *testing.T) {
 	req, err := http.NewRequest("POST", "/v0.5/traces", bytes.NewReader(b))
 	assert.NoError(err)
 	req.Header.Set(header.ContainerID, "abcdef123789456")
-	tp, err := decodeTracerPayload(v05, req, NewIDProvider("", func(_ origindetection.OriginInfo) (string, error) {
+	tp, _, err := decodeTracerPayload(v05, req, NewIDProvider("", func(_ origindetection.OriginInfo) (string, error) {
 		return "abcdef123789456", nil
-	}), "python", "3.8.1", "1.2.3")
+	}), "python", "3.8.1", "1.2.3", false)
 	assert.NoError(err)
 	assert.EqualValues(tp, &pb.TracerPayload{
 		ContainerID:     "abcdef123789456",
@@ -840,6 +841,75 @@ func TestHandleTraces(t *testing.T) {
 	})
 }
 
+func TestReceiverLoadShedding(t *testing.T) {
+	traces := pb.Traces{
+		{{TraceID: 1, SpanID: 1, Service: "web", Metrics: map[string]float64{"_sampling_priority_v1": 2}}},
+		{{TraceID: 2, SpanID: 1, Service: "web", Metrics: map[string]float64{"_sampling_priority_v1": 0}}},
+		{{TraceID: 3, SpanID: 1, Service: "web"}},
+		{
+			{TraceID: 4, SpanID: 1, Service: "web"},
+			{TraceID: 4, SpanID: 2, ParentID: 1, Service: "web", Metrics: map[string]float64{"_sampling_priority_v1": 1}},
+		},
+	}
+	bts, err := traces.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+	receiver.Shedder = watchdog.NewShedder(0.5, 0)
+	overLimit := watchdog.Info{CPU: watchdog.CPUInfo{UserAvg: 1}}
+	handler := receiver.handleWithVersion(v04, receiver.handleTraces)
+	send := func() *http.Response {
+		rr := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/v0.4/traces", bytes.NewReader(bts))
+		req.Header.Set("Content-Type", "application/msgpack")
+		req.Header.Set(header.SendRealHTTPStatus, "true")
+		handler.ServeHTTP(rr, req)
+		return rr.Result()
+	}
+	ts := receiver.Stats.GetTagStats(info.Tags{EndpointVersion: "v0.4", Service: "web"})
+
+	t.Run("none", func(t *testing.T) {
+		resp := send()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Len(t, (<-receiver.out).Chunks(), 4)
+	})
+
+	t.Run("drop_unkept", func(t *testing.T) {
+		require.Equal(t, watchdog.ShedUnkept, receiver.Shedder.Update(overLimit))
+		resp := send()
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		chunks := (<-receiver.out).Chunks()
+		// the trace without a sampling priority is kept
+		require.Len(t, chunks, 3)
+		assert.EqualValues(t, 1, chunks[0].Spans[0].TraceID)
+		assert.EqualValues(t, 3, chunks[1].Spans[0].TraceID)
+		assert.EqualValues(t, 4, chunks[2].Spans[0].TraceID)
+		assert.EqualValues(t, 1, ts.TracesDropped.LoadShedding.Load())
+	})
+
+	t.Run("reject_requests", func(t *testing.T) {
+		receiver.Shedder.Update(overLimit)
+		require.Equal(t, watchdog.ShedRequests, receiver.Shedder.Update(overLimit))
+		// twice the limits, half of the requests are rejected
+		require.InDelta(t, 0.5, receiver.Shedder.RejectRatio(), 1e-9)
+		var refused int
+		for i := 0; i < 200; i++ {
+			resp := send()
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusTooManyRequests {
+				refused++
+			} else {
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+			}
+		}
+		assert.InDelta(t, 100, refused, 40)
+		assert.Len(t, receiver.out, 200-refused)
+		assert.EqualValues(t, refused, receiver.Stats.GetTagStats(info.Tags{EndpointVersion: "v0.4"}).PayloadRefused.Load())
+	})
+}
+
 func TestClientComputedTopLevel(t *testing.T) {
 	conf := newTestReceiverConfig()
 	rcv := newTestReceiverFromConfig(conf)
@@ -0,0 +1,202 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/capture"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+const (
+	// defaultCaptureDuration is the duration of the captures which don't specify one.
+	defaultCaptureDuration = time.Minute
+	// maxCaptureDuration is the maximum duration of a capture.
+	maxCaptureDuration = 10 * time.Minute
+	// maxCaptureSize is the maximum size in bytes of the payloads recorded by a capture,
+	// beyond which the capture stops.
+	maxCaptureSize = 256 * 1024 * 1024
+)
+
+// capturedEndpoints holds the patterns of the endpoints whose requests are captured:
+// the traces and stats endpoints, whose payloads can be replayed.
+var capturedEndpoints = map[string]struct{}{
+	"/spans":        {},
+	"/v0.1/spans":   {},
+	"/v0.2/traces":  {},
+	"/v0.3/traces":  {},
+	"/v0.4/traces":  {},
+	"/v0.5/traces":  {},
+	"/v0.7/traces":  {},
+	"/v0.6/stats":   {},
+	"/api/v2/spans": {},
+	"/api/traces":   {},
+}
+
+// payloadCapture records the requests received by the receiver to a capture file,
+// for a time window and up to a maximum size.
+type payloadCapture struct {
+	active *atomic.Bool
+
+	mu     sync.Mutex
+	file   *os.File
+	writer *capture.Writer
+	timer  *time.Timer
+	// size holds the size of the payloads recorded by the capture in progress
+	size int64
+	// maxBytes is the maximum size of a recorded payload, maxSize the one of all of them
+	maxBytes int64
+	maxSize  int64
+}
+
+func newPayloadCapture(maxBytes int64) *payloadCapture {
+	return &payloadCapture{
+		active:   atomic.NewBool(false),
+		maxBytes: maxBytes,
+		maxSize:  maxCaptureSize,
+	}
+}
+
+// start starts capturing the requests for the given duration, to a new file in dir.
+// It returns the path to the capture file.
+func (c *payloadCapture) start(dir string, d time.Duration) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writer != nil {
+		return "", errors.New("a capture is already in progress")
+	}
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	f, err := os.CreateTemp(dir, fmt.Sprintf("trace-capture-%d-*.gz", time.Now().Unix()))
+	if err != nil {
+		return "", err
+	}
+	w, err := capture.NewWriter(f)
+	if err != nil {
+		f.Close()
+		return "", err
+	}
+	c.file, c.writer, c.size = f, w, 0
+	c.timer = time.AfterFunc(d, c.stop)
+	c.active.Store(true)
+	log.Infof("Capturing the received payloads to %s for %s", f.Name(), d)
+	return f.Name(), nil
+}
+
+// stop stops the capture in progress, if any.
+func (c *payloadCapture) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopLocked()
+}
+
+// stopLocked stops the capture in progress, if any. c.mu must be held.
+func (c *payloadCapture) stopLocked() {
+	if c.writer == nil {
+		return
+	}
+	c.active.Store(false)
+	c.timer.Stop()
+	if err := c.writer.Close(); err != nil {
+		log.Errorf("Error writing the capture file %s: %v", c.file.Name(), err)
+	}
+	if err := c.file.Close(); err != nil {
+		log.Errorf("Error closing the capture file %s: %v", c.file.Name(), err)
+	}
+	log.Infof("Capture to %s done", c.file.Name())
+	c.file, c.writer, c.timer = nil, nil, nil
+}
+
+// record writes req to the capture file. The body of req is replaced so that it
+// can still be read by the handler.
+func (c *payloadCapture) record(req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, c.maxBytes+1))
+	// the handler reads whatever was left unread, or gets the same error
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), req.Body), Closer: req.Body}
+	if err != nil || int64(len(body)) > c.maxBytes {
+		return
+	}
+	rec := &capture.Record{
+		Time:   time.Now(),
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: req.Header,
+		Body:   body,
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.writer == nil {
+		// the capture ended in the meantime
+		return
+	}
+	if c.size+int64(len(body)) > c.maxSize {
+		log.Warnf("The capture to %s reached its maximum size of %d bytes", c.file.Name(), c.maxSize)
+		c.stopLocked()
+		return
+	}
+	c.size += int64(len(body))
+	if err := c.writer.Write(rec); err != nil {
+		log.Errorf("Error writing to the capture file %s: %v", c.file.Name(), err)
+	}
+}
+
+// middleware returns a handler recording the requests to h while a capture is in progress,
+// if pattern is one of the captured endpoints.
+func (c *payloadCapture) middleware(pattern string, h http.Handler) http.Handler {
+	if _, ok := capturedEndpoints[pattern]; !ok {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if c.active.Load() {
+			c.record(req)
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// CaptureHandler returns the handler starting a capture of the received payloads. It
+// accepts the optional "duration" (e.g. 30s, 10m at most) and "path" (the directory of the
+// capture file) form values, and replies with the path to the capture file.
+func (r *HTTPReceiver) CaptureHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		d := defaultCaptureDuration
+		if v := req.FormValue("duration"); v != "" {
+			var err error
+			if d, err = time.ParseDuration(v); err != nil || d <= 0 || d > maxCaptureDuration {
+				http.Error(w, fmt.Sprintf("invalid duration %q, must be positive and at most %s", v, maxCaptureDuration), http.StatusBadRequest)
+				return
+			}
+		}
+		path, err := r.capture.start(req.FormValue("path"), d)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"path": path}) //nolint:errcheck
+	})
+}
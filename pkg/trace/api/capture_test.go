@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/capture"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+)
+
+func TestCapture(t *testing.T) {
+	rcv := newTestReceiverFromConfig(newTestReceiverConfig())
+	server := httptest.NewServer(rcv.buildMux())
+	defer server.Close()
+	dir := t.TempDir()
+
+	startCapture := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/capture", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		rcv.CaptureHandler().ServeHTTP(rr, req)
+		return rr
+	}
+	rr := startCapture(url.Values{"duration": {"-1s"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	rr = startCapture(url.Values{"duration": {"11m"}})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = startCapture(url.Values{"duration": {"1m"}, "path": {dir}})
+	require.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+	var resp map[string]string
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, dir, filepath.Dir(resp["path"]))
+
+	rr = startCapture(url.Values{"path": {dir}})
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "a capture is already in progress")
+
+	bts, err := testutil.GetTestTraces(2, 2, true).MarshalMsg(nil)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v0.4/traces", bytes.NewReader(bts))
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set("Dd-Api-Key", "secret")
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	select {
+	case p := <-rcv.out:
+		// the handler could still decode the payload
+		assert.Len(t, p.Chunks(), 2)
+	case <-time.After(time.Second):
+		t.Fatal("no payload received")
+	}
+
+	rcv.capture.stop()
+	assert.False(t, rcv.capture.active.Load())
+
+	f, err := os.Open(resp["path"])
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := capture.NewReader(f)
+	require.NoError(t, err)
+	rec, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, http.MethodPost, rec.Method)
+	assert.Equal(t, "/v0.4/traces", rec.URL)
+	assert.Equal(t, "application/msgpack", rec.Header.Get("Content-Type"))
+	assert.Empty(t, rec.Header.Get("Dd-Api-Key"))
+	assert.Equal(t, bts, rec.Body)
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestCaptureLimits(t *testing.T) {
+	c := newPayloadCapture(100)
+	c.maxSize = 10
+	path, err := c.start(t.TempDir(), time.Minute)
+	require.NoError(t, err)
+	defer c.stop()
+
+	var handled int
+	h := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) { handled++ })
+	send := func(h http.Handler, pattern, body string) {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, pattern, strings.NewReader(body)))
+	}
+	// the proxied payloads are not captured
+	send(c.middleware("/v0.1/pipeline_stats", h), "/v0.1/pipeline_stats", "proxy")
+	send(c.middleware("/v0.4/traces", h), "/v0.4/traces", "first")
+	// the capture stops once it reaches its maximum size
+	send(c.middleware("/v0.6/stats", h), "/v0.6/stats", "second")
+	assert.False(t, c.active.Load())
+	assert.Equal(t, 3, handled)
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := capture.NewReader(f)
+	require.NoError(t, err)
+	rec, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "/v0.4/traces", rec.URL)
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,626 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+const (
+	// jaegerFlagDebug is the flag of the spans of the traces forced by the user.
+	jaegerFlagDebug = 2
+	// jaegerChildOf is the type of the reference to the parent of a span.
+	jaegerChildOf = 0
+)
+
+// jaegerProcess is the process emitting Jaeger spans.
+type jaegerProcess struct {
+	serviceName string
+	tags        []jaegerTag
+}
+
+// jaegerTag is a tag of a Jaeger span. Its value is a string, bool, int64,
+// float64 or []byte.
+type jaegerTag struct {
+	key   string
+	value interface{}
+}
+
+type jaegerRef struct {
+	refType int32
+	spanID  uint64
+}
+
+type jaegerLog struct {
+	timestamp int64 // in nanoseconds
+	fields    []jaegerTag
+}
+
+// jaegerSpan is a Jaeger span, as defined by jaeger.thrift and model.proto.
+type jaegerSpan struct {
+	traceIDHigh   uint64
+	traceIDLow    uint64
+	spanID        uint64
+	parentID      uint64
+	operationName string
+	references    []jaegerRef
+	flags         int64
+	start         int64 // in nanoseconds
+	duration      int64 // in nanoseconds
+	tags          []jaegerTag
+	logs          []jaegerLog
+	// process overrides the process of the batch.
+	process *jaegerProcess
+}
+
+// decodeJaegerBatch decodes the Jaeger batch of a request, encoded in Thrift
+// binary or protobuf.
+func decodeJaegerBatch(req *http.Request) ([]*pb.Span, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var process *jaegerProcess
+	var jspans []*jaegerSpan
+	switch mediaType := getMediaType(req); mediaType {
+	case "application/x-thrift", "application/vnd.apache.thrift.binary":
+		process, jspans, err = unmarshalJaegerThrift(body)
+	case "application/x-protobuf", "application/protobuf":
+		process, jspans, err = unmarshalJaegerProto(body)
+	default:
+		return nil, fmt.Errorf("unsupported media type: %q", mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]*pb.Span, 0, len(jspans))
+	for _, js := range jspans {
+		spans = append(spans, convertJaegerSpan(js, process))
+	}
+	return spans, nil
+}
+
+// convertJaegerSpan converts a Jaeger span emitted by process to a Datadog span.
+func convertJaegerSpan(js *jaegerSpan, process *jaegerProcess) *pb.Span {
+	span := &pb.Span{
+		TraceID:  js.traceIDLow,
+		SpanID:   js.spanID,
+		ParentID: js.parentID,
+		Name:     js.operationName,
+		Start:    js.start,
+		Duration: js.duration,
+		Meta:     make(map[string]string, len(js.tags)),
+		Metrics:  make(map[string]float64),
+	}
+	if span.ParentID == 0 {
+		for _, ref := range js.references {
+			if ref.refType == jaegerChildOf {
+				span.ParentID = ref.spanID
+				break
+			}
+		}
+	}
+	if js.process != nil {
+		process = js.process
+	}
+	if process != nil {
+		span.Service = process.serviceName
+		for _, t := range process.tags {
+			setJaegerTag(span, t)
+		}
+	}
+	for _, t := range js.tags {
+		setJaegerTag(span, t)
+	}
+	// sampling.priority is the OpenTracing tag of the traces forced by the user
+	if p, ok := span.Metrics["sampling.priority"]; ok {
+		delete(span.Metrics, "sampling.priority")
+		if p > 0 {
+			traceutil.SetMetric(span, keySamplingPriority, 2)
+		} else {
+			traceutil.SetMetric(span, keySamplingPriority, -1)
+		}
+	} else if js.flags&jaegerFlagDebug != 0 {
+		traceutil.SetMetric(span, keySamplingPriority, 2)
+	}
+
+	events := make([]spanEvent, 0, len(js.logs))
+	for _, l := range js.logs {
+		e := spanEvent{TimeUnixNano: l.timestamp}
+		for _, f := range l.fields {
+			if name, ok := f.value.(string); ok && f.key == "event" {
+				e.Name = name
+				continue
+			}
+			if e.Attributes == nil {
+				e.Attributes = make(map[string]interface{}, len(l.fields))
+			}
+			e.Attributes[f.key] = f.value
+		}
+		events = append(events, e)
+	}
+	completeSpan(span, spanKind(span.Meta["span.kind"]), js.traceIDHigh, events)
+	return span
+}
+
+// setJaegerTag sets a tag on a span, as a metric if it is numeric.
+func setJaegerTag(span *pb.Span, t jaegerTag) {
+	switch v := t.value.(type) {
+	case string:
+		span.Meta[t.key] = v
+	case bool:
+		span.Meta[t.key] = strconv.FormatBool(v)
+	case int64:
+		span.Metrics[t.key] = float64(v)
+	case float64:
+		span.Metrics[t.key] = v
+	case []byte:
+		span.Meta[t.key] = base64.StdEncoding.EncodeToString(v)
+	}
+}
+
+// Thrift binary protocol types, see https://github.com/apache/thrift/blob/master/doc/specs/thrift-binary-protocol.md.
+const (
+	thriftStop   = 0
+	thriftBool   = 2
+	thriftByte   = 3
+	thriftDouble = 4
+	thriftI16    = 6
+	thriftI32    = 8
+	thriftI64    = 10
+	thriftString = 11
+	thriftStruct = 12
+	thriftMap    = 13
+	thriftSet    = 14
+	thriftList   = 15
+)
+
+var errInvalidThrift = errors.New("invalid thrift payload")
+
+// thriftMaxDepth is the maximum nesting depth of the skipped values, so that
+// a crafted payload can't exhaust the stack.
+const thriftMaxDepth = 64
+
+// thriftReader reads values encoded in the Thrift binary protocol. Once it
+// fails, it returns zero values and holds the error.
+type thriftReader struct {
+	b   []byte
+	err error
+	// depth is the nesting depth of the value being skipped.
+	depth int
+}
+
+func (r *thriftReader) read(n int) []byte {
+	if r.err != nil || n < 0 || n > len(r.b) {
+		r.err = errInvalidThrift
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *thriftReader) byte() byte {
+	if b := r.read(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *thriftReader) i16() int16 {
+	if b := r.read(2); b != nil {
+		return int16(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) i32() int32 {
+	if b := r.read(4); b != nil {
+		return int32(binary.BigEndian.Uint32(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) i64() int64 {
+	if b := r.read(8); b != nil {
+		return int64(binary.BigEndian.Uint64(b))
+	}
+	return 0
+}
+
+func (r *thriftReader) double() float64 {
+	return math.Float64frombits(uint64(r.i64()))
+}
+
+func (r *thriftReader) binary() []byte {
+	return r.read(int(r.i32()))
+}
+
+func (r *thriftReader) string() string {
+	return string(r.binary())
+}
+
+// listHeader reads the header of a list or a set.
+func (r *thriftReader) listHeader() (elemType byte, size int) {
+	elemType, size = r.byte(), int(r.i32())
+	if size < 0 || size > len(r.b) {
+		// each element takes at least a byte
+		r.err = errInvalidThrift
+	}
+	return elemType, size
+}
+
+// list reads a list, calling fn to read each element if they are of type typ,
+// or skipping them.
+func (r *thriftReader) list(typ byte, fn func()) {
+	elemType, size := r.listHeader()
+	for i := 0; i < size && r.err == nil; i++ {
+		if elemType == typ {
+			fn()
+		} else {
+			r.skip(elemType)
+		}
+	}
+}
+
+// structure reads the fields of a struct, calling fn with the ID and the type
+// of each of them. fn returns false when it did not read the field, which is
+// then skipped.
+func (r *thriftReader) structure(fn func(id int16, typ byte) bool) {
+	for r.err == nil {
+		typ := r.byte()
+		if typ == thriftStop {
+			return
+		}
+		id := r.i16()
+		if r.err == nil && !fn(id, typ) {
+			r.skip(typ)
+		}
+	}
+}
+
+func (r *thriftReader) skip(typ byte) {
+	r.depth++
+	defer func() { r.depth-- }()
+	if r.depth > thriftMaxDepth {
+		r.err = errInvalidThrift
+		return
+	}
+	switch typ {
+	case thriftBool, thriftByte:
+		r.read(1)
+	case thriftI16:
+		r.read(2)
+	case thriftI32:
+		r.read(4)
+	case thriftDouble, thriftI64:
+		r.read(8)
+	case thriftString:
+		r.binary()
+	case thriftStruct:
+		r.structure(func(int16, byte) bool { return false })
+	case thriftMap:
+		keyType, valueType, size := r.byte(), r.byte(), int(r.i32())
+		if size < 0 || size > len(r.b) {
+			r.err = errInvalidThrift
+		}
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(keyType)
+			r.skip(valueType)
+		}
+	case thriftSet, thriftList:
+		elemType, size := r.listHeader()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(elemType)
+		}
+	default:
+		r.err = errInvalidThrift
+	}
+}
+
+// unmarshalJaegerThrift unmarshals a Batch struct of jaeger.thrift.
+func unmarshalJaegerThrift(b []byte) (*jaegerProcess, []*jaegerSpan, error) {
+	r := &thriftReader{b: b}
+	var process *jaegerProcess
+	var spans []*jaegerSpan
+	r.structure(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftStruct:
+			process = r.jaegerProcess()
+		case id == 2 && typ == thriftList:
+			r.list(thriftStruct, func() { spans = append(spans, r.jaegerSpan()) })
+		default:
+			return false
+		}
+		return true
+	})
+	return process, spans, r.err
+}
+
+func (r *thriftReader) jaegerProcess() *jaegerProcess {
+	p := &jaegerProcess{}
+	r.structure(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftString:
+			p.serviceName = r.string()
+		case id == 2 && typ == thriftList:
+			p.tags = r.jaegerTags()
+		default:
+			return false
+		}
+		return true
+	})
+	return p
+}
+
+func (r *thriftReader) jaegerSpan() *jaegerSpan {
+	s := &jaegerSpan{}
+	r.structure(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI64:
+			s.traceIDLow = uint64(r.i64())
+		case id == 2 && typ == thriftI64:
+			s.traceIDHigh = uint64(r.i64())
+		case id == 3 && typ == thriftI64:
+			s.spanID = uint64(r.i64())
+		case id == 4 && typ == thriftI64:
+			s.parentID = uint64(r.i64())
+		case id == 5 && typ == thriftString:
+			s.operationName = r.string()
+		case id == 6 && typ == thriftList:
+			r.list(thriftStruct, func() { s.references = append(s.references, r.jaegerRef()) })
+		case id == 7 && typ == thriftI32:
+			s.flags = int64(r.i32())
+		case id == 8 && typ == thriftI64:
+			s.start = r.i64() * int64(time.Microsecond)
+		case id == 9 && typ == thriftI64:
+			s.duration = r.i64() * int64(time.Microsecond)
+		case id == 10 && typ == thriftList:
+			s.tags = r.jaegerTags()
+		case id == 11 && typ == thriftList:
+			r.list(thriftStruct, func() { s.logs = append(s.logs, r.jaegerLog()) })
+		default:
+			return false
+		}
+		return true
+	})
+	return s
+}
+
+func (r *thriftReader) jaegerRef() jaegerRef {
+	var ref jaegerRef
+	r.structure(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI32:
+			ref.refType = r.i32()
+		case id == 4 && typ == thriftI64:
+			ref.spanID = uint64(r.i64())
+		default:
+			return false
+		}
+		return true
+	})
+	return ref
+}
+
+func (r *thriftReader) jaegerLog() jaegerLog {
+	var l jaegerLog
+	r.structure(func(id int16, typ byte) bool {
+		switch {
+		case id == 1 && typ == thriftI64:
+			l.timestamp = r.i64() * int64(time.Microsecond)
+		case id == 2 && typ == thriftList:
+			l.fields = r.jaegerTags()
+		default:
+			return false
+		}
+		return true
+	})
+	return l
+}
+
+func (r *thriftReader) jaegerTags() []jaegerTag {
+	var tags []jaegerTag
+	r.list(thriftStruct, func() {
+		var (
+			t      jaegerTag
+			vType  int32
+			values [5]interface{}
+		)
+		r.structure(func(id int16, typ byte) bool {
+			switch {
+			case id == 1 && typ == thriftString:
+				t.key = r.string()
+			case id == 2 && typ == thriftI32:
+				vType = r.i32()
+			case id == 3 && typ == thriftString:
+				values[0] = r.string()
+			case id == 4 && typ == thriftDouble:
+				values[1] = r.double()
+			case id == 5 && typ == thriftBool:
+				values[2] = r.byte() != 0
+			case id == 6 && typ == thriftI64:
+				values[3] = r.i64()
+			case id == 7 && typ == thriftString:
+				values[4] = r.binary()
+			default:
+				return false
+			}
+			return true
+		})
+		// the value of a tag is the field of its TagType: STRING, DOUBLE,
+		// BOOL, LONG or BINARY
+		if vType >= 0 && int(vType) < len(values) && values[vType] != nil {
+			t.value = values[vType]
+			tags = append(tags, t)
+		}
+	})
+	return tags
+}
+
+// unmarshalJaegerProto unmarshals a Batch message of model.proto.
+func unmarshalJaegerProto(b []byte) (*jaegerProcess, []*jaegerSpan, error) {
+	var process *jaegerProcess
+	var spans []*jaegerSpan
+	err := walkProto(b, func(num protowire.Number, v []byte, _ uint64) error {
+		var err error
+		switch num {
+		case 1:
+			var s *jaegerSpan
+			s, err = unmarshalJaegerProtoSpan(v)
+			spans = append(spans, s)
+		case 2:
+			process, err = unmarshalJaegerProtoProcess(v)
+		}
+		return err
+	})
+	return process, spans, err
+}
+
+func unmarshalJaegerProtoSpan(b []byte) (*jaegerSpan, error) {
+	s := &jaegerSpan{}
+	err := walkProto(b, func(num protowire.Number, v []byte, n uint64) error {
+		var err error
+		switch num {
+		case 1:
+			if len(v) > 8 {
+				s.traceIDHigh = uint64FromBytes(v[:len(v)-8])
+			}
+			s.traceIDLow = uint64FromBytes(v)
+		case 2:
+			s.spanID = uint64FromBytes(v)
+		case 3:
+			s.operationName = string(v)
+		case 4:
+			var ref jaegerRef
+			err = walkProto(v, func(num protowire.Number, v []byte, n uint64) error {
+				switch num {
+				case 2:
+					ref.spanID = uint64FromBytes(v)
+				case 3:
+					ref.refType = int32(n)
+				}
+				return nil
+			})
+			s.references = append(s.references, ref)
+		case 5:
+			s.flags = int64(n)
+		case 6:
+			s.start, err = unmarshalProtoTime(v)
+		case 7:
+			s.duration, err = unmarshalProtoTime(v)
+		case 8:
+			var t jaegerTag
+			t, err = unmarshalJaegerProtoTag(v)
+			s.tags = append(s.tags, t)
+		case 9:
+			var l jaegerLog
+			err = walkProto(v, func(num protowire.Number, v []byte, _ uint64) error {
+				var err error
+				switch num {
+				case 1:
+					l.timestamp, err = unmarshalProtoTime(v)
+				case 2:
+					var t jaegerTag
+					t, err = unmarshalJaegerProtoTag(v)
+					l.fields = append(l.fields, t)
+				}
+				return err
+			})
+			s.logs = append(s.logs, l)
+		case 10:
+			s.process, err = unmarshalJaegerProtoProcess(v)
+		}
+		return err
+	})
+	return s, err
+}
+
+func unmarshalJaegerProtoProcess(b []byte) (*jaegerProcess, error) {
+	p := &jaegerProcess{}
+	err := walkProto(b, func(num protowire.Number, v []byte, _ uint64) error {
+		switch num {
+		case 1:
+			p.serviceName = string(v)
+		case 2:
+			t, err := unmarshalJaegerProtoTag(v)
+			p.tags = append(p.tags, t)
+			return err
+		}
+		return nil
+	})
+	return p, err
+}
+
+// unmarshalJaegerProtoTag unmarshals a KeyValue message, whose value is the
+// field of its ValueType: STRING, BOOL, INT64, FLOAT64 or BINARY.
+func unmarshalJaegerProtoTag(b []byte) (jaegerTag, error) {
+	var t jaegerTag
+	var vType uint64
+	values := [5]interface{}{"", false, int64(0), float64(0), []byte{}}
+	err := walkProto(b, func(num protowire.Number, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			t.key = string(v)
+		case 2:
+			vType = n
+		case 3:
+			values[0] = string(v)
+		case 4:
+			values[1] = n != 0
+		case 5:
+			values[2] = int64(n)
+		case 6:
+			values[3] = math.Float64frombits(n)
+		case 7:
+			values[4] = v
+		}
+		return nil
+	})
+	if vType < uint64(len(values)) {
+		t.value = values[vType]
+	}
+	return t, err
+}
+
+// unmarshalProtoTime unmarshals a google.protobuf.Timestamp or Duration
+// message, in nanoseconds.
+func unmarshalProtoTime(b []byte) (int64, error) {
+	var seconds, nanos int64
+	err := walkProto(b, func(num protowire.Number, _ []byte, n uint64) error {
+		switch num {
+		case 1:
+			seconds = int64(n)
+		case 2:
+			nanos = int64(int32(n))
+		}
+		return nil
+	})
+	return seconds*int64(time.Second) + nanos, err
+}
+
+// uint64FromBytes returns the big-endian integer held by the last 8 bytes of b.
+func uint64FromBytes(b []byte) uint64 {
+	if len(b) >= 8 {
+		return binary.BigEndian.Uint64(b[len(b)-8:])
+	}
+	var buf [8]byte
+	copy(buf[8-len(b):], b)
+	return binary.BigEndian.Uint64(buf[:])
+}
@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/binary"
+	"math"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// thriftWriter writes values in the Thrift binary protocol.
+type thriftWriter struct{ b []byte }
+
+func (w *thriftWriter) field(typ byte, id int16) {
+	w.b = append(w.b, typ)
+	w.b = binary.BigEndian.AppendUint16(w.b, uint16(id))
+}
+
+func (w *thriftWriter) stop() { w.b = append(w.b, thriftStop) }
+
+func (w *thriftWriter) list(typ byte, size int) {
+	w.b = append(w.b, typ)
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(size))
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.field(thriftI32, id)
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.field(thriftI64, id)
+	w.b = binary.BigEndian.AppendUint64(w.b, uint64(v))
+}
+
+func (w *thriftWriter) string(id int16, v string) {
+	w.field(thriftString, id)
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(len(v)))
+	w.b = append(w.b, v...)
+}
+
+// tag writes a Tag struct with the value of the given TagType.
+func (w *thriftWriter) tag(key string, vType int32, value interface{}) {
+	w.string(1, key)
+	w.i32(2, vType)
+	switch v := value.(type) {
+	case string:
+		w.string(3, v)
+	case float64:
+		w.field(thriftDouble, 4)
+		w.b = binary.BigEndian.AppendUint64(w.b, math.Float64bits(v))
+	case bool:
+		w.field(thriftBool, 5)
+		if v {
+			w.b = append(w.b, 1)
+		} else {
+			w.b = append(w.b, 0)
+		}
+	case int64:
+		w.i64(6, v)
+	}
+	w.stop()
+}
+
+func TestJaegerThrift(t *testing.T) {
+	var w thriftWriter
+	// Process
+	w.field(thriftStruct, 1)
+	w.string(1, "checkout")
+	w.field(thriftList, 2)
+	w.list(thriftStruct, 1)
+	w.tag("hostname", 0, "host-1")
+	w.stop()
+	// Spans
+	w.field(thriftList, 2)
+	w.list(thriftStruct, 2)
+	{
+		w.i64(1, 0x2a)
+		w.i64(2, 0x1)
+		w.i64(3, 0x10)
+		w.i64(4, 0)
+		w.string(5, "HTTP POST")
+		w.i32(7, 1)
+		w.i64(8, 1000)
+		w.i64(9, 250)
+		w.field(thriftList, 10)
+		w.list(thriftStruct, 5)
+		w.tag("span.kind", 0, "server")
+		w.tag("http.method", 0, "POST")
+		w.tag("http.status_code", 3, int64(500))
+		w.tag("error", 2, true)
+		w.tag("ratio", 1, 0.5)
+		w.field(thriftList, 11)
+		w.list(thriftStruct, 1)
+		w.i64(1, 1100)
+		w.field(thriftList, 2)
+		w.list(thriftStruct, 2)
+		w.tag("event", 0, "exception")
+		w.tag("message", 0, "boom")
+		w.stop()
+		// unknown fields are skipped
+		w.field(thriftMap, 42)
+		w.b = append(w.b, thriftString, thriftI32, 0, 0, 0, 1, 0, 0, 0, 1, 'k', 0, 0, 0, 1)
+		w.stop()
+	}
+	{
+		w.i64(1, 0x2a)
+		w.i64(2, 0x1)
+		w.i64(3, 0x11)
+		w.string(5, "SELECT")
+		// CHILD_OF reference
+		w.field(thriftList, 6)
+		w.list(thriftStruct, 1)
+		w.i32(1, 0)
+		w.i64(2, 0x2a)
+		w.i64(3, 0x1)
+		w.i64(4, 0x10)
+		w.stop()
+		w.i32(7, 3)
+		w.field(thriftList, 10)
+		w.list(thriftStruct, 2)
+		w.tag("span.kind", 0, "client")
+		w.tag("db.system", 0, "redis")
+		w.stop()
+	}
+	w.stop()
+
+	rr, tp := postSpans(t, jaegerV1, decodeJaegerBatch, "application/x-thrift", w.b)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.NotNil(t, tp)
+	require.Len(t, tp.Chunks, 1)
+	chunk := tp.Chunks[0]
+	// the debug flag of a span forces the trace
+	assert.EqualValues(t, sampler.PriorityUserKeep, chunk.Priority)
+	require.Len(t, chunk.Spans, 2)
+	server, client := chunk.Spans[0], chunk.Spans[1]
+
+	assert.EqualValues(t, 0x2a, server.TraceID)
+	assert.Equal(t, "0000000000000001", server.Meta["_dd.p.tid"])
+	assert.EqualValues(t, 0x10, server.SpanID)
+	assert.Equal(t, "checkout", server.Service)
+	assert.Equal(t, "HTTP POST", server.Name)
+	assert.Equal(t, "POST", server.Resource)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, "host-1", server.Meta["hostname"])
+	assert.EqualValues(t, 500, server.Metrics["http.status_code"])
+	assert.EqualValues(t, 0.5, server.Metrics["ratio"])
+	assert.EqualValues(t, 1, server.Error)
+	assert.EqualValues(t, 1000000, server.Start)
+	assert.EqualValues(t, 250000, server.Duration)
+	assert.Equal(t, `[{"time_unix_nano":1100000,"name":"exception","attributes":{"message":"boom"}}]`, server.Meta["events"])
+
+	assert.EqualValues(t, 0x10, client.ParentID)
+	assert.Equal(t, "cache", client.Type)
+	assert.Equal(t, "SELECT", client.Resource)
+}
+
+func TestJaegerProto(t *testing.T) {
+	keyValue := func(key string, vType uint64, num protowire.Number, value []byte) []byte {
+		var b []byte
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendString(b, key)
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, vType)
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, value)
+	}
+	var timestamp []byte
+	timestamp = protowire.AppendTag(timestamp, 1, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 2)
+	timestamp = protowire.AppendTag(timestamp, 2, protowire.VarintType)
+	timestamp = protowire.AppendVarint(timestamp, 500)
+
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x2a})
+	span = protowire.AppendTag(span, 2, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 0x07})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendString(span, "consume")
+	span = protowire.AppendTag(span, 6, protowire.BytesType)
+	span = protowire.AppendBytes(span, timestamp)
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, keyValue("span.kind", 0, 3, []byte("consumer")))
+
+	var process []byte
+	process = protowire.AppendTag(process, 1, protowire.BytesType)
+	process = protowire.AppendString(process, "worker")
+
+	var batch []byte
+	batch = protowire.AppendTag(batch, 1, protowire.BytesType)
+	batch = protowire.AppendBytes(batch, span)
+	batch = protowire.AppendTag(batch, 2, protowire.BytesType)
+	batch = protowire.AppendBytes(batch, process)
+
+	rr, tp := postSpans(t, jaegerV1, decodeJaegerBatch, "application/x-protobuf", batch)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.NotNil(t, tp)
+	require.Len(t, tp.Chunks, 1)
+	assert.EqualValues(t, sampler.PriorityAutoKeep, tp.Chunks[0].Priority)
+	s := tp.Chunks[0].Spans[0]
+	assert.EqualValues(t, 0x2a, s.TraceID)
+	assert.NotContains(t, s.Meta, "_dd.p.tid")
+	assert.EqualValues(t, 7, s.SpanID)
+	assert.Equal(t, "worker", s.Service)
+	assert.Equal(t, "consume", s.Name)
+	assert.Equal(t, "consumer", s.Meta["span.kind"])
+	assert.EqualValues(t, 2000000500, s.Start)
+}
+
+func TestJaegerInvalid(t *testing.T) {
+	for name, tt := range map[string]struct {
+		contentType string
+		body        string
+	}{
+		"truncated thrift": {"application/x-thrift", "\x0c\x00\x01\x0b\x00\x01\x00\x00\x00\xff"},
+		"thrift list size": {"application/x-thrift", "\x0f\x00\x02\x0c\x7f\xff\xff\xff"},
+		"unknown type":     {"application/x-thrift", "\x01\x00\x01"},
+		"proto":            {"application/x-protobuf", "\x0a\xff"},
+		"content type":     {"application/json", `{}`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr, tp := postSpans(t, jaegerV1, decodeJaegerBatch, tt.contentType, []byte(tt.body))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Nil(t, tp)
+		})
+	}
+}
+
+func TestJaegerThriftMaxDepth(t *testing.T) {
+	// nested writes a Batch with an unknown field holding structs nested depth
+	// times.
+	nested := func(depth int) []byte {
+		var w thriftWriter
+		for i := 0; i < depth; i++ {
+			w.field(thriftStruct, 3)
+		}
+		for i := 0; i <= depth; i++ {
+			w.stop()
+		}
+		return w.b
+	}
+
+	_, _, err := unmarshalJaegerThrift(nested(thriftMaxDepth))
+	assert.NoError(t, err)
+
+	_, _, err = unmarshalJaegerThrift(nested(thriftMaxDepth + 1))
+	assert.Equal(t, errInvalidThrift, err)
+
+	// a deeply nested payload does not exhaust the stack
+	_, _, err = unmarshalJaegerThrift(nested(1 << 20))
+	assert.Equal(t, errInvalidThrift, err)
+}
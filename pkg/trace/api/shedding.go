@@ -0,0 +1,233 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"github.com/tinylib/msgp/msgp"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+// unmarshalKeptTraces decodes the msgpack traces of bts into dest like
+// pb.Traces.UnmarshalMsg, but discards each trace not kept by the tracer as
+// soon as it is decoded, reusing its spans to decode the next ones. It returns
+// the number of traces discarded.
+func unmarshalKeptTraces(bts []byte, dest *pb.Traces) (int64, error) {
+	sz, bts, err := msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return 0, err
+	}
+	// each trace takes at least a byte
+	if int(sz) > len(bts) {
+		return 0, msgp.ErrShortBytes
+	}
+	traces := make(pb.Traces, sz)
+	// i is the index of the next trace kept
+	var i int
+	var shed int64
+	for range sz {
+		var n uint32
+		if n, bts, err = msgp.ReadArrayHeaderBytes(bts); err != nil {
+			return shed, err
+		}
+		if int(n) > len(bts) {
+			return shed, msgp.ErrShortBytes
+		}
+		if cap(traces[i]) >= int(n) {
+			traces[i] = traces[i][:n]
+		} else {
+			traces[i] = make(pb.Trace, n)
+		}
+		for j := range traces[i] {
+			if msgp.IsNil(bts) {
+				if bts, err = msgp.ReadNilBytes(bts); err != nil {
+					return shed, err
+				}
+				traces[i][j] = nil
+				continue
+			}
+			if traces[i][j] == nil {
+				traces[i][j] = new(pb.Span)
+			} else {
+				// the span of a discarded trace
+				*traces[i][j] = pb.Span{}
+			}
+			if bts, err = traces[i][j].UnmarshalMsg(bts); err != nil {
+				return shed, err
+			}
+		}
+		if keptTrace(traces[i]) {
+			i++
+		} else {
+			shed++
+		}
+	}
+	*dest = traces[:i]
+	return shed, nil
+}
+
+// unmarshalKeptTracerPayload decodes the msgpack tracer payload of bts into tp
+// like pb.TracerPayload.UnmarshalMsg, but discards the chunks not kept by the
+// tracer, without decoding their spans when their priority comes first. It
+// returns the number of chunks discarded.
+func unmarshalKeptTracerPayload(bts []byte, tp *pb.TracerPayload) (int64, error) {
+	sz, bts, err := msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return 0, err
+	}
+	// the fields other than the chunks are left to the generated decoder
+	var fields [][]byte
+	var shed int64
+	for range sz {
+		start := bts
+		var field []byte
+		if field, bts, err = msgp.ReadMapKeyZC(bts); err != nil {
+			return shed, err
+		}
+		if msgp.UnsafeString(field) != "chunks" {
+			if bts, err = msgp.Skip(bts); err != nil {
+				return shed, err
+			}
+			fields = append(fields, start[:len(start)-len(bts)])
+			continue
+		}
+		if tp.Chunks, bts, shed, err = unmarshalKeptChunks(bts); err != nil {
+			return shed, err
+		}
+	}
+	others := msgp.AppendMapHeader(nil, uint32(len(fields)))
+	for _, f := range fields {
+		others = append(others, f...)
+	}
+	_, err = tp.UnmarshalMsg(others)
+	return shed, err
+}
+
+// unmarshalKeptChunks decodes the msgpack array of chunks of bts, discarding
+// those not kept by the tracer. It returns the chunks kept, the remaining
+// bytes, and the number of chunks discarded.
+func unmarshalKeptChunks(bts []byte) ([]*pb.TraceChunk, []byte, int64, error) {
+	sz, bts, err := msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return nil, bts, 0, err
+	}
+	if int(sz) > len(bts) {
+		return nil, bts, 0, msgp.ErrShortBytes
+	}
+	chunks := make([]*pb.TraceChunk, 0, sz)
+	var shed int64
+	for range sz {
+		if msgp.IsNil(bts) {
+			if bts, err = msgp.ReadNilBytes(bts); err != nil {
+				return chunks, bts, shed, err
+			}
+			chunks = append(chunks, nil)
+			continue
+		}
+		if p, ok := chunkPriority(bts); ok && p <= 0 {
+			if bts, err = msgp.Skip(bts); err != nil {
+				return chunks, bts, shed, err
+			}
+			shed++
+			continue
+		}
+		chunk := new(pb.TraceChunk)
+		if bts, err = chunk.UnmarshalMsg(bts); err != nil {
+			return chunks, bts, shed, err
+		}
+		if !keptByTracer(chunk) {
+			shed++
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	return chunks, bts, shed, nil
+}
+
+// chunkPriority returns the sampling priority of the msgpack chunk of bts if
+// it is set before its spans.
+func chunkPriority(bts []byte) (int32, bool) {
+	sz, bts, err := msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return 0, false
+	}
+	for range sz {
+		var field []byte
+		if field, bts, err = msgp.ReadMapKeyZC(bts); err != nil {
+			return 0, false
+		}
+		switch msgp.UnsafeString(field) {
+		case "priority":
+			p, _, err := msgp.ReadInt32Bytes(bts)
+			return p, err == nil && p != int32(sampler.PriorityNone)
+		case "spans":
+			return 0, false
+		}
+		if bts, err = msgp.Skip(bts); err != nil {
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// shedUnkeptTraces removes the traces of dest not kept by the tracer when
+// shedUnkept is set, and returns how many were removed. It is used for the
+// payloads which can't be filtered while being decoded.
+func shedUnkeptTraces(dest *pb.Traces, shedUnkept bool) int64 {
+	if !shedUnkept {
+		return 0
+	}
+	kept := (*dest)[:0]
+	for _, trace := range *dest {
+		if keptTrace(trace) {
+			kept = append(kept, trace)
+		}
+	}
+	shed := int64(len(*dest) - len(kept))
+	clear((*dest)[len(kept):])
+	*dest = kept
+	return shed
+}
+
+// shedUnkeptChunks removes the chunks of tp not kept by the tracer, so that
+// they don't go through the processing, and returns how many were removed.
+func shedUnkeptChunks(tp *pb.TracerPayload) int64 {
+	kept := tp.Chunks[:0]
+	for _, chunk := range tp.Chunks {
+		if keptByTracer(chunk) {
+			kept = append(kept, chunk)
+		}
+	}
+	shed := int64(len(tp.Chunks) - len(kept))
+	clear(tp.Chunks[len(kept):])
+	tp.Chunks = kept
+	return shed
+}
+
+// keptByTracer returns whether the tracer kept the chunk, i.e. set a sampling
+// priority greater than 0 on it or on one of its spans, or set none.
+func keptByTracer(chunk *pb.TraceChunk) bool {
+	if chunk == nil {
+		return false
+	}
+	if p, ok := sampler.GetSamplingPriority(chunk); ok {
+		return p > 0
+	}
+	return keptTrace(chunk.Spans)
+}
+
+// keptTrace returns whether the tracer kept the trace, i.e. set a sampling
+// priority greater than 0 on one of its spans, or set none: the traces without
+// a sampling decision are left to the samplers of the agent.
+func keptTrace(trace pb.Trace) bool {
+	for _, s := range trace {
+		if p, ok := s.GetMetrics()[keySamplingPriority]; ok && p != float64(sampler.PriorityNone) {
+			return p > 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+)
+
+func TestUnmarshalKeptTraces(t *testing.T) {
+	traces := pb.Traces{
+		{{TraceID: 1, SpanID: 1, Error: 1, Meta: map[string]string{"k": "v"}, Metrics: map[string]float64{"_sampling_priority_v1": 0}}},
+		{
+			{TraceID: 2, SpanID: 1, Metrics: map[string]float64{"_sampling_priority_v1": 1}},
+			{TraceID: 2, SpanID: 2, ParentID: 1},
+		},
+		{{TraceID: 3, SpanID: 1}},
+		{{TraceID: 4, SpanID: 1, Metrics: map[string]float64{"_sampling_priority_v1": 2}}},
+	}
+	bts, err := traces.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	var kept pb.Traces
+	shed, err := unmarshalKeptTraces(bts, &kept)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, shed)
+	require.Len(t, kept, 3)
+	require.Len(t, kept[0], 2)
+	// the spans reused from a discarded trace don't keep any of its fields
+	assert.Equal(t, &pb.Span{TraceID: 2, SpanID: 1, Metrics: map[string]float64{"_sampling_priority_v1": 1}}, kept[0][0])
+	assert.Equal(t, &pb.Span{TraceID: 2, SpanID: 2, ParentID: 1}, kept[0][1])
+	// the traces without a sampling priority are kept
+	assert.Equal(t, traces[2], kept[1])
+	assert.Equal(t, traces[3], kept[2])
+
+	_, err = unmarshalKeptTraces(bts[:len(bts)-1], &kept)
+	assert.Error(t, err)
+}
+
+func TestUnmarshalKeptTracerPayload(t *testing.T) {
+	in := &pb.TracerPayload{
+		ContainerID:  "abcdef",
+		LanguageName: "go",
+		Env:          "prod",
+		Tags:         map[string]string{"k": "v"},
+		Chunks: []*pb.TraceChunk{
+			{Priority: int32(sampler.PriorityAutoKeep), Spans: []*pb.Span{{TraceID: 1}}},
+			{Priority: int32(sampler.PriorityAutoDrop), Spans: []*pb.Span{{TraceID: 2}}},
+			{Priority: int32(sampler.PriorityNone), Spans: []*pb.Span{{TraceID: 3, Metrics: map[string]float64{"_sampling_priority_v1": 1}}}},
+			{Priority: int32(sampler.PriorityNone), Spans: []*pb.Span{{TraceID: 4}}},
+			{Priority: int32(sampler.PriorityUserDrop), Spans: []*pb.Span{{TraceID: 5}}},
+		},
+	}
+	bts, err := in.MarshalMsg(nil)
+	require.NoError(t, err)
+
+	var tp pb.TracerPayload
+	shed, err := unmarshalKeptTracerPayload(bts, &tp)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, shed)
+	assert.Equal(t, "abcdef", tp.ContainerID)
+	assert.Equal(t, "go", tp.LanguageName)
+	assert.Equal(t, "prod", tp.Env)
+	assert.Equal(t, map[string]string{"k": "v"}, tp.Tags)
+	require.Len(t, tp.Chunks, 3)
+	assert.EqualValues(t, 1, tp.Chunks[0].Spans[0].TraceID)
+	assert.EqualValues(t, 3, tp.Chunks[1].Spans[0].TraceID)
+	// the chunks without a sampling priority are kept
+	assert.EqualValues(t, 4, tp.Chunks[2].Spans[0].TraceID)
+
+	_, err = unmarshalKeptTracerPayload(bts[:len(bts)-1], &tp)
+	assert.Error(t, err)
+}
+
+func TestChunkPriority(t *testing.T) {
+	chunk := &pb.TraceChunk{Priority: int32(sampler.PriorityUserDrop), Spans: []*pb.Span{{TraceID: 1}}}
+	bts, err := chunk.MarshalMsg(nil)
+	require.NoError(t, err)
+	p, ok := chunkPriority(bts)
+	assert.True(t, ok)
+	assert.EqualValues(t, sampler.PriorityUserDrop, p)
+
+	// the priority is not looked for past the spans
+	bts = msgp.AppendMapHeader(nil, 2)
+	bts = msgp.AppendString(bts, "spans")
+	bts = msgp.AppendArrayHeader(bts, 0)
+	bts = msgp.AppendString(bts, "priority")
+	bts = msgp.AppendInt32(bts, 0)
+	_, ok = chunkPriority(bts)
+	assert.False(t, ok)
+
+	// nor is an unset priority
+	bts, err = (&pb.TraceChunk{Priority: int32(sampler.PriorityNone)}).MarshalMsg(nil)
+	require.NoError(t, err)
+	_, ok = chunkPriority(bts)
+	assert.False(t, ok)
+}
@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/apiutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// keySamplingPriority is the metric holding the sampling priority of a trace.
+const keySamplingPriority = "_sampling_priority_v1"
+
+// spansDecoder decodes the spans of a request received in a third-party format,
+// converted to Datadog spans.
+type spansDecoder func(req *http.Request) ([]*pb.Span, error)
+
+// handleSpans returns the handler of the endpoint receiving spans in a
+// third-party format. The spans are grouped by trace into a tracer payload,
+// which then goes through the same normalization, obfuscation and sampling as
+// the payloads of the Datadog tracers.
+func (r *HTTPReceiver) handleSpans(v Version, decode spansDecoder) http.Handler {
+	return r.handleWithVersion(v, func(v Version, w http.ResponseWriter, req *http.Request) {
+		defer req.Body.Close()
+
+		select {
+		case r.recvsem <- struct{}{}:
+		case <-time.After(time.Duration(r.conf.DecoderTimeout) * time.Millisecond):
+			log.Debugf("trace-agent is overwhelmed, a %s payload has been rejected", v)
+			io.Copy(io.Discard, req.Body) //nolint:errcheck
+			w.WriteHeader(http.StatusTooManyRequests)
+			r.tagStats(v, req.Header, "").PayloadRefused.Inc()
+			return
+		}
+		defer func() { <-r.recvsem }()
+
+		spans, err := decode(req)
+		var service string
+		if len(spans) > 0 {
+			service = spans[0].Service
+		}
+		ts := r.tagStats(v, req.Header, service)
+		if err != nil {
+			httpDecodingError(err, []string{"handler:spans", fmt.Sprintf("v:%s", v)}, w, r.statsd)
+			ts.TracesDropped.DecodingError.Inc()
+			log.Errorf("Cannot decode %s spans payload: %v", v, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		if len(spans) == 0 {
+			return
+		}
+
+		tp := &pb.TracerPayload{
+			ContainerID:     r.containerIDProvider.GetContainerID(req.Context(), req.Header),
+			LanguageName:    req.Header.Get(header.Lang),
+			LanguageVersion: req.Header.Get(header.LangVersion),
+			TracerVersion:   req.Header.Get(header.TracerVersion),
+			Chunks:          r.spansChunks(spans),
+		}
+		if ctags := getContainerTags(r.conf.ContainerTags, tp.ContainerID); ctags != "" {
+			tp.Tags = map[string]string{tagContainersTags: ctags}
+		}
+		ts.TracesReceived.Add(int64(len(tp.Chunks)))
+		ts.TracesBytes.Add(req.Body.(*apiutil.LimitedReader).Count)
+		ts.PayloadAccepted.Inc()
+
+		r.out <- &Payload{
+			Source:        ts,
+			TracerPayload: tp,
+		}
+	})
+}
+
+// spansChunks groups the spans by trace ID. The traces are kept unless
+// a span carries a sampling priority, as third-party tracers only send the
+// spans of the sampled traces.
+func (r *HTTPReceiver) spansChunks(spans []*pb.Span) []*pb.TraceChunk {
+	var chunks []*pb.TraceChunk
+	byID := make(map[uint64]*pb.TraceChunk)
+	for _, span := range spans {
+		chunk, ok := byID[span.TraceID]
+		if !ok {
+			chunk = &pb.TraceChunk{Priority: int32(sampler.PriorityAutoKeep)}
+			if r.conf.ProbabilisticSamplerEnabled {
+				chunk.Priority = int32(sampler.PriorityNone)
+			}
+			byID[span.TraceID] = chunk
+			chunks = append(chunks, chunk)
+		}
+		if p, ok := span.Metrics[keySamplingPriority]; ok {
+			chunk.Priority = int32(p)
+		}
+		chunk.Spans = append(chunk.Spans, span)
+	}
+	return chunks
+}
+
+// spanKind returns the kind of span named name, case insensitive.
+func spanKind(name string) ptrace.SpanKind {
+	switch strings.ToLower(name) {
+	case "server":
+		return ptrace.SpanKindServer
+	case "client":
+		return ptrace.SpanKindClient
+	case "producer":
+		return ptrace.SpanKindProducer
+	case "consumer":
+		return ptrace.SpanKindConsumer
+	case "internal":
+		return ptrace.SpanKindInternal
+	default:
+		return ptrace.SpanKindUnspecified
+	}
+}
+
+// spanEvent is an event of a span, in the format of the "events" tag.
+type spanEvent struct {
+	TimeUnixNano int64                  `json:"time_unix_nano,omitempty"`
+	Name         string                 `json:"name,omitempty"`
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// completeSpan fills the fields of a span received in a third-party format,
+// which the Datadog tracers set themselves, from its kind and its tags.
+func completeSpan(span *pb.Span, kind ptrace.SpanKind, traceIDHigh uint64, events []spanEvent) {
+	if traceIDHigh != 0 {
+		traceutil.SetMeta(span, "_dd.p.tid", fmt.Sprintf("%016x", traceIDHigh))
+	}
+	if kind != ptrace.SpanKindUnspecified {
+		traceutil.SetMeta(span, "span.kind", traceutil.OTelSpanKindName(kind))
+	}
+	computeTopLevelAndMeasured(span, kind)
+	if len(events) > 0 {
+		if b, err := json.Marshal(events); err == nil {
+			traceutil.SetMeta(span, "events", string(b))
+		}
+	}
+
+	// the error tag holds either a boolean or the error message
+	if v, ok := span.Meta["error"]; ok {
+		delete(span.Meta, "error")
+		if isErr, err := strconv.ParseBool(v); err != nil {
+			span.Error = 1
+			if _, ok := span.Meta["error.msg"]; !ok {
+				span.Meta["error.msg"] = v
+			}
+		} else if isErr {
+			span.Error = 1
+		}
+	}
+	if _, ok := span.Meta["env"]; !ok {
+		if env := span.Meta["deployment.environment"]; env != "" {
+			traceutil.SetMeta(span, "env", traceutil.NormalizeTag(env))
+		}
+	}
+	if _, ok := span.Meta["version"]; !ok {
+		if version := span.Meta["service.version"]; version != "" {
+			traceutil.SetMeta(span, "version", version)
+		}
+	}
+	if span.Resource == "" {
+		if r := resourceFromTags(span.Meta); r != "" {
+			span.Resource = r
+		} else {
+			span.Resource = span.Name
+		}
+	}
+	if span.Type == "" {
+		span.Type = spanType(kind, span.Meta)
+	}
+}
+
+// spanType returns the Datadog type of a span of the given kind.
+func spanType(kind ptrace.SpanKind, meta map[string]string) string {
+	switch kind {
+	case ptrace.SpanKindServer:
+		return "web"
+	case ptrace.SpanKindClient:
+		db := meta["db.system"]
+		if db == "" {
+			db = meta["db.type"]
+		}
+		switch db {
+		case "":
+			return "http"
+		case "redis", "memcached":
+			return "cache"
+		default:
+			return "db"
+		}
+	default:
+		return "custom"
+	}
+}
+
+// setPeer sets the tags of the remote service a span is communicating with.
+func setPeer(span *pb.Span, service string, ip net.IP, port int) {
+	if service != "" {
+		traceutil.SetMeta(span, "peer.service", service)
+	}
+	if len(ip) > 0 && !ip.IsUnspecified() {
+		traceutil.SetMeta(span, "out.host", ip.String())
+	}
+	if port > 0 {
+		traceutil.SetMetric(span, "network.destination.port", float64(port))
+	}
+}
+
+var errInvalidProto = errors.New("invalid protobuf payload")
+
+// walkProto calls fn with each field of a protobuf message: v holds the value
+// of the length-delimited fields, n the value of the numeric ones.
+func walkProto(b []byte, fn func(num protowire.Number, v []byte, n uint64) error) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return errInvalidProto
+		}
+		b = b[l:]
+		var v []byte
+		var n uint64
+		switch typ {
+		case protowire.VarintType:
+			n, l = protowire.ConsumeVarint(b)
+		case protowire.Fixed64Type:
+			n, l = protowire.ConsumeFixed64(b)
+		case protowire.Fixed32Type:
+			var n32 uint32
+			n32, l = protowire.ConsumeFixed32(b)
+			n = uint64(n32)
+		case protowire.BytesType:
+			v, l = protowire.ConsumeBytes(b)
+		default:
+			l = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if l < 0 {
+			return errInvalidProto
+		}
+		b = b[l:]
+		if err := fn(num, v, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -142,4 +142,30 @@ const (
 	// Response: Service sampling rates (see description in v04).
 	//
 	V10 Version = "v1.0"
+
+	// zipkinV2 API
+	//
+	// Request: Zipkin spans, see https://zipkin.io/zipkin-api/.
+	// 	Content-Type: application/json
+	// 	Payload: a JSON array of Zipkin v2 spans.
+	//
+	// 	Content-Type: application/x-protobuf
+	// 	Payload: ListOfSpans (zipkin2/zipkin.proto)
+	//
+	// Response: 202 Accepted.
+	//
+	zipkinV2 Version = "zipkin_v2"
+
+	// jaegerV1 API
+	//
+	// Request: Jaeger batch, as accepted by the /api/traces endpoint of the Jaeger collector.
+	// 	Content-Type: application/x-thrift
+	// 	Payload: Batch (jaeger.thrift), in the Thrift binary protocol.
+	//
+	// 	Content-Type: application/x-protobuf
+	// 	Payload: Batch (model.proto)
+	//
+	// Response: 202 Accepted.
+	//
+	jaegerV1 Version = "jaeger_v1"
 )
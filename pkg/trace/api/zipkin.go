@@ -0,0 +1,245 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// zipkinSpan is a Zipkin v2 span, see https://zipkin.io/zipkin-api/#/default/post_spans.
+type zipkinSpan struct {
+	TraceID        string             `json:"traceId"`
+	ParentID       string             `json:"parentId"`
+	ID             string             `json:"id"`
+	Kind           string             `json:"kind"`
+	Name           string             `json:"name"`
+	Timestamp      int64              `json:"timestamp"` // in microseconds
+	Duration       int64              `json:"duration"`  // in microseconds
+	LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint"`
+	RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint"`
+	Annotations    []zipkinAnnotation `json:"annotations"`
+	Tags           map[string]string  `json:"tags"`
+	Debug          bool               `json:"debug"`
+}
+
+type zipkinEndpoint struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int    `json:"port"`
+}
+
+type zipkinAnnotation struct {
+	Timestamp int64  `json:"timestamp"` // in microseconds
+	Value     string `json:"value"`
+}
+
+// decodeZipkinSpans decodes the Zipkin v2 spans of a request, encoded in JSON
+// or protobuf.
+func decodeZipkinSpans(req *http.Request) ([]*pb.Span, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	var zspans []*zipkinSpan
+	switch mediaType := getMediaType(req); mediaType {
+	case "application/json":
+		err = json.Unmarshal(body, &zspans)
+	case "application/x-protobuf", "application/protobuf":
+		zspans, err = unmarshalZipkinProto(body)
+	default:
+		return nil, fmt.Errorf("unsupported media type: %q", mediaType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	spans := make([]*pb.Span, 0, len(zspans))
+	for _, zs := range zspans {
+		span, err := convertZipkinSpan(zs)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, span)
+	}
+	return spans, nil
+}
+
+// convertZipkinSpan converts a Zipkin span to a Datadog span.
+func convertZipkinSpan(zs *zipkinSpan) (*pb.Span, error) {
+	traceIDHigh, traceID, err := parseZipkinID(zs.TraceID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trace ID: %v", err)
+	}
+	_, spanID, err := parseZipkinID(zs.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid span ID: %v", err)
+	}
+	var parentID uint64
+	if zs.ParentID != "" {
+		if _, parentID, err = parseZipkinID(zs.ParentID); err != nil {
+			return nil, fmt.Errorf("invalid parent ID: %v", err)
+		}
+	}
+	span := &pb.Span{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: parentID,
+		Name:     zs.Name,
+		Start:    zs.Timestamp * 1000,
+		Duration: zs.Duration * 1000,
+		Meta:     make(map[string]string, len(zs.Tags)),
+		Metrics:  make(map[string]float64),
+	}
+	for k, v := range zs.Tags {
+		span.Meta[k] = v
+	}
+	if zs.LocalEndpoint != nil {
+		span.Service = zs.LocalEndpoint.ServiceName
+	}
+	if e := zs.RemoteEndpoint; e != nil {
+		ip := net.ParseIP(e.IPv4)
+		if ip == nil {
+			ip = net.ParseIP(e.IPv6)
+		}
+		setPeer(span, e.ServiceName, ip, e.Port)
+	}
+	if zs.Debug {
+		traceutil.SetMetric(span, keySamplingPriority, 2)
+	}
+	events := make([]spanEvent, 0, len(zs.Annotations))
+	for _, a := range zs.Annotations {
+		events = append(events, spanEvent{TimeUnixNano: a.Timestamp * 1000, Name: a.Value})
+	}
+	completeSpan(span, spanKind(zs.Kind), traceIDHigh, events)
+	return span, nil
+}
+
+// parseZipkinID parses a 64 or 128-bit hexadecimal ID.
+func parseZipkinID(id string) (high, low uint64, err error) {
+	if id == "" || len(id) > 32 {
+		return 0, 0, fmt.Errorf("%q is not a 64 or 128-bit hexadecimal ID", id)
+	}
+	if len(id) > 16 {
+		if high, err = strconv.ParseUint(id[:len(id)-16], 16, 64); err != nil {
+			return 0, 0, err
+		}
+		id = id[len(id)-16:]
+	}
+	low, err = strconv.ParseUint(id, 16, 64)
+	return high, low, err
+}
+
+// zipkinProtoKinds are the span kinds by value of the Span.Kind enum.
+var zipkinProtoKinds = []string{"", "CLIENT", "SERVER", "PRODUCER", "CONSUMER"}
+
+// unmarshalZipkinProto unmarshals a ListOfSpans message of zipkin2/zipkin.proto.
+func unmarshalZipkinProto(b []byte) ([]*zipkinSpan, error) {
+	var spans []*zipkinSpan
+	err := walkProto(b, func(num protowire.Number, v []byte, _ uint64) error {
+		if num != 1 {
+			return nil
+		}
+		span, err := unmarshalZipkinProtoSpan(v)
+		spans = append(spans, span)
+		return err
+	})
+	return spans, err
+}
+
+func unmarshalZipkinProtoSpan(b []byte) (*zipkinSpan, error) {
+	span := &zipkinSpan{Tags: make(map[string]string)}
+	err := walkProto(b, func(num protowire.Number, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			span.TraceID = hex.EncodeToString(v)
+		case 2:
+			span.ParentID = hex.EncodeToString(v)
+		case 3:
+			span.ID = hex.EncodeToString(v)
+		case 4:
+			if n < uint64(len(zipkinProtoKinds)) {
+				span.Kind = zipkinProtoKinds[n]
+			}
+		case 5:
+			span.Name = string(v)
+		case 6:
+			span.Timestamp = int64(n)
+		case 7:
+			span.Duration = int64(n)
+		case 8, 9:
+			e, err := unmarshalZipkinProtoEndpoint(v)
+			if num == 8 {
+				span.LocalEndpoint = e
+			} else {
+				span.RemoteEndpoint = e
+			}
+			return err
+		case 10:
+			var a zipkinAnnotation
+			err := walkProto(v, func(num protowire.Number, v []byte, n uint64) error {
+				switch num {
+				case 1:
+					a.Timestamp = int64(n)
+				case 2:
+					a.Value = string(v)
+				}
+				return nil
+			})
+			span.Annotations = append(span.Annotations, a)
+			return err
+		case 11:
+			var key, value string
+			err := walkProto(v, func(num protowire.Number, v []byte, _ uint64) error {
+				switch num {
+				case 1:
+					key = string(v)
+				case 2:
+					value = string(v)
+				}
+				return nil
+			})
+			span.Tags[key] = value
+			return err
+		case 12:
+			span.Debug = n != 0
+		}
+		return nil
+	})
+	return span, err
+}
+
+func unmarshalZipkinProtoEndpoint(b []byte) (*zipkinEndpoint, error) {
+	e := &zipkinEndpoint{}
+	err := walkProto(b, func(num protowire.Number, v []byte, n uint64) error {
+		switch num {
+		case 1:
+			e.ServiceName = string(v)
+		case 2:
+			if len(v) > 0 {
+				e.IPv4 = net.IP(v).String()
+			}
+		case 3:
+			if len(v) > 0 {
+				e.IPv6 = net.IP(v).String()
+			}
+		case 4:
+			e.Port = int(int32(n))
+		}
+		return nil
+	})
+	return e, err
+}
@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+)
+
+// postSpans posts a payload to the handler of a third-party format, and
+// returns the tracer payload sent to the agent, nil if there is none.
+func postSpans(t *testing.T, v Version, decode spansDecoder, contentType string, body []byte) (*httptest.ResponseRecorder, *pb.TracerPayload) {
+	receiver := newTestReceiverFromConfig(newTestReceiverConfig())
+	rr := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	receiver.handleSpans(v, decode).ServeHTTP(rr, req)
+	select {
+	case p := <-receiver.out:
+		return rr, p.TracerPayload
+	default:
+		return rr, nil
+	}
+}
+
+func TestZipkinJSON(t *testing.T) {
+	body := `[
+		{
+			"traceId": "5af7183fb1d4cf5f4bf6f7d1f7f3a2c1",
+			"id": "352bff9a74ca9ad2",
+			"kind": "SERVER",
+			"name": "get /users/{id}",
+			"timestamp": 1556604172355737,
+			"duration": 1431,
+			"localEndpoint": {"serviceName": "frontend", "ipv4": "192.168.99.1", "port": 8080},
+			"tags": {"http.method": "GET", "http.route": "/users/{id}", "env": "prod"}
+		},
+		{
+			"traceId": "5af7183fb1d4cf5f4bf6f7d1f7f3a2c1",
+			"parentId": "352bff9a74ca9ad2",
+			"id": "6b221d5bc9e6496c",
+			"kind": "CLIENT",
+			"name": "select",
+			"timestamp": 1556604172355800,
+			"duration": 500,
+			"localEndpoint": {"serviceName": "frontend"},
+			"remoteEndpoint": {"serviceName": "postgres", "ipv4": "10.0.0.12", "port": 5432},
+			"annotations": [{"timestamp": 1556604172355900, "value": "retry"}],
+			"tags": {"db.type": "postgresql", "error": "connection reset"}
+		},
+		{
+			"traceId": "0000000000000042",
+			"id": "0000000000000001",
+			"name": "job",
+			"debug": true,
+			"localEndpoint": {"serviceName": "worker"}
+		}
+	]`
+	rr, tp := postSpans(t, zipkinV2, decodeZipkinSpans, "application/json", []byte(body))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.NotNil(t, tp)
+	require.Len(t, tp.Chunks, 2)
+
+	chunk := tp.Chunks[0]
+	assert.EqualValues(t, sampler.PriorityAutoKeep, chunk.Priority)
+	require.Len(t, chunk.Spans, 2)
+	server, client := chunk.Spans[0], chunk.Spans[1]
+
+	assert.EqualValues(t, 0x4bf6f7d1f7f3a2c1, server.TraceID)
+	assert.EqualValues(t, 0x352bff9a74ca9ad2, server.SpanID)
+	assert.Equal(t, "5af7183fb1d4cf5f", server.Meta["_dd.p.tid"])
+	assert.Equal(t, "frontend", server.Service)
+	assert.Equal(t, "get /users/{id}", server.Name)
+	assert.Equal(t, "GET /users/{id}", server.Resource)
+	assert.Equal(t, "web", server.Type)
+	assert.Equal(t, "server", server.Meta["span.kind"])
+	assert.EqualValues(t, 1556604172355737000, server.Start)
+	assert.EqualValues(t, 1431000, server.Duration)
+	assert.True(t, traceutil.HasTopLevel(server))
+	assert.Zero(t, server.Error)
+
+	assert.Equal(t, server.SpanID, client.ParentID)
+	assert.Equal(t, "db", client.Type)
+	assert.Equal(t, "select", client.Resource)
+	assert.EqualValues(t, 1, client.Error)
+	assert.Equal(t, "connection reset", client.Meta["error.msg"])
+	assert.NotContains(t, client.Meta, "error")
+	assert.Equal(t, "postgres", client.Meta["peer.service"])
+	assert.Equal(t, "10.0.0.12", client.Meta["out.host"])
+	assert.EqualValues(t, 5432, client.Metrics["network.destination.port"])
+	assert.Equal(t, `[{"time_unix_nano":1556604172355900000,"name":"retry"}]`, client.Meta["events"])
+	assert.True(t, traceutil.IsMeasured(client))
+
+	debug := tp.Chunks[1]
+	assert.EqualValues(t, sampler.PriorityUserKeep, debug.Priority)
+	assert.Equal(t, "custom", debug.Spans[0].Type)
+}
+
+func TestZipkinProto(t *testing.T) {
+	var endpoint []byte
+	endpoint = protowire.AppendTag(endpoint, 1, protowire.BytesType)
+	endpoint = protowire.AppendString(endpoint, "frontend")
+	endpoint = protowire.AppendTag(endpoint, 2, protowire.BytesType)
+	endpoint = protowire.AppendBytes(endpoint, []byte{10, 0, 0, 1})
+
+	var tag []byte
+	tag = protowire.AppendTag(tag, 1, protowire.BytesType)
+	tag = protowire.AppendString(tag, "http.method")
+	tag = protowire.AppendTag(tag, 2, protowire.BytesType)
+	tag = protowire.AppendString(tag, "POST")
+
+	var span []byte
+	span = protowire.AppendTag(span, 1, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 0x2a})
+	span = protowire.AppendTag(span, 3, protowire.BytesType)
+	span = protowire.AppendBytes(span, []byte{0, 0, 0, 0, 0, 0, 0, 0x07})
+	span = protowire.AppendTag(span, 4, protowire.VarintType)
+	span = protowire.AppendVarint(span, 2) // SERVER
+	span = protowire.AppendTag(span, 5, protowire.BytesType)
+	span = protowire.AppendString(span, "post")
+	span = protowire.AppendTag(span, 6, protowire.Fixed64Type)
+	span = protowire.AppendFixed64(span, 1000)
+	span = protowire.AppendTag(span, 7, protowire.VarintType)
+	span = protowire.AppendVarint(span, 20)
+	span = protowire.AppendTag(span, 8, protowire.BytesType)
+	span = protowire.AppendBytes(span, endpoint)
+	span = protowire.AppendTag(span, 11, protowire.BytesType)
+	span = protowire.AppendBytes(span, tag)
+	// unknown fields are ignored
+	span = protowire.AppendTag(span, 42, protowire.VarintType)
+	span = protowire.AppendVarint(span, 1)
+
+	var list []byte
+	list = protowire.AppendTag(list, 1, protowire.BytesType)
+	list = protowire.AppendBytes(list, span)
+
+	rr, tp := postSpans(t, zipkinV2, decodeZipkinSpans, "application/x-protobuf", list)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	require.NotNil(t, tp)
+	require.Len(t, tp.Chunks, 1)
+	require.Len(t, tp.Chunks[0].Spans, 1)
+	s := tp.Chunks[0].Spans[0]
+	assert.EqualValues(t, 42, s.TraceID)
+	assert.EqualValues(t, 7, s.SpanID)
+	assert.Equal(t, "frontend", s.Service)
+	assert.Equal(t, "post", s.Name)
+	assert.Equal(t, "POST", s.Resource)
+	assert.Equal(t, "web", s.Type)
+	assert.EqualValues(t, 1000000, s.Start)
+	assert.EqualValues(t, 20000, s.Duration)
+}
+
+func TestZipkinInvalid(t *testing.T) {
+	for name, tt := range map[string]struct {
+		contentType string
+		body        string
+	}{
+		"json":         {"application/json", `[{"traceId": "1"`},
+		"trace ID":     {"application/json", `[{"traceId": "xyz", "id": "1"}]`},
+		"span ID":      {"application/json", `[{"traceId": "1", "id": ""}]`},
+		"proto":        {"application/x-protobuf", "\x0a\xff"},
+		"content type": {"text/plain", `[]`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr, tp := postSpans(t, zipkinV2, decodeZipkinSpans, tt.contentType, []byte(tt.body))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.Nil(t, tp)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package capture implements the capture files of the payloads received by the
+// trace-agent, and their replay against a running trace-agent.
+//
+// A capture file is a gzip stream starting with a magic header, followed by the
+// records. Each record is made of its JSON encoded metadata and of the request body,
+// both prefixed with their length as an uvarint.
+package capture
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// magic starts every capture file, its last byte being the version of the format.
+const magic = "DDTRACECAPTURE\x01"
+
+// maxRecordLen is the maximum length of the metadata and of the body of a record.
+const maxRecordLen = 256 * 1024 * 1024
+
+// Record is a request received by the trace-agent.
+type Record struct {
+	// Time is the time the request was received.
+	Time time.Time `json:"time"`
+	// Method is the HTTP method of the request.
+	Method string `json:"method"`
+	// URL is the path and the query of the request.
+	URL string `json:"url"`
+	// Header holds the headers of the request, including its content type.
+	Header http.Header `json:"header"`
+	// Body is the raw body of the request.
+	Body []byte `json:"-"`
+}
+
+// sensitiveHeaders are the headers never written to capture files.
+var sensitiveHeaders = []string{"Dd-Api-Key", "Authorization", "Cookie", "Proxy-Authorization"}
+
+// Writer writes records to a capture file. It is not safe for concurrent use.
+type Writer struct {
+	zw  *gzip.Writer
+	bw  *bufio.Writer
+	buf []byte
+}
+
+// NewWriter returns a Writer writing the capture file to w. Close must be called
+// to flush it.
+func NewWriter(w io.Writer) (*Writer, error) {
+	cw := &Writer{zw: gzip.NewWriter(w)}
+	cw.bw = bufio.NewWriter(cw.zw)
+	if _, err := cw.bw.WriteString(magic); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+// Write writes a record. The sensitive headers of the record are left out.
+func (w *Writer) Write(r *Record) error {
+	meta := *r
+	meta.Header = r.Header.Clone()
+	for _, h := range sensitiveHeaders {
+		meta.Header.Del(h)
+	}
+	b, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+	if err := w.writeChunk(b); err != nil {
+		return err
+	}
+	return w.writeChunk(r.Body)
+}
+
+func (w *Writer) writeChunk(b []byte) error {
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(len(b)))
+	if _, err := w.bw.Write(w.buf); err != nil {
+		return err
+	}
+	_, err := w.bw.Write(b)
+	return err
+}
+
+// Close flushes the capture file. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// Reader reads the records of a capture file.
+type Reader struct {
+	br *bufio.Reader
+}
+
+// NewReader returns a Reader reading the capture file from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a trace capture file: %v", err)
+	}
+	br := bufio.NewReader(zr)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return nil, errors.New("not a trace capture file, or unsupported version")
+	}
+	return &Reader{br: br}, nil
+}
+
+// Next returns the next record of the capture file, or io.EOF at its end.
+func (r *Reader) Next() (*Record, error) {
+	meta, err := r.readChunk()
+	if err != nil {
+		return nil, err
+	}
+	var rec Record
+	if err := json.Unmarshal(meta, &rec); err != nil {
+		return nil, fmt.Errorf("invalid record: %v", err)
+	}
+	if rec.Body, err = r.readChunk(); err != nil {
+		return nil, noEOF(err)
+	}
+	return &rec, nil
+}
+
+func (r *Reader) readChunk() ([]byte, error) {
+	n, err := binary.ReadUvarint(r.br)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxRecordLen {
+		return nil, fmt.Errorf("invalid record length %d", n)
+	}
+	b := make([]byte, n)
+	_, err = io.ReadFull(r.br, b)
+	return b, noEOF(err)
+}
+
+// noEOF turns io.EOF into io.ErrUnexpectedEOF, for the end of the file in the
+// middle of a record.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
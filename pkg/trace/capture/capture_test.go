@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRecords() []*Record {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	return []*Record{
+		{
+			Time:   now,
+			Method: http.MethodPut,
+			URL:    "/v0.4/traces",
+			Header: http.Header{"Content-Type": {"application/msgpack"}, "X-Datadog-Trace-Count": {"1"}},
+			Body:   []byte{0x91, 0x90},
+		},
+		{
+			Time:   now.Add(20 * time.Millisecond),
+			Method: http.MethodPost,
+			URL:    "/api/v2/spans?foo=bar",
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   []byte(`[]`),
+		},
+		{
+			Time:   now.Add(30 * time.Millisecond),
+			Method: http.MethodPost,
+			URL:    "/v0.6/stats",
+			Header: http.Header{},
+			Body:   []byte{},
+		},
+	}
+}
+
+func writeCapture(t *testing.T, records []*Record) *bytes.Buffer {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	require.NoError(t, err)
+	for _, r := range records {
+		require.NoError(t, w.Write(r))
+	}
+	require.NoError(t, w.Close())
+	return &buf
+}
+
+func TestWriterReader(t *testing.T) {
+	records := testRecords()
+	records[0].Header.Set("Dd-Api-Key", "secret")
+	buf := writeCapture(t, records)
+	assert.Equal(t, "secret", records[0].Header.Get("Dd-Api-Key"), "the record must not be modified")
+
+	r, err := NewReader(buf)
+	require.NoError(t, err)
+	records[0].Header.Del("Dd-Api-Key")
+	for _, want := range records {
+		got, err := r.Next()
+		require.NoError(t, err)
+		assert.True(t, want.Time.Equal(got.Time))
+		got.Time = want.Time
+		assert.Equal(t, want, got)
+	}
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	t.Run("truncated", func(t *testing.T) {
+		var zbuf bytes.Buffer
+		w, err := NewWriter(&zbuf)
+		require.NoError(t, err)
+		// the metadata of a record without its body
+		require.NoError(t, w.writeChunk([]byte(`{"method":"PUT"}`)))
+		require.NoError(t, w.Close())
+		r, err := NewReader(&zbuf)
+		require.NoError(t, err)
+		_, err = r.Next()
+		assert.Equal(t, io.ErrUnexpectedEOF, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewReader(bytes.NewReader([]byte("not a capture")))
+		assert.Error(t, err)
+	})
+}
+
+func TestReplay(t *testing.T) {
+	var received []*Record
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		received = append(received, &Record{Method: req.Method, URL: req.URL.RequestURI(), Header: req.Header, Body: body})
+		if req.URL.Path == "/v0.6/stats" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	records := testRecords()
+	r, err := NewReader(writeCapture(t, records))
+	require.NoError(t, err)
+	start := time.Now()
+	stats, err := Replay(context.Background(), r, srv.Client(), srv.URL+"/", true)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	assert.Equal(t, ReplayStats{Sent: 3, Failed: 1}, stats)
+	require.Len(t, received, 3)
+	for i, want := range records {
+		got := received[i]
+		assert.Equal(t, want.Method, got.Method)
+		assert.Equal(t, want.URL, got.URL)
+		assert.Equal(t, want.Body, got.Body)
+		for k := range want.Header {
+			assert.Equal(t, want.Header.Get(k), got.Header.Get(k))
+		}
+	}
+}
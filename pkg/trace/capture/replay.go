@@ -0,0 +1,90 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package capture
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ReplayStats are the results of a replay.
+type ReplayStats struct {
+	// Sent is the number of requests sent.
+	Sent int
+	// Failed is the number of requests which could not be sent or got a reply
+	// with a status code other than 2xx.
+	Failed int
+}
+
+// Replay sends the records of r to the trace-agent listening at baseURL (e.g.
+// http://localhost:8126). With realtime, the requests are paced like they were
+// received, otherwise they are sent as fast as possible. It stops at the first
+// error reading the capture file, or when ctx is done.
+func Replay(ctx context.Context, r *Reader, client *http.Client, baseURL string, realtime bool) (ReplayStats, error) {
+	var (
+		stats ReplayStats
+		first time.Time // time the first record was received
+		start time.Time // time the replay started
+	)
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			return stats, nil
+		}
+		if err != nil {
+			return stats, err
+		}
+		if realtime {
+			if first.IsZero() {
+				first, start = rec.Time, time.Now()
+			}
+			if wait := rec.Time.Sub(first) - time.Since(start); wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return stats, ctx.Err()
+				}
+			}
+		}
+		stats.Sent++
+		if err := send(ctx, client, baseURL, rec); err != nil {
+			if ctx.Err() != nil {
+				return stats, ctx.Err()
+			}
+			stats.Failed++
+		}
+	}
+}
+
+// send sends a record to the trace-agent.
+func send(ctx context.Context, client *http.Client, baseURL string, rec *Record) error {
+	req, err := http.NewRequestWithContext(ctx, rec.Method, baseURL+rec.URL, bytes.NewReader(rec.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range rec.Header {
+		// the length of the body is set by the client
+		if k != "Content-Length" {
+			req.Header[k] = v
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s", rec.Method, rec.URL, resp.Status)
+	}
+	return nil
+}
@@ -131,6 +131,14 @@ type ObfuscationConfig struct {
 	// CreditCards holds the configuration for obfuscating credit cards.
 	CreditCards obfuscate.CreditCardsConfig `mapstructure:"credit_cards"`
 
+	// GraphQL holds the configuration for obfuscating the resource, query and variable
+	// tags of spans of type "graphql".
+	GraphQL obfuscate.GraphQLConfig `mapstructure:"graphql"`
+
+	// DynamoDB holds the configuration for obfuscating the "dynamodb.request" tag
+	// for spans of type "dynamodb" or "aws".
+	DynamoDB obfuscate.JSONConfig `mapstructure:"dynamodb"`
+
 	// Cache holds the configuration for caching obfuscation results.
 	Cache obfuscate.CacheConfig `mapstructure:"cache"`
 }
@@ -153,11 +161,13 @@ func obfuscationMode(conf *AgentConfig, sqllexerEnabled bool) obfuscate.Obfuscat
 func (o *ObfuscationConfig) Export(conf *AgentConfig) obfuscate.Config {
 	return obfuscate.Config{
 		SQL: obfuscate.SQLConfig{
-			TableNames:       conf.HasFeature("table_names"),
-			ReplaceDigits:    conf.HasFeature("quantize_sql_tables") || conf.HasFeature("replace_sql_digits"),
-			KeepSQLAlias:     conf.HasFeature("keep_sql_alias"),
-			DollarQuotedFunc: conf.HasFeature("dollar_quoted_func"),
-			ObfuscationMode:  obfuscationMode(conf, conf.HasFeature("sqllexer")),
+			TableNames:        conf.HasFeature("table_names"),
+			CollectProcedures: conf.HasFeature("sql_procedures"),
+			Fingerprint:       conf.HasFeature("sql_fingerprint"),
+			ReplaceDigits:     conf.HasFeature("quantize_sql_tables") || conf.HasFeature("replace_sql_digits"),
+			KeepSQLAlias:      conf.HasFeature("keep_sql_alias"),
+			DollarQuotedFunc:  conf.HasFeature("dollar_quoted_func"),
+			ObfuscationMode:   obfuscationMode(conf, conf.HasFeature("sqllexer")),
 		},
 		ES:                   o.ES,
 		OpenSearch:           o.OpenSearch,
@@ -169,6 +179,8 @@ func (o *ObfuscationConfig) Export(conf *AgentConfig) obfuscate.Config {
 		Valkey:               o.Valkey,
 		Memcached:            o.Memcached,
 		CreditCard:           o.CreditCards,
+		GraphQL:              o.GraphQL,
+		DynamoDB:             o.DynamoDB,
 		Logger:               new(debugLogger),
 		Cache:                o.Cache,
 	}
@@ -209,6 +221,170 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// Filter rule actions.
+const (
+	// FilterDrop drops the traces having a span matching the rule.
+	FilterDrop = "drop"
+	// FilterKeep keeps the traces having a span matching the rule, regardless
+	// of the drop rules following it.
+	FilterKeep = "keep"
+	// FilterSetTag sets the tag to the value on the spans matching the rule.
+	FilterSetTag = "set_tag"
+	// FilterRemoveTag removes the tag from the spans matching the rule.
+	FilterRemoveTag = "remove_tag"
+	// FilterReplaceTag replaces the matches of the pattern in the value of
+	// the tag of the spans matching the rule.
+	FilterReplaceTag = "replace_tag"
+)
+
+// FilterRule specifies a rule of the trace filters, matching the spans by their
+// properties to drop or keep their trace, or to rewrite their tags. A span
+// matches a rule when it matches all its conditions, the unset conditions
+// matching any span.
+type FilterRule struct {
+	// Name is the name of the rule, reported in the rule hit counters.
+	Name string `mapstructure:"name"`
+
+	// Action is one of drop, keep, set_tag, remove_tag or replace_tag.
+	Action string `mapstructure:"action"`
+
+	// Service, Operation and Resource are the regexp patterns the service,
+	// the operation name and the resource of the span must match.
+	Service   string `mapstructure:"service"`
+	Operation string `mapstructure:"operation"`
+	Resource  string `mapstructure:"resource"`
+
+	// Tags maps the tags the span must have to the regexp pattern their value
+	// must match, any value matching when it is empty.
+	Tags map[string]string `mapstructure:"tags"`
+
+	// MinDurationMS and MaxDurationMS are the bounds of the duration of the
+	// span in milliseconds, when positive.
+	MinDurationMS float64 `mapstructure:"min_duration_ms"`
+	MaxDurationMS float64 `mapstructure:"max_duration_ms"`
+
+	// Error is whether the span must be in error, when set.
+	Error *bool `mapstructure:"error"`
+
+	// Tag is the tag rewritten by the set_tag, remove_tag and replace_tag
+	// actions.
+	Tag string `mapstructure:"tag"`
+
+	// Value is the value set by the set_tag action.
+	Value string `mapstructure:"value"`
+
+	// Pattern and Repl are the regexp pattern and the replacement string of
+	// the replace_tag action.
+	Pattern string `mapstructure:"pattern"`
+	Repl    string `mapstructure:"repl"`
+
+	// ServiceRe, OperationRe, ResourceRe, TagsRe and Re hold the compiled
+	// patterns and are only used internally.
+	ServiceRe   *regexp.Regexp            `mapstructure:"-"`
+	OperationRe *regexp.Regexp            `mapstructure:"-"`
+	ResourceRe  *regexp.Regexp            `mapstructure:"-"`
+	TagsRe      map[string]*regexp.Regexp `mapstructure:"-"`
+	Re          *regexp.Regexp            `mapstructure:"-"`
+}
+
+// ServiceBudget specifies the maximum number of spans per second ingested for
+// a service, for a service in an env, or for all the services of an env.
+type ServiceBudget struct {
+	// Service and Env select the traces the budget applies to, by the service
+	// of their root span and their env. An empty value matches any service or
+	// env, but one of them is required.
+	Service string `mapstructure:"service" json:"service"`
+	Env     string `mapstructure:"env" json:"env"`
+
+	// MaxSpansPerSecond is the number of spans per second kept for the
+	// matching traces.
+	MaxSpansPerSecond float64 `mapstructure:"max_spans_per_second" json:"max_spans_per_second"`
+}
+
+// SDSConfig specifies the scanning of the span tag values with the Sensitive
+// Data Scanner rules, redacting the sensitive data they match.
+type SDSConfig struct {
+	// Enabled enables the scanning.
+	Enabled bool
+
+	// RulesFile is the path of the SDS rules file, in the format of the logs
+	// local SDS rules file.
+	RulesFile string
+}
+
+// ServiceMapConfig specifies the service dependency map, whose caller to callee
+// edges are derived from the received spans before sampling.
+type ServiceMapConfig struct {
+	// Enabled enables the service map metrics.
+	Enabled bool
+
+	// MaxEdges is the maximum number of edges reported per flush. The spans of
+	// the edges beyond it are only counted as dropped.
+	MaxEdges int
+}
+
+// Tail sampling policy types.
+const (
+	// TailSamplingLatency keeps the traces having a span lasting at least the
+	// threshold.
+	TailSamplingLatency = "latency"
+	// TailSamplingError keeps the traces having a span in error.
+	TailSamplingError = "error"
+	// TailSamplingResource keeps the traces having a span whose resource
+	// matches the pattern.
+	TailSamplingResource = "resource"
+	// TailSamplingTag keeps the traces having a span whose tag value matches
+	// the pattern.
+	TailSamplingTag = "tag"
+)
+
+// TailSamplingConfig specifies the tail-based sampling, which buffers the trace
+// chunks dropped by the samplers and keeps the traces matching a policy.
+type TailSamplingConfig struct {
+	// Enabled enables the tail-based sampling.
+	Enabled bool
+
+	// DecisionWait is how long the chunks of a trace are buffered, from the
+	// first one received, before the policies are applied to the trace.
+	DecisionWait time.Duration
+
+	// MaxMemory is the maximum size in bytes of the buffered chunks and of the
+	// decisions. Once reached, the oldest decisions are forgotten and the
+	// policies are applied to the oldest traces early.
+	MaxMemory int64
+
+	// Policies are the policies a trace is kept by, a trace is kept as soon
+	// as one of them matches.
+	Policies []*TailSamplingPolicy
+}
+
+// TailSamplingPolicy specifies a policy of the tail-based sampling.
+type TailSamplingPolicy struct {
+	// Name is the name of the policy, reported in the kept traces.
+	Name string `mapstructure:"name"`
+
+	// Type is one of latency, error, resource or tag.
+	Type string `mapstructure:"type"`
+
+	// ThresholdMS is the minimum duration in milliseconds of a span for
+	// latency policies.
+	ThresholdMS float64 `mapstructure:"threshold_ms"`
+
+	// Service restricts the policy to the spans of a service, when set.
+	Service string `mapstructure:"service"`
+
+	// Key is the tag checked by tag policies.
+	Key string `mapstructure:"key"`
+
+	// Pattern is the regexp the resource or the tag value must match for
+	// resource and tag policies. Any value matches for tag policies when
+	// empty.
+	Pattern string `mapstructure:"pattern"`
+
+	// Re holds the compiled Pattern and is only used internally.
+	Re *regexp.Regexp `mapstructure:"-"`
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -224,6 +400,38 @@ type WriterConfig struct {
 	FlushPeriodSeconds float64 `mapstructure:"flush_period_seconds"`
 }
 
+// Formats of the files written by the file export.
+const (
+	FileExportMsgpack = "msgpack"
+	FileExportJSON    = "json"
+)
+
+// FileExportConfig specifies the export of the sampled traces and of the stats to
+// rotating local files, in addition to the endpoints.
+type FileExportConfig struct {
+	// Enabled enables the file export.
+	Enabled bool
+
+	// Dir is the directory the files are written to.
+	Dir string
+
+	// Format is the encoding of the payloads in the files, msgpack or json. The
+	// msgpack files are a sequence of payloads, the JSON files have a payload per
+	// line.
+	Format string
+
+	// MaxFileSize is the size in bytes from which a file is rotated.
+	MaxFileSize int64
+
+	// MaxFileAge is the duration after which a file is rotated, so that files are
+	// completed even when there is little traffic.
+	MaxFileAge time.Duration
+
+	// MaxFiles is the number of rotated files kept for each of the traces and the
+	// stats, the oldest being removed. All files are kept when 0.
+	MaxFiles int
+}
+
 // FargateOrchestratorName is a Fargate orchestrator name.
 type FargateOrchestratorName string
 
@@ -337,6 +545,16 @@ type AgentConfig struct {
 	ComputeStatsBySpanKind bool          // enables/disables the computing of stats based on a span's `span.kind` field
 	PeerTags               []string      // additional tags to use for peer entity stats aggregation
 
+	// ExtraAggregationTags holds the span tags used as additional stats aggregation dimensions,
+	// e.g. tenant or region.
+	ExtraAggregationTags []string
+	// ExtraAggregationTagsMaxValues is the maximum number of distinct values of each extra aggregation
+	// tag in a stats bucket. The values beyond it are folded into a placeholder.
+	ExtraAggregationTagsMaxValues int
+
+	// ServiceMap specifies the service dependency map metrics.
+	ServiceMap ServiceMapConfig
+
 	// Sampler configuration
 	ExtraSampleRate float64
 	TargetTPS       float64
@@ -344,6 +562,10 @@ type AgentConfig struct {
 	MaxEPS          float64
 	MaxRemoteTPS    float64
 
+	// ServiceBudgets limit the spans per second kept by service and env,
+	// lowering the sampling rates sent to the tracers and dropping the excess.
+	ServiceBudgets []*ServiceBudget
+
 	// Rare Sampler configuration
 	RareSamplerEnabled        bool
 	RareSamplerTPS            int
@@ -358,6 +580,9 @@ type AgentConfig struct {
 	// Error Tracking Standalone
 	ErrorTrackingStandalone bool
 
+	// Tail-based sampling configuration
+	TailSampling TailSamplingConfig
+
 	// Receiver
 	ReceiverEnabled bool // specifies whether Receiver listeners are enabled. Unless OTLPReceiver is used, this should always be true.
 	ReceiverHost    string
@@ -381,6 +606,7 @@ type AgentConfig struct {
 	SynchronousFlushing     bool // Mode where traces are only submitted when FlushAsync is called, used for Serverless Extension
 	StatsWriter             *WriterConfig
 	TraceWriter             *WriterConfig
+	FileExport              FileExportConfig
 	ConnectionResetInterval time.Duration // frequency at which outgoing connections are reset. 0 means no reset is performed
 	// MaxSenderRetries is the maximum number of retries that a sender will perform
 	// before giving up. Note that the sender may not perform all MaxSenderRetries if
@@ -419,6 +645,14 @@ type AgentConfig struct {
 	// It maps tag keys to a set of replacements. Only supported in A6.
 	ReplaceTags []*ReplaceRule
 
+	// FilterRules are the rules dropping or keeping traces, and rewriting the
+	// tags of spans, by their properties.
+	FilterRules []*FilterRule
+
+	// SDS specifies the redaction of the span tag values by the Sensitive Data
+	// Scanner.
+	SDS SDSConfig
+
 	// GlobalTags list metadata that will be added to all spans
 	GlobalTags map[string]string
 
@@ -453,6 +687,12 @@ type AgentConfig struct {
 	// OTLPReceiver holds the configuration for OpenTelemetry receiver.
 	OTLPReceiver *OTLP
 
+	// ZipkinReceiverEnabled enables the endpoint receiving Zipkin v2 spans.
+	ZipkinReceiverEnabled bool
+
+	// JaegerReceiverEnabled enables the endpoint receiving Jaeger batches.
+	JaegerReceiverEnabled bool
+
 	// ProfilingProxy specifies settings for the profiling proxy.
 	ProfilingProxy ProfilingProxyConfig
 
@@ -561,6 +801,11 @@ func New() *AgentConfig {
 
 		ErrorTrackingStandalone: false,
 
+		TailSampling: TailSamplingConfig{
+			DecisionWait: 10 * time.Second,
+			MaxMemory:    100 * 1024 * 1024, // 100MB
+		},
+
 		ReceiverEnabled:        true,
 		ReceiverHost:           "localhost",
 		ReceiverPort:           8126,
@@ -573,6 +818,12 @@ func New() *AgentConfig {
 		TraceWriter:             new(WriterConfig),
 		ConnectionResetInterval: 0, // disabled
 		MaxSenderRetries:        4,
+		FileExport: FileExportConfig{
+			Format:      FileExportMsgpack,
+			MaxFileSize: 100 * 1024 * 1024, // 100MB
+			MaxFileAge:  5 * time.Minute,
+			MaxFiles:    10,
+		},
 
 		StatsdHost:    "localhost",
 		StatsdPort:    8125,
@@ -611,6 +862,12 @@ func New() *AgentConfig {
 		Features:               make(map[string]struct{}),
 		PeerTagsAggregation:    true,
 		ComputeStatsBySpanKind: true,
+
+		ExtraAggregationTagsMaxValues: 100,
+
+		ServiceMap: ServiceMapConfig{
+			MaxEdges: 1000,
+		},
 	}
 }
 
@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"strconv"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+// RuleFilter applies the filter rules to the traces: the drop and keep rules
+// decide whether a trace is kept, and the tag rules rewrite the tags of the
+// spans.
+type RuleFilter struct {
+	traceRules []*config.FilterRule
+	tagRules   []*config.FilterRule
+}
+
+// NewRuleFilter returns a RuleFilter applying the given compiled rules.
+func NewRuleFilter(rules []*config.FilterRule) *RuleFilter {
+	f := &RuleFilter{}
+	for _, r := range rules {
+		switch r.Action {
+		case config.FilterDrop, config.FilterKeep:
+			f.traceRules = append(f.traceRules, r)
+		default:
+			f.tagRules = append(f.tagRules, r)
+		}
+	}
+	return f
+}
+
+// Allows returns whether the trace is kept by the drop and keep rules, along
+// with the first rule one of its spans matches, nil if there is none. The
+// traces matching no rule are kept.
+func (f *RuleFilter) Allows(trace pb.Trace) (bool, *config.FilterRule) {
+	for _, r := range f.traceRules {
+		for _, s := range trace {
+			if ruleMatches(r, s) {
+				return r.Action == config.FilterKeep, r
+			}
+		}
+	}
+	return true, nil
+}
+
+// Rewrite applies the tag rules to the spans of the trace, calling hit with
+// the name of the rule for each span it rewrites.
+func (f *RuleFilter) Rewrite(trace pb.Trace, hit func(rule string)) {
+	for _, r := range f.tagRules {
+		for _, s := range trace {
+			if !ruleMatches(r, s) {
+				continue
+			}
+			if rewriteTag(r, s) {
+				hit(r.Name)
+			}
+		}
+	}
+}
+
+// rewriteTag applies a tag rule to a span, and returns whether it changed it.
+func rewriteTag(r *config.FilterRule, s *pb.Span) bool {
+	switch r.Action {
+	case config.FilterSetTag:
+		if s.Meta == nil {
+			s.Meta = make(map[string]string)
+		}
+		delete(s.Metrics, r.Tag)
+		s.Meta[r.Tag] = r.Value
+		return true
+	case config.FilterRemoveTag:
+		_, inMeta := s.Meta[r.Tag]
+		_, inMetrics := s.Metrics[r.Tag]
+		delete(s.Meta, r.Tag)
+		delete(s.Metrics, r.Tag)
+		return inMeta || inMetrics
+	case config.FilterReplaceTag:
+		if v, ok := s.Meta[r.Tag]; ok {
+			s.Meta[r.Tag] = r.Re.ReplaceAllString(v, r.Repl)
+			return true
+		}
+		if v, ok := s.Metrics[r.Tag]; ok {
+			// the replaced value moves to the meta unless it is still numeric
+			replaced := r.Re.ReplaceAllString(strconv.FormatFloat(v, 'f', -1, 64), r.Repl)
+			if f, err := strconv.ParseFloat(replaced, 64); err == nil {
+				s.Metrics[r.Tag] = f
+			} else {
+				delete(s.Metrics, r.Tag)
+				if s.Meta == nil {
+					s.Meta = make(map[string]string)
+				}
+				s.Meta[r.Tag] = replaced
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMatches returns true if the span matches all the conditions of the rule.
+func ruleMatches(r *config.FilterRule, s *pb.Span) bool {
+	if r.ServiceRe != nil && !r.ServiceRe.MatchString(s.Service) {
+		return false
+	}
+	if r.OperationRe != nil && !r.OperationRe.MatchString(s.Name) {
+		return false
+	}
+	if r.ResourceRe != nil && !r.ResourceRe.MatchString(s.Resource) {
+		return false
+	}
+	if r.MinDurationMS > 0 && float64(s.Duration) < r.MinDurationMS*1e6 {
+		return false
+	}
+	if r.MaxDurationMS > 0 && float64(s.Duration) > r.MaxDurationMS*1e6 {
+		return false
+	}
+	if r.Error != nil && *r.Error != (s.Error != 0) {
+		return false
+	}
+	for k, re := range r.TagsRe {
+		v, ok := s.Meta[k]
+		if !ok {
+			var f float64
+			if f, ok = s.Metrics[k]; !ok {
+				return false
+			}
+			v = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		if re != nil && !re.MatchString(v) {
+			return false
+		}
+	}
+	return true
+}
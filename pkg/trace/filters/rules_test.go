@@ -0,0 +1,126 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package filters
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func TestRuleFilterAllows(t *testing.T) {
+	isError := true
+	rules := []*config.FilterRule{
+		{Name: "keep_errors", Action: config.FilterKeep, Error: &isError},
+		{Name: "drop_health_checks", Action: config.FilterDrop, ServiceRe: regexp.MustCompile("^web$"), ResourceRe: regexp.MustCompile("^GET /health")},
+		{Name: "drop_fast_internal", Action: config.FilterDrop, MaxDurationMS: 1, TagsRe: map[string]*regexp.Regexp{"internal": nil}},
+		{Name: "drop_canary", Action: config.FilterDrop, OperationRe: regexp.MustCompile("^http"), TagsRe: map[string]*regexp.Regexp{"version": regexp.MustCompile("-canary$")}},
+		{Name: "drop_slow_batches", Action: config.FilterDrop, MinDurationMS: 1000, TagsRe: map[string]*regexp.Regexp{"batch.size": regexp.MustCompile("^[0-9]{3,}$")}},
+	}
+	f := NewRuleFilter(rules)
+
+	for name, tt := range map[string]struct {
+		span    *pb.Span
+		allowed bool
+		rule    string
+	}{
+		"health check": {
+			span:    &pb.Span{Service: "web", Resource: "GET /health"},
+			allowed: false,
+			rule:    "drop_health_checks",
+		},
+		"health check of another service": {
+			span:    &pb.Span{Service: "api", Resource: "GET /health"},
+			allowed: true,
+		},
+		"failed health check": {
+			span:    &pb.Span{Service: "web", Resource: "GET /health", Error: 1},
+			allowed: true,
+			rule:    "keep_errors",
+		},
+		"fast internal": {
+			span:    &pb.Span{Service: "api", Duration: 500000, Meta: map[string]string{"internal": ""}},
+			allowed: false,
+			rule:    "drop_fast_internal",
+		},
+		"slow internal": {
+			span:    &pb.Span{Service: "api", Duration: 5000000, Meta: map[string]string{"internal": ""}},
+			allowed: true,
+		},
+		"canary": {
+			span:    &pb.Span{Name: "http.request", Meta: map[string]string{"version": "1.2-canary"}},
+			allowed: false,
+			rule:    "drop_canary",
+		},
+		"canary of another operation": {
+			span:    &pb.Span{Name: "grpc.server", Meta: map[string]string{"version": "1.2-canary"}},
+			allowed: true,
+		},
+		"numeric tag": {
+			span:    &pb.Span{Duration: 2e9, Metrics: map[string]float64{"batch.size": 500}},
+			allowed: false,
+			rule:    "drop_slow_batches",
+		},
+		"no match": {
+			span:    &pb.Span{Service: "api", Resource: "GET /users"},
+			allowed: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root := &pb.Span{Service: "lb", Resource: "proxy"}
+			allowed, rule := f.Allows(pb.Trace{root, tt.span})
+			assert.Equal(t, tt.allowed, allowed)
+			if tt.rule == "" {
+				assert.Nil(t, rule)
+			} else if assert.NotNil(t, rule) {
+				assert.Equal(t, tt.rule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestRuleFilterRewrite(t *testing.T) {
+	rules := []*config.FilterRule{
+		{Name: "strip_customer_ids", Action: config.FilterReplaceTag, ServiceRe: regexp.MustCompile("^billing$"), Tag: "customer.id", Re: regexp.MustCompile("[0-9]+"), Repl: "?"},
+		{Name: "round_ratio", Action: config.FilterReplaceTag, Tag: "ratio", Re: regexp.MustCompile(`\..*`), Repl: ""},
+		{Name: "remove_email", Action: config.FilterRemoveTag, Tag: "user.email"},
+		{Name: "set_team", Action: config.FilterSetTag, ServiceRe: regexp.MustCompile("^billing$"), Tag: "team", Value: "payments"},
+		{Name: "drop_health_checks", Action: config.FilterDrop, ResourceRe: regexp.MustCompile("^GET /health")},
+	}
+	f := NewRuleFilter(rules)
+
+	billing := &pb.Span{
+		Service: "billing",
+		Meta:    map[string]string{"customer.id": "cust-1234", "user.email": "a@b.c"},
+		Metrics: map[string]float64{"ratio": 0.75, "team": 1},
+	}
+	web := &pb.Span{
+		Service:  "web",
+		Resource: "GET /health",
+		Meta:     map[string]string{"customer.id": "cust-1234"},
+	}
+	hits := make(map[string]int)
+	f.Rewrite(pb.Trace{billing, web}, func(rule string) { hits[rule]++ })
+
+	assert.Equal(t, map[string]string{"customer.id": "cust-?", "team": "payments"}, billing.Meta)
+	assert.Equal(t, map[string]float64{"ratio": 0}, billing.Metrics)
+	assert.Equal(t, map[string]string{"customer.id": "cust-1234"}, web.Meta)
+	assert.Equal(t, map[string]int{"strip_customer_ids": 1, "round_ratio": 1, "remove_email": 1, "set_team": 1}, hits)
+
+	t.Run("non-numeric", func(t *testing.T) {
+		f := NewRuleFilter([]*config.FilterRule{
+			{Name: "mask", Action: config.FilterReplaceTag, Tag: "account", Re: regexp.MustCompile("[0-9]"), Repl: "x"},
+		})
+		span := &pb.Span{Metrics: map[string]float64{"account": 42}}
+		f.Rewrite(pb.Trace{span}, func(string) {})
+		assert.Empty(t, span.Metrics)
+		assert.Equal(t, map[string]string{"account": "xx"}, span.Meta)
+	})
+}
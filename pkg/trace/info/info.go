@@ -26,6 +26,7 @@ import (
 
 	template "github.com/DataDog/datadog-agent/pkg/template/text"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 )
@@ -44,6 +45,7 @@ var (
 	rateByService map[string]float64
 	// The rates by service with empty env values removed (As they are confusing to view for customers)
 	rateByServiceFiltered map[string]float64
+	serviceBudgets        []sampler.ServiceBudgetUsage
 	start                 = time.Now()
 	once                  sync.Once
 	infoTmpl              *template.Template
@@ -59,6 +61,9 @@ const (
   Pid: {{.Status.Pid}}
   Uptime: {{.Status.Uptime}} seconds
   Mem alloc: {{.Status.MemStats.Alloc}} bytes
+{{- with .Status.Watchdog.ShedStage }}
+  WARNING: Over the resource limits, load shedding stage: {{ . }}
+{{- end }}
 
   Hostname: {{.Status.Config.Hostname}}
   Receiver: {{.Status.Config.ReceiverHost}}:{{.Status.Config.ReceiverPort}}
@@ -86,6 +91,9 @@ const (
   Priority sampling rate for '{{ $key }}': {{percent $value}} %
   {{ end }}
   {{ end }}
+  {{ range $i, $b := .Status.ServiceBudgets }}
+  Budget for 'service:{{ $b.Service }},env:{{ $b.Env }}': {{ printf "%.1f" $b.SpansPerSecond }} of {{ $b.MaxSpansPerSecond }} spans/s received, sampling rate {{percent $b.Rate}} %, {{ $b.DroppedSpans }} spans dropped
+  {{ end }}
 
   --- Writer stats (1 min) ---
 
@@ -171,6 +179,19 @@ func publishRateByServiceFiltered() interface{} {
 	return rateByServiceFiltered
 }
 
+// UpdateServiceBudgets updates the usage of the service budgets.
+func UpdateServiceBudgets(usage []sampler.ServiceBudgetUsage) {
+	infoMu.Lock()
+	defer infoMu.Unlock()
+	serviceBudgets = usage
+}
+
+func publishServiceBudgets() interface{} {
+	infoMu.RLock()
+	defer infoMu.RUnlock()
+	return serviceBudgets
+}
+
 // UpdateWatchdogInfo updates internal stats about the watchdog.
 func UpdateWatchdogInfo(wi watchdog.Info) {
 	infoMu.Lock()
@@ -218,12 +239,13 @@ type StatusInfo struct {
 		Version   string
 		GitCommit string
 	} `json:"version"`
-	Receiver      []TagStats         `json:"receiver"`
-	RateByService map[string]float64 `json:"ratebyservice_filtered"`
-	TraceWriter   TraceWriterInfo    `json:"trace_writer"`
-	StatsWriter   StatsWriterInfo    `json:"stats_writer"`
-	Watchdog      watchdog.Info      `json:"watchdog"`
-	Config        config.AgentConfig `json:"config"`
+	Receiver       []TagStats                   `json:"receiver"`
+	RateByService  map[string]float64           `json:"ratebyservice_filtered"`
+	ServiceBudgets []sampler.ServiceBudgetUsage `json:"service_budgets"`
+	TraceWriter    TraceWriterInfo              `json:"trace_writer"`
+	StatsWriter    StatsWriterInfo              `json:"stats_writer"`
+	Watchdog       watchdog.Info                `json:"watchdog"`
+	Config         config.AgentConfig           `json:"config"`
 }
 
 func getProgramBanner(version string) (string, string) {
@@ -343,6 +365,7 @@ func initInfo(conf *config.AgentConfig) error {
 	expvar.Publish("ratebyservice", expvar.Func(publishRateByService))
 	expvar.Publish("ratebyservice_filtered", expvar.Func(publishRateByServiceFiltered))
 	expvar.Publish("watchdog", expvar.Func(publishWatchdogInfo))
+	expvar.Publish("service_budgets", expvar.Func(publishServiceBudgets))
 
 	// copy the config to ensure we don't expose sensitive data such as API keys
 	c := *conf
@@ -468,6 +468,7 @@ func TestPublishReceiverStats(t *testing.T) {
 				atom(7),
 				atom(8),
 				atom(9),
+				atom(10),
 			},
 			SpansMalformed: &SpansMalformed{
 				atom(1),
@@ -508,6 +509,8 @@ func TestPublishReceiverStats(t *testing.T) {
 			EventsSampled:         atom(14),
 			PayloadAccepted:       atom(15),
 			PayloadRefused:        atom(16),
+			FilterRuleHits:        &FilterRuleHits{counts: map[string]int64{"drop_health_checks": 3}},
+			SDSRuleHits:           &FilterRuleHits{counts: map[string]int64{"email": 2}},
 		},
 	}}
 
@@ -518,12 +521,14 @@ func TestPublishReceiverStats(t *testing.T) {
 			"EndpointVersion":       "",
 			"EventsExtracted":       13.0,
 			"EventsSampled":         14.0,
+			"FilterRuleHits":        map[string]interface{}{"drop_health_checks": 3.0},
 			"Interpreter":           "",
 			"Lang":                  "go",
 			"LangVendor":            "",
 			"LangVersion":           "",
 			"PayloadAccepted":       15.0,
 			"PayloadRefused":        16.0,
+			"SDSRuleHits":           map[string]interface{}{"email": 2.0},
 			"Service":               "service",
 			"SpansDropped":          11.0,
 			"SpansFiltered":         12.0,
@@ -556,6 +561,7 @@ func TestPublishReceiverStats(t *testing.T) {
 				"SpanIDZero":      5.0,
 				"ForeignSpan":     6.0,
 				"MSGPShortBytes":  9.0,
+				"LoadShedding":    10.0,
 				"Timeout":         7.0,
 				"EOF":             8.0,
 			},
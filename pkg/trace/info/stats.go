@@ -6,6 +6,7 @@
 package info
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strconv"
@@ -164,6 +165,16 @@ func (ts *TagStats) publishAndReset(statsd statsd.ClientInterface) {
 			counter.Swap(0), append(tags, "reason:"+reason), 1)
 	}
 
+	for rule, count := range ts.FilterRuleHits.swap() {
+		_ = statsd.Count("datadog.trace_agent.receiver.filter_rule_hits",
+			count, append(tags, "rule:"+rule), 1)
+	}
+
+	for rule, count := range ts.SDSRuleHits.swap() {
+		_ = statsd.Count("datadog.trace_agent.receiver.sds_rule_hits",
+			count, append(tags, "rule:"+rule), 1)
+	}
+
 	for priority, counter := range ts.TracesPerSamplingPriority.tagCounters() {
 		count := counter.Swap(0)
 		if count > 0 {
@@ -217,6 +228,9 @@ type TracesDropped struct {
 	EOF atomic.Int64
 	// MSGPShortBytes is when a msgp payload is bad due to missing bytes
 	MSGPShortBytes atomic.Int64
+	// LoadShedding is when a trace not kept by the tracer is dropped because the agent
+	// is over its resource limits.
+	LoadShedding atomic.Int64
 }
 
 func (s *TracesDropped) tagCounters() map[string]*atomic.Int64 {
@@ -230,6 +244,7 @@ func (s *TracesDropped) tagCounters() map[string]*atomic.Int64 {
 		"timeout":           &s.Timeout,
 		"unexpected_eof":    &s.EOF,
 		"msgp_short_bytes":  &s.MSGPShortBytes,
+		"load_shedding":     &s.LoadShedding,
 	}
 }
 
@@ -319,6 +334,72 @@ func (s *SpansMalformed) String() string {
 	return mapToString(s.tagValues())
 }
 
+// FilterRuleHits counts the hits of the filter rules, or of the Sensitive Data
+// Scanner rules, by rule name.
+type FilterRuleHits struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// Inc increments the hits of the rule by 1.
+func (h *FilterRuleHits) Inc(rule string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.counts == nil {
+		h.counts = make(map[string]int64)
+	}
+	h.counts[rule]++
+}
+
+// Values returns the hits by rule name.
+func (h *FilterRuleHits) Values() map[string]int64 {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v := make(map[string]int64, len(h.counts))
+	for rule, count := range h.counts {
+		v[rule] = count
+	}
+	return v
+}
+
+// MarshalJSON implements json.Marshaler.
+func (h *FilterRuleHits) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Values())
+}
+
+// swap returns the hits by rule name and resets them.
+func (h *FilterRuleHits) swap() map[string]int64 {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	counts := h.counts
+	h.counts = nil
+	return counts
+}
+
+// update absorbs recent hits on top of existing ones.
+func (h *FilterRuleHits) update(recent *FilterRuleHits) {
+	if h == nil {
+		return
+	}
+	for rule, count := range recent.Values() {
+		h.mu.Lock()
+		if h.counts == nil {
+			h.counts = make(map[string]int64)
+		}
+		h.counts[rule] += count
+		h.mu.Unlock()
+	}
+}
+
 // maxAbsPriority specifies the absolute maximum priority for stats purposes. For example, with a value
 // of 10, the range of priorities reported will be [-10, 10].
 const maxAbsPriority = 10
@@ -411,6 +492,10 @@ type Stats struct {
 	TracesDropped *TracesDropped
 	// SpansMalformed contains stats about the count of malformed traces by reason
 	SpansMalformed *SpansMalformed
+	// FilterRuleHits counts the traces and spans matched by the filter rules, by rule name.
+	FilterRuleHits *FilterRuleHits
+	// SDSRuleHits counts the span tag values matched by the Sensitive Data Scanner rules, by rule name.
+	SDSRuleHits *FilterRuleHits
 }
 
 // NewStats returns new, ready to use stats.
@@ -418,6 +503,8 @@ func NewStats() Stats {
 	return Stats{
 		TracesDropped:  new(TracesDropped),
 		SpansMalformed: new(SpansMalformed),
+		FilterRuleHits: new(FilterRuleHits),
+		SDSRuleHits:    new(FilterRuleHits),
 	}
 }
 
@@ -432,6 +519,7 @@ func (s *Stats) update(recent *Stats) {
 	s.TracesDropped.Timeout.Add(recent.TracesDropped.Timeout.Load())
 	s.TracesDropped.EOF.Add(recent.TracesDropped.EOF.Load())
 	s.TracesDropped.MSGPShortBytes.Add(recent.TracesDropped.MSGPShortBytes.Load())
+	s.TracesDropped.LoadShedding.Add(recent.TracesDropped.LoadShedding.Load())
 	s.SpansMalformed.DuplicateSpanID.Add(recent.SpansMalformed.DuplicateSpanID.Load())
 	s.SpansMalformed.ServiceEmpty.Add(recent.SpansMalformed.ServiceEmpty.Load())
 	s.SpansMalformed.ServiceTruncate.Add(recent.SpansMalformed.ServiceTruncate.Load())
@@ -461,6 +549,8 @@ func (s *Stats) update(recent *Stats) {
 	s.PayloadAccepted.Add(recent.PayloadAccepted.Load())
 	s.PayloadRefused.Add(recent.PayloadRefused.Load())
 	s.TracesPerSamplingPriority.update(&recent.TracesPerSamplingPriority)
+	s.FilterRuleHits.update(recent.FilterRuleHits)
+	s.SDSRuleHits.update(recent.SDSRuleHits)
 }
 
 func (s *Stats) isEmpty() bool {
@@ -34,6 +34,7 @@ func TestTracesDropped(t *testing.T) {
 			"decoding_error":    1,
 			"foreign_span":      1,
 			"msgp_short_bytes":  0,
+			"load_shedding":     0,
 			"trace_id_zero":     1,
 			"span_id_zero":      1,
 			"timeout":           0,
@@ -227,6 +228,10 @@ func TestReceiverStats(t *testing.T) {
 		stats.SpansMalformed.InvalidStartDate.Store(14)
 		stats.SpansMalformed.InvalidDuration.Store(15)
 		stats.SpansMalformed.InvalidHTTPStatusCode.Store(16)
+		stats.FilterRuleHits = new(FilterRuleHits)
+		stats.FilterRuleHits.Inc("drop_health_checks")
+		stats.SDSRuleHits = new(FilterRuleHits)
+		stats.SDSRuleHits.Inc("email")
 		return &ReceiverStats{
 			Stats: map[Tags]*TagStats{
 				tags: {
@@ -240,7 +245,7 @@ func TestReceiverStats(t *testing.T) {
 	t.Run("PublishAndReset", func(t *testing.T) {
 		rs := testStats()
 		rs.PublishAndReset(statsclient)
-		assert.EqualValues(t, 44, len(statsclient.CountCalls))
+		assert.EqualValues(t, 47, len(statsclient.CountCalls))
 		assertStatsAreReset(t, rs)
 	})
 
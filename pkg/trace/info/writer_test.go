@@ -7,6 +7,8 @@ package info
 
 import (
 	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 )
 
 func TestPublishTraceWriterInfo(t *testing.T) {
@@ -73,3 +75,18 @@ func TestPublishRateByService(t *testing.T) {
 			"foo": 123.0,
 		})
 }
+
+func TestPublishServiceBudgets(t *testing.T) {
+	serviceBudgets = []sampler.ServiceBudgetUsage{{Service: "web", Env: "prod", MaxSpansPerSecond: 100, SpansPerSecond: 250, Rate: 0.4, KeptSpans: 6000, DroppedSpans: 120}}
+
+	testExpvarPublish(t, publishServiceBudgets,
+		[]interface{}{map[string]interface{}{
+			"Service":           "web",
+			"Env":               "prod",
+			"MaxSpansPerSecond": 100.0,
+			"SpansPerSecond":    250.0,
+			"Rate":              0.4,
+			"KeptSpans":         6000.0,
+			"DroppedSpans":      120.0,
+		}})
+}
@@ -6,6 +6,7 @@ package remoteconfighandler
 import (
 	reflect "reflect"
 
+	config "github.com/DataDog/datadog-agent/pkg/trace/config"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -113,3 +114,38 @@ func (mr *MockrareSamplerMockRecorder) SetEnabled(enabled interface{}) *gomock.C
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEnabled", reflect.TypeOf((*MockrareSampler)(nil).SetEnabled), enabled)
 }
+
+// MockserviceBudgets is a mock of serviceBudgets interface.
+type MockserviceBudgets struct {
+	ctrl     *gomock.Controller
+	recorder *MockserviceBudgetsMockRecorder
+}
+
+// MockserviceBudgetsMockRecorder is the mock recorder for MockserviceBudgets.
+type MockserviceBudgetsMockRecorder struct {
+	mock *MockserviceBudgets
+}
+
+// NewMockserviceBudgets creates a new mock instance.
+func NewMockserviceBudgets(ctrl *gomock.Controller) *MockserviceBudgets {
+	mock := &MockserviceBudgets{ctrl: ctrl}
+	mock.recorder = &MockserviceBudgetsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockserviceBudgets) EXPECT() *MockserviceBudgetsMockRecorder {
+	return m.recorder
+}
+
+// UpdateBudgets mocks base method.
+func (m *MockserviceBudgets) UpdateBudgets(budgets []*config.ServiceBudget) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateBudgets", budgets)
+}
+
+// UpdateBudgets indicates an expected call of UpdateBudgets.
+func (mr *MockserviceBudgetsMockRecorder) UpdateBudgets(budgets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBudgets", reflect.TypeOf((*MockserviceBudgets)(nil).UpdateBudgets), budgets)
+}
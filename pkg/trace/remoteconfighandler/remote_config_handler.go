@@ -34,12 +34,17 @@ type rareSampler interface {
 	SetEnabled(enabled bool)
 }
 
+type serviceBudgets interface {
+	UpdateBudgets(budgets []*config.ServiceBudget)
+}
+
 // RemoteConfigHandler holds pointers to samplers that need to be updated when APM remote config changes
 type RemoteConfigHandler struct {
 	remoteClient                  config.RemoteClient
 	prioritySampler               prioritySampler
 	errorsSampler                 errorsSampler
 	rareSampler                   rareSampler
+	serviceBudgets                serviceBudgets
 	agentConfig                   *config.AgentConfig
 	configState                   *state.AgentConfigState
 	configHTTPClient              *http.Client
@@ -47,7 +52,7 @@ type RemoteConfigHandler struct {
 }
 
 // New creates a new RemoteConfigHandler
-func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler) *RemoteConfigHandler {
+func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler rareSampler, errorsSampler errorsSampler, serviceBudgets serviceBudgets) *RemoteConfigHandler {
 	if conf.RemoteConfigClient == nil {
 		return nil
 	}
@@ -68,6 +73,7 @@ func New(conf *config.AgentConfig, prioritySampler prioritySampler, rareSampler
 		prioritySampler: prioritySampler,
 		rareSampler:     rareSampler,
 		errorsSampler:   errorsSampler,
+		serviceBudgets:  serviceBudgets,
 		agentConfig:     conf,
 		configState: &state.AgentConfigState{
 			FallbackLogLevel: level.String(),
@@ -230,4 +236,26 @@ func (h *RemoteConfigHandler) updateSamplers(config apmsampling.SamplerConfig) {
 		rareSamplerEnabled = h.agentConfig.RareSamplerEnabled
 	}
 	h.rareSampler.SetEnabled(rareSamplerEnabled)
+
+	if h.serviceBudgets != nil {
+		h.serviceBudgets.UpdateBudgets(toServiceBudgets(config.ServiceBudgets))
+	}
+}
+
+// toServiceBudgets converts the service budgets of the remote configuration,
+// returning nil if they are not set so that the ones of the agent configuration
+// are restored.
+func toServiceBudgets(rcBudgets []apmsampling.ServiceBudget) []*config.ServiceBudget {
+	if rcBudgets == nil {
+		return nil
+	}
+	budgets := make([]*config.ServiceBudget, 0, len(rcBudgets))
+	for _, b := range rcBudgets {
+		if (b.Service == "" && b.Env == "") || b.MaxSpansPerSecond <= 0 {
+			log.Errorf("ignoring invalid service budget from remote configuration: %+v", b)
+			continue
+		}
+		budgets = append(budgets, &config.ServiceBudget{Service: b.Service, Env: b.Env, MaxSpansPerSecond: b.MaxSpansPerSecond})
+	}
+	return budgets
 }
@@ -35,7 +35,7 @@ func TestStart(t *testing.T) {
 	rareSampler := NewMockrareSampler(ctrl)
 	pkglog.SetupLogger(pkglog.Default(), "debug")
 
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	remoteClient.EXPECT().Subscribe(state.ProductAPMSampling, gomock.Any()).Times(1)
 	remoteClient.EXPECT().Subscribe(state.ProductAgentConfig, gomock.Any()).Times(1)
@@ -60,7 +60,7 @@ func TestPrioritySampler(t *testing.T) {
 	pkglog.SetupLogger(pkglog.Default(), "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DebugServerPort: 1}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -91,7 +91,7 @@ func TestErrorsSampler(t *testing.T) {
 	pkglog.SetupLogger(pkglog.Default(), "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DebugServerPort: 1}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -122,7 +122,7 @@ func TestRareSampler(t *testing.T) {
 	pkglog.SetupLogger(pkglog.Default(), "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DebugServerPort: 1}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -153,7 +153,7 @@ func TestEnvPrecedence(t *testing.T) {
 	pkglog.SetupLogger(pkglog.Default(), "debug")
 
 	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DefaultEnv: "agent-env", DebugServerPort: 1}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	payload := apmsampling.SamplerConfig{
 		AllEnvs: apmsampling.SamplerEnvConfig{
@@ -185,6 +185,41 @@ func TestEnvPrecedence(t *testing.T) {
 	ctrl.Finish()
 }
 
+func TestServiceBudgets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	remoteClient := NewMockRemoteClient(ctrl)
+	prioritySampler := NewMockprioritySampler(ctrl)
+	errorsSampler := NewMockerrorsSampler(ctrl)
+	rareSampler := NewMockrareSampler(ctrl)
+	serviceBudgets := NewMockserviceBudgets(ctrl)
+	pkglog.SetupLogger(pkglog.Default(), "debug")
+
+	agentConfig := config.AgentConfig{RemoteConfigClient: remoteClient, TargetTPS: 41, ErrorTPS: 41, RareSamplerEnabled: true, DebugServerPort: 1}
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, serviceBudgets)
+
+	update := func(payload apmsampling.SamplerConfig) {
+		raw, _ := json.Marshal(payload)
+		h.onUpdate(map[string]state.RawConfig{"datadog/2/APM_SAMPLING/samplerconfig/config": {Config: raw}}, applyEmpty)
+	}
+	prioritySampler.EXPECT().UpdateTargetTPS(float64(41)).Times(2)
+	errorsSampler.EXPECT().UpdateTargetTPS(float64(41)).Times(2)
+	rareSampler.EXPECT().SetEnabled(true).Times(2)
+
+	serviceBudgets.EXPECT().UpdateBudgets([]*config.ServiceBudget{
+		{Service: "web", Env: "prod", MaxSpansPerSecond: 100},
+	}).Times(1)
+	update(apmsampling.SamplerConfig{ServiceBudgets: []apmsampling.ServiceBudget{
+		{Service: "web", Env: "prod", MaxSpansPerSecond: 100},
+		{MaxSpansPerSecond: 100}, // invalid
+	}})
+
+	// the budgets of the agent configuration are restored
+	serviceBudgets.EXPECT().UpdateBudgets(gomock.Nil()).Times(1)
+	update(apmsampling.SamplerConfig{})
+
+	ctrl.Finish()
+}
+
 func TestLogLevel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	remoteClient := NewMockRemoteClient(ctrl)
@@ -208,7 +243,7 @@ func TestLogLevel(t *testing.T) {
 			return "fakeToken"
 		},
 	}
-	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler)
+	h := New(&agentConfig, prioritySampler, rareSampler, errorsSampler, nil)
 
 	layer := state.RawConfig{Config: []byte(`{"name": "layer1", "config": {"log_level": "debug"}}`)}
 	configOrder := state.RawConfig{Config: []byte(`{"internal_order": ["layer1", "layer2"]}`)}
@@ -0,0 +1,263 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// ServiceBudgets enforces the budgets of spans per second of services and envs.
+// The spans received for a budget are counted, including the ones of the
+// traces dropped by the tracers, to compute the sampling rate bringing the
+// kept spans within the budget. This rate caps the rates sent to the tracers,
+// and the spans kept beyond the budget are dropped, for the tracers ignoring
+// the rates. The traces kept by the user or by the errors and rare samplers are
+// never dropped.
+//
+// A trace is counted in the most specific budget matching the service of its
+// root span and its env: the budget of its service in its env, then the budget
+// of its service in any env, then the budget of its env.
+type ServiceBudgets struct {
+	agentEnv string
+	// local are the budgets of the agent configuration, applied when the
+	// remote configuration doesn't set any.
+	local []*config.ServiceBudget
+
+	mu      sync.RWMutex // guards budgets
+	budgets map[ServiceSignature]*serviceBudget
+}
+
+// serviceBudget holds the state of a budget.
+type serviceBudget struct {
+	mu   sync.Mutex
+	conf config.ServiceBudget
+
+	// seen counts the spans received in a circular buffer of numBuckets of bucketDuration.
+	seen         [numBuckets]float32
+	lastBucketID int64
+	// rate is the sampling rate keeping the spans within the budget.
+	rate float64
+
+	// tokens is the number of spans which can still be kept, refilled at
+	// MaxSpansPerSecond up to a second of budget.
+	tokens     float64
+	lastRefill time.Time
+
+	// kept and dropped count the spans kept and dropped since the last usage report.
+	kept    int64
+	dropped int64
+}
+
+// ServiceBudgetUsage reports the usage of a budget.
+type ServiceBudgetUsage struct {
+	Service           string
+	Env               string
+	MaxSpansPerSecond float64
+	// SpansPerSecond is the estimated number of spans per second received,
+	// before the sampling of the tracers.
+	SpansPerSecond float64
+	// Rate is the sampling rate keeping the spans within the budget.
+	Rate float64
+	// KeptSpans and DroppedSpans are the spans kept and dropped because of the
+	// budget since the previous report.
+	KeptSpans    int64
+	DroppedSpans int64
+}
+
+// NewServiceBudgets returns the ServiceBudgets enforcing the budgets of conf.
+func NewServiceBudgets(conf *config.AgentConfig) *ServiceBudgets {
+	b := &ServiceBudgets{
+		agentEnv: conf.DefaultEnv,
+		local:    conf.ServiceBudgets,
+	}
+	b.UpdateBudgets(nil)
+	return b
+}
+
+// UpdateBudgets replaces the budgets, or restores the ones of the agent
+// configuration if budgets is nil. The state of the budgets left unchanged is
+// kept.
+func (b *ServiceBudgets) UpdateBudgets(budgets []*config.ServiceBudget) {
+	if b == nil {
+		return
+	}
+	if budgets == nil {
+		budgets = b.local
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	old := b.budgets
+	b.budgets = make(map[ServiceSignature]*serviceBudget, len(budgets))
+	for _, conf := range budgets {
+		key := ServiceSignature{Name: conf.Service, Env: conf.Env}
+		if sb, ok := old[key]; ok {
+			sb.mu.Lock()
+			sb.conf = *conf
+			sb.mu.Unlock()
+			b.budgets[key] = sb
+			continue
+		}
+		b.budgets[key] = &serviceBudget{conf: *conf, rate: 1, tokens: conf.MaxSpansPerSecond}
+	}
+	if len(budgets) > 0 {
+		log.Infof("Enforcing %d service budgets", len(budgets))
+	}
+}
+
+// match returns the budget of the service in the env, if any.
+func (b *ServiceBudgets) match(service, env string) *serviceBudget {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.budgets) == 0 {
+		return nil
+	}
+	for _, key := range []ServiceSignature{{Name: service, Env: env}, {Name: service}, {Env: env}} {
+		if sb, ok := b.budgets[key]; ok {
+			return sb
+		}
+	}
+	return nil
+}
+
+// Sample counts the spans of the chunk in its budget and returns false if the
+// chunk is kept but the budget is exhausted, and it must be dropped. The chunks
+// kept by the user (priority UserKeep and above) or by the errors and rare
+// samplers, named by keptBy, are always kept and don't spend the budget.
+func (b *ServiceBudgets) Sample(now time.Time, chunk *pb.TraceChunk, root *pb.Span, tracerEnv string, clientDroppedP0sWeight float64, keep bool, keptBy Name) bool {
+	if b == nil || root == nil {
+		return keep
+	}
+	sb := b.match(root.Service, toSamplerEnv(tracerEnv, b.agentEnv))
+	if sb == nil {
+		return keep
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	spans := float32(len(chunk.Spans))
+	sb.count(now, spans*(weightRoot(root)+float32(clientDroppedP0sWeight)))
+	if !keep {
+		return false
+	}
+	if keptBy == NameError || keptBy == NameRare {
+		return true
+	}
+	if priority, ok := GetSamplingPriority(chunk); ok && priority >= PriorityUserKeep {
+		return true
+	}
+	sb.refill(now)
+	if sb.tokens <= 0 {
+		sb.dropped += int64(len(chunk.Spans))
+		return false
+	}
+	sb.tokens -= float64(len(chunk.Spans))
+	sb.kept += int64(len(chunk.Spans))
+	return true
+}
+
+// Rate returns the sampling rate keeping the spans of the service in the env
+// within its budget, or false if there is no budget for it.
+func (b *ServiceBudgets) Rate(service, env string) (float64, bool) {
+	if b == nil {
+		return 0, false
+	}
+	sb := b.match(service, toSamplerEnv(env, b.agentEnv))
+	if sb == nil {
+		return 0, false
+	}
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.rate, true
+}
+
+// capRates lowers the rates of the services to the rates of their budgets.
+func (b *ServiceBudgets) capRates(rates map[ServiceSignature]float64) {
+	if b == nil {
+		return
+	}
+	for sig, rate := range rates {
+		if budgetRate, ok := b.Rate(sig.Name, sig.Env); ok && budgetRate < rate {
+			rates[sig] = budgetRate
+		}
+	}
+}
+
+// Usage returns the usage of the budgets, and resets the counts of kept and
+// dropped spans.
+func (b *ServiceBudgets) Usage() []ServiceBudgetUsage {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	usage := make([]ServiceBudgetUsage, 0, len(b.budgets))
+	for _, sb := range b.budgets {
+		sb.mu.Lock()
+		var maxSeen float32
+		for _, c := range sb.seen {
+			maxSeen = max(maxSeen, c)
+		}
+		usage = append(usage, ServiceBudgetUsage{
+			Service:           sb.conf.Service,
+			Env:               sb.conf.Env,
+			MaxSpansPerSecond: sb.conf.MaxSpansPerSecond,
+			SpansPerSecond:    float64(maxSeen) / bucketDuration.Seconds(),
+			Rate:              sb.rate,
+			KeptSpans:         sb.kept,
+			DroppedSpans:      sb.dropped,
+		})
+		sb.kept, sb.dropped = 0, 0
+		sb.mu.Unlock()
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Service != usage[j].Service {
+			return usage[i].Service < usage[j].Service
+		}
+		return usage[i].Env < usage[j].Env
+	})
+	return usage
+}
+
+// count adds n received spans, updating the rate when the buckets rotate.
+// sb must be locked.
+func (sb *serviceBudget) count(now time.Time, n float32) {
+	bucketID := now.Unix() / int64(bucketDuration.Seconds())
+	if prevBucketID := sb.lastBucketID; prevBucketID != bucketID {
+		var maxBucket float32
+		maxBucket, sb.seen = zeroAndGetMax(sb.seen, prevBucketID, bucketID)
+		sb.lastBucketID = bucketID
+		sb.updateRate(float64(maxBucket) / bucketDuration.Seconds())
+	}
+	sb.seen[bucketID%numBuckets] += n
+}
+
+// updateRate computes the rate keeping seenSPS spans per second within the
+// budget. Like the rates of the Sampler, its increases are bounded.
+// sb must be locked.
+func (sb *serviceBudget) updateRate(seenSPS float64) {
+	rate := 1.0
+	if seenSPS > sb.conf.MaxSpansPerSecond {
+		rate = sb.conf.MaxSpansPerSecond / seenSPS
+	}
+	if sb.rate > 0 && rate/sb.rate > maxRateIncrease {
+		rate = sb.rate * maxRateIncrease
+	}
+	sb.rate = min(rate, 1)
+}
+
+// refill adds the tokens accumulated since the last refill. sb must be locked.
+func (sb *serviceBudget) refill(now time.Time) {
+	if !sb.lastRefill.IsZero() && now.After(sb.lastRefill) {
+		elapsed := now.Sub(sb.lastRefill).Seconds()
+		sb.tokens = min(sb.tokens+elapsed*sb.conf.MaxSpansPerSecond, sb.conf.MaxSpansPerSecond)
+	}
+	sb.lastRefill = now
+}
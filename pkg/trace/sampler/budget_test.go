@@ -0,0 +1,186 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func testBudgetChunk(service string, spans int) (*pb.TraceChunk, *pb.Span) {
+	chunk := &pb.TraceChunk{}
+	for i := 0; i < spans; i++ {
+		chunk.Spans = append(chunk.Spans, &pb.Span{TraceID: 1, SpanID: uint64(i + 1), Service: service})
+	}
+	return chunk, chunk.Spans[0]
+}
+
+func TestServiceBudgetsMatch(t *testing.T) {
+	b := NewServiceBudgets(&config.AgentConfig{
+		DefaultEnv: "prod",
+		ServiceBudgets: []*config.ServiceBudget{
+			{Service: "web", Env: "prod", MaxSpansPerSecond: 1},
+			{Service: "web", MaxSpansPerSecond: 2},
+			{Env: "staging", MaxSpansPerSecond: 3},
+		},
+	})
+	for _, tt := range []struct {
+		service, env string
+		budget       float64
+	}{
+		{"web", "prod", 1},
+		{"web", "", 1}, // the agent env
+		{"web", "staging", 2},
+		{"db", "staging", 3},
+		{"db", "prod", 0},
+	} {
+		sb := b.match(tt.service, toSamplerEnv(tt.env, b.agentEnv))
+		if tt.budget == 0 {
+			assert.Nil(t, sb, "%s in %s", tt.service, tt.env)
+			continue
+		}
+		require.NotNil(t, sb, "%s in %s", tt.service, tt.env)
+		assert.Equal(t, tt.budget, sb.conf.MaxSpansPerSecond, "%s in %s", tt.service, tt.env)
+	}
+}
+
+func TestServiceBudgetsRate(t *testing.T) {
+	b := NewServiceBudgets(&config.AgentConfig{
+		ServiceBudgets: []*config.ServiceBudget{{Service: "web", MaxSpansPerSecond: 100}},
+	})
+	now := time.Unix(1000, 0)
+
+	// 1000 spans per second, in traces kept at 10% by the tracer
+	for i := 0; i < 3*numBuckets; i++ {
+		for j := 0; j < 10; j++ {
+			chunk, root := testBudgetChunk("web", 10)
+			root.Metrics = map[string]float64{KeySamplingRateGlobal: 0.1}
+			b.Sample(now, chunk, root, "", 0, false, NamePriority)
+		}
+		now = now.Add(bucketDuration / 5)
+	}
+	rate, ok := b.Rate("web", "")
+	assert.True(t, ok)
+	assert.InDelta(t, 0.1, rate, 0.001)
+
+	rates := map[ServiceSignature]float64{{Name: "web"}: 0.5, {Name: "db"}: 0.5}
+	b.capRates(rates)
+	assert.Equal(t, map[ServiceSignature]float64{{Name: "web"}: rate, {Name: "db"}: 0.5}, rates)
+
+	// the rate increases by 20% at most once the traffic drops
+	chunk, root := testBudgetChunk("web", 1)
+	b.Sample(now.Add(numBuckets*bucketDuration), chunk, root, "", 0, false, NamePriority)
+	newRate, _ := b.Rate("web", "")
+	assert.InDelta(t, rate*maxRateIncrease, newRate, 0.001)
+
+	_, ok = b.Rate("db", "")
+	assert.False(t, ok)
+}
+
+func TestServiceBudgetsDrop(t *testing.T) {
+	b := NewServiceBudgets(&config.AgentConfig{
+		ServiceBudgets: []*config.ServiceBudget{{Service: "web", MaxSpansPerSecond: 10}},
+	})
+	now := time.Unix(1000, 0)
+	var kept int
+	for i := 0; i < 10; i++ {
+		chunk, root := testBudgetChunk("web", 4)
+		if b.Sample(now, chunk, root, "", 0, true, NamePriority) {
+			kept++
+		}
+	}
+	// the budget is spent by the third chunk
+	assert.Equal(t, 3, kept)
+
+	chunk, root := testBudgetChunk("web", 4)
+	assert.False(t, b.Sample(now, chunk, root, "", 0, false, NamePriority), "dropped chunks stay dropped")
+	chunk, root = testBudgetChunk("db", 4)
+	assert.True(t, b.Sample(now, chunk, root, "", 0, true, NamePriority), "chunks without budget are kept")
+	chunk, root = testBudgetChunk("web", 4)
+	assert.True(t, b.Sample(now.Add(time.Second), chunk, root, "", 0, true, NamePriority), "the budget is refilled")
+
+	usage := b.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, "web", usage[0].Service)
+	assert.EqualValues(t, 16, usage[0].KeptSpans)
+	assert.EqualValues(t, 28, usage[0].DroppedSpans)
+	usage = b.Usage()
+	assert.Zero(t, usage[0].KeptSpans)
+	assert.Zero(t, usage[0].DroppedSpans)
+}
+
+func TestServiceBudgetsExemptions(t *testing.T) {
+	b := NewServiceBudgets(&config.AgentConfig{
+		ServiceBudgets: []*config.ServiceBudget{{Service: "web", MaxSpansPerSecond: 4}},
+	})
+	now := time.Unix(1000, 0)
+	chunk, root := testBudgetChunk("web", 4)
+	require.True(t, b.Sample(now, chunk, root, "", 0, true, NamePriority))
+
+	// the budget is exhausted
+	chunk, root = testBudgetChunk("web", 4)
+	assert.False(t, b.Sample(now, chunk, root, "", 0, true, NamePriority))
+	chunk, root = testBudgetChunk("web", 4)
+	assert.True(t, b.Sample(now, chunk, root, "", 0, true, NameError), "chunks kept by the errors sampler are kept")
+	chunk, root = testBudgetChunk("web", 4)
+	assert.True(t, b.Sample(now, chunk, root, "", 0, true, NameRare), "chunks kept by the rare sampler are kept")
+	chunk, root = testBudgetChunk("web", 4)
+	chunk.Priority = int32(PriorityUserKeep)
+	assert.True(t, b.Sample(now, chunk, root, "", 0, true, NamePriority), "chunks kept by the user are kept")
+	chunk, root = testBudgetChunk("web", 4)
+	chunk.Priority = int32(PriorityAutoKeep)
+	assert.False(t, b.Sample(now, chunk, root, "", 0, true, NamePriority))
+
+	usage := b.Usage()
+	require.Len(t, usage, 1)
+	assert.EqualValues(t, 4, usage[0].KeptSpans)
+	assert.EqualValues(t, 8, usage[0].DroppedSpans)
+}
+
+func TestServiceBudgetsUpdate(t *testing.T) {
+	local := []*config.ServiceBudget{{Service: "web", MaxSpansPerSecond: 10}}
+	b := NewServiceBudgets(&config.AgentConfig{ServiceBudgets: local})
+	now := time.Unix(1000, 0)
+	chunk, root := testBudgetChunk("web", 4)
+	b.Sample(now, chunk, root, "", 0, true, NamePriority)
+
+	b.UpdateBudgets([]*config.ServiceBudget{
+		{Service: "web", MaxSpansPerSecond: 20},
+		{Env: "prod", MaxSpansPerSecond: 5},
+	})
+	usage := b.Usage()
+	require.Len(t, usage, 2)
+	assert.Equal(t, ServiceBudgetUsage{Env: "prod", MaxSpansPerSecond: 5, Rate: 1}, usage[0])
+	assert.Equal(t, 20.0, usage[1].MaxSpansPerSecond)
+	assert.EqualValues(t, 4, usage[1].KeptSpans, "the state of the budget is kept")
+
+	b.UpdateBudgets([]*config.ServiceBudget{})
+	assert.Empty(t, b.Usage())
+
+	b.UpdateBudgets(nil)
+	usage = b.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, 10.0, usage[0].MaxSpansPerSecond)
+}
+
+func TestServiceBudgetsNil(t *testing.T) {
+	var b *ServiceBudgets
+	chunk, root := testBudgetChunk("web", 4)
+	assert.True(t, b.Sample(time.Now(), chunk, root, "", 0, true, NamePriority))
+	_, ok := b.Rate("web", "")
+	assert.False(t, ok)
+	assert.Nil(t, b.Usage())
+	b.UpdateBudgets(nil)
+
+	s := NewPrioritySampler(&config.AgentConfig{ExtraSampleRate: 1}, &DynamicConfig{})
+	assert.NotPanics(t, func() { s.ratesByService() })
+}
@@ -20,6 +20,9 @@ type DynamicConfig struct {
 	// RateByService contains the rate for each service/env tuple,
 	// used in priority sampling by client libs.
 	RateByService RateByService
+	// ServiceBudgets holds the budgets of spans per second capping the rates by
+	// service, when set.
+	ServiceBudgets *ServiceBudgets
 }
 
 // NewDynamicConfig creates a new dynamic config object which maps service signatures
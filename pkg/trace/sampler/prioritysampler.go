@@ -45,6 +45,8 @@ type PrioritySampler struct {
 	// This struct is shared with the agent API which sends the rates in http responses to spans post requests
 	rateByService *RateByService
 	catalog       *serviceKeyCatalog
+	// budgets cap the rates of the services having a budget.
+	budgets *ServiceBudgets
 }
 
 // NewPrioritySampler returns an initialized Sampler
@@ -54,6 +56,7 @@ func NewPrioritySampler(conf *config.AgentConfig, dynConf *DynamicConfig) *Prior
 		sampler:       newSampler(conf.ExtraSampleRate, conf.TargetTPS),
 		rateByService: &dynConf.RateByService,
 		catalog:       newServiceLookup(conf.MaxCatalogEntries),
+		budgets:       dynConf.ServiceBudgets,
 	}
 	return s
 }
@@ -154,5 +157,7 @@ func (s *PrioritySampler) countSignature(now time.Time, root *pb.Span, signature
 // agents to pick the right service rate.
 func (s *PrioritySampler) ratesByService() map[ServiceSignature]float64 {
 	rates, defaultRate := s.sampler.getAllSignatureSampleRates()
-	return s.catalog.ratesByService(s.agentEnv, rates, defaultRate)
+	rbs := s.catalog.ratesByService(s.agentEnv, rates, defaultRate)
+	s.budgets.capRates(rbs)
+	return rbs
 }
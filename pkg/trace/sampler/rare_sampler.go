@@ -47,6 +47,7 @@ type RareSampler struct {
 	mu      sync.RWMutex
 
 	limiter     *rate.Limiter
+	tps         float64
 	ttl         time.Duration
 	cardinality int
 	seen        map[Signature]*seenSpans
@@ -61,6 +62,7 @@ func NewRareSampler(conf *config.AgentConfig) *RareSampler {
 		misses:      atomic.NewInt64(0),
 		shrinks:     atomic.NewInt64(0),
 		limiter:     rate.NewLimiter(rate.Limit(conf.RareSamplerTPS), rareSamplerBurst),
+		tps:         float64(conf.RareSamplerTPS),
 		ttl:         conf.RareSamplerCooldownPeriod,
 		cardinality: conf.RareSamplerCardinality,
 		seen:        make(map[Signature]*seenSpans),
@@ -87,6 +89,12 @@ func (e *RareSampler) IsEnabled() bool {
 	return e.enabled.Load()
 }
 
+// ShedLoad scales the traces per second kept by the sampler by factor while
+// the agent sheds load, a factor of 1 restoring it.
+func (e *RareSampler) ShedLoad(factor float64) {
+	e.limiter.SetLimit(rate.Limit(e.tps * factor))
+}
+
 func (e *RareSampler) handlePriorityTrace(now time.Time, env string, t *pb.TraceChunk, ttl time.Duration) {
 	expire := now.Add(ttl)
 	for _, s := range t.Spans {
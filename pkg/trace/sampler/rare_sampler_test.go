@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
 
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
@@ -155,3 +156,13 @@ func getTraceChunkWithSpansAndPriority(spans []*pb.Span, priority SamplingPriori
 		Spans:    spans,
 	}
 }
+
+func TestRareSamplerShedLoad(t *testing.T) {
+	c := config.New()
+	c.RareSamplerTPS = 5
+	e := NewRareSampler(c)
+	e.ShedLoad(0.1)
+	assert.Equal(t, rate.Limit(0.5), e.limiter.Limit())
+	e.ShedLoad(1)
+	assert.Equal(t, rate.Limit(5), e.limiter.Limit())
+}
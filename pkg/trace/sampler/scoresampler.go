@@ -37,13 +37,19 @@ type ScoreSampler struct {
 	disabled        bool
 	mu              sync.Mutex
 	shrinkAllowList map[Signature]float64
+
+	// tpsMu guards requestedTPS, the target TPS set by the configuration,
+	// and shedFactor, the factor it is scaled by while the agent sheds load.
+	tpsMu        sync.Mutex
+	requestedTPS float64
+	shedFactor   float64
 }
 
 // NewNoPrioritySampler returns an initialized Sampler dedicated to traces with
 // no priority set.
 func NewNoPrioritySampler(conf *config.AgentConfig) *NoPrioritySampler {
 	s := newSampler(conf.ExtraSampleRate, conf.TargetTPS)
-	return &NoPrioritySampler{ScoreSampler{Sampler: s, samplingRateKey: noPriorityRateKey}}
+	return &NoPrioritySampler{ScoreSampler{Sampler: s, samplingRateKey: noPriorityRateKey, requestedTPS: conf.TargetTPS, shedFactor: 1}}
 }
 
 var _ AdditionalMetricsReporter = (*NoPrioritySampler)(nil)
@@ -57,7 +63,7 @@ func (s *NoPrioritySampler) report(statsd statsd.ClientInterface) {
 // for reporting).
 func NewErrorsSampler(conf *config.AgentConfig) *ErrorsSampler {
 	s := newSampler(conf.ExtraSampleRate, conf.ErrorTPS)
-	return &ErrorsSampler{ScoreSampler{Sampler: s, samplingRateKey: errorsRateKey, disabled: conf.ErrorTPS == 0}}
+	return &ErrorsSampler{ScoreSampler{Sampler: s, samplingRateKey: errorsRateKey, disabled: conf.ErrorTPS == 0, requestedTPS: conf.ErrorTPS, shedFactor: 1}}
 }
 
 var _ AdditionalMetricsReporter = (*ErrorsSampler)(nil)
@@ -89,12 +95,26 @@ func (s *ScoreSampler) Sample(now time.Time, trace pb.Trace, root *pb.Span, env
 
 // UpdateTargetTPS updates the target tps
 func (s *ScoreSampler) UpdateTargetTPS(targetTPS float64) {
-	s.Sampler.updateTargetTPS(targetTPS)
+	s.tpsMu.Lock()
+	defer s.tpsMu.Unlock()
+	s.requestedTPS = targetTPS
+	s.Sampler.updateTargetTPS(targetTPS * s.shedFactor)
 }
 
 // GetTargetTPS returns the target tps
 func (s *ScoreSampler) GetTargetTPS() float64 {
-	return s.Sampler.targetTPS.Load()
+	s.tpsMu.Lock()
+	defer s.tpsMu.Unlock()
+	return s.requestedTPS
+}
+
+// ShedLoad scales the target tps by factor while the agent sheds load, a
+// factor of 1 restoring it.
+func (s *ScoreSampler) ShedLoad(factor float64) {
+	s.tpsMu.Lock()
+	defer s.tpsMu.Unlock()
+	s.shedFactor = factor
+	s.Sampler.updateTargetTPS(s.requestedTPS * factor)
 }
 
 func (s *ScoreSampler) applySampleRate(root *pb.Span, rate float64) bool {
@@ -162,3 +162,18 @@ func BenchmarkSampler(b *testing.B) {
 		s.Sample(ts, trace, trace[0], defaultEnv)
 	}
 }
+
+func TestShedLoad(t *testing.T) {
+	s := getTestErrorsSampler(10)
+	s.ShedLoad(0.1)
+	assert.Equal(t, 1.0, s.Sampler.targetTPS.Load())
+	assert.Equal(t, 10.0, s.GetTargetTPS())
+
+	// the target set while shedding load is scaled too
+	s.UpdateTargetTPS(20)
+	assert.Equal(t, 2.0, s.Sampler.targetTPS.Load())
+	assert.Equal(t, 20.0, s.GetTargetTPS())
+
+	s.ShedLoad(1)
+	assert.Equal(t, 20.0, s.Sampler.targetTPS.Load())
+}
@@ -42,6 +42,7 @@ type BucketsAggregationKey struct {
 	PeerTagsHash   uint64
 	IsTraceRoot    pb.Trilean
 	GRPCStatusCode string
+	ExtraTagsHash  uint64
 }
 
 // PayloadAggregationKey specifies the key by which a payload is aggregated.
@@ -95,6 +96,7 @@ func NewAggregationFromSpan(s *StatSpan, origin string, aggKey PayloadAggregatio
 			IsTraceRoot:    isTraceRoot,
 			GRPCStatusCode: s.grpcStatusCode,
 			PeerTagsHash:   tagsFnvHash(s.matchingPeerTags),
+			ExtraTagsHash:  tagsFnvHash(s.extraTags),
 		},
 	}
 	return agg
@@ -137,10 +139,64 @@ func NewAggregationFromGroup(g *pb.ClientGroupedStats) Aggregation {
 			PeerTagsHash:   tagsFnvHash(g.PeerTags),
 			IsTraceRoot:    g.IsTraceRoot,
 			GRPCStatusCode: g.GRPCStatusCode,
+			ExtraTagsHash:  tagsFnvHash(g.ExtraAggregationTags),
 		},
 	}
 }
 
+// tagValueOverflow replaces the values of the extra aggregation tags beyond the
+// cardinality limit.
+const tagValueOverflow = "_overflow"
+
+// extraTagsLimiter caps the number of distinct values of each extra aggregation
+// tag within a stats bucket.
+type extraTagsLimiter struct {
+	maxValues int
+	// values holds the values seen by tag name.
+	values map[string]map[string]struct{}
+}
+
+// newExtraTagsLimiter returns an extraTagsLimiter allowing maxValues distinct values
+// per tag, or nil if maxValues isn't positive, which allows any number of values.
+func newExtraTagsLimiter(maxValues int) *extraTagsLimiter {
+	if maxValues <= 0 {
+		return nil
+	}
+	return &extraTagsLimiter{
+		maxValues: maxValues,
+		values:    make(map[string]map[string]struct{}),
+	}
+}
+
+// limit returns the given "key:value" tags, the values of which go beyond the limit
+// being replaced by tagValueOverflow. The given slice is never modified.
+func (l *extraTagsLimiter) limit(tags []string) []string {
+	if l == nil {
+		return tags
+	}
+	out, copied := tags, false
+	for i, t := range tags {
+		k, v, _ := strings.Cut(t, ":")
+		seen, ok := l.values[k]
+		if !ok {
+			seen = make(map[string]struct{})
+			l.values[k] = seen
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		if len(seen) < l.maxValues {
+			seen[v] = struct{}{}
+			continue
+		}
+		if !copied {
+			out, copied = append([]string(nil), tags...), true
+		}
+		out[i] = k + ":" + tagValueOverflow
+	}
+	return out
+}
+
 /*
 The gRPC codes Google API checks for "CANCELLED". Sometimes we receive "Canceled" from upstream,
 sometimes "CANCELLED", which is why both spellings appear in the map.
@@ -262,6 +262,22 @@ func TestPeerTagsToAggregateForSpan(t *testing.T) {
 	}
 }
 
+func TestExtraTagsLimiter(t *testing.T) {
+	assert := assert.New(t)
+	l := newExtraTagsLimiter(2)
+	tags := []string{"tenant:a", "region:us1"}
+	assert.Equal(tags, l.limit(tags))
+	assert.Equal([]string{"tenant:b", "region:us1"}, l.limit([]string{"tenant:b", "region:us1"}))
+	tags = []string{"tenant:c", "region:eu1"}
+	assert.Equal([]string{"tenant:_overflow", "region:eu1"}, l.limit(tags))
+	assert.Equal([]string{"tenant:c", "region:eu1"}, tags, "the given tags must not be modified")
+	assert.Equal([]string{"tenant:a", "region:_overflow"}, l.limit([]string{"tenant:a", "region:ap1"}))
+
+	var unlimited *extraTagsLimiter
+	assert.Nil(newExtraTagsLimiter(0))
+	assert.Equal(tags, unlimited.limit(tags))
+}
+
 func TestIsRootSpan(t *testing.T) {
 	sc := &SpanConcentrator{}
 	for _, tt := range []struct {
@@ -106,6 +106,8 @@ func getTestStatsWithStart(t *testing.T, start time.Time, incPeerTags bool) *pb.
 			s.PeerTags = nil
 		}
 		s.DBType = ""
+		// tracers don't aggregate their stats by the extra aggregation tags
+		s.ExtraAggregationTags = nil
 		s.OkSummary = encodeTestSketch(t, generateTestSketch(t))
 		s.ErrorSummary = encodeTestSketch(t, generateTestSketch(t))
 		stats = append(stats, s)
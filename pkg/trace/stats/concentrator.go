@@ -53,8 +53,10 @@ type Concentrator struct {
 func NewConcentrator(conf *config.AgentConfig, writer Writer, now time.Time, statsd statsd.ClientInterface) *Concentrator {
 	bsize := conf.BucketInterval.Nanoseconds()
 	sc := NewSpanConcentrator(&SpanConcentratorConfig{
-		ComputeStatsBySpanKind: conf.ComputeStatsBySpanKind,
-		BucketInterval:         bsize,
+		ComputeStatsBySpanKind:        conf.ComputeStatsBySpanKind,
+		BucketInterval:                bsize,
+		ExtraAggregationTags:          conf.ExtraAggregationTags,
+		ExtraAggregationTagsMaxValues: conf.ExtraAggregationTagsMaxValues,
 	}, now)
 	_, disabledCIDStats := conf.Features["disable_cid_stats"]
 	_, disabledProcessStats := conf.Features["disable_process_stats"]
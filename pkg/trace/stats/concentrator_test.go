@@ -818,6 +818,37 @@ func TestPeerTags(t *testing.T) {
 	})
 }
 
+func TestExtraAggregationTags(t *testing.T) {
+	assert := assert.New(t)
+	now := time.Now()
+	var spans []*pb.Span
+	for i, tenant := range []string{"a", "b", "a", "c", "d"} {
+		spans = append(spans, &pb.Span{
+			ParentID: 0,
+			SpanID:   uint64(i + 1),
+			Service:  "myservice",
+			Name:     "http.server.request",
+			Resource: "GET /users",
+			Duration: 100,
+			Meta:     map[string]string{"span.kind": "server", "tenant": tenant, "region": "us1"},
+		})
+	}
+	traceutil.ComputeTopLevel(spans)
+	testTrace := toProcessedTrace(spans, "none", "", "", "", "")
+	c := NewTestConcentrator(now)
+	c.spanConcentrator.extraTagKeys = []string{"tenant"}
+	c.spanConcentrator.extraTagsMaxValues = 2
+	c.addNow(testTrace, infraTags{})
+	stats := c.flushNow(now.UnixNano()+int64(c.spanConcentrator.bufferLen)*testBucketInterval, false)
+	hits := make(map[string]uint64)
+	for _, st := range stats.Stats[0].Stats[0].Stats {
+		if assert.Len(st.ExtraAggregationTags, 1) {
+			hits[st.ExtraAggregationTags[0]] += st.Hits
+		}
+	}
+	assert.Equal(map[string]uint64{"tenant:a": 2, "tenant:b": 1, "tenant:_overflow": 2}, hits)
+}
+
 // TestComputeStatsThroughSpanKindCheck ensures that we generate stats for spans that have an eligible span.kind.
 func TestComputeStatsThroughSpanKindCheck(t *testing.T) {
 	assert := assert.New(t)
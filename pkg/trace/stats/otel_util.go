@@ -140,11 +140,20 @@ func obfuscateSpanForConcentrator(o *obfuscate.Obfuscator, span *pb.Span, conf *
 		return
 	}
 	switch span.Type {
-	case "sql", "cassandra":
+	case "sql":
 		_, err := transform.ObfuscateSQLSpan(o, span)
 		if err != nil {
 			log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
 		}
+	case "cassandra":
+		_, err := transform.ObfuscateCQLSpan(o, span)
+		if err != nil {
+			log.Debugf("Error parsing CQL query: %v. Resource: %q", err, span.Resource)
+		}
+	case "graphql":
+		if conf.Obfuscation.GraphQL.Enabled {
+			transform.ObfuscateGraphQLSpan(o, span)
+		}
 	case "redis":
 		span.Resource = o.QuantizeRedisString(span.Resource)
 		if conf.Obfuscation.Redis.Enabled {
@@ -22,6 +22,11 @@ type SpanConcentratorConfig struct {
 	ComputeStatsBySpanKind bool
 	// BucketInterval the size of our pre-aggregation per bucket
 	BucketInterval int64
+	// ExtraAggregationTags are the span tags used as additional aggregation dimensions
+	ExtraAggregationTags []string
+	// ExtraAggregationTagsMaxValues is the maximum number of distinct values of each extra aggregation
+	// tag per bucket, the other values being folded into a placeholder. There is no limit if it is 0.
+	ExtraAggregationTagsMaxValues int
 }
 
 // StatSpan holds all the required fields from a span needed to calculate stats
@@ -42,6 +47,7 @@ type StatSpan struct {
 	isTopLevel       bool
 	matchingPeerTags []string
 	grpcStatusCode   string
+	extraTags        []string
 }
 
 func matchingPeerTags(meta map[string]string, peerTagKeys []string) []string {
@@ -58,6 +64,17 @@ func matchingPeerTags(meta map[string]string, peerTagKeys []string) []string {
 	return pt
 }
 
+// matchingExtraTags returns the "key:value" tags of the span with one of the given keys.
+func matchingExtraTags(meta map[string]string, keys []string) []string {
+	var tags []string
+	for _, k := range keys {
+		if v, ok := meta[k]; ok && v != "" {
+			tags = append(tags, k+":"+v)
+		}
+	}
+	return tags
+}
+
 // peerTagKeysToAggregateForSpan returns the set of peerTagKeys to use for stats aggregation for the given
 // span.kind and _dd.base_service
 func peerTagKeysToAggregateForSpan(spanKind string, baseService string, peerTagKeys []string) []string {
@@ -90,6 +107,10 @@ type SpanConcentrator struct {
 	// wait such time before flushing the stats.
 	// This only applies to past buckets. Stats buckets in the future are allowed with no restriction.
 	bufferLen int
+	// extraTagKeys are the tags used as additional aggregation dimensions.
+	extraTagKeys []string
+	// extraTagsMaxValues caps the number of distinct values of each extra tag per bucket.
+	extraTagsMaxValues int
 
 	// mu protects the buckets field
 	mu      sync.Mutex
@@ -103,6 +124,8 @@ func NewSpanConcentrator(cfg *SpanConcentratorConfig, now time.Time) *SpanConcen
 		bsize:                  cfg.BucketInterval,
 		oldestTs:               alignTs(now.UnixNano(), cfg.BucketInterval),
 		bufferLen:              defaultBufferLen,
+		extraTagKeys:           cfg.ExtraAggregationTags,
+		extraTagsMaxValues:     cfg.ExtraAggregationTagsMaxValues,
 		mu:                     sync.Mutex{},
 		buckets:                make(map[int64]*RawBucket),
 	}
@@ -156,6 +179,7 @@ func (sc *SpanConcentrator) NewStatSpan(
 		matchingPeerTags: matchingPeerTags(meta, peerTags),
 
 		grpcStatusCode: getGRPCStatusCode(meta, metrics),
+		extraTags:      matchingExtraTags(meta, sc.extraTagKeys),
 	}, true
 }
 
@@ -184,8 +208,12 @@ func (sc *SpanConcentrator) addSpan(s *StatSpan, aggKey PayloadAggregationKey, t
 	b, ok := sc.buckets[btime]
 	if !ok {
 		b = NewRawBucket(uint64(btime), uint64(sc.bsize))
+		b.extraTagsLimiter = newExtraTagsLimiter(sc.extraTagsMaxValues)
 		sc.buckets[btime] = b
 	}
+	if len(s.extraTags) > 0 {
+		s.extraTags = b.extraTagsLimiter.limit(s.extraTags)
+	}
 	if tags.processTagsHash != 0 && len(tags.processTags) > 0 {
 		b.processTagsByHash[tags.processTagsHash] = tags.processTags
 	}
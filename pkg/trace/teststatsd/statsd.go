@@ -44,14 +44,16 @@ type Client struct {
 	mu sync.RWMutex
 	statsd.NoOpClient
 
-	GaugeErr       error
-	GaugeCalls     []MetricsArgs
-	CountErr       error
-	CountCalls     []MetricsArgs
-	HistogramErr   error
-	HistogramCalls []MetricsArgs
-	TimingErr      error
-	TimingCalls    []MetricsArgs
+	GaugeErr          error
+	GaugeCalls        []MetricsArgs
+	CountErr          error
+	CountCalls        []MetricsArgs
+	HistogramErr      error
+	HistogramCalls    []MetricsArgs
+	DistributionErr   error
+	DistributionCalls []MetricsArgs
+	TimingErr         error
+	TimingCalls       []MetricsArgs
 }
 
 // Reset resets client's internal records.
@@ -64,6 +66,8 @@ func (c *Client) Reset() {
 	c.CountCalls = c.CountCalls[:0]
 	c.HistogramErr = nil
 	c.HistogramCalls = c.HistogramCalls[:0]
+	c.DistributionErr = nil
+	c.DistributionCalls = c.DistributionCalls[:0]
 	c.TimingErr = nil
 	c.TimingCalls = c.TimingCalls[:0]
 }
@@ -108,6 +112,14 @@ func (c *Client) Histogram(name string, value float64, tags []string, rate float
 	return c.HistogramErr
 }
 
+// Distribution records a call to a Distribution operation and replies with DistributionErr
+func (c *Client) Distribution(name string, value float64, tags []string, rate float64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.DistributionCalls = append(c.DistributionCalls, MetricsArgs{Name: name, Value: value, Tags: tags, Rate: rate})
+	return c.DistributionErr
+}
+
 // Timing records a call to a Timing operation.
 func (c *Client) Timing(name string, value time.Duration, tags []string, rate float64) error {
 	c.mu.Lock()
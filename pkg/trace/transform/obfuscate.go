@@ -6,6 +6,8 @@
 package transform
 
 import (
+	"strings"
+
 	"github.com/DataDog/datadog-agent/pkg/obfuscate"
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
@@ -26,10 +28,22 @@ const (
 	TagOpenSearchBody = "opensearch.body"
 	// TagSQLQuery represents a SQL query tag
 	TagSQLQuery = "sql.query"
+	// TagSQLProcedures represents the tag listing the procedures called by a SQL query
+	TagSQLProcedures = "sql.procedures"
+	// TagSQLFingerprint represents the tag identifying the normalized SQL query
+	TagSQLFingerprint = "sql.fingerprint"
 	// TagHTTPURL represents an HTTP URL tag
 	TagHTTPURL = "http.url"
 	// TagDBMS represents a DBMS tag
 	TagDBMS = "db.type"
+	// TagGraphQLSource represents a GraphQL query tag
+	TagGraphQLSource = "graphql.source"
+	// TagGraphQLDocument represents a GraphQL query tag, as named by the OpenTelemetry semantic conventions
+	TagGraphQLDocument = "graphql.document"
+	// TagGraphQLVariablesPrefix is the prefix of the GraphQL variable tags
+	TagGraphQLVariablesPrefix = "graphql.variables."
+	// TagDynamoDBRequest represents a DynamoDB request body tag
+	TagDynamoDBRequest = "dynamodb.request"
 )
 
 const (
@@ -43,6 +57,20 @@ func ObfuscateSQLSpan(o *obfuscate.Obfuscator, span *pb.Span) (*obfuscate.Obfusc
 		return nil, nil
 	}
 	oq, err := o.ObfuscateSQLStringForDBMS(span.Resource, span.Meta[TagDBMS])
+	return setObfuscatedQuery(span, oq, err)
+}
+
+// ObfuscateCQLSpan obfuscates a Cassandra span using pkg/obfuscate logic
+func ObfuscateCQLSpan(o *obfuscate.Obfuscator, span *pb.Span) (*obfuscate.ObfuscatedQuery, error) {
+	if span.Resource == "" {
+		return nil, nil
+	}
+	oq, err := o.ObfuscateCQLString(span.Resource)
+	return setObfuscatedQuery(span, oq, err)
+}
+
+// setObfuscatedQuery sets the resource and query tag of a span to its obfuscated query.
+func setObfuscatedQuery(span *pb.Span, oq *obfuscate.ObfuscatedQuery, err error) (*obfuscate.ObfuscatedQuery, error) {
 	if err != nil {
 		// we have an error, discard the SQL to avoid polluting user resources.
 		span.Resource = TextNonParsable
@@ -53,10 +81,30 @@ func ObfuscateSQLSpan(o *obfuscate.Obfuscator, span *pb.Span) (*obfuscate.Obfusc
 	if len(oq.Metadata.TablesCSV) > 0 {
 		traceutil.SetMeta(span, "sql.tables", oq.Metadata.TablesCSV)
 	}
+	if len(oq.Metadata.Procedures) > 0 {
+		traceutil.SetMeta(span, TagSQLProcedures, strings.Join(oq.Metadata.Procedures, ","))
+	}
+	if oq.Metadata.Fingerprint != "" {
+		traceutil.SetMeta(span, TagSQLFingerprint, oq.Metadata.Fingerprint)
+	}
 	traceutil.SetMeta(span, TagSQLQuery, oq.Query)
 	return oq, nil
 }
 
+// ObfuscateGraphQLSpan obfuscates a GraphQL span using pkg/obfuscate logic: the literal
+// values of its resource and query tags are replaced and its variable values are removed.
+func ObfuscateGraphQLSpan(o *obfuscate.Obfuscator, span *pb.Span) {
+	span.Resource = o.ObfuscateGraphQLString(span.Resource)
+	for k, v := range span.Meta {
+		switch {
+		case k == TagGraphQLSource || k == TagGraphQLDocument:
+			span.Meta[k] = o.ObfuscateGraphQLString(v)
+		case strings.HasPrefix(k, TagGraphQLVariablesPrefix):
+			span.Meta[k] = "?"
+		}
+	}
+}
+
 // ObfuscateRedisSpan obfuscates a Redis span using pkg/obfuscate logic
 func ObfuscateRedisSpan(o *obfuscate.Obfuscator, span *pb.Span, removeAllArgs bool) {
 	if span.Meta == nil || span.Meta[TagRedisRawCommand] == "" {
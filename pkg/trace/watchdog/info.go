@@ -41,6 +41,9 @@ type Info struct {
 	CPU CPUInfo
 	// Mem contains basic Mem info
 	Mem MemInfo
+	// ShedStage is the name of the load shedding stage, empty when no load
+	// is shed.
+	ShedStage string `json:",omitempty"`
 }
 
 // CurrentInfo is used to query CPU and Mem info, it keeps data from
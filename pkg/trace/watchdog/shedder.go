@@ -0,0 +1,161 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package watchdog
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// ShedStage is a stage of the load shedding. Each stage also sheds the load
+// of the previous ones.
+type ShedStage int32
+
+const (
+	// ShedNone is the stage when the resource usage is within the limits.
+	ShedNone ShedStage = iota
+	// ShedUnkept drops the trace chunks not kept by the tracers, i.e. with a
+	// sampling priority lower or equal to 0, before they are processed. The
+	// chunks without a sampling priority are kept.
+	ShedUnkept
+	// ShedSamplers lowers the budgets of the rare and errors samplers.
+	ShedSamplers
+	// ShedRequests rejects a share of the trace requests, proportional to the
+	// load over the limits.
+	ShedRequests
+)
+
+// shedRelaxRatio is the ratio of the limits under which the resource usage
+// must be for the shedding stage to be lowered.
+const shedRelaxRatio = 0.8
+
+// String returns the name of the stage.
+func (s ShedStage) String() string {
+	switch s {
+	case ShedNone:
+		return "none"
+	case ShedUnkept:
+		return "drop_unkept"
+	case ShedSamplers:
+		return "lower_samplers"
+	case ShedRequests:
+		return "reject_requests"
+	default:
+		return "unknown"
+	}
+}
+
+// Shedder grades the load shedding from the CPU and memory usage: the stage is
+// raised by one at each check over a limit, and lowered by one at each check
+// under shedRelaxRatio of the limits, so that the least valuable data is shed
+// first and the agent recovers gradually.
+//
+// In the ShedRequests stage, the share of the requests accepted is divided by
+// the load at each check over the limits, so that only as many requests are
+// rejected as needed to bring the usage back under the limits.
+type Shedder struct {
+	maxCPU float64
+	maxMem float64
+	stage  atomic.Int32
+	reject atomic.Uint64 // float64 bits of the share of the requests rejected
+
+	mu       sync.Mutex // guards onChange
+	onChange []func(ShedStage)
+}
+
+// NewShedder returns a Shedder for the given limits of CPU, in cores, and
+// of memory, in bytes. A limit of 0 is disabled.
+func NewShedder(maxCPU, maxMem float64) *Shedder {
+	return &Shedder{maxCPU: maxCPU, maxMem: maxMem}
+}
+
+// OnChange registers fn to be called with the new stage whenever it changes.
+func (s *Shedder) OnChange(fn func(ShedStage)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onChange = append(s.onChange, fn)
+}
+
+// Stage returns the current stage.
+func (s *Shedder) Stage() ShedStage {
+	if s == nil {
+		return ShedNone
+	}
+	return ShedStage(s.stage.Load())
+}
+
+// RejectRatio returns the share of the requests rejected, between 0 and 1.
+func (s *Shedder) RejectRatio() float64 {
+	if s == nil {
+		return 0
+	}
+	return math.Float64frombits(s.reject.Load())
+}
+
+// Reject returns whether a request should be rejected, picking them at random
+// in the share given by RejectRatio.
+func (s *Shedder) Reject() bool {
+	ratio := s.RejectRatio()
+	return ratio > 0 && rand.Float64() < ratio
+}
+
+// Update grades the stage from the resource usage in wi and returns it.
+func (s *Shedder) Update(wi Info) ShedStage {
+	load := s.load(wi)
+	cur := s.Stage()
+	next := cur
+	switch {
+	case load > 1 && cur < ShedRequests:
+		next = cur + 1
+	case load < shedRelaxRatio && cur > ShedNone:
+		next = cur - 1
+	}
+	s.updateRejectRatio(next, load)
+	if next == cur {
+		return cur
+	}
+	s.stage.Store(int32(next))
+	if next > cur {
+		log.Warnf("Resource usage over the limits (%.0f%%), raising the load shedding stage to %s", load*100, next)
+	} else {
+		log.Infof("Resource usage back under the limits (%.0f%%), lowering the load shedding stage to %s", load*100, next)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fn := range s.onChange {
+		fn(next)
+	}
+	return next
+}
+
+// updateRejectRatio updates the share of the requests rejected in the stage
+// next for the given load.
+func (s *Shedder) updateRejectRatio(next ShedStage, load float64) {
+	switch {
+	case next < ShedRequests:
+		s.reject.Store(0)
+	case load > 1:
+		// the load measured is the one of the requests accepted
+		accept := (1 - s.RejectRatio()) / load
+		s.reject.Store(math.Float64bits(1 - accept))
+	}
+}
+
+// load returns the highest ratio of the resource usage in wi to its limit.
+func (s *Shedder) load(wi Info) float64 {
+	var load float64
+	if s.maxCPU > 0 {
+		load = wi.CPU.UserAvg / s.maxCPU
+	}
+	if s.maxMem > 0 {
+		load = max(load, float64(wi.Mem.Alloc)/s.maxMem)
+	}
+	return load
+}
@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package watchdog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShedder(t *testing.T) {
+	s := NewShedder(0.5, 1000)
+	var changes []ShedStage
+	s.OnChange(func(stage ShedStage) { changes = append(changes, stage) })
+
+	over := Info{CPU: CPUInfo{UserAvg: 0.6}, Mem: MemInfo{Alloc: 100}}
+	between := Info{CPU: CPUInfo{UserAvg: 0.45}, Mem: MemInfo{Alloc: 100}}
+	under := Info{CPU: CPUInfo{UserAvg: 0.1}, Mem: MemInfo{Alloc: 100}}
+	overMem := Info{CPU: CPUInfo{UserAvg: 0.1}, Mem: MemInfo{Alloc: 1001}}
+
+	assert.Equal(t, ShedNone, s.Update(under))
+	// the stages are raised one at a time, up to the rejection of the requests
+	assert.Equal(t, ShedUnkept, s.Update(over))
+	assert.Equal(t, ShedSamplers, s.Update(overMem))
+	assert.Zero(t, s.RejectRatio())
+	assert.False(t, s.Reject())
+	assert.Equal(t, ShedRequests, s.Update(over))
+	// only the share of the requests over the limits is rejected
+	assert.InDelta(t, 1-1/1.2, s.RejectRatio(), 1e-9)
+	assert.Equal(t, ShedRequests, s.Update(over))
+	assert.InDelta(t, 1-1/1.2/1.2, s.RejectRatio(), 1e-9)
+	// the stage and the share of the requests rejected are kept close to the limits
+	assert.Equal(t, ShedRequests, s.Update(between))
+	assert.InDelta(t, 1-1/1.2/1.2, s.RejectRatio(), 1e-9)
+	// and lowered one at a time under them
+	assert.Equal(t, ShedSamplers, s.Update(under))
+	assert.Equal(t, ShedSamplers, s.Stage())
+	assert.Zero(t, s.RejectRatio())
+	assert.Equal(t, ShedUnkept, s.Update(under))
+	assert.Equal(t, ShedNone, s.Update(under))
+	assert.Equal(t, ShedNone, s.Update(under))
+
+	assert.Equal(t, []ShedStage{ShedUnkept, ShedSamplers, ShedRequests, ShedSamplers, ShedUnkept, ShedNone}, changes)
+}
+
+func TestShedderDisabled(t *testing.T) {
+	s := NewShedder(0, 0)
+	assert.Equal(t, ShedNone, s.Update(Info{CPU: CPUInfo{UserAvg: 10}, Mem: MemInfo{Alloc: 1e12}}))
+
+	var nilShedder *Shedder
+	assert.Equal(t, ShedNone, nilShedder.Stage())
+	assert.False(t, nilShedder.Reject())
+}
+
+func TestShedderReject(t *testing.T) {
+	s := NewShedder(1, 0)
+	for i := 0; i < 3; i++ {
+		s.Update(Info{CPU: CPUInfo{UserAvg: 2}})
+	}
+	require.Equal(t, ShedRequests, s.Stage())
+	require.InDelta(t, 0.5, s.RejectRatio(), 1e-9)
+
+	var rejected int
+	for i := 0; i < 1000; i++ {
+		if s.Reject() {
+			rejected++
+		}
+	}
+	assert.InDelta(t, 500, rejected, 100)
+}
+
+func TestShedStageString(t *testing.T) {
+	assert.Equal(t, "none", ShedNone.String())
+	assert.Equal(t, "drop_unkept", ShedUnkept.String())
+	assert.Equal(t, "lower_samplers", ShedSamplers.String())
+	assert.Equal(t, "reject_requests", ShedRequests.String())
+}
@@ -0,0 +1,171 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tinylib/msgp/msgp"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+)
+
+// fileSuffixInProgress is the suffix of the file being written, removed once it is
+// rotated so that the complete files can be picked up.
+const fileSuffixInProgress = ".tmp"
+
+// fileExporter writes payloads to rotating local files. The files are named
+// <prefix>-<creation time>.<format>.
+type fileExporter struct {
+	cfg    config.FileExportConfig
+	prefix string
+
+	mu    sync.Mutex
+	file  *os.File
+	size  int64       // bytes written to file
+	timer *time.Timer // rotates file once it is too old
+
+	easylog *log.ThrottledLogger
+}
+
+// newFileExporter returns a fileExporter writing the files starting with prefix,
+// or nil if the file export is disabled.
+func newFileExporter(cfg config.FileExportConfig, prefix string) *fileExporter {
+	if !cfg.Enabled {
+		return nil
+	}
+	log.Infof("Exporting the %s to %s files in %s", prefix, cfg.Format, cfg.Dir)
+	e := &fileExporter{
+		cfg:     cfg,
+		prefix:  prefix,
+		easylog: log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
+	}
+	e.removeIncompleteFiles()
+	return e
+}
+
+// removeIncompleteFiles removes the files left in progress by a previous run, which
+// may end with a truncated payload.
+func (e *fileExporter) removeIncompleteFiles() {
+	files, err := filepath.Glob(filepath.Join(e.cfg.Dir, e.prefix+"-*"+fileSuffixInProgress))
+	if err != nil {
+		return
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil {
+			log.Warnf("Error removing the incomplete %s export file %s: %v", e.prefix, f, err)
+		}
+	}
+}
+
+// export appends p to the current file, rotating it when it is full.
+func (e *fileExporter) export(p msgp.Marshaler) {
+	if e == nil {
+		return
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if e.cfg.Format == config.FileExportJSON {
+		if b, err = json.Marshal(p); err == nil {
+			b = append(b, '\n')
+		}
+	} else {
+		b, err = p.MarshalMsg(nil)
+	}
+	if err != nil {
+		e.easylog.Error("Error encoding the %s for the file export: %v", e.prefix, err)
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil && e.cfg.MaxFileSize > 0 && e.size+int64(len(b)) > e.cfg.MaxFileSize {
+		e.rotate()
+	}
+	if e.file == nil {
+		if err := e.open(); err != nil {
+			e.easylog.Error("Error creating the %s export file: %v", e.prefix, err)
+			return
+		}
+	}
+	n, err := e.file.Write(b)
+	e.size += int64(n)
+	if err != nil {
+		e.easylog.Error("Error writing to the %s export file %s: %v", e.prefix, e.file.Name(), err)
+	}
+}
+
+// open creates a new file. e must be locked.
+func (e *fileExporter) open() error {
+	name := e.prefix + "-" + time.Now().UTC().Format("20060102T150405.000000000") + "." + e.cfg.Format + fileSuffixInProgress
+	f, err := os.OpenFile(filepath.Join(e.cfg.Dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return err
+	}
+	e.file, e.size = f, 0
+	if e.cfg.MaxFileAge > 0 {
+		e.timer = time.AfterFunc(e.cfg.MaxFileAge, func() {
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			if e.file == f {
+				e.rotate()
+			}
+		})
+	}
+	return nil
+}
+
+// rotate completes the current file and removes the oldest files beyond the limit.
+// e must be locked.
+func (e *fileExporter) rotate() {
+	if e.timer != nil {
+		e.timer.Stop()
+		e.timer = nil
+	}
+	name := e.file.Name()
+	if err := e.file.Close(); err != nil {
+		e.easylog.Error("Error closing the %s export file %s: %v", e.prefix, name, err)
+	}
+	e.file = nil
+	if err := os.Rename(name, strings.TrimSuffix(name, fileSuffixInProgress)); err != nil {
+		e.easylog.Error("Error completing the %s export file %s: %v", e.prefix, name, err)
+	}
+	if e.cfg.MaxFiles <= 0 {
+		return
+	}
+	files, err := filepath.Glob(filepath.Join(e.cfg.Dir, e.prefix+"-*."+e.cfg.Format))
+	if err != nil {
+		return
+	}
+	// the names sort by creation time
+	sort.Strings(files)
+	for _, f := range files[:max(len(files)-e.cfg.MaxFiles, 0)] {
+		if err := os.Remove(f); err != nil {
+			e.easylog.Error("Error removing the %s export file %s: %v", e.prefix, f, err)
+		}
+	}
+}
+
+// stop completes the current file.
+func (e *fileExporter) stop() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.file != nil {
+		e.rotate()
+	}
+}
@@ -0,0 +1,193 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+
+	gzip "github.com/DataDog/datadog-agent/comp/trace/compression/impl-gzip"
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/timing"
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+func testFileExportConfig(t *testing.T, format string) config.FileExportConfig {
+	return config.FileExportConfig{
+		Enabled:     true,
+		Dir:         t.TempDir(),
+		Format:      format,
+		MaxFileSize: 100 * 1024 * 1024,
+		MaxFileAge:  time.Hour,
+		MaxFiles:    10,
+	}
+}
+
+func exportedFiles(t *testing.T, dir, pattern string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	require.NoError(t, err)
+	return files
+}
+
+func TestFileExporter(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		e := newFileExporter(config.FileExportConfig{Dir: t.TempDir()}, "traces")
+		assert.Nil(t, e)
+		e.export(&pb.StatsPayload{})
+		e.stop()
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		cfg := testFileExportConfig(t, config.FileExportMsgpack)
+		e := newFileExporter(cfg, "stats")
+		payloads := []*pb.StatsPayload{
+			{AgentHostname: "a", Stats: []*pb.ClientStatsPayload{{Hostname: "a", Stats: []*pb.ClientStatsBucket{testutil.RandomBucket(3)}}}},
+			{AgentHostname: "b"},
+		}
+		for _, p := range payloads {
+			e.export(p)
+		}
+		assert.Len(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"+fileSuffixInProgress), 1)
+		assert.Empty(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"))
+		e.stop()
+		assert.Empty(t, exportedFiles(t, cfg.Dir, "*"+fileSuffixInProgress))
+		files := exportedFiles(t, cfg.Dir, "stats-*.msgpack")
+		require.Len(t, files, 1)
+
+		f, err := os.Open(files[0])
+		require.NoError(t, err)
+		defer f.Close()
+		r := msgp.NewReader(f)
+		for _, want := range payloads {
+			var got pb.StatsPayload
+			require.NoError(t, got.DecodeMsg(r))
+			assert.Equal(t, want.AgentHostname, got.AgentHostname)
+			assert.Len(t, got.Stats, len(want.Stats))
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		cfg := testFileExportConfig(t, config.FileExportJSON)
+		e := newFileExporter(cfg, "traces")
+		e.export(&pb.AgentPayload{HostName: "a", TracerPayloads: []*pb.TracerPayload{{Env: "prod"}}})
+		e.export(&pb.AgentPayload{HostName: "b"})
+		e.stop()
+		files := exportedFiles(t, cfg.Dir, "traces-*.json")
+		require.Len(t, files, 1)
+
+		b, err := os.ReadFile(files[0])
+		require.NoError(t, err)
+		var hosts []string
+		sc := bufio.NewScanner(bytes.NewReader(b))
+		for sc.Scan() {
+			var p pb.AgentPayload
+			require.NoError(t, json.Unmarshal(sc.Bytes(), &p))
+			hosts = append(hosts, p.HostName)
+		}
+		assert.Equal(t, []string{"a", "b"}, hosts)
+	})
+
+	t.Run("rotation", func(t *testing.T) {
+		cfg := testFileExportConfig(t, config.FileExportMsgpack)
+		p := &pb.StatsPayload{AgentHostname: "host"}
+		b, err := p.MarshalMsg(nil)
+		require.NoError(t, err)
+		cfg.MaxFileSize = int64(2 * len(b))
+		cfg.MaxFiles = 2
+		e := newFileExporter(cfg, "stats")
+		for i := 0; i < 7; i++ {
+			e.export(p)
+		}
+		// 3 complete files of 2 payloads were written, the oldest was removed
+		assert.Len(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"), 2)
+		assert.Len(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"+fileSuffixInProgress), 1)
+		for _, f := range exportedFiles(t, cfg.Dir, "stats-*.msgpack") {
+			fi, err := os.Stat(f)
+			require.NoError(t, err)
+			assert.EqualValues(t, 2*len(b), fi.Size())
+		}
+		e.stop()
+		assert.Len(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"), 2)
+	})
+
+	t.Run("incomplete", func(t *testing.T) {
+		cfg := testFileExportConfig(t, config.FileExportMsgpack)
+		for _, name := range []string{"stats-1.msgpack.tmp", "stats-1.json.tmp", "stats-2.msgpack", "traces-1.msgpack.tmp"} {
+			require.NoError(t, os.WriteFile(filepath.Join(cfg.Dir, name), []byte("x"), 0o640))
+		}
+		e := newFileExporter(cfg, "stats")
+		defer e.stop()
+		// the files left in progress by a previous run are removed
+		assert.Empty(t, exportedFiles(t, cfg.Dir, "stats-*"+fileSuffixInProgress))
+		assert.Len(t, exportedFiles(t, cfg.Dir, "stats-*.msgpack"), 1)
+		assert.Len(t, exportedFiles(t, cfg.Dir, "traces-*"+fileSuffixInProgress), 1)
+	})
+
+	t.Run("age", func(t *testing.T) {
+		cfg := testFileExportConfig(t, config.FileExportMsgpack)
+		cfg.MaxFileAge = 10 * time.Millisecond
+		e := newFileExporter(cfg, "stats")
+		e.export(&pb.StatsPayload{})
+		assert.Eventually(t, func() bool {
+			return len(exportedFiles(t, cfg.Dir, "stats-*.msgpack")) == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Empty(t, exportedFiles(t, cfg.Dir, "*"+fileSuffixInProgress))
+		e.stop()
+	})
+}
+
+func TestStatsWriterFileExport(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	cfg := &config.AgentConfig{
+		Endpoints:     []*config.Endpoint{{Host: srv.URL, APIKey: "123"}},
+		StatsWriter:   &config.WriterConfig{ConnectionLimit: 20, QueueSize: 20},
+		ContainerTags: func(_ string) ([]string, error) { return nil, nil },
+		FileExport:    testFileExportConfig(t, config.FileExportMsgpack),
+	}
+	sw := NewStatsWriter(cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, &timing.NoopReporter{})
+	go sw.Run()
+	sw.Write(&pb.StatsPayload{Stats: []*pb.ClientStatsPayload{{Stats: []*pb.ClientStatsBucket{testutil.RandomBucket(3)}}}})
+	sw.Stop()
+	assert.Len(t, exportedFiles(t, cfg.FileExport.Dir, "stats-*.msgpack"), 1)
+}
+
+func TestTraceWriterFileExport(t *testing.T) {
+	srv := newTestServer()
+	defer srv.Close()
+	cfg := &config.AgentConfig{
+		Hostname:    testHostname,
+		DefaultEnv:  testEnv,
+		Endpoints:   []*config.Endpoint{{APIKey: "123", Host: srv.URL}},
+		TraceWriter: &config.WriterConfig{ConnectionLimit: 200, QueueSize: 40},
+		FileExport:  testFileExportConfig(t, config.FileExportJSON),
+	}
+	tw := NewTraceWriter(cfg, mockSampler, mockSampler, mockSampler, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, &timing.NoopReporter{}, gzip.NewComponent())
+	tw.WriteChunks(randomSampledSpans(20, 8))
+	tw.Stop()
+
+	files := exportedFiles(t, cfg.FileExport.Dir, "traces-*.json")
+	require.Len(t, files, 1)
+	b, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	var p pb.AgentPayload
+	require.NoError(t, json.Unmarshal(b, &p))
+	assert.Equal(t, testHostname, p.HostName)
+	require.Len(t, p.TracerPayloads, 1)
+	assert.Len(t, p.TracerPayloads[0].Chunks, 1)
+}
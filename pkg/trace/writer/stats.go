@@ -43,10 +43,11 @@ const (
 // DatadogStatsWriter ingests stats buckets, combining them over time and flushing them to the API.
 // This implements the stats.Writer interface.
 type DatadogStatsWriter struct {
-	senders []*sender
-	stop    chan struct{}
-	stats   *info.StatsWriterInfo
-	conf    *config.AgentConfig
+	senders      []*sender
+	fileExporter *fileExporter // nil unless the file export is enabled
+	stop         chan struct{}
+	stats        *info.StatsWriterInfo
+	conf         *config.AgentConfig
 
 	// syncMode reports whether the writer should flush on its own or only when FlushSync is called
 	syncMode  bool
@@ -75,6 +76,8 @@ func NewStatsWriter(
 		conf:      cfg,
 		statsd:    statsd,
 		timing:    timing,
+
+		fileExporter: newFileExporter(cfg.FileExport, "stats"),
 	}
 	climit := cfg.StatsWriter.ConnectionLimit
 	if climit == 0 {
@@ -142,6 +145,7 @@ func (w *DatadogStatsWriter) Stop() {
 	w.stop <- struct{}{}
 	<-w.stop
 	stopSenders(w.senders)
+	w.fileExporter.stop()
 }
 
 // Add appends this StatsPayload to the writer's buffer (flushing immediately if syncMode is enabled)
@@ -172,6 +176,7 @@ func (w *DatadogStatsWriter) SendPayload(p *pb.StatsPayload) {
 		return
 	}
 	sendPayloads(w.senders, req, w.syncMode)
+	w.fileExporter.export(p)
 }
 
 func (w *DatadogStatsWriter) sendPayloads() {
@@ -62,6 +62,7 @@ type TraceWriter struct {
 	hostname     string
 	env          string
 	senders      []*sender
+	fileExporter *fileExporter // nil unless the file export is enabled
 	stop         chan struct{}
 	stats        *info.TraceWriterInfo
 	wg           sync.WaitGroup // waits flusher + reporter + compressor
@@ -101,6 +102,7 @@ func NewTraceWriter(
 		rareSampler:        rareSampler,
 		hostname:           cfg.Hostname,
 		env:                cfg.DefaultEnv,
+		fileExporter:       newFileExporter(cfg.FileExport, "traces"),
 		stats:              &info.TraceWriterInfo{},
 		stop:               make(chan struct{}),
 		flushChan:          make(chan chan struct{}),
@@ -183,6 +185,7 @@ func (w *TraceWriter) Stop() {
 	w.wg.Wait()
 	w.flush()
 	stopSenders(w.senders)
+	w.fileExporter.stop()
 	w.flushTicker.Stop()
 }
 
@@ -267,6 +270,7 @@ func (w *TraceWriter) flushPayloads(payloads []*pb.TracerPayload) {
 	log.Debugf("Reported agent rates: target_tps=%v errors_tps=%v rare_sampling=%v", p.TargetTPS, p.ErrorTPS, p.RareSamplerEnabled)
 
 	w.serialize(&p)
+	w.fileExporter.export(&p)
 }
 
 var outPool = sync.Pool{}